@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys sends keys to the model and returns the input line
+func typeKeys(m Model, keys ...tea.KeyMsg) (Model, string) {
+	for _, k := range keys {
+		next, _ := m.handleKey(k)
+		m = next.(Model)
+	}
+	return m, m.lines[m.cursorRow].Text
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestPrefixInput(t *testing.T) {
+	esc := tea.KeyMsg{Type: tea.KeyEscape}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	ctrlK := tea.KeyMsg{Type: tea.KeyCtrlK}
+
+	// Default: backtick, one symbol at a time
+	m, line := typeKeys(newRideTestModel(), runeKey('`'), runeKey('i'), runeKey('5'), runeKey('`'), runeKey('X'), runeKey('`'), left)
+	if line != aplIndent+"⍳5`X`" || m.prefixActive {
+		t.Errorf("backtick: line %q, active %v", line, m.prefixActive)
+	}
+
+	// Another prefix key; backtick is then just a character
+	m = newRideTestModel()
+	m.config.PrefixKey = "ctrl+k"
+	m, line = typeKeys(m, runeKey('`'), ctrlK, runeKey('r'), ctrlK, runeKey('X'), ctrlK, left)
+	if line != aplIndent+"`⍴X" || m.prefixActive {
+		t.Errorf("ctrl+k: line %q, active %v", line, m.prefixActive)
+	}
+
+	// Sticky: symbols until Esc, other keys still work
+	m = newRideTestModel()
+	m.config.PrefixSticky = true
+	m, line = typeKeys(m, runeKey('`'), runeKey('i'), runeKey('r'), left, runeKey('a'), runeKey('X'))
+	if line != aplIndent+"⍳⍺X⍴" || !m.prefixActive {
+		t.Errorf("sticky: line %q, active %v", line, m.prefixActive)
+	}
+	if m.cursorCol != len(aplIndent)+3 {
+		t.Errorf("sticky: Left didn't move the cursor: col %d", m.cursorCol)
+	}
+	m, line = typeKeys(m, esc, runeKey('i'))
+	if line != aplIndent+"⍳⍺Xi⍴" || m.prefixActive {
+		t.Errorf("after Esc: line %q, active %v", line, m.prefixActive)
+	}
+
+	// A sticky prefix that isn't a character turns itself off too
+	m.config.PrefixKey = "ctrl+k"
+	m, _ = typeKeys(m, ctrlK, runeKey('w'))
+	if m, _ = typeKeys(m, ctrlK); m.prefixActive {
+		t.Error("ctrl+k didn't end sticky mode")
+	}
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss/v2"
@@ -15,9 +20,21 @@ const aplcartURL = "https://raw.githubusercontent.com/abrudz/aplcart/master/tabl
 type APLcartEntry struct {
 	Syntax      string
 	Description string
+	Class       string // What the syntax is: "monadic function", "dyadic operator", ...
+	Type        string // How it's written: "primitive", "idiom", "dfn", ...
+	Group       string // Broad area, e.g. "Mathematics"
+	Category    string // Narrower area within the group
 	Keywords    string
 }
 
+// aplcartFields are the columns a query can filter on with name:value
+var aplcartFields = map[string]func(APLcartEntry) string{
+	"class":    func(e APLcartEntry) string { return e.Class },
+	"type":     func(e APLcartEntry) string { return e.Type },
+	"group":    func(e APLcartEntry) string { return e.Group },
+	"category": func(e APLcartEntry) string { return e.Category },
+}
+
 // APLcart is a searchable APLcart pane
 type APLcart struct {
 	entries        []APLcartEntry
@@ -27,6 +44,8 @@ type APLcart struct {
 	scroll         int
 	loading        bool
 	err            error
+	source         string // Where data came from: "cache", "network" or "stale cache"
+	listH          int    // Entry rows in the last render, for mouse clicks
 	SelectedSyntax string // Set when Enter pressed
 }
 
@@ -40,22 +59,79 @@ func NewAPLcart() *APLcart {
 // APLcartLoaded is sent when data is fetched
 type APLcartLoaded struct {
 	Entries []APLcartEntry
+	Source  string
 	Err     error
 }
 
-// FetchAPLcart fetches the APLcart data
-func FetchAPLcart() tea.Msg {
-	resp, err := http.Get(aplcartURL)
+// aplcartCachePath returns where the downloaded TSV is cached
+func aplcartCachePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "gritt", "aplcart.tsv")
+}
+
+// FetchAPLcart returns a command that loads APLcart data, from the disk
+// cache if it is younger than ttl, otherwise from GitHub
+func FetchAPLcart(ttl time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		return fetchAPLcart(aplcartURL, aplcartCachePath(), ttl)
+	}
+}
+
+func fetchAPLcart(url, cachePath string, ttl time.Duration) APLcartLoaded {
+	cached, age, cacheErr := loadAPLcartCache(cachePath)
+	if cacheErr == nil && age < ttl {
+		return APLcartLoaded{Entries: parseAPLcart(cached), Source: "cache"}
+	}
+
+	body, err := downloadAPLcart(url)
 	if err != nil {
+		// Offline or GitHub unavailable - any cache beats nothing
+		if cacheErr == nil {
+			return APLcartLoaded{Entries: parseAPLcart(cached), Source: "stale cache"}
+		}
 		return APLcartLoaded{Err: err}
 	}
+
+	// Failing to cache only costs a refetch next time
+	saveAPLcartCache(cachePath, body)
+	return APLcartLoaded{Entries: parseAPLcart(body), Source: "network"}
+}
+
+func downloadAPLcart(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching APLcart: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// loadAPLcartCache reads the cached TSV and reports its age
+func loadAPLcartCache(path string) ([]byte, time.Duration, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return APLcartLoaded{Err: err}
+		return nil, 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
 	}
+	return data, time.Since(info.ModTime()), nil
+}
 
+// saveAPLcartCache writes the TSV to the cache, creating its directory
+func saveAPLcartCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// parseAPLcart parses the APLcart TSV (header row, then one entry per line)
+func parseAPLcart(body []byte) []APLcartEntry {
 	lines := strings.Split(string(body), "\n")
 	entries := make([]APLcartEntry, 0, len(lines))
 
@@ -70,20 +146,100 @@ func FetchAPLcart() tea.Msg {
 		entries = append(entries, APLcartEntry{
 			Syntax:      fields[0],
 			Description: fields[1],
+			Class:       fields[2],
+			Type:        fields[3],
+			Group:       fields[4],
+			Category:    fields[5],
 			Keywords:    fields[6],
 		})
 	}
 
-	return APLcartLoaded{Entries: entries}
+	return entries
 }
 
-func (a *APLcart) SetData(entries []APLcartEntry, err error) {
+// aplcartPlaceholders returns the columns of placeholder metavariables in an
+// APLcart syntax: lone uppercase letters (X, Y, M, ...) outside quotes
+func aplcartPlaceholders(runes []rune) []int {
+	var cols []int
+	inQuote := false
+	for i, r := range runes {
+		if r == '\'' {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote || r < 'A' || r > 'Z' {
+			continue
+		}
+		if i > 0 && isAPLNameChar(runes[i-1]) {
+			continue
+		}
+		if i+1 < len(runes) && isAPLNameChar(runes[i+1]) {
+			continue
+		}
+		cols = append(cols, i)
+	}
+	return cols
+}
+
+// aplPrimitives are the glyphs of Dyalog's primitive functions and operators
+const aplPrimitives = "+-×÷*⍟○!?|⌈⌊⊥⊤⊣⊢=≠≤<≥>≡≢∨∧⍲⍱↑↓⊂⊃⊆⌷⍋⍒⍳⍸∊⍷∪∩~/\\⌿⍀,⍪⍴⌽⊖⍉⍎⍕⍬⌹¨⍨⍣∘⍤⍥@⍠⌸⌺⌶&"
+
+// aplcartDocSymbols returns the help_urls symbols to try for an APLcart
+// syntax: its first primitive outside quotes, with the glyph after it first
+// (∘. and other two-glyph symbols), or nil if it has none
+func aplcartDocSymbols(syntax string) []string {
+	runes := []rune(syntax)
+	inQuote := false
+	for i, r := range runes {
+		if r == '\'' {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote || !strings.ContainsRune(aplPrimitives, r) {
+			continue
+		}
+		if i+1 < len(runes) && (runes[i+1] == '.' || strings.ContainsRune(aplPrimitives, runes[i+1])) {
+			return []string{string(runes[i : i+2]), string(r)}
+		}
+		return []string{string(r)}
+	}
+	return nil
+}
+
+// Selected returns the highlighted entry
+func (a *APLcart) Selected() (APLcartEntry, bool) {
+	if a.loading || a.err != nil || a.selected < 0 || a.selected >= len(a.filtered) {
+		return APLcartEntry{}, false
+	}
+	return a.filtered[a.selected], true
+}
+
+func (a *APLcart) SetData(entries []APLcartEntry, source string, err error) {
 	a.loading = false
 	a.err = err
+	a.source = source
 	a.entries = entries
 	a.filtered = entries
 }
 
+// aplcartQuery splits a query into its name:value modifiers (for the
+// columns in aplcartFields) and the rest, the text to search for
+func aplcartQuery(query string) (text string, mods map[string]string) {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		name, value, ok := strings.Cut(word, ":")
+		if _, field := aplcartFields[name]; ok && field && value != "" {
+			if mods == nil {
+				mods = make(map[string]string)
+			}
+			mods[name] = value
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), mods
+}
+
 func (a *APLcart) filter() {
 	if a.query == "" {
 		a.filtered = a.entries
@@ -92,12 +248,21 @@ func (a *APLcart) filter() {
 		return
 	}
 
-	q := strings.ToLower(a.query)
+	// Modifiers match part of their column, so class:operator finds
+	// monadic and dyadic operators
+	q, mods := aplcartQuery(a.query)
 	a.filtered = nil
 	for _, e := range a.entries {
-		if strings.Contains(strings.ToLower(e.Description), q) ||
+		match := true
+		for name, value := range mods {
+			if !strings.Contains(strings.ToLower(aplcartFields[name](e)), value) {
+				match = false
+				break
+			}
+		}
+		if match && (strings.Contains(strings.ToLower(e.Description), q) ||
 			strings.Contains(strings.ToLower(e.Keywords), q) ||
-			strings.Contains(strings.ToLower(e.Syntax), q) {
+			strings.Contains(strings.ToLower(e.Syntax), q)) {
 			a.filtered = append(a.filtered, e)
 		}
 	}
@@ -112,29 +277,32 @@ func (a *APLcart) filter() {
 }
 
 func (a *APLcart) Title() string {
+	if a.source != "" {
+		return "APLcart (" + a.source + ")"
+	}
 	return "APLcart"
 }
 
 func (a *APLcart) Render(w, h int) string {
 	if a.loading {
-		loadStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+		loadStyle := lipgloss.NewStyle().Foreground(theme.Comment)
 		return loadStyle.Render("Loading APLcart...")
 	}
 
 	if a.err != nil {
-		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		errStyle := lipgloss.NewStyle().Foreground(theme.Error)
 		return errStyle.Render("Error: " + a.err.Error())
 	}
 
 	var sb strings.Builder
 
 	// Query line
-	promptStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	promptStyle := lipgloss.NewStyle().Foreground(theme.APLcart)
 	sb.WriteString(promptStyle.Render("/ "))
 	sb.WriteString(a.query)
 	sb.WriteString(cursorStyle.Render(" "))
-	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	sb.WriteString(countStyle.Render("  (" + itoa(len(a.filtered)) + ")"))
+	countStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+	sb.WriteString(countStyle.Render("  (" + strconv.Itoa(len(a.filtered)) + ")"))
 	sb.WriteString("\n")
 
 	// Separator
@@ -142,11 +310,21 @@ func (a *APLcart) Render(w, h int) string {
 	sb.WriteString("\n")
 
 	// Entries list
-	selectedStyle := lipgloss.NewStyle().Background(lipgloss.Color("214")).Foreground(lipgloss.Color("0"))
-	syntaxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
-	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	selectedStyle := lipgloss.NewStyle().Background(theme.APLcart).Foreground(theme.CursorText)
+	syntaxStyle := lipgloss.NewStyle().Foreground(theme.APLcart).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Text)
 
+	// The selected entry's class and area go on the last line, if there's
+	// room for them and a row of entries
 	listH := h - 2
+	details := a.details()
+	if details != "" && listH > 1 {
+		listH--
+	} else {
+		details = ""
+	}
+	a.listH = listH
+
 	for i := a.scroll; i < len(a.filtered) && i < a.scroll+listH; i++ {
 		e := a.filtered[i]
 
@@ -179,19 +357,29 @@ func (a *APLcart) Render(w, h int) string {
 		}
 	}
 
+	if details != "" {
+		rows := min(len(a.filtered)-a.scroll, listH)
+		sb.WriteString(strings.Repeat("\n", listH-rows+1))
+		sb.WriteString(countStyle.Render(truncateRunes(details, w)))
+	}
+
 	return sb.String()
 }
 
-func itoa(n int) string {
-	if n == 0 {
-		return "0"
+// details describes the selected entry from its class, type, group and
+// category columns, skipping empty ones
+func (a *APLcart) details() string {
+	e, ok := a.Selected()
+	if !ok {
+		return ""
 	}
-	s := ""
-	for n > 0 {
-		s = string(rune('0'+n%10)) + s
-		n /= 10
+	var parts []string
+	for _, p := range []string{e.Class, e.Type, strings.Trim(e.Group+" / "+e.Category, " /")} {
+		if p != "" {
+			parts = append(parts, p)
+		}
 	}
-	return s
+	return strings.Join(parts, " · ")
 }
 
 func (a *APLcart) HandleKey(msg tea.KeyMsg) bool {
@@ -247,7 +435,7 @@ func (a *APLcart) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 		return false
 	}
 
-	if msg.Type == tea.MouseLeft && y >= 2 {
+	if msg.Type == tea.MouseLeft && y >= 2 && (a.listH == 0 || y-2 < a.listH) {
 		idx := y - 2 + a.scroll
 		if idx >= 0 && idx < len(a.filtered) {
 			a.selected = idx
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const testAPLcartTSV = "syntax\tdesc\tc\td\te\tf\tkeywords\n" +
+	"⍳X\tIndices\t\t\t\t\tiota count\n" +
+	"≢X\tTally\t\t\t\t\tcount length\n"
+
+func TestFetchAPLcartCache(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(testAPLcartTSV))
+	}))
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "gritt", "aplcart.tsv")
+
+	// No cache: fetch from network and write the cache
+	got := fetchAPLcart(srv.URL, cachePath, time.Hour)
+	if got.Err != nil || got.Source != "network" || len(got.Entries) != 2 {
+		t.Fatalf("first fetch = %+v, want 2 entries from network", got)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// Fresh cache: no request
+	got = fetchAPLcart(srv.URL, cachePath, time.Hour)
+	if got.Source != "cache" || len(got.Entries) != 2 || hits != 1 {
+		t.Errorf("second fetch = %+v (hits=%d), want cache hit", got, hits)
+	}
+
+	// Expired cache: refetch
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(cachePath, old, old)
+	got = fetchAPLcart(srv.URL, cachePath, time.Hour)
+	if got.Source != "network" || hits != 2 {
+		t.Errorf("expired fetch = %+v (hits=%d), want network", got, hits)
+	}
+}
+
+func TestFetchAPLcartOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "aplcart.tsv")
+
+	// No cache and no network: error
+	if got := fetchAPLcart(srv.URL, cachePath, time.Hour); got.Err == nil {
+		t.Errorf("fetch with no cache and failing server = %+v, want error", got)
+	}
+
+	// Expired cache is used when the network fails
+	saveAPLcartCache(cachePath, []byte(testAPLcartTSV))
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(cachePath, old, old)
+	got := fetchAPLcart(srv.URL, cachePath, time.Hour)
+	if got.Err != nil || got.Source != "stale cache" || len(got.Entries) != 2 {
+		t.Errorf("offline fetch = %+v, want 2 entries from stale cache", got)
+	}
+}
+
+func TestAPLcartPlaceholders(t *testing.T) {
+	tests := []struct {
+		syntax string
+		want   []int
+	}{
+		{"X⍴Y", []int{0, 2}},
+		{"(≢X)↑Y", []int{2, 5}},
+		{"⎕A⍳X", []int{3}},  // ⎕A is a name, not a placeholder
+		{"'X',Y", []int{4}}, // quoted X is literal
+		{"Xs←⍳N", []int{4}}, // multi-letter names aren't placeholders
+		{"{⍵÷2}", nil},
+	}
+	for _, tt := range tests {
+		got := aplcartPlaceholders([]rune(tt.syntax))
+		if len(got) != len(tt.want) {
+			t.Errorf("aplcartPlaceholders(%q) = %v, want %v", tt.syntax, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("aplcartPlaceholders(%q) = %v, want %v", tt.syntax, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAPLcartInsertTabstops(t *testing.T) {
+	cfg, _ := LoadConfig()
+	m := Model{
+		lines:     []Line{{Text: aplIndent}},
+		cursorCol: len(aplIndent),
+		panes:     NewPaneManager(80, 24),
+		keys:      cfg.ToKeyMap(),
+		config:    cfg,
+		debugLog:  &LogBuffer{},
+	}
+
+	ac := NewAPLcart()
+	ac.SetData([]APLcartEntry{{Syntax: "X⍴Y", Description: "Reshape"}}, "cache", nil)
+	m.panes.Add(NewPane("aplcart", ac, 0, 0, 60, 10))
+	m.panes.Focus("aplcart")
+
+	key := func(msg tea.KeyMsg) {
+		next, _ := m.handleKey(msg)
+		m = next.(Model)
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.currentLine(); got != aplIndent+"X⍴Y" {
+		t.Fatalf("after insert: line = %q", got)
+	}
+	if m.cursorCol != len(aplIndent) || !m.tabstopSelected {
+		t.Fatalf("cursor should select X: col=%d selected=%v", m.cursorCol, m.tabstopSelected)
+	}
+
+	// Typing replaces the selected placeholder
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2 3")})
+	if got := m.currentLine(); got != aplIndent+"2 3⍴Y" {
+		t.Errorf("after replacing X: line = %q", got)
+	}
+
+	// Tab jumps to and selects Y
+	key(tea.KeyMsg{Type: tea.KeyTab})
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("⍳6")})
+	if got := m.currentLine(); got != aplIndent+"2 3⍴⍳6" {
+		t.Errorf("after replacing Y: line = %q", got)
+	}
+
+	// No placeholders left - Tab ends tabbing
+	key(tea.KeyMsg{Type: tea.KeyTab})
+	if m.tabstopsActive {
+		t.Error("tabstops still active after last placeholder")
+	}
+
+	// Capitals typed before the idiom aren't placeholders, nor is the text
+	// after it
+	m.lines = []Line{{Text: aplIndent + "X+ ⋄ Z"}}
+	m.cursorCol = len(aplIndent) + 2
+	m.panes.Add(NewPane("aplcart", ac, 0, 0, 60, 10))
+	m.panes.Focus("aplcart")
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.currentLine(); got != aplIndent+"X+X⍴Y ⋄ Z" {
+		t.Fatalf("second insert: line = %q", got)
+	}
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4")})
+	key(tea.KeyMsg{Type: tea.KeyTab})
+	if m.cursorCol != len(aplIndent)+4 {
+		t.Errorf("Tab went to col %d, want %d (Y)", m.cursorCol, len(aplIndent)+4)
+	}
+	key(tea.KeyMsg{Type: tea.KeyTab})
+	if m.tabstopsActive || m.cursorCol != len(aplIndent)+4 {
+		t.Errorf("Tab past Y: active %v, col %d", m.tabstopsActive, m.cursorCol)
+	}
+}
+
+func TestAPLcartDocSymbols(t *testing.T) {
+	tests := []struct {
+		syntax string
+		want   []string
+	}{
+		{"X⍴Y", []string{"⍴"}},
+		{"{⍵/⍳⍴⍵}X", []string{"/⍳", "/"}},
+		{"X∘.=Y", []string{"∘.", "∘"}},
+		{"'⍳'≡X", []string{"≡"}},
+		{"⎕NGET X", nil},
+		{"X←Y", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := aplcartDocSymbols(tt.syntax); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("aplcartDocSymbols(%q) = %q, want %q", tt.syntax, got, tt.want)
+		}
+	}
+}
+
+func TestAPLcartDocHelp(t *testing.T) {
+	db := newTestDocsDB(t)
+	if _, err := db.Exec("INSERT INTO help_urls VALUES ('⍳', 'Ref / Iota'), ('⍴', 'Ref / Rho')"); err != nil {
+		t.Fatal(err)
+	}
+	m := newRideTestModel()
+	m.docs = openedDocs(db)
+
+	ac := NewAPLcart()
+	ac.SetData([]APLcartEntry{
+		{Syntax: "{⍳⍴⍵}X", Description: "Indices"},
+		{Syntax: "X Y", Description: "Strand"},
+	}, "cache", nil)
+	m.panes.Add(NewPane("aplcart", ac, 0, 0, 60, 10))
+	m.panes.Focus("aplcart")
+	f1 := tea.KeyMsg{Type: tea.KeyF1}
+
+	// F1 opens the page for the entry's first primitive, keeping APLcart open
+	next, _ := m.handleKey(f1)
+	m = next.(Model)
+	pane := m.panes.Get("docs")
+	if pane == nil || pane.Content.(*DocPane).navPath != "Ref / Iota" {
+		t.Fatalf("docs pane = %v", pane)
+	}
+	if m.panes.Get("aplcart") == nil {
+		t.Error("APLcart closed")
+	}
+
+	// Back in APLcart, with the docs open, F1 shows the next entry's
+	m.panes.Focus("aplcart")
+	ac.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	next, _ = m.handleKey(f1)
+	m = next.(Model)
+	if m.statusMsg != "No primitive in X Y" || m.panes.Get("docs") == nil {
+		t.Errorf("no primitive: status %q", m.statusMsg)
+	}
+}
+
+func TestAPLcartFilterColumns(t *testing.T) {
+	tsv := "syntax\tdescription\tclass\ttype\tgroup\tcategory\tkeywords\n" +
+		"X⍴Y\tReshape\tdyadic function\tprimitive\tStructural\tShape\treshape\n" +
+		"f⍨Y\tSelfie\tmonadic operator\tprimitive\tOperators\tArguments\tcommute\n" +
+		"f⍤g\tAtop\tdyadic operator\tprimitive\tOperators\tComposition\tatop rank\n" +
+		"{⍵⍴⍨≢⍵}Y\tSquare shape\tmonadic function\tdfn\tStructural\tShape\treshape tally\n"
+	entries := parseAPLcart([]byte(tsv))
+	if want := (APLcartEntry{"X⍴Y", "Reshape", "dyadic function", "primitive", "Structural", "Shape", "reshape"}); entries[0] != want {
+		t.Errorf("parsed %+v", entries[0])
+	}
+
+	ac := NewAPLcart()
+	ac.SetData(entries, "cache", nil)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"class:operator", []string{"f⍨Y", "f⍤g"}},
+		{"CLASS:Operator  rank", []string{"f⍤g"}},
+		{"reshape type:dfn", []string{"{⍵⍴⍨≢⍵}Y"}},
+		{"group:structural category:shape", []string{"X⍴Y", "{⍵⍴⍨≢⍵}Y"}},
+		{"class:operator type:dfn", nil},
+		{"nothing:here", nil}, // Not a column, so searched for as text
+		{"class:", nil},       // No value, likewise
+		{"self", []string{"f⍨Y"}},
+	}
+	for _, tt := range tests {
+		ac.query = tt.query
+		ac.filter()
+		var got []string
+		for _, e := range ac.filtered {
+			got = append(got, e.Syntax)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q found %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	// The selected entry's columns are shown under the list
+	ac.query = "class:operator"
+	ac.filter()
+	ac.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	lines := strings.Split(stripANSI(ac.Render(60, 8)), "\n")
+	if len(lines) != 8 || lines[7] != "dyadic operator · primitive · Operators / Composition" {
+		t.Errorf("render = %q", lines)
+	}
+}
+
+func TestAPLcartColumnsAlign(t *testing.T) {
+	ac := NewAPLcart()
+	ac.SetData([]APLcartEntry{
+		{Syntax: "X⌽Y", Description: "Rotate"},
+		{Syntax: "ab", Description: "Name"},
+		{Syntax: "⍳⍴⍵", Description: "Indices"},
+	}, "cache", nil)
+	lines := strings.Split(stripANSI(ac.Render(30, 5)), "\n")
+	// The syntax column is 10 wide, glyphs or not
+	for i, desc := range []string{"Rotate", "Name", "Indices"} {
+		l := lines[2+i]
+		if j := strings.Index(l, desc); j < 0 || lipgloss.Width(l[:j]) != 11 {
+			t.Errorf("%s not at column 11: %q", desc, l)
+		}
+	}
+}
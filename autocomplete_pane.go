@@ -1,25 +1,124 @@
 package main
 
 import (
+	"image/color"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss/v2"
 )
 
+// NameClass is what a completion names, shown as a glyph and color in the
+// popup
+type NameClass int
+
+const (
+	ClassUnknown   NameClass = iota // Not yet looked up, or not defined
+	ClassVariable                   // ⎕NC 2
+	ClassFunction                   // ⎕NC 3
+	ClassOperator                   // ⎕NC 4
+	ClassNamespace                  // ⎕NC 9: namespaces, classes, instances
+	ClassSystem                     // ⎕IO, ⎕NGET
+	ClassKeyword                    // :If, :EndFor
+	ClassCommand                    // )ed, ]link.create
+)
+
+// classPrefixWidth is the columns before each option: its glyph and a space
+const classPrefixWidth = 2
+
+// glyph marks the class in the popup
+func (c NameClass) glyph() string {
+	switch c {
+	case ClassVariable:
+		return "v"
+	case ClassFunction:
+		return "f"
+	case ClassOperator:
+		return "o"
+	case ClassNamespace:
+		return "#"
+	case ClassSystem:
+		return "⎕"
+	case ClassKeyword:
+		return ":"
+	case ClassCommand:
+		return ")"
+	}
+	return " "
+}
+
+// color is the class's color in the popup; nil leaves the text plain
+func (c NameClass) color() color.Color {
+	switch c {
+	case ClassVariable:
+		return theme.Success
+	case ClassFunction:
+		return theme.Info
+	case ClassOperator:
+		return theme.Changed
+	case ClassNamespace:
+		return theme.Link
+	case ClassSystem, ClassKeyword, ClassCommand:
+		return theme.Comment
+	}
+	return nil
+}
+
+// nameClassOf converts a ⎕NC result (3.2, 9.1, ...) to a class
+func nameClassOf(nc float64) NameClass {
+	switch int(nc) {
+	case 2:
+		return ClassVariable
+	case 3:
+		return ClassFunction
+	case 4:
+		return ClassOperator
+	case 9:
+		return ClassNamespace
+	}
+	return ClassUnknown
+}
+
+// classifyOption gives the class of an option that's clear from its
+// spelling; user names need ⎕NC
+func classifyOption(option string) NameClass {
+	switch {
+	case strings.HasPrefix(option, "⎕"):
+		return ClassSystem
+	case strings.HasPrefix(option, ":"):
+		return ClassKeyword
+	case strings.HasPrefix(option, ")"), strings.HasPrefix(option, "]"):
+		return ClassCommand
+	}
+	return ClassUnknown
+}
+
+// Completion is an option from Dyalog and what it names
+type Completion struct {
+	Text  string
+	Class NameClass
+}
+
 // Autocomplete holds state for the completion popup overlay.
 // This is NOT a pane - it's rendered as an overlay while the session/editor stays focused.
 type Autocomplete struct {
-	Options  []string // Completion options from Dyalog
-	Selected int      // Currently selected index
-	Skip     int      // Characters to replace before cursor
-	Token    int      // Window token (0 for session, >0 for editor)
-	TriggerCol int    // Cursor column when autocomplete was triggered
+	Options    []Completion // Completion options from Dyalog
+	Selected   int          // Currently selected index
+	Skip       int          // Characters to replace before cursor
+	Token      int          // Window token (0 for session, >0 for editor)
+	TriggerCol int          // Cursor column when autocomplete was triggered
+
+	notes map[string]string // One-line descriptions by option, "" if there's none
 }
 
-// NewAutocomplete creates autocomplete state
+// NewAutocomplete creates autocomplete state, classifying the options whose
+// class is clear from their spelling
 func NewAutocomplete(options []string, skip, token, triggerCol int) *Autocomplete {
+	completions := make([]Completion, len(options))
+	for i, opt := range options {
+		completions[i] = Completion{Text: opt, Class: classifyOption(opt)}
+	}
 	return &Autocomplete{
-		Options:    options,
+		Options:    completions,
 		Selected:   0,
 		Skip:       skip,
 		Token:      token,
@@ -27,6 +126,32 @@ func NewAutocomplete(options []string, skip, token, triggerCol int) *Autocomplet
 	}
 }
 
+// Unclassified returns the options that need ⎕NC to classify
+func (a *Autocomplete) Unclassified() []string {
+	var names []string
+	for _, opt := range a.Options {
+		if opt.Class == ClassUnknown {
+			names = append(names, opt.Text)
+		}
+	}
+	return names
+}
+
+// SetClasses classifies names by their ⎕NC results
+func (a *Autocomplete) SetClasses(names []string, ncs []float64) {
+	classes := make(map[string]NameClass, len(names))
+	for i, name := range names {
+		if i < len(ncs) {
+			classes[name] = nameClassOf(ncs[i])
+		}
+	}
+	for i, opt := range a.Options {
+		if c, ok := classes[opt.Text]; ok && opt.Class == ClassUnknown {
+			a.Options[i].Class = c
+		}
+	}
+}
+
 // CycleNext moves selection to next option (wraps around)
 func (a *Autocomplete) CycleNext() {
 	if len(a.Options) == 0 {
@@ -46,27 +171,45 @@ func (a *Autocomplete) CyclePrev() {
 // SelectedOption returns the currently selected option
 func (a *Autocomplete) SelectedOption() string {
 	if a.Selected >= 0 && a.Selected < len(a.Options) {
-		return a.Options[a.Selected]
+		return a.Options[a.Selected].Text
 	}
 	return ""
 }
 
+// SetNote records the description shown under the options while option is
+// selected; "" records that there's none, so it isn't looked up again
+func (a *Autocomplete) SetNote(option, note string) {
+	if a.notes == nil {
+		a.notes = make(map[string]string)
+	}
+	a.notes[option] = note
+}
+
+// Note returns the selected option's description, and whether it has been
+// looked up
+func (a *Autocomplete) Note() (string, bool) {
+	note, ok := a.notes[a.SelectedOption()]
+	return note, ok
+}
+
 // Render returns the popup content for overlay rendering
 func (a *Autocomplete) Render(maxW, maxH int) string {
 	if len(a.Options) == 0 {
 		return ""
 	}
 
-	selectedStyle := lipgloss.NewStyle().Background(AccentColor).Foreground(lipgloss.Color("0"))
-	borderStyle := lipgloss.NewStyle().Foreground(AccentColor)
+	selectedStyle := lipgloss.NewStyle().Background(theme.Accent).Foreground(theme.CursorText)
+	borderStyle := lipgloss.NewStyle().Foreground(theme.Border)
 
 	// Calculate dimensions
 	contentW := 0
 	for _, opt := range a.Options {
-		if len(opt) > contentW {
-			contentW = len(opt)
+		if n := classPrefixWidth + len([]rune(opt.Text)); n > contentW {
+			contentW = n
 		}
 	}
+	note, _ := a.Note()
+	contentW = max(contentW, len([]rune(note)))
 	if contentW > maxW-4 {
 		contentW = maxW - 4
 	}
@@ -75,8 +218,8 @@ func (a *Autocomplete) Render(maxW, maxH int) string {
 	}
 
 	contentH := len(a.Options)
-	if contentH > maxH-2 {
-		contentH = maxH - 2
+	if contentH > maxH-2-a.noteRows() {
+		contentH = max(maxH-2-a.noteRows(), 1)
 	}
 
 	// Calculate scroll offset to keep selection visible
@@ -91,22 +234,36 @@ func (a *Autocomplete) Render(maxW, maxH int) string {
 	lines = append(lines, borderStyle.Render("┌"+strings.Repeat("─", contentW)+"┐"))
 
 	// Options
+	textW := contentW - classPrefixWidth
 	for i := scrollOffset; i < len(a.Options) && i < scrollOffset+contentH; i++ {
-		opt := a.Options[i]
+		opt := a.Options[i].Text
 		optRunes := []rune(opt)
-		if len(optRunes) > contentW {
-			opt = string(optRunes[:contentW-1]) + "…"
+		if len(optRunes) > textW {
+			opt = string(optRunes[:textW-1]) + "…"
 		}
 
-		padded := opt + strings.Repeat(" ", contentW-len([]rune(opt)))
+		prefix := a.Options[i].Class.glyph() + " "
+		padded := opt + strings.Repeat(" ", textW-len([]rune(opt)))
 
 		if i == a.Selected {
-			lines = append(lines, borderStyle.Render("│")+selectedStyle.Render(padded)+borderStyle.Render("│"))
+			lines = append(lines, borderStyle.Render("│")+selectedStyle.Render(prefix+padded)+borderStyle.Render("│"))
 		} else {
-			lines = append(lines, borderStyle.Render("│")+padded+borderStyle.Render("│"))
+			style := lipgloss.NewStyle()
+			if c := a.Options[i].Class.color(); c != nil {
+				style = style.Foreground(c)
+			}
+			lines = append(lines, borderStyle.Render("│")+style.Faint(true).Render(prefix)+style.Render(padded)+borderStyle.Render("│"))
 		}
 	}
 
+	// The selected option's description, under a rule
+	if note != "" {
+		noteStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+		note = truncateRunes(note, contentW)
+		lines = append(lines, borderStyle.Render("├"+strings.Repeat("─", contentW)+"┤"))
+		lines = append(lines, borderStyle.Render("│")+noteStyle.Render(note+strings.Repeat(" ", contentW-len([]rune(note))))+borderStyle.Render("│"))
+	}
+
 	// Bottom border
 	lines = append(lines, borderStyle.Render("└"+strings.Repeat("─", contentW)+"┘"))
 
@@ -117,18 +274,28 @@ func (a *Autocomplete) Render(maxW, maxH int) string {
 func (a *Autocomplete) Width() int {
 	w := 10
 	for _, opt := range a.Options {
-		if len(opt) > w {
-			w = len(opt)
+		if n := classPrefixWidth + len([]rune(opt.Text)); n > w {
+			w = n
 		}
 	}
+	note, _ := a.Note()
+	w = max(w, len([]rune(note)))
 	return w + 2 // borders
 }
 
 // Height returns the rendered height of the popup
 func (a *Autocomplete) Height(maxH int) int {
 	h := len(a.Options)
-	if h > maxH-2 {
-		h = maxH - 2
+	if h > maxH-2-a.noteRows() {
+		h = max(maxH-2-a.noteRows(), 1)
+	}
+	return h + 2 + a.noteRows() // borders
+}
+
+// noteRows is the rows the selected option's description takes
+func (a *Autocomplete) noteRows() int {
+	if note, _ := a.Note(); note != "" {
+		return 2
 	}
-	return h + 2 // borders
+	return 0
 }
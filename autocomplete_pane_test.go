@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyCompletion(t *testing.T) {
+	tests := []struct {
+		line    string
+		col     int
+		skip    int
+		option  string
+		want    string
+		wantCol int
+	}{
+		// Replaces the typed prefix rather than appending to it
+		{"⎕NG", 3, 3, "⎕NGET", "⎕NGET", 5},
+		{"x←Foo+1", 5, 3, "FooBar", "x←FooBar+1", 8},
+		// Zero skip inserts at the cursor
+		{"a ", 2, 0, "abc", "a abc", 5},
+		// Out-of-range skip and col are clamped
+		{"ab", 2, 5, "abc", "abc", 3},
+		{"ab", 9, 2, "abc", "abc", 3},
+	}
+	for _, tt := range tests {
+		got, col := applyCompletion([]rune(tt.line), tt.col, tt.skip, tt.option)
+		if string(got) != tt.want || col != tt.wantCol {
+			t.Errorf("applyCompletion(%q, %d, %d, %q) = %q, %d; want %q, %d",
+				tt.line, tt.col, tt.skip, tt.option, string(got), col, tt.want, tt.wantCol)
+		}
+	}
+}
+
+func TestAutocompleteCycle(t *testing.T) {
+	ac := NewAutocomplete([]string{"a", "b", "c"}, 1, 0, 1)
+	ac.CyclePrev()
+	if ac.SelectedOption() != "c" {
+		t.Errorf("CyclePrev from first = %q, want c", ac.SelectedOption())
+	}
+	ac.CycleNext()
+	ac.CycleNext()
+	if ac.SelectedOption() != "b" {
+		t.Errorf("after two CycleNext = %q, want b", ac.SelectedOption())
+	}
+}
+
+func TestAutocompleteRenderClasses(t *testing.T) {
+	ac := NewAutocomplete([]string{"Foo", "⎕NGET", "]link.create", "x"}, 1, 0, 1)
+	ac.SetClasses([]string{"Foo", "x"}, []float64{3.1, 2.1})
+
+	lines := strings.Split(stripANSI(ac.Render(40, 20)), "\n")
+	for i, want := range []string{"│f Foo", "│⎕ ⎕NGET", "│) ]link.create", "│v x"} {
+		if !strings.HasPrefix(lines[i+1], want) {
+			t.Errorf("row %d = %q, want prefix %q", i, lines[i+1], want)
+		}
+	}
+	if got, want := ac.Width(), classPrefixWidth+len("]link.create")+2; got != want {
+		t.Errorf("Width() = %d, want %d", got, want)
+	}
+	if ac.SelectedOption() != "Foo" {
+		t.Errorf("SelectedOption() = %q", ac.SelectedOption())
+	}
+}
+
+func TestAutocompleteNote(t *testing.T) {
+	ac := NewAutocomplete([]string{"⎕NGET", "⎕NPUT"}, 1, 0, 1)
+	if _, ok := ac.Note(); ok {
+		t.Error("note before lookup")
+	}
+	h := ac.Height(20)
+
+	ac.SetNote("⎕NGET", "Native File Get")
+	note, ok := ac.Note()
+	if note != "Native File Get" || !ok {
+		t.Errorf("Note() = %q, %v", note, ok)
+	}
+	lines := strings.Split(stripANSI(ac.Render(40, 20)), "\n")
+	if got := lines[len(lines)-2]; !strings.HasPrefix(got, "│Native File Get") {
+		t.Errorf("note row = %q", got)
+	}
+	if len(lines) != ac.Height(20) || ac.Height(20) != h+2 {
+		t.Errorf("rendered %d rows, Height() = %d (was %d)", len(lines), ac.Height(20), h)
+	}
+
+	// Only the selected option's note shows
+	ac.CycleNext()
+	if lines := strings.Split(ac.Render(40, 20), "\n"); len(lines) != h {
+		t.Errorf("unlooked-up option: %d rows, want %d", len(lines), h)
+	}
+}
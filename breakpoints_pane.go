@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// BreakpointEntry is one function's breakpoints
+type BreakpointEntry struct {
+	Name  string
+	Lines []int // ⎕STOP line numbers, ascending (0 = header, like EditorWindow.Stop)
+}
+
+// Breakpoints tracks ⎕STOP settings across functions. Updated from editor and
+// tracer windows and from ⎕STOP queries. Shared by pointer so it survives
+// Model copies.
+type Breakpoints struct {
+	stops map[string][]int
+}
+
+// Set records a function's breakpoints; no lines removes the function
+func (b *Breakpoints) Set(name string, lines []int) {
+	name = strings.TrimPrefix(name, "#.")
+	if name == "" {
+		return
+	}
+	if len(lines) == 0 {
+		delete(b.stops, name)
+		return
+	}
+	if b.stops == nil {
+		b.stops = make(map[string][]int)
+	}
+	sorted := append([]int(nil), lines...)
+	sort.Ints(sorted)
+	b.stops[name] = sorted
+}
+
+// Names returns the functions with breakpoints, sorted
+func (b *Breakpoints) Names() []string {
+	names := make([]string, 0, len(b.stops))
+	for name := range b.stops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List returns the functions with breakpoints, sorted by name
+func (b *Breakpoints) List() []BreakpointEntry {
+	var entries []BreakpointEntry
+	for _, name := range b.Names() {
+		entries = append(entries, BreakpointEntry{Name: name, Lines: b.stops[name]})
+	}
+	return entries
+}
+
+// aplNames quotes names as an APL nested vector of strings. Each is
+// enclosed on its own: stranded, one-letter names would make a simple
+// string ('a' 'b' is 'ab').
+func aplNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "(⊂,'" + strings.ReplaceAll(name, "'", "''") + "')"
+	}
+	return "," + strings.Join(quoted, ",")
+}
+
+// stopQueryExpr asks for ⎕STOP of every function and operator in the current
+// namespace plus the known names, one "name: lines" row each
+func stopQueryExpr(known []string) string {
+	names := "⎕NL ¯3 ¯4"
+	if len(known) > 0 {
+		names = "∪(" + names + ")," + aplNames(known)
+	}
+	return "↑{⍵,': ',⍕⎕STOP ⍵}¨" + names
+}
+
+// stopClearExpr clears ⎕STOP on the given functions without output
+func stopClearExpr(names []string) string {
+	return "{}⍬∘⎕STOP¨" + aplNames(names)
+}
+
+// parseBreakArgs parses the break command's "FnName 3" (or "FnName[3]");
+// several line numbers may follow the name
+func parseBreakArgs(args string) (name string, lines []int, err error) {
+	fields := strings.Fields(strings.NewReplacer("[", " ", "]", " ").Replace(args))
+	if len(fields) < 2 {
+		return "", nil, fmt.Errorf("usage: break FnName line")
+	}
+	for _, field := range fields[1:] {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return "", nil, fmt.Errorf("bad line number %q", field)
+		}
+		lines = append(lines, n)
+	}
+	return fields[0], lines, nil
+}
+
+// stopToggleExpr toggles ⎕STOP on lines of a function and prints the
+// function's stops afterwards
+func stopToggleExpr(name string, lines []int) string {
+	nums := make([]string, len(lines))
+	for i, n := range lines {
+		nums[i] = strconv.Itoa(n)
+	}
+	fn := "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	return fmt.Sprintf("{}((,%s){(⍺~⍵),⍵~⍺}⎕STOP%s)⎕STOP%s⋄⎕STOP%s", strings.Join(nums, " "), fn, fn, fn)
+}
+
+// parseStops parses a printed ⎕STOP result; ok is false for anything else,
+// such as an error message
+func parseStops(outputs []string) (lines []int, ok bool) {
+	for _, field := range strings.Fields(strings.Join(outputs, " ")) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		lines = append(lines, n)
+	}
+	return lines, true
+}
+
+// parseStopQuery parses stopQueryExpr output into name → lines. Functions
+// without breakpoints map to nil.
+func parseStopQuery(outputs []string) map[string][]int {
+	stops := make(map[string][]int)
+	for _, line := range strings.Split(strings.Join(outputs, "\n"), "\n") {
+		name, rest, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || name == "" {
+			continue
+		}
+		var lines []int
+		for _, field := range strings.Fields(rest) {
+			if n, err := strconv.Atoi(field); err == nil {
+				lines = append(lines, n)
+			}
+		}
+		stops[name] = lines
+	}
+	return stops
+}
+
+// BreakpointsPane lists every function with breakpoints
+type BreakpointsPane struct {
+	getEntries func() []BreakpointEntry
+	selected   int
+
+	// Set by HandleKey for the model to act on
+	JumpTo     string   // Open this function
+	ClearNames []string // Clear ⎕STOP on these functions
+
+	// Styles
+	normalStyle   lipgloss.Style
+	selectedStyle lipgloss.Style
+	lineStyle     lipgloss.Style
+}
+
+// NewBreakpointsPane creates a breakpoints pane
+func NewBreakpointsPane(getEntries func() []BreakpointEntry) *BreakpointsPane {
+	b := &BreakpointsPane{
+		getEntries:  getEntries,
+		normalStyle: lipgloss.NewStyle(),
+	}
+	b.ApplyTheme()
+	return b
+}
+
+// ApplyTheme rebuilds the pane's styles from the theme
+func (b *BreakpointsPane) ApplyTheme() {
+	b.selectedStyle = lipgloss.NewStyle().Background(theme.Selection)
+	b.lineStyle = lipgloss.NewStyle().Foreground(theme.Breakpoint) // Like the editor's ●
+}
+
+func (b *BreakpointsPane) Title() string {
+	return fmt.Sprintf("breakpoints (%d)", len(b.getEntries()))
+}
+
+func (b *BreakpointsPane) Render(w, h int) string {
+	entries := b.getEntries()
+	if len(entries) == 0 {
+		return "  (no breakpoints)"
+	}
+
+	// Clamp selected to valid range
+	if b.selected >= len(entries) {
+		b.selected = len(entries) - 1
+	}
+	if b.selected < 0 {
+		b.selected = 0
+	}
+
+	var lines []string
+	for i, entry := range entries {
+		nums := make([]string, len(entry.Lines))
+		for j, n := range entry.Lines {
+			nums[j] = strconv.Itoa(n)
+		}
+
+		// Format: "name  [1 3 5]", truncating the name to fit
+		stops := "[" + strings.Join(nums, " ") + "]"
+		name := entry.Name
+		nameWidth := w - len(stops) - 2
+		if len([]rune(name)) > nameWidth && nameWidth > 3 {
+			name = string([]rune(name)[:nameWidth-3]) + "..."
+		}
+		pad := max(w-len([]rune(name))-len(stops), 1)
+
+		if i == b.selected {
+			lines = append(lines, b.selectedStyle.Render(name+strings.Repeat(" ", pad)+stops))
+		} else {
+			lines = append(lines, b.normalStyle.Render(name)+strings.Repeat(" ", pad)+b.lineStyle.Render(stops))
+		}
+	}
+
+	// Pad remaining height
+	for len(lines) < h {
+		lines = append(lines, strings.Repeat(" ", w))
+	}
+
+	return strings.Join(lines[:h], "\n")
+}
+
+func (b *BreakpointsPane) HandleKey(msg tea.KeyMsg) bool {
+	entries := b.getEntries()
+	if len(entries) == 0 {
+		return false
+	}
+	if b.selected >= len(entries) {
+		b.selected = len(entries) - 1
+	}
+
+	switch msg.Type {
+	case tea.KeyUp:
+		if b.selected > 0 {
+			b.selected--
+		}
+		return true
+	case tea.KeyDown:
+		if b.selected < len(entries)-1 {
+			b.selected++
+		}
+		return true
+	case tea.KeyEnter:
+		b.JumpTo = entries[b.selected].Name
+		return true
+	case tea.KeyDelete:
+		b.ClearNames = []string{entries[b.selected].Name}
+		return true
+	case tea.KeyRunes:
+		switch string(msg.Runes) {
+		case "d":
+			b.ClearNames = []string{entries[b.selected].Name}
+			return true
+		case "D":
+			b.ClearNames = make([]string, len(entries))
+			for i, entry := range entries {
+				b.ClearNames[i] = entry.Name
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BreakpointsPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
+	entries := b.getEntries()
+	if len(entries) == 0 {
+		return false
+	}
+
+	if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
+		// Click to select
+		if y >= 0 && y < len(entries) {
+			b.selected = y
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBreakpointsRegistry(t *testing.T) {
+	var b Breakpoints
+	b.Set("#.Foo", []int{5, 1})
+	b.Set("Bar", []int{2})
+	b.Set("Baz", nil)
+
+	want := []BreakpointEntry{{Name: "Bar", Lines: []int{2}}, {Name: "Foo", Lines: []int{1, 5}}}
+	if got := b.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	b.Set("Foo", nil)
+	if got := b.Names(); !reflect.DeepEqual(got, []string{"Bar"}) {
+		t.Errorf("Names() after clearing Foo = %v", got)
+	}
+}
+
+func TestStopExpressions(t *testing.T) {
+	if got := stopQueryExpr(nil); got != "↑{⍵,': ',⍕⎕STOP ⍵}¨⎕NL ¯3 ¯4" {
+		t.Errorf("stopQueryExpr(nil) = %q", got)
+	}
+	if got := stopQueryExpr([]string{"ns.Foo"}); got != "↑{⍵,': ',⍕⎕STOP ⍵}¨∪(⎕NL ¯3 ¯4),,(⊂,'ns.Foo')" {
+		t.Errorf("stopQueryExpr(ns.Foo) = %q", got)
+	}
+	if got := stopClearExpr([]string{"A", "B"}); got != "{}⍬∘⎕STOP¨,(⊂,'A'),(⊂,'B')" {
+		t.Errorf("stopClearExpr = %q", got)
+	}
+}
+
+func TestAplNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"a", "b"}, ",(⊂,'a'),(⊂,'b')"}, // Not the simple string 'ab'
+		{[]string{"x"}, ",(⊂,'x')"},
+		{[]string{"Foo", "ns.Bar"}, ",(⊂,'Foo'),(⊂,'ns.Bar')"},
+		{[]string{"it's"}, ",(⊂,'it''s')"},
+	}
+	for _, tt := range tests {
+		if got := aplNames(tt.names); got != tt.want {
+			t.Errorf("aplNames(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestParseStopQuery(t *testing.T) {
+	got := parseStopQuery([]string{"Foo: 1 3  \nBar:     ", "ns.Baz: 0"})
+	want := map[string][]int{"Foo": {1, 3}, "Bar": nil, "ns.Baz": {0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStopQuery = %v, want %v", got, want)
+	}
+}
+
+func TestBreakpointsPaneKeys(t *testing.T) {
+	b := &Breakpoints{}
+	b.Set("Foo", []int{1})
+	b.Set("Bar", []int{2, 4})
+	p := NewBreakpointsPane(b.List)
+
+	p.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	p.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if p.JumpTo != "Foo" {
+		t.Errorf("JumpTo = %q, want Foo", p.JumpTo)
+	}
+
+	p.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if !reflect.DeepEqual(p.ClearNames, []string{"Foo"}) {
+		t.Errorf("d: ClearNames = %v", p.ClearNames)
+	}
+	p.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if !reflect.DeepEqual(p.ClearNames, []string{"Bar", "Foo"}) {
+		t.Errorf("D: ClearNames = %v", p.ClearNames)
+	}
+}
+
+func TestParseBreakArgs(t *testing.T) {
+	tests := []struct {
+		args  string
+		name  string
+		lines []int
+		ok    bool
+	}{
+		{"Foo 3", "Foo", []int{3}, true},
+		{"ns.Foo[2]", "ns.Foo", []int{2}, true},
+		{"  Foo 1 4 ", "Foo", []int{1, 4}, true},
+		{"Foo", "", nil, false},
+		{"Foo x", "", nil, false},
+		{"Foo -1", "", nil, false},
+	}
+	for _, tt := range tests {
+		name, lines, err := parseBreakArgs(tt.args)
+		if (err == nil) != tt.ok || name != tt.name || !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("parseBreakArgs(%q) = %q, %v, %v", tt.args, name, lines, err)
+		}
+	}
+}
+
+func TestStopToggleExpr(t *testing.T) {
+	want := "{}((,3){(⍺~⍵),⍵~⍺}⎕STOP'Foo')⎕STOP'Foo'⋄⎕STOP'Foo'"
+	if got := stopToggleExpr("Foo", []int{3}); got != want {
+		t.Errorf("stopToggleExpr = %q, want %q", got, want)
+	}
+	if lines, ok := parseStops([]string{"1 3\n"}); !ok || !reflect.DeepEqual(lines, []int{1, 3}) {
+		t.Errorf("parseStops(1 3) = %v, %v", lines, ok)
+	}
+	if lines, ok := parseStops(nil); !ok || lines != nil {
+		t.Errorf("parseStops(empty) = %v, %v", lines, ok)
+	}
+	if _, ok := parseStops([]string{"VALUE ERROR"}); ok {
+		t.Error("parseStops should reject an error message")
+	}
+}
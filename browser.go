@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var errNoDisplay = errors.New("no display")
+
+// browserCommand returns the command that opens url in the system browser,
+// or nil if there's no display to open it on (e.g. over ssh)
+func browserCommand(goos, url string, getenv func(string) string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	if getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+		return nil
+	}
+	return exec.Command("xdg-open", url)
+}
+
+// openURL opens url in the system browser. Without one (e.g. over ssh), it's
+// copied to the clipboard instead.
+func (m *Model) openURL(url string) tea.Cmd {
+	cmd := browserCommand(runtime.GOOS, url, os.Getenv)
+	err := errNoDisplay
+	if cmd != nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		m.log("Can't open %s in a browser: %v", url, err)
+		m.statusMsg = "No browser, copied " + url
+		return copyToClipboard(url)
+	}
+	go cmd.Wait() // Reap it; the browser carries on without us
+	m.log("Opened %s", url)
+	m.statusMsg = "Opened " + url
+	return nil
+}
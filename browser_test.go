@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestBrowserCommand(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	none := env(nil)
+	if cmd := browserCommand("darwin", "https://x", none); cmd == nil || cmd.Args[0] != "open" {
+		t.Errorf("darwin: %v", cmd)
+	}
+	if cmd := browserCommand("windows", "https://x", none); cmd == nil || cmd.Args[len(cmd.Args)-1] != "https://x" {
+		t.Errorf("windows: %v", cmd)
+	}
+	if cmd := browserCommand("linux", "https://x", env(map[string]string{"DISPLAY": ":0"})); cmd == nil || cmd.Args[0] != "xdg-open" {
+		t.Errorf("linux: %v", cmd)
+	}
+	if cmd := browserCommand("linux", "https://x", none); cmd != nil {
+		t.Errorf("linux without a display: %v", cmd.Args)
+	}
+}
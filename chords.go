@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chordTimeout is how long a multi-key leader chord waits for its next key
+const chordTimeout = 1500 * time.Millisecond
+
+// chordNode is a step in the trie of key sequences that follow the leader:
+// the action bound to the keys pressed so far, if any, and the keys that
+// continue the chord
+type chordNode struct {
+	action string
+	next   map[string]*chordNode
+}
+
+// add binds a space-separated key sequence such as "w h" to action
+func (n *chordNode) add(seq, action string) {
+	node := n
+	for _, k := range strings.Fields(seq) {
+		if node.next == nil {
+			node.next = make(map[string]*chordNode)
+		}
+		child := node.next[k]
+		if child == nil {
+			child = &chordNode{}
+			node.next[k] = child
+		}
+		node = child
+	}
+	if node != n {
+		node.action = action
+	}
+}
+
+// chordTimeoutMsg ends a chord still waiting for a key after chordTimeout.
+// seq tells a stale timeout from the current chord's.
+type chordTimeoutMsg struct {
+	seq int
+}
+
+// startChord begins a chord after the leader key
+func (m *Model) startChord() {
+	m.chord = m.keys.Chords
+	if m.chord == nil {
+		m.chord = &chordNode{}
+	}
+	m.chordKeys = nil
+}
+
+// endChord abandons the chord in progress
+func (m *Model) endChord() {
+	m.chord = nil
+	m.chordKeys = nil
+}
+
+// chordKey advances the chord with a key. A complete sequence runs its
+// action; a prefix of longer sequences waits for the next key, running its
+// own action if it has one and the chord times out. Unknown keys end the
+// chord.
+func (m Model) chordKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	node := m.chord.next[msg.String()]
+	if node == nil {
+		m.endChord()
+		return m, nil
+	}
+	if len(node.next) == 0 {
+		m.endChord()
+		return m.leaderAction(node.action)
+	}
+
+	m.chord = node
+	m.chordKeys = append(m.chordKeys, msg.String())
+	m.chordSeq++
+	seq := m.chordSeq
+	return m, tea.Tick(chordTimeout, func(time.Time) tea.Msg { return chordTimeoutMsg{seq: seq} })
+}
+
+// chordTimedOut handles a chordTimeoutMsg
+func (m Model) chordTimedOut(msg chordTimeoutMsg) (tea.Model, tea.Cmd) {
+	if m.chord == nil || msg.seq != m.chordSeq {
+		return m, nil
+	}
+	action := m.chord.action
+	m.endChord()
+	if action == "" {
+		return m, nil
+	}
+	return m.leaderAction(action)
+}
+
+// leaderAction runs the action bound to a leader chord. Actions are named
+// after their KeyMapConfig keys.
+func (m Model) leaderAction(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case "toggle_debug":
+		m.toggleDebugPane()
+	case "toggle_stack":
+		m.toggleStackPane()
+	case "toggle_locals":
+		m.toggleVariablesPane()
+	case "toggle_breakpoint":
+		m.toggleBreakpoint()
+	case "reconnect":
+		return m.reconnect()
+	case "command_palette":
+		m.openCommandPalette()
+	case "pane_move_mode":
+		if m.panes.FocusedPane() != nil {
+			m.paneMoveMode = true
+		}
+	case "show_keys":
+		m.toggleKeysPane()
+	case "jump_definition":
+		m.jumpToDefinition()
+	case "doc_symbol":
+		return m.openDocHelp()
+	case "cycle_pane":
+		if m.panes.HasPanes() {
+			m.panes.FocusNext()
+		}
+	case "focus_left":
+		m.panes.FocusDirection(-1, 0)
+	case "focus_right":
+		m.panes.FocusDirection(1, 0)
+	case "focus_up":
+		m.panes.FocusDirection(0, -1)
+	case "focus_down":
+		m.panes.FocusDirection(0, 1)
+	case "quit":
+		m.confirmQuit = true
+	}
+	return m, nil
+}
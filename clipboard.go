@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// osc52 returns the OSC 52 escape sequence that sets the system clipboard
+// (supported by most modern terminals, and by tmux with set-clipboard on)
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+// termOutput is the program's output (tea.WithOutput). Writes are taken one
+// at a time, so a sequence sent from a Cmd goes between the renderer's
+// frames, never into one. The *os.File is embedded so bubbletea still sees
+// a terminal it can size.
+type termOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *termOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+// WriteString hides the embedded File's, which would skip the lock
+func (o *termOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// programOutput is where the TUI draws, and where terminal sequences of
+// its own (OSC 52) are written
+var programOutput = &termOutput{File: os.Stdout}
+
+// copyToClipboard returns a command that writes text to the clipboard via OSC 52
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		io.WriteString(programOutput, osc52(text))
+		return nil
+	}
+}
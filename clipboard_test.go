@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOSC52(t *testing.T) {
+	got := osc52("⍳")
+	want := "\x1b]52;c;4o2z\x07"
+	if got != want {
+		t.Errorf("osc52(⍳) = %q, want %q", got, want)
+	}
+}
+
+func TestCopyToClipboardOutput(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved := programOutput
+	programOutput = &termOutput{File: f}
+	t.Cleanup(func() { programOutput = saved })
+
+	// Written to the program's output, whole, alongside its frames
+	const frame = "frame\n"
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(2)
+		go func() { defer wg.Done(); io.WriteString(programOutput, frame) }()
+		go func() { defer wg.Done(); copyToClipboard("⍳")() }()
+	}
+	wg.Wait()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest := strings.ReplaceAll(strings.ReplaceAll(string(data), osc52("⍳"), ""), frame, "")
+	if rest != "" || strings.Count(string(data), osc52("⍳")) != 20 {
+		t.Errorf("output = %q", data)
+	}
+}
+
+func TestSymbolSearchCodepointAndCopy(t *testing.T) {
+	s := NewSymbolSearch()
+	s.query = "iota"
+	s.filter()
+
+	out := s.Render(60, 10)
+	if !strings.Contains(out, "U+2373") {
+		t.Errorf("render missing codepoint U+2373:\n%s", out)
+	}
+
+	s.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if s.CopySymbol != '⍳' {
+		t.Errorf("CopySymbol = %q, want ⍳", string(s.CopySymbol))
+	}
+	if s.query != "iota" {
+		t.Errorf("query changed to %q by copy", s.query)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build sqlite_fts5
+
+package main
+
+// ftsBuilt is whether sqlite was built with FTS5, the default for -fts
+const ftsBuilt = true
@@ -0,0 +1,34 @@
+//go:build sqlite_fts5
+
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateFTS(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := createTables(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO docs (path, file, content) VALUES ('Ref / Iota', 'ref/iota.md', 'index generator')`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Built with the tag, the index must be made, and made twice harmlessly
+	for range 2 {
+		if err := createFTS(db); err != nil {
+			t.Fatalf("createFTS: %v", err)
+		}
+	}
+	var path string
+	if err := db.QueryRow("SELECT path FROM docs_fts WHERE docs_fts MATCH 'generator'").Scan(&path); err != nil || path != "Ref / Iota" {
+		t.Errorf("MATCH 'generator' = %q, %v", path, err)
+	}
+}
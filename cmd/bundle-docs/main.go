@@ -3,6 +3,19 @@
 // content keyed by navigation path.
 //
 //	go build ./cmd/bundle-docs
+//
+// To refresh a database quickly, keep the clone with -repo-dir and update
+// the database in place with -incremental: the clone is pulled rather than
+// fetched again, and only docs that changed are written.
+//
+//	bundle-docs -repo-dir ~/src/dyalog-docs -incremental
+//
+// Build with -tags sqlite_fts5 to also produce the docs_fts full-text index
+// used by gritt's docs search (without it -fts is off and the index skipped).
+//
+// Heading anchors go in the anchors table (path, slug → content line) so
+// links to a section of a page open at that section. The meta table records
+// the docs commit, build time, bundle-docs version and doc count.
 package main
 
 import (
@@ -15,7 +28,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"gopkg.in/yaml.v3"
@@ -33,29 +50,34 @@ func main() {
 	repo := flag.String("repo", "git@github.com:Dyalog/documentation.git", "documentation repo URL")
 	helpURLs := flag.String("help-urls", "help_urls.h", "path to help_urls.h")
 	keep := flag.Bool("keep", false, "keep cloned repo (print path)")
+	repoDir := flag.String("repo-dir", "", "clone the repo here, or pull if it's already cloned, and keep it")
+	incremental := flag.Bool("incremental", false, "update the existing -o database in place, writing only changed docs")
+	fts := flag.Bool("fts", ftsBuilt, "build docs_fts full-text index (needs -tags sqlite_fts5, on by default with it)")
 	flag.Parse()
 
-	// Clone repo
-	tmpDir, err := os.MkdirTemp("", "dyalog-docs-*")
-	if err != nil {
+	root := *repoDir
+	if root == "" {
+		// Clone repo
+		tmpDir, err := os.MkdirTemp("", "dyalog-docs-*")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !*keep {
+			defer os.RemoveAll(tmpDir)
+		}
+		if err := cloneRepo(*repo, tmpDir); err != nil {
+			log.Fatal(err)
+		}
+		if *keep {
+			fmt.Fprintf(os.Stderr, "Repo cloned to: %s\n", tmpDir)
+		}
+		root = tmpDir
+	} else if err := updateRepo(*repo, root); err != nil {
 		log.Fatal(err)
 	}
-	if !*keep {
-		defer os.RemoveAll(tmpDir)
-	}
-
-	fmt.Fprintf(os.Stderr, "Cloning %s...\n", *repo)
-	cmd := exec.Command("git", "clone", "--depth=1", "--branch=main", "--single-branch", *repo, tmpDir)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("git clone failed: %v", err)
-	}
-	if *keep {
-		fmt.Fprintf(os.Stderr, "Repo cloned to: %s\n", tmpDir)
-	}
 
 	// Parse top-level mkdocs.yml
-	cfg, err := parseMkdocs(filepath.Join(tmpDir, "mkdocs.yml"))
+	cfg, err := parseMkdocs(filepath.Join(root, "mkdocs.yml"))
 	if err != nil {
 		log.Fatalf("parsing mkdocs.yml: %v", err)
 	}
@@ -66,48 +88,49 @@ func main() {
 	if docsDir == "" {
 		docsDir = "docs"
 	}
-	walkNav(cfg.Nav, filepath.Join(tmpDir, docsDir), tmpDir, nil, &docs)
+	walkNav(cfg.Nav, filepath.Join(root, docsDir), root, nil, &docs)
 
 	fmt.Fprintf(os.Stderr, "Found %d documents\n", len(docs))
 
-	// Write database
-	os.Remove(*output)
+	// Write database, from scratch unless updating one that exists
+	if *incremental {
+		if _, err := os.Stat(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "No %s to update, building it\n", *output)
+			*incremental = false
+		}
+	}
+	if !*incremental {
+		os.Remove(*output)
+	}
 	db, err := sql.Open("sqlite3", *output)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	if _, err := db.Exec(`
-		CREATE TABLE docs (
-			path TEXT PRIMARY KEY,
-			file TEXT NOT NULL,
-			content TEXT NOT NULL
-		);
-		CREATE TABLE help_urls (
-			symbol TEXT PRIMARY KEY,
-			path TEXT NOT NULL
-		);
-	`); err != nil {
+	if err := createTables(db); err != nil {
 		log.Fatal(err)
 	}
 
+	// Full-text index, kept in step with docs by trigger so every write
+	// below updates it in the same transaction
+	if *fts {
+		if err := createFTS(db); err != nil {
+			log.Printf("warning: skipping full-text index: %v", err)
+		}
+	}
+
 	tx, err := db.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
-	ins, err := tx.Prepare("INSERT OR IGNORE INTO docs (path, file, content) VALUES (?, ?, ?)")
+	w, err := newDocWriter(tx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	for _, d := range docs {
-		if _, err := ins.Exec(d.path, d.file, d.content); err != nil {
-			log.Printf("insert %s: %v", d.path, err)
-		}
-	}
-	if err := tx.Commit(); err != nil {
-		log.Fatal(err)
+		w.put(d)
 	}
 
 	// Build file-to-path index for help_urls matching
@@ -119,70 +142,339 @@ func main() {
 		fileIndex[norm] = d.path
 	}
 
-	// Parse help_urls.h and insert mappings
+	// Parse help_urls.h
+	var entries []helpURLEntry
 	if *helpURLs != "" {
-		entries, err := parseHelpURLs(*helpURLs)
+		entries, err = parseHelpURLs(*helpURLs)
 		if err != nil {
 			log.Printf("warning: help_urls: %v", err)
-		} else {
-			// First pass: find unmatched URLs and try to add their files to the docs table.
-			// These are disambiguation pages (e.g. symbols/iota) referenced by help_urls.h
-			// but not in the mkdocs nav.
-			added := 0
-			tx2, err := db.Begin()
-			if err != nil {
-				log.Fatal(err)
-			}
-			docIns, err := tx2.Prepare("INSERT OR IGNORE INTO docs (path, file, content) VALUES (?, ?, ?)")
-			if err != nil {
-				log.Fatal(err)
-			}
-			for _, e := range entries {
-				if _, ok := matchHelpURL(e.url, fileIndex); ok {
-					continue // already in docs
-				}
-				// Try to find the markdown file in the repo
-				navPath, filePath, content, ok := findHelpFile(e.url, tmpDir)
-				if !ok {
-					continue
-				}
-				docIns.Exec(navPath, filePath, content)
-				fileIndex[normalizeFilePath(filePath)] = navPath
-				added++
-			}
-			if err := tx2.Commit(); err != nil {
-				log.Fatal(err)
-			}
-			if added > 0 {
-				fmt.Fprintf(os.Stderr, "Added %d disambiguation pages from help_urls.h\n", added)
-			}
+		}
+	}
 
-			// Second pass: now match all help URLs to docs
-			matched := 0
-			tx3, err := db.Begin()
-			if err != nil {
-				log.Fatal(err)
-			}
-			hins, err := tx3.Prepare("INSERT OR IGNORE INTO help_urls (symbol, path) VALUES (?, ?)")
-			if err != nil {
-				log.Fatal(err)
-			}
-			for _, e := range entries {
-				if navPath, ok := matchHelpURL(e.url, fileIndex); ok {
-					hins.Exec(e.symbol, navPath)
-					matched++
-				}
+	// Find unmatched URLs and try to add their files to the docs table.
+	// These are disambiguation pages (e.g. symbols/iota) referenced by
+	// help_urls.h but not in the mkdocs nav.
+	added := 0
+	for _, e := range entries {
+		if _, _, ok := matchHelpURL(e.url, fileIndex); ok {
+			continue // already in docs
+		}
+		// Try to find the markdown file in the repo
+		navPath, filePath, content, ok := findHelpFile(e.url, root)
+		if !ok {
+			continue
+		}
+		w.put(docEntry{path: navPath, file: filePath, content: content})
+		fileIndex[normalizeFilePath(filePath)] = navPath
+		added++
+	}
+	if added > 0 {
+		fmt.Fprintf(os.Stderr, "Added %d disambiguation pages from help_urls.h\n", added)
+	}
+
+	// Docs no longer in the repo
+	if err := w.removeStale(); err != nil {
+		log.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+	if *incremental {
+		fmt.Fprintf(os.Stderr, "Docs: %d added, %d changed, %d removed, %d unchanged\n",
+			w.added, w.changed, w.removed, len(w.seen)-w.added-w.changed)
+	}
+
+	// Now match all help URLs to docs
+	if *helpURLs != "" {
+		matched := 0
+		tx3, err := db.Begin()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The mapping is cheap to rebuild, so it always is
+		if _, err := tx3.Exec("DELETE FROM help_urls"); err != nil {
+			log.Fatal(err)
+		}
+		hins, err := tx3.Prepare("INSERT OR IGNORE INTO help_urls (symbol, path) VALUES (?, ?)")
+		if err != nil {
+			log.Fatal(err)
+		}
+		warned := make(map[string]bool) // Ambiguous URLs, logged once
+		for _, e := range entries {
+			navPath, others, ok := matchHelpURL(e.url, fileIndex)
+			if !ok {
+				continue
 			}
-			if err := tx3.Commit(); err != nil {
-				log.Fatal(err)
+			if len(others) > 0 && !warned[e.url] {
+				log.Printf("warning: help url %s also matches %s; using %s", e.url, strings.Join(others, ", "), navPath)
+				warned[e.url] = true
 			}
-			fmt.Fprintf(os.Stderr, "Help URLs: %d parsed, %d matched to docs\n", len(entries), matched)
+			hins.Exec(e.symbol, navPath)
+			matched++
+		}
+		if err := tx3.Commit(); err != nil {
+			log.Fatal(err)
 		}
+		fmt.Fprintf(os.Stderr, "Help URLs: %d parsed, %d matched to docs\n", len(entries), matched)
+	}
+
+	// Where the docs came from, for gritt to show and check the age of
+	if err := writeMeta(db, root, time.Now()); err != nil {
+		log.Printf("warning: meta: %v", err)
 	}
 
 	fmt.Fprintf(os.Stderr, "Wrote %s\n", *output)
 }
 
+// writeMeta records the database's provenance in the meta table: the docs
+// commit it was built from, when, by which bundle-docs, and how many docs
+// it holds
+func writeMeta(db *sql.DB, repoDir string, now time.Time) error {
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM docs").Scan(&count); err != nil {
+		return err
+	}
+	commit, err := repoCommit(repoDir)
+	if err != nil {
+		log.Printf("warning: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, kv := range [][2]string{
+		{"commit", commit},
+		{"built_at", now.UTC().Format(time.RFC3339)},
+		{"bundler", bundlerVersion()},
+		{"docs", strconv.Itoa(count)},
+	} {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES (?, ?)", kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// repoCommit returns the SHA of the docs clone's HEAD
+func repoCommit(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// bundlerVersion identifies this build of bundle-docs: its module version,
+// and the gritt commit it was built from when Go recorded one
+func bundlerVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) >= 7 {
+			version += " " + s.Value[:7]
+		}
+	}
+	return version
+}
+
+// cloneRepo shallow-clones the docs repo's main branch into dir
+func cloneRepo(repo, dir string) error {
+	fmt.Fprintf(os.Stderr, "Cloning %s...\n", repo)
+	cmd := exec.Command("git", "clone", "--depth=1", "--branch=main", "--single-branch", repo, dir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+	return nil
+}
+
+// updateRepo brings the clone in dir up to date with main, cloning it first
+// if it isn't there yet
+func updateRepo(repo, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return cloneRepo(repo, dir)
+	}
+	// The clone is ours, so it just moves to the latest main
+	fmt.Fprintf(os.Stderr, "Updating %s...\n", dir)
+	for _, args := range [][]string{
+		{"fetch", "origin", "main"},
+		{"reset", "--hard", "FETCH_HEAD"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+// docWriter writes docs into the docs table, leaving rows whose content
+// hasn't changed alone. An existing database is updated in place rather
+// than rebuilt; a new one just gets inserts.
+type docWriter struct {
+	tx       *sql.Tx
+	existing map[string]string // path → content already in the table
+	anchored map[string]bool   // Paths with rows in anchors
+	seen     map[string]bool   // Paths written (or left as they were) this run
+
+	added, changed, removed int
+}
+
+func newDocWriter(tx *sql.Tx) (*docWriter, error) {
+	w := &docWriter{tx: tx, existing: make(map[string]string), anchored: make(map[string]bool), seen: make(map[string]bool)}
+	rows, err := tx.Query("SELECT path, content FROM docs")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path, content string
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, err
+		}
+		w.existing[path] = content
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	arows, err := tx.Query("SELECT DISTINCT path FROM anchors")
+	if err != nil {
+		return nil, err
+	}
+	defer arows.Close()
+	for arows.Next() {
+		var path string
+		if err := arows.Scan(&path); err != nil {
+			return nil, err
+		}
+		w.anchored[path] = true
+	}
+	return w, arows.Err()
+}
+
+// put writes a doc unless it's unchanged. The first doc with a path wins.
+func (w *docWriter) put(d docEntry) {
+	if w.seen[d.path] {
+		return
+	}
+	w.seen[d.path] = true
+	old, ok := w.existing[d.path]
+	var err error
+	switch {
+	case !ok:
+		_, err = w.tx.Exec("INSERT INTO docs (path, file, content) VALUES (?, ?, ?)", d.path, d.file, d.content)
+		w.added++
+	case old != d.content:
+		_, err = w.tx.Exec("UPDATE docs SET file = ?, content = ? WHERE path = ?", d.file, d.content, d.path)
+		w.changed++
+	}
+	if err != nil {
+		log.Printf("insert %s: %v", d.path, err)
+		return
+	}
+	// A database from before anchors were recorded gets them on the next run
+	if !ok || old != d.content || !w.anchored[d.path] {
+		if err := w.putAnchors(d); err != nil {
+			log.Printf("anchors %s: %v", d.path, err)
+		}
+	}
+}
+
+// putAnchors replaces the heading anchors recorded for a doc
+func (w *docWriter) putAnchors(d docEntry) error {
+	if _, err := w.tx.Exec("DELETE FROM anchors WHERE path = ?", d.path); err != nil {
+		return err
+	}
+	for _, a := range headingAnchors(d.content) {
+		if _, err := w.tx.Exec("INSERT INTO anchors (path, slug, line) VALUES (?, ?, ?)", d.path, a.slug, a.line); err != nil {
+			return err
+		}
+	}
+	w.anchored[d.path] = true
+	return nil
+}
+
+// removeStale deletes docs that were in the table but weren't put this run
+func (w *docWriter) removeStale() error {
+	for path := range w.existing {
+		if w.seen[path] {
+			continue
+		}
+		if _, err := w.tx.Exec("DELETE FROM docs WHERE path = ?", path); err != nil {
+			return err
+		}
+		if _, err := w.tx.Exec("DELETE FROM anchors WHERE path = ?", path); err != nil {
+			return err
+		}
+		w.removed++
+	}
+	return nil
+}
+
+// createTables creates the tables a new database needs, and any an older
+// one lacks
+func createTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS docs (
+			path TEXT PRIMARY KEY,
+			file TEXT NOT NULL,
+			content TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS help_urls (
+			symbol TEXT PRIMARY KEY,
+			path TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS anchors (
+			path TEXT NOT NULL,
+			slug TEXT NOT NULL,
+			line INTEGER NOT NULL,
+			PRIMARY KEY (path, slug)
+		);
+		CREATE TABLE IF NOT EXISTS meta (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);
+	`)
+	return err
+}
+
+// createFTS creates the docs_fts FTS5 table, filled from docs, and the
+// triggers that mirror writes to docs into it. Fails if the sqlite build
+// lacks FTS5.
+func createFTS(db *sql.DB) error {
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'docs_fts'`).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := db.Exec(`
+			CREATE VIRTUAL TABLE docs_fts USING fts5(content, path UNINDEXED);
+			INSERT INTO docs_fts (content, path) SELECT content, path FROM docs;
+		`); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS docs_fts_insert AFTER INSERT ON docs BEGIN
+			INSERT INTO docs_fts (content, path) VALUES (new.content, new.path);
+		END;
+		CREATE TRIGGER IF NOT EXISTS docs_fts_update AFTER UPDATE ON docs BEGIN
+			DELETE FROM docs_fts WHERE path = old.path;
+			INSERT INTO docs_fts (content, path) VALUES (new.content, new.path);
+		END;
+		CREATE TRIGGER IF NOT EXISTS docs_fts_delete AFTER DELETE ON docs BEGIN
+			DELETE FROM docs_fts WHERE path = old.path;
+		END;
+	`)
+	return err
+}
+
 type docEntry struct {
 	path    string // nav breadcrumb
 	file    string // relative path in repo
@@ -386,25 +678,37 @@ func expandMacro(expr string, macros map[string]string) string {
 }
 
 // matchHelpURL tries to match a help URL path to a doc entry's file path.
-func matchHelpURL(url string, fileIndex map[string]string) (string, bool) {
+// When several files end with the URL, the shortest (then first by name)
+// wins, so the same docs always give the same mapping; the rest are
+// returned as others.
+func matchHelpURL(url string, fileIndex map[string]string) (navPath string, others []string, ok bool) {
 	// Direct match
 	if navPath, ok := fileIndex[url]; ok {
-		return navPath, true
+		return navPath, nil, true
 	}
 
 	// Try with /index suffix (section pages)
 	if navPath, ok := fileIndex[url+"/index"]; ok {
-		return navPath, true
+		return navPath, nil, true
 	}
 
-	// Partial suffix match: find the entry whose normalized file path ends with the URL
-	for filePath, navPath := range fileIndex {
-		if strings.HasSuffix(filePath, "/"+url) || filePath == url {
-			return navPath, true
+	// Partial suffix match: find the entries whose normalized file path ends with the URL
+	var matches []string
+	for filePath := range fileIndex {
+		if strings.HasSuffix(filePath, "/"+url) {
+			matches = append(matches, filePath)
 		}
 	}
-
-	return "", false
+	if len(matches) == 0 {
+		return "", nil, false
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if len(matches[i]) != len(matches[j]) {
+			return len(matches[i]) < len(matches[j])
+		}
+		return matches[i] < matches[j]
+	})
+	return fileIndex[matches[0]], matches[1:], true
 }
 
 // findHelpFile locates a markdown file in the cloned repo for a help URL path
@@ -454,6 +758,11 @@ func cleanContent(raw []byte) string {
 	// Remove hidden divs (search keywords)
 	s = hiddenDivRe.ReplaceAllString(s, "")
 
+	// Convert <table> to a markdown table, then !!! note admonitions
+	// (which may hold tables) to blockquotes
+	s = tableRe.ReplaceAllStringFunc(s, convertTable)
+	s = convertAdmonitions(s)
+
 	// Convert <h1>...<h3> to markdown headings
 	s = h1Re.ReplaceAllString(s, "# $1")
 	s = h2Re.ReplaceAllString(s, "## $1")
@@ -480,6 +789,186 @@ func cleanContent(raw []byte) string {
 	return s
 }
 
+var admonitionRe = regexp.MustCompile(`^(?:!!!|\?\?\?\+?)\s+([\w-]+)(?:\s+"([^"]*)")?\s*$`)
+
+// convertAdmonitions turns mkdocs admonitions (!!! type "Title", or
+// collapsible ???) and their indented bodies into blockquotes headed by
+// the title, or the type when there's none
+func convertAdmonitions(s string) string {
+	lines := strings.Split(s, "\n")
+	var out []string
+	inFence := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		m := admonitionRe.FindStringSubmatch(line)
+		if inFence || m == nil {
+			out = append(out, line)
+			continue
+		}
+
+		// The body is the indented lines after, with blank lines between
+		var body []string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			l := lines[j]
+			if strings.TrimSpace(l) == "" {
+				body = append(body, "")
+				continue
+			}
+			if !strings.HasPrefix(l, "    ") && !strings.HasPrefix(l, "\t") {
+				break
+			}
+			body = append(body, strings.TrimPrefix(strings.TrimPrefix(l, "\t"), "    "))
+		}
+		for len(body) > 0 && body[len(body)-1] == "" {
+			body = body[:len(body)-1]
+			j--
+		}
+		i = j - 1
+		for len(body) > 0 && body[0] == "" {
+			body = body[1:]
+		}
+
+		title := m[2]
+		if title == "" {
+			title = strings.ToUpper(m[1][:1]) + m[1][1:]
+		}
+		out = append(out, "> **"+title+"**")
+		if len(body) > 0 {
+			out = append(out, ">")
+		}
+		// Admonitions can nest
+		for _, l := range strings.Split(convertAdmonitions(strings.Join(body, "\n")), "\n") {
+			if l == "" {
+				out = append(out, ">")
+			} else {
+				out = append(out, "> "+l)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+var (
+	tableRe     = regexp.MustCompile(`(?ims)^([ \t]*)<table[^>]*>(.*?)</table>`)
+	tableRowRe  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	tableCellRe = regexp.MustCompile(`(?is)<t([hd])[^>]*>(.*?)</t[hd]>`)
+	codeTagRe   = regexp.MustCompile(`(?is)<(?:code|kbd)>(.*?)</(?:code|kbd)>`)
+	anyTagRe    = regexp.MustCompile(`<[^>]+>`)
+	spacesRe    = regexp.MustCompile(`\s+`)
+)
+
+// convertTable turns an HTML table into a markdown one, indented as the
+// <table> tag was. Its first row is the header; cells lose their markup
+// except code, and short rows are padded.
+func convertTable(table string) string {
+	m := tableRe.FindStringSubmatch(table)
+	indent := m[1]
+	var rows [][]string
+	width := 0
+	for _, tr := range tableRowRe.FindAllStringSubmatch(m[2], -1) {
+		var row []string
+		for _, td := range tableCellRe.FindAllStringSubmatch(tr[1], -1) {
+			cell := codeTagRe.ReplaceAllString(td[2], "`$1`")
+			cell = anyTagRe.ReplaceAllString(cell, "")
+			cell = strings.TrimSpace(spacesRe.ReplaceAllString(cell, " "))
+			row = append(row, strings.ReplaceAll(cell, "|", "\\|"))
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+			width = max(width, len(row))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	// Blank lines around, so it isn't run into a paragraph
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		sb.WriteString(indent + "| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString(indent + "|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// anchor is a heading's link target in a doc: the slug mkdocs gives it and
+// the line of the content it's on
+type anchor struct {
+	slug string
+	line int
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+	attrIDRe   = regexp.MustCompile(`\s*\{[^}]*#([\w-]+)[^}]*\}\s*$`)
+	mdLinkRe   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	slugDropRe = regexp.MustCompile(`[^\w\s-]`)
+	slugSepRe  = regexp.MustCompile(`[-\s]+`)
+)
+
+// headingAnchors returns the anchors of the ATX headings in cleaned
+// content, skipping code blocks. Headings with an attribute list id
+// ({ #id }) use it; others are slugged the way mkdocs' toc does, with
+// _1, _2... added to repeats.
+func headingAnchors(content string) []anchor {
+	var anchors []anchor
+	used := make(map[string]bool)
+	inFence := false
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		m := headingRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var slug string
+		if id := attrIDRe.FindStringSubmatch(m[1]); id != nil {
+			slug = id[1]
+		} else {
+			slug = slugify(m[1])
+		}
+		if slug == "" {
+			continue
+		}
+		for n, base := 1, slug; used[slug]; n++ {
+			slug = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[slug] = true
+		anchors = append(anchors, anchor{slug: slug, line: i})
+	}
+	return anchors
+}
+
+// slugify turns heading text into an anchor as Python-Markdown's toc does:
+// markup and non-ASCII dropped, lowercased, runs of spaces and hyphens
+// made one hyphen
+func slugify(heading string) string {
+	s := mdLinkRe.ReplaceAllString(heading, "$1")
+	s = strings.Map(func(r rune) rune {
+		if r > 0x7f || r == '`' || r == '*' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.ToLower(strings.TrimSpace(slugDropRe.ReplaceAllString(s, "")))
+	return slugSepRe.ReplaceAllString(s, "-")
+}
+
 var (
 	hiddenDivRe = regexp.MustCompile(`(?s)<div[^>]*display:\s*none[^>]*>.*?</div>\s*`)
 	h1Re        = regexp.MustCompile(`<h1[^>]*>(.*?)</h1>`)
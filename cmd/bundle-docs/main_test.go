@@ -0,0 +1,217 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDocWriterIncremental(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := createTables(db); err != nil {
+		t.Fatal(err)
+	}
+	fts := createFTS(db) == nil // Only with -tags sqlite_fts5
+
+	write := func(docs ...docEntry) *docWriter {
+		t.Helper()
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := newDocWriter(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range docs {
+			w.put(d)
+		}
+		if err := w.removeStale(); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	w := write(
+		docEntry{"Ref / Iota", "ref/iota.md", "index generator\n\n## Monadic\n"},
+		docEntry{"Ref / Rho", "ref/rho.md", "shape"},
+		docEntry{"Ref / Rho", "ref/rho2.md", "duplicate"}, // First path wins
+		docEntry{"Ref / Old", "ref/old.md", "gone soon\n\n## Soon\n"},
+	)
+	if w.added != 3 {
+		t.Errorf("first run added %d, want 3", w.added)
+	}
+
+	// As if the database was built before anchors were
+	db.Exec("DELETE FROM anchors WHERE path = 'Ref / Iota'")
+
+	w = write(
+		docEntry{"Ref / Iota", "ref/iota.md", "index generator\n\n## Monadic\n"},
+		docEntry{"Ref / Rho", "ref/rho.md", "shape and reshape\n\n## Reshape\n"},
+		docEntry{"Ref / Tally", "ref/tally.md", "count"},
+	)
+	if w.added != 1 || w.changed != 1 || w.removed != 1 {
+		t.Errorf("update: added %d, changed %d, removed %d, want 1 each", w.added, w.changed, w.removed)
+	}
+
+	got := map[string]string{}
+	rows, _ := db.Query("SELECT path, content FROM docs")
+	for rows.Next() {
+		var path, content string
+		rows.Scan(&path, &content)
+		got[path] = content
+	}
+	rows.Close()
+	want := map[string]string{"Ref / Iota": "index generator\n\n## Monadic\n", "Ref / Rho": "shape and reshape\n\n## Reshape\n", "Ref / Tally": "count"}
+	if len(got) != len(want) || got["Ref / Rho"] != want["Ref / Rho"] || got["Ref / Tally"] != "count" {
+		t.Errorf("docs = %v, want %v", got, want)
+	}
+
+	// Anchors follow the content they came from
+	var line int
+	if err := db.QueryRow("SELECT line FROM anchors WHERE path = 'Ref / Rho' AND slug = 'reshape'").Scan(&line); err != nil || line != 2 {
+		t.Errorf("anchor of changed doc = %d, %v, want line 2", line, err)
+	}
+	var n int
+	db.QueryRow("SELECT count(*) FROM anchors WHERE path = 'Ref / Old'").Scan(&n)
+	if n != 0 {
+		t.Errorf("removed doc has %d anchors", n)
+	}
+	if err := db.QueryRow("SELECT line FROM anchors WHERE path = 'Ref / Iota' AND slug = 'monadic'").Scan(&line); err != nil {
+		t.Errorf("unchanged doc without anchors didn't get them: %v", err)
+	}
+
+	if !fts {
+		t.Log("sqlite built without FTS5; index not checked")
+		return
+	}
+	// The index follows updates and deletes
+	db.QueryRow("SELECT count(*) FROM docs_fts").Scan(&n)
+	if n != 3 {
+		t.Errorf("docs_fts has %d rows, want 3", n)
+	}
+	var path string
+	if err := db.QueryRow("SELECT path FROM docs_fts WHERE docs_fts MATCH 'reshape'").Scan(&path); err != nil || path != "Ref / Rho" {
+		t.Errorf("match on changed content = %q, %v", path, err)
+	}
+	if err := db.QueryRow("SELECT path FROM docs_fts WHERE docs_fts MATCH 'soon'").Scan(&path); err != sql.ErrNoRows {
+		t.Errorf("removed doc still indexed: %v", err)
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	content := "# Index Generator `⍳`\n\nIntro\n\n```apl\n# not a heading\n```\n\n" +
+		"## Examples\n\n### See [Iota](iota.md) -- again!\n\n## Examples\n\n## Options { #opts }\n\n## ⍳\n"
+	want := []anchor{
+		{"index-generator", 0},
+		{"examples", 8},
+		{"see-iota-again", 10},
+		{"examples_1", 12},
+		{"opts", 14},
+	}
+	if got := headingAnchors(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("headingAnchors =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWriteMeta(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := createTables(db); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO docs VALUES ('Ref / Iota', 'ref/iota.md', 'index generator')")
+
+	// Not a clone, so no commit, but the rest is recorded
+	now := time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+	for range 2 {
+		if err := writeMeta(db, t.TempDir(), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := map[string]string{}
+	rows, _ := db.Query("SELECT key, value FROM meta")
+	for rows.Next() {
+		var k, v string
+		rows.Scan(&k, &v)
+		got[k] = v
+	}
+	rows.Close()
+	if got["built_at"] != "2026-09-01T08:00:00Z" || got["docs"] != "1" || got["bundler"] == "" || len(got) != 4 {
+		t.Errorf("meta = %v", got)
+	}
+}
+
+func TestCleanContentAdmonitions(t *testing.T) {
+	raw := "Intro\n\n!!! note\n    Arrays start at `⎕IO`.\n\n    Second paragraph.\n\nAfter\n\n" +
+		"??? warning \"Legacy\"\n    Old form.\n\n    !!! tip\n        Nested.\n\n```\n!!! note\n    code, not an admonition\n```\n"
+	want := "Intro\n\n> **Note**\n>\n> Arrays start at `⎕IO`.\n>\n> Second paragraph.\n\nAfter\n\n" +
+		"> **Legacy**\n>\n> Old form.\n>\n> > **Tip**\n> >\n> > Nested.\n\n```\n!!! note\n    code, not an admonition\n```\n"
+	if got := cleanContent([]byte(raw)); got != want {
+		t.Errorf("cleanContent =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCleanContentTables(t *testing.T) {
+	raw := `Before
+<table>
+<tr><th>Name</th><th>Meaning</th></tr>
+<tr><td><code>⎕IO</code></td><td>Index <strong>origin</strong>,
+  0 or 1</td></tr>
+<tr><td>a|b</td></tr>
+</table>
+After
+
+!!! note
+    <table><tr><td>x</td><td>y</td></tr></table>
+`
+	want := "Before\n\n" +
+		"| Name | Meaning |\n| --- | --- |\n| `⎕IO` | Index origin, 0 or 1 |\n| a\\|b |  |\n\n" +
+		"After\n\n> **Note**\n>\n> | x | y |\n> | --- | --- |\n\n"
+	if got := cleanContent([]byte(raw)); got != want {
+		t.Errorf("cleanContent =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMatchHelpURL(t *testing.T) {
+	fileIndex := map[string]string{
+		"language-reference-guide/symbols/iota":          "Ref / Symbols / Iota",
+		"programming-reference-guide/index":              "Prog",
+		"release-notes/v19/symbols/iota":                 "Release / Iota",
+		"object-reference/misc/symbols/iota":             "Object / Iota",
+		"language-reference-guide/primitive-functions/x": "Ref / X",
+	}
+	tests := []struct {
+		url, want string
+		others    int
+	}{
+		{"language-reference-guide/symbols/iota", "Ref / Symbols / Iota", 0},
+		{"programming-reference-guide", "Prog", 0},
+		{"primitive-functions/x", "Ref / X", 0},
+		// Three files end with symbols/iota: the shortest wins, every time
+		{"symbols/iota", "Release / Iota", 2},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got, others, ok := matchHelpURL(tt.url, fileIndex)
+			if !ok || got != tt.want || len(others) != tt.others {
+				t.Fatalf("matchHelpURL(%q) = %q, %v, %v; want %q with %d others", tt.url, got, others, ok, tt.want, tt.others)
+			}
+		}
+	}
+	if _, _, ok := matchHelpURL("symbols/rho", fileIndex); ok {
+		t.Error("matched a URL no file ends with")
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -9,53 +13,144 @@ import (
 
 // Command represents an executable command in the palette
 type Command struct {
-	Name string
-	Help string
+	Name   string
+	Help   string
+	Args   string // Argument hint (e.g. "[ns:]path"), empty if none taken
+	Interp bool   // Sent to the interpreter as typed ()fns, ]link.status)
 }
 
+// commandMRULimit caps how many recently used commands are remembered
+const commandMRULimit = 10
+
 // CommandPalette is a searchable command list
 type CommandPalette struct {
 	commands       []Command
 	filtered       []Command
+	recent         []string // Recently used command names, most recent first
 	query          string
 	selected       int
 	scrollOffset   int    // First visible item index
+	args           string // Text after "name " when the query names a command taking arguments
 	SelectedAction string // Set when Enter pressed
+	SelectedArgs   string // Arguments for SelectedAction
 }
 
-// NewCommandPalette creates a command palette with the given commands
-func NewCommandPalette(commands []Command) *CommandPalette {
+// NewCommandPalette creates a command palette with the given commands,
+// listing the recently used ones (most recent first) at the top
+func NewCommandPalette(commands []Command, recent []string) *CommandPalette {
 	cp := &CommandPalette{
 		commands: commands,
-		filtered: commands,
+		recent:   recent,
 	}
+	cp.filter()
 	return cp
 }
 
+// AddCommands appends commands (e.g. once the interpreter's are known),
+// keeping the current query
+func (c *CommandPalette) AddCommands(commands []Command) {
+	c.commands = append(c.commands, commands...)
+	c.filter()
+}
+
+// recency returns a bonus for recently used commands (0 if not recent)
+func (c *CommandPalette) recency(name string) int {
+	for i, r := range c.recent {
+		if r == name {
+			return commandMRULimit - i
+		}
+	}
+	return 0
+}
+
 func (c *CommandPalette) filter() {
-	if c.query == "" {
-		c.filtered = c.commands
-	} else {
-		q := strings.ToLower(c.query)
-		c.filtered = nil
+	// "name args..." narrows to that command, passing the rest as arguments
+	c.args = ""
+	if name, args, ok := strings.Cut(c.query, " "); ok {
 		for _, cmd := range c.commands {
-			if strings.Contains(strings.ToLower(cmd.Name), q) ||
-				strings.Contains(strings.ToLower(cmd.Help), q) {
-				c.filtered = append(c.filtered, cmd)
+			if cmd.Args != "" && strings.EqualFold(cmd.Name, name) {
+				c.filtered = []Command{cmd}
+				c.args = strings.TrimSpace(args)
+				c.selected = 0
+				c.scrollOffset = 0
+				return
 			}
 		}
 	}
 
-	// Reset selection and scroll if out of bounds
-	if c.selected >= len(c.filtered) {
-		c.selected = len(c.filtered) - 1
+	type scored struct {
+		cmd   Command
+		score int
 	}
-	if c.selected < 0 {
-		c.selected = 0
+	var matches []scored
+	for _, cmd := range c.commands {
+		score := 0
+		if c.query != "" {
+			// Name matches count for more than help text matches
+			score = max(fuzzyScore(c.query, cmd.Name), fuzzyScore(c.query, cmd.Help)/2)
+			if score == 0 {
+				continue
+			}
+		}
+		// Recency only reorders matches, so a poor but recent match can
+		// still lose to a much better one
+		matches = append(matches, scored{cmd, score + 4*c.recency(cmd.Name)})
 	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	c.filtered = make([]Command, len(matches))
+	for i, sc := range matches {
+		c.filtered[i] = sc.cmd
+	}
+
+	// Best match first
+	c.selected = 0
 	c.scrollOffset = 0
 }
 
+// commandMRUPath returns where recently used palette commands are stored
+func commandMRUPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "gritt", "commands_mru.json")
+}
+
+// loadCommandMRU reads the recently used command names (nil if none saved)
+func loadCommandMRU(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil
+	}
+	return names
+}
+
+// saveCommandMRU writes the recently used command names, creating the directory
+func saveCommandMRU(path string, names []string) error {
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// touchCommandMRU moves name to the front of the recently used list
+func touchCommandMRU(names []string, name string) []string {
+	out := []string{name}
+	for _, n := range names {
+		if n != name && len(out) < commandMRULimit {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
 func (c *CommandPalette) Title() string {
 	return "Commands"
 }
@@ -64,10 +159,19 @@ func (c *CommandPalette) Render(w, h int) string {
 	var sb strings.Builder
 
 	// Query line
-	promptStyle := lipgloss.NewStyle().Foreground(AccentColor)
+	promptStyle := lipgloss.NewStyle().Foreground(theme.Accent)
 	sb.WriteString(promptStyle.Render(": "))
 	sb.WriteString(c.query)
 	sb.WriteString(cursorStyle.Render(" "))
+
+	helpStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+
+	// Argument hint for the selected command, if it takes any
+	if c.selected >= 0 && c.selected < len(c.filtered) && c.filtered[c.selected].Args != "" {
+		cmd := c.filtered[c.selected]
+		hint := truncateRunes(cmd.Name+" "+cmd.Args, max(w-lipgloss.Width(c.query)-5, 1))
+		sb.WriteString("  " + helpStyle.Render(hint))
+	}
 	sb.WriteString("\n")
 
 	// Separator
@@ -75,8 +179,8 @@ func (c *CommandPalette) Render(w, h int) string {
 	sb.WriteString("\n")
 
 	// Commands list
-	selectedStyle := lipgloss.NewStyle().Background(AccentColor).Foreground(lipgloss.Color("0"))
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	selectedStyle := lipgloss.NewStyle().Background(theme.Accent).Foreground(theme.CursorText)
+	interpStyle := lipgloss.NewStyle().Foreground(theme.Info)
 
 	listH := h - 2 // Account for query line and separator
 	if listH < 1 {
@@ -107,6 +211,9 @@ func (c *CommandPalette) Render(w, h int) string {
 		if i == c.selected {
 			// Render selected line with highlight
 			line = selectedStyle.Render(padRight(name, maxName)) + " " + helpStyle.Render(help)
+		} else if cmd.Interp {
+			// Interpreter commands stand apart from gritt's own actions
+			line = interpStyle.Render(padRight(name, maxName)) + " " + helpStyle.Render(help)
 		} else {
 			line = padRight(name, maxName) + " " + helpStyle.Render(help)
 		}
@@ -139,11 +246,13 @@ func (c *CommandPalette) Render(w, h int) string {
 	return sb.String()
 }
 
+// padRight pads s with spaces to width display columns, so columns line up
+// however many bytes its glyphs take
 func padRight(s string, width int) string {
-	if len(s) >= width {
-		return s
+	if w := lipgloss.Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
 	}
-	return s + strings.Repeat(" ", width-len(s))
+	return s
 }
 
 func (c *CommandPalette) HandleKey(msg tea.KeyMsg) bool {
@@ -166,18 +275,22 @@ func (c *CommandPalette) HandleKey(msg tea.KeyMsg) bool {
 		return true
 
 	case tea.KeyEnter:
-		if c.selected >= 0 && c.selected < len(c.filtered) {
-			c.SelectedAction = c.filtered[c.selected].Name
-		}
+		c.choose(c.selected)
 		return true
 
 	case tea.KeyBackspace:
 		if len(c.query) > 0 {
-			c.query = c.query[:len(c.query)-1]
+			runes := []rune(c.query)
+			c.query = string(runes[:len(runes)-1])
 			c.filter()
 		}
 		return true
 
+	case tea.KeySpace:
+		c.query += " "
+		c.filter()
+		return true
+
 	case tea.KeyEscape:
 		// Let parent handle escape
 		return false
@@ -193,6 +306,29 @@ func (c *CommandPalette) HandleKey(msg tea.KeyMsg) bool {
 	return false
 }
 
+// insertChar adds r to the query, for APL symbols typed with the prefix key
+// (an eval expression)
+func (c *CommandPalette) insertChar(r rune) {
+	c.query += string(r)
+	c.filter()
+}
+
+// choose selects the command at idx. A command that takes arguments but has
+// none yet completes its name into the query so they can be typed.
+func (c *CommandPalette) choose(idx int) {
+	if idx < 0 || idx >= len(c.filtered) {
+		return
+	}
+	cmd := c.filtered[idx]
+	if cmd.Args != "" && c.args == "" {
+		c.query = cmd.Name + " "
+		c.filter()
+		return
+	}
+	c.SelectedAction = cmd.Name
+	c.SelectedArgs = c.args
+}
+
 // AdjustScroll ensures selected item is visible given the list height
 func (c *CommandPalette) AdjustScroll(listH int) {
 	if listH < 1 {
@@ -213,7 +349,7 @@ func (c *CommandPalette) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 		idx := c.scrollOffset + y - 2 // Account for scroll offset, query and separator
 		if idx >= 0 && idx < len(c.filtered) {
 			c.selected = idx
-			c.SelectedAction = c.filtered[c.selected].Name
+			c.choose(idx)
 			return true
 		}
 	}
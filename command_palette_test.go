@@ -0,0 +1,224 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var testCommands = []Command{
+	{Name: "debug", Help: "Toggle debug pane"},
+	{Name: "stack", Help: "Toggle stack pane"},
+	{Name: "symbols", Help: "Search APL symbols"},
+	{Name: "search-docs", Help: "Full-text search of Dyalog docs"},
+	{Name: "quit", Help: "Quit gritt"},
+}
+
+func paletteNames(c *CommandPalette) []string {
+	var names []string
+	for _, cmd := range c.filtered {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+func TestCommandPaletteFuzzy(t *testing.T) {
+	c := NewCommandPalette(testCommands, nil)
+	c.query = "sdc"
+	c.filter()
+	if len(c.filtered) == 0 || c.filtered[0].Name != "search-docs" {
+		t.Errorf("sdc matched %v, want search-docs first", paletteNames(c))
+	}
+
+	c.query = "zzz"
+	c.filter()
+	if len(c.filtered) != 0 {
+		t.Errorf("zzz matched %v, want nothing", paletteNames(c))
+	}
+}
+
+func TestCommandPaletteRecent(t *testing.T) {
+	// Empty query: recent commands first, most recent first, then the rest in order
+	c := NewCommandPalette(testCommands, []string{"quit", "stack"})
+	want := []string{"quit", "stack", "debug", "symbols", "search-docs"}
+	got := paletteNames(c)
+	if len(got) != len(want) {
+		t.Fatalf("filtered = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("filtered = %v, want %v", got, want)
+		}
+	}
+
+	// With a query, recency breaks ties between similar matches
+	c = NewCommandPalette(testCommands, []string{"stack"})
+	c.query = "s"
+	c.filter()
+	if c.filtered[0].Name != "stack" {
+		t.Errorf("s matched %v, want recent stack first", paletteNames(c))
+	}
+}
+
+func TestCommandMRU(t *testing.T) {
+	names := touchCommandMRU([]string{"a", "b", "c"}, "b")
+	if len(names) != 3 || names[0] != "b" || names[1] != "a" || names[2] != "c" {
+		t.Errorf("touch b = %v, want [b a c]", names)
+	}
+
+	var many []string
+	for i := 0; i < commandMRULimit+5; i++ {
+		many = touchCommandMRU(many, strconv.Itoa(i))
+	}
+	if len(many) != commandMRULimit {
+		t.Errorf("len = %d, want %d", len(many), commandMRULimit)
+	}
+
+	path := filepath.Join(t.TempDir(), "gritt", "commands_mru.json")
+	if got := loadCommandMRU(path); got != nil {
+		t.Errorf("missing file loaded %v, want nil", got)
+	}
+	if err := saveCommandMRU(path, []string{"quit", "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	got := loadCommandMRU(path)
+	if len(got) != 2 || got[0] != "quit" || got[1] != "debug" {
+		t.Errorf("loaded %v, want [quit debug]", got)
+	}
+}
+
+func TestCommandPaletteArgs(t *testing.T) {
+	cmds := append([]Command{{Name: "link", Help: "Link a directory", Args: "[ns:]path"}}, testCommands...)
+	c := NewCommandPalette(cmds, nil)
+
+	// Enter on an argument command with no arguments completes its name
+	c.query = "lin"
+	c.filter()
+	c.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.SelectedAction != "" || c.query != "link " {
+		t.Fatalf("action = %q, query = %q; want none, %q", c.SelectedAction, c.query, "link ")
+	}
+	if !strings.Contains(c.Render(60, 8), "link [ns:]path") {
+		t.Error("render missing argument hint")
+	}
+
+	for _, r := range "#.app:/src/app" {
+		c.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(c.filtered) != 1 || c.filtered[0].Name != "link" {
+		t.Fatalf("filtered = %v, want [link]", paletteNames(c))
+	}
+	c.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.SelectedAction != "link" || c.SelectedArgs != "#.app:/src/app" {
+		t.Errorf("selected %q %q, want link #.app:/src/app", c.SelectedAction, c.SelectedArgs)
+	}
+
+	// Commands without arguments aren't matched by "name args"
+	c = NewCommandPalette(cmds, nil)
+	c.query = "quit now"
+	c.filter()
+	if c.args != "" {
+		t.Errorf("args = %q for a command without arguments", c.args)
+	}
+}
+
+func TestDispatchCommandArgs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // Selecting a command records it as recent
+
+	cfg, _ := LoadConfig()
+	m := Model{
+		lines:     []Line{{Text: aplIndent}},
+		cursorCol: len(aplIndent),
+		ready:     true,
+		width:     80,
+		height:    24,
+		panes:     NewPaneManager(80, 24),
+		keys:      cfg.ToKeyMap(),
+		config:    cfg,
+		debugLog:  &LogBuffer{},
+	}
+	m.openCommandPalette()
+
+	for _, r := range "link #.app:/src/app" {
+		next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+
+	if m.panes.Get("commands") != nil {
+		t.Error("palette still open after running command")
+	}
+	if got := m.currentLine(); got != aplIndent+"]link.create #.app /src/app" {
+		t.Errorf("input line = %q", got)
+	}
+	if want := []string{"]link.create #.app /src/app"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sentInput = %q, want %q", m.sentInput, want)
+	}
+}
+
+func TestLinkCreateExpr(t *testing.T) {
+	if got := linkCreateExpr("#.app:/src/app"); got != "]link.create #.app /src/app" {
+		t.Errorf("got %q", got)
+	}
+	if got := linkCreateExpr("/src/app"); got != "]link.create /src/app" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"ab", 4, "ab  "},
+		{"⍳", 3, "⍳  "}, // Three bytes, one column
+		{"X⌽Y", 5, "X⌽Y  "},
+		{"`⍳", 3, "`⍳ "},
+		{"⍳⍴⌽", 2, "⍳⍴⌽"}, // Too wide already
+		{"", 2, "  "},
+	}
+	for _, tt := range tests {
+		if got := padRight(tt.s, tt.width); got != tt.want {
+			t.Errorf("padRight(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchEval(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newRideTestModel()
+	m.openCommandPalette()
+
+	typeRunes := func(s string) {
+		for _, r := range s {
+			next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = next.(Model)
+		}
+	}
+	enter := func() {
+		next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+		m = next.(Model)
+	}
+
+	// Enter on eval asks for the expression; the prefix key types into it
+	typeRunes("eval")
+	enter()
+	typeRunes("`l←`lWA")
+	if m.currentLine() != aplIndent {
+		t.Errorf("symbols went to the session: %q", m.currentLine())
+	}
+	enter()
+
+	if m.panes.Get("commands") != nil {
+		t.Error("palette still open after eval")
+	}
+	if want := []string{"⎕←⎕WA"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sentInput = %q, want %q", m.sentInput, want)
+	}
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 )
@@ -14,9 +23,177 @@ var defaultConfigJSON []byte
 
 // Config holds all gritt configuration
 type Config struct {
-	Accent     string           `json:"accent"`
-	Keys       KeyMapConfig     `json:"keys"`
-	TracerKeys TracerKeysConfig `json:"tracer_keys"`
+	Accent       string           `json:"accent"` // Old name for theme.accent, used if that's unset
+	Theme        ThemeConfig      `json:"theme"`
+	Keys         KeyMapConfig     `json:"keys"`
+	TracerKeys   TracerKeysConfig `json:"tracer_keys"`
+	Editor       EditorConfig     `json:"editor"`
+	APLcart      APLcartConfig    `json:"aplcart"`
+	StatusLine   bool             `json:"status_line"`    // Show address, latency and interpreter info
+	CopyOnSelect bool             `json:"copy_on_select"` // Copy double/triple-click selections to the clipboard
+	WrapLines    bool             `json:"wrap_lines"`     // Wrap long session lines instead of scrolling sideways
+	Scrollback   int              `json:"scrollback"`     // Most session lines kept (0 = default, -1 = no limit)
+	ResultVar    string           `json:"result_var"`     // Keep each result in this variable (empty = off, session_result.go)
+
+	Backtick     map[string]string `json:"backtick"`      // Key → symbol after the prefix, over backtickMap ("" unmaps a key)
+	PrefixKey    string            `json:"prefix_key"`    // Key starting symbol input, e.g. "`" or "ctrl+k"
+	PrefixSticky bool              `json:"prefix_sticky"` // Prefix stays on for several symbols, until Esc
+	PrefixSheet  bool              `json:"prefix_sheet"`  // Show a cheat sheet of symbols while the prefix is on
+
+	Connections map[string]ConnectionConfig `json:"connections"` // Named interpreters for -c and the connect command
+}
+
+// ResolvedTheme returns the theme section with its preset's colors filled in
+// (unknown presets fall back to "default")
+func (c Config) ResolvedTheme() ThemeConfig {
+	t := c.Theme
+	if t.Accent == "" {
+		t.Accent = c.Accent
+	}
+	base, ok := themePresets[t.Preset]
+	if !ok {
+		base = themePresets["default"]
+	}
+	return t.over(base)
+}
+
+// defaultScrollback is how many session lines are kept if scrollback is unset
+const defaultScrollback = 10000
+
+// ScrollbackLines returns the most session lines to keep, or 0 for no limit
+func (c Config) ScrollbackLines() int {
+	switch {
+	case c.Scrollback < 0:
+		return 0
+	case c.Scrollback == 0:
+		return defaultScrollback
+	}
+	return c.Scrollback
+}
+
+// Prefix returns the key that starts symbol input, backtick by default
+func (c Config) Prefix() string {
+	if c.PrefixKey == "" {
+		return "`"
+	}
+	return c.PrefixKey
+}
+
+// BacktickSymbol returns the symbol typed by the prefix then r: the backtick
+// section's entry if it has a valid one, else Dyalog's layout
+func (c Config) BacktickSymbol(r rune) (rune, bool) {
+	if s, ok := c.Backtick[string(r)]; ok {
+		if s == "" {
+			return 0, false
+		}
+		if sym, ok := singleRune(s); ok {
+			return sym, true
+		}
+	}
+	sym, ok := backtickMap[r]
+	return sym, ok
+}
+
+// backtickWarnings checks the backtick section: keys and symbols must be
+// single characters, and two keys typing the same symbol is probably a
+// mistake
+func (c Config) backtickWarnings() []error {
+	var warnings []error
+	keys := slices.Sorted(maps.Keys(c.Backtick))
+	for _, k := range keys {
+		if _, ok := singleRune(k); !ok {
+			warnings = append(warnings, fmt.Errorf("backtick: key %q isn't a single character", k))
+		}
+		if v := c.Backtick[k]; v != "" {
+			if _, ok := singleRune(v); !ok {
+				warnings = append(warnings, fmt.Errorf("backtick: %q for `%s isn't a single character", v, k))
+			}
+		}
+	}
+
+	// Symbols typed by more than one key, where one of them is overridden
+	overridden := make(map[rune]bool)
+	for _, k := range keys {
+		if r, ok := singleRune(k); ok {
+			overridden[r] = true
+		}
+	}
+	typedBy := make(map[rune][]rune)
+	add := func(r rune) {
+		if sym, ok := c.BacktickSymbol(r); ok {
+			typedBy[sym] = append(typedBy[sym], r)
+		}
+	}
+	for r := range backtickMap {
+		if !overridden[r] {
+			add(r)
+		}
+	}
+	for r := range overridden {
+		add(r)
+	}
+	for _, sym := range slices.Sorted(maps.Keys(typedBy)) {
+		by := typedBy[sym]
+		if len(by) < 2 || !slices.ContainsFunc(by, func(r rune) bool { return overridden[r] }) {
+			continue
+		}
+		slices.Sort(by)
+		names := make([]string, len(by))
+		for i, r := range by {
+			names[i] = "`" + string(r)
+		}
+		warnings = append(warnings, fmt.Errorf("backtick: %s all type %c", strings.Join(names, ", "), sym))
+	}
+	return warnings
+}
+
+// backtickKeycodes maps each symbol to the backtick key that types it, for
+// the symbol search pane (the first key, if there are several)
+func (c Config) backtickKeycodes() map[rune]string {
+	var keys []rune
+	for r := range backtickMap {
+		keys = append(keys, r)
+	}
+	for k := range c.Backtick {
+		if r, ok := singleRune(k); ok {
+			keys = append(keys, r)
+		}
+	}
+	slices.Sort(keys)
+	codes := make(map[rune]string)
+	for _, r := range slices.Compact(keys) {
+		if sym, ok := c.BacktickSymbol(r); ok && codes[sym] == "" {
+			codes[sym] = "`" + string(r)
+		}
+	}
+	return codes
+}
+
+// singleRune returns the only rune in s
+func singleRune(s string) (rune, bool) {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || n != len(s) {
+		return 0, false
+	}
+	return r, true
+}
+
+// APLcartConfig holds APLcart data settings
+type APLcartConfig struct {
+	CacheTTL string `json:"cache_ttl"` // How long the downloaded TSV is reused, e.g. "24h"
+}
+
+// TTL returns the parsed cache lifetime, defaulting to 24h
+func (c APLcartConfig) TTL() time.Duration {
+	if d, err := time.ParseDuration(c.CacheTTL); err == nil {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// EditorConfig holds editor pane settings
+type EditorConfig struct {
+	IndentWidth int `json:"indent_width"` // Spaces per indent level (0 = default)
 }
 
 // TracerKeysConfig defines single-key bindings for tracer mode
@@ -29,12 +206,16 @@ type TracerKeysConfig struct {
 	Backward  string `json:"backward"`
 	Forward   string `json:"forward"`
 	EditMode  string `json:"edit_mode"`
+	Cutback   string `json:"cutback"`
 }
 
 // KeyMapConfig defines key bindings in config file format
 type KeyMapConfig struct {
 	Leader           []string `json:"leader"`
 	Execute          []string `json:"execute"`
+	ExecuteBlock     []string `json:"execute_block"`
+	NewLine          []string `json:"new_line"`
+	InsertResult     []string `json:"insert_result"`
 	ToggleDebug      []string `json:"toggle_debug"`
 	ToggleStack      []string `json:"toggle_stack"`
 	ToggleLocals     []string `json:"toggle_locals"`
@@ -48,6 +229,12 @@ type KeyMapConfig struct {
 	ShowKeys         []string `json:"show_keys"`
 	Autocomplete     []string `json:"autocomplete"`
 	DocHelp          []string `json:"doc_help"`
+	DocSymbol        []string `json:"doc_symbol"`
+	JumpDefinition   []string `json:"jump_definition"`
+	FocusLeft        []string `json:"focus_left"`
+	FocusRight       []string `json:"focus_right"`
+	FocusUp          []string `json:"focus_up"`
+	FocusDown        []string `json:"focus_down"`
 
 	Up    []string `json:"up"`
 	Down  []string `json:"down"`
@@ -62,18 +249,37 @@ type KeyMapConfig struct {
 	Delete    []string `json:"delete"`
 }
 
-// LoadConfig loads configuration from first found config file
-func LoadConfig() Config {
-	paths := []string{
+// configPaths are the config files gritt looks for, in order
+func configPaths() []string {
+	return []string{
 		"gritt.json",
 		filepath.Join(os.Getenv("HOME"), ".config", "gritt", "gritt.json"),
 		"gritt.default.json",
 	}
+}
+
+// LoadConfig loads configuration from first found config file. Files that
+// exist but don't parse are skipped and reported in warnings, as are unknown
+// keys, so gritt still starts with the next file or the defaults.
+func LoadConfig() (Config, []error) {
+	cfg, _, warnings := LoadConfigPath()
+	return cfg, warnings
+}
 
-	for _, path := range paths {
-		if cfg, err := loadConfigFile(path); err == nil {
-			return cfg
+// LoadConfigPath is LoadConfig that also returns the file it loaded, or ""
+// for the embedded default
+func LoadConfigPath() (Config, string, []error) {
+	var warnings []error
+	for _, path := range configPaths() {
+		cfg, fileWarnings, err := loadConfigFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
 		}
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		return cfg, path, append(warnings, fileWarnings...)
 	}
 
 	// Fall back to embedded default config
@@ -81,21 +287,68 @@ func LoadConfig() Config {
 	if err := json.Unmarshal(defaultConfigJSON, &cfg); err != nil {
 		panic("embedded default config is invalid: " + err.Error())
 	}
-	return cfg
+	return cfg, "", warnings
+}
+
+// findConfig returns the first config file that exists, or "" if there are
+// none. Unlike LoadConfigPath it doesn't skip files that fail to parse.
+func findConfig() string {
+	for _, path := range configPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
 }
 
-func loadConfigFile(path string) (Config, error) {
+// loadConfigFile reads a config file. JSON that doesn't parse is an error
+// naming the line and column; keys gritt doesn't know are only warnings.
+func loadConfigFile(path string) (Config, []error, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
 
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return Config{}, err
+		return Config{}, nil, configError(path, data, err)
 	}
 
-	return cfg, nil
+	// Decode again, strictly, to catch misspelt keys
+	var warnings []error
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&Config{}); err != nil {
+		warnings = append(warnings, fmt.Errorf("%s: %s", path, strings.TrimPrefix(err.Error(), "json: ")))
+	}
+	for _, w := range cfg.backtickWarnings() {
+		warnings = append(warnings, fmt.Errorf("%s: %w", path, w))
+	}
+
+	return cfg, warnings, nil
+}
+
+// configError prefixes a JSON error with the file and, for syntax and type
+// errors, the line and column where it happened
+func configError(path string, data []byte, err error) error {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset < 0 {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Offset is just past the byte where decoding stopped
+	before := data[:min(int(offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := max(len(before)-bytes.LastIndexByte(before, '\n')-1, 1)
+	return fmt.Errorf("%s:%d:%d: %w", path, line, col, err)
 }
 
 // ToKeyMap converts config to KeyMap
@@ -103,6 +356,9 @@ func (c *Config) ToKeyMap() KeyMap {
 	return KeyMap{
 		Leader:           c.binding(c.Keys.Leader, "", "leader"),
 		Execute:          c.binding(c.Keys.Execute, "", "execute"),
+		ExecuteBlock:     c.binding(c.Keys.ExecuteBlock, "", "execute joined"),
+		NewLine:          c.binding(c.Keys.NewLine, "", "new line"),
+		InsertResult:     c.binding(c.Keys.InsertResult, "", "insert last result"),
 		ToggleDebug:      c.bindingWithLeader(c.Keys.ToggleDebug, "debug"),
 		ToggleStack:      c.bindingWithLeader(c.Keys.ToggleStack, "stack"),
 		ToggleLocals:     c.bindingWithLeader(c.Keys.ToggleLocals, "locals"),
@@ -116,6 +372,13 @@ func (c *Config) ToKeyMap() KeyMap {
 		ShowKeys:         c.bindingWithLeader(c.Keys.ShowKeys, "show keys"),
 		Autocomplete:     c.binding(c.Keys.Autocomplete, "", "autocomplete"),
 		DocHelp:          c.binding(c.Keys.DocHelp, "", "doc help"),
+		DocSymbol:        c.bindingWithLeader(c.Keys.DocSymbol, "symbol docs"),
+		JumpDefinition:   c.bindingWithLeader(c.Keys.JumpDefinition, "edit name"),
+		FocusLeft:        c.bindingWithLeader(c.Keys.FocusLeft, "focus left"),
+		FocusRight:       c.bindingWithLeader(c.Keys.FocusRight, "focus right"),
+		FocusUp:          c.bindingWithLeader(c.Keys.FocusUp, "focus up"),
+		FocusDown:        c.bindingWithLeader(c.Keys.FocusDown, "focus down"),
+		Chords:           c.leaderChords(),
 		Up:          c.binding(c.Keys.Up, "", "up"),
 		Down:        c.binding(c.Keys.Down, "", "down"),
 		Left:        c.binding(c.Keys.Left, "", "left"),
@@ -146,6 +409,38 @@ func (c *Config) binding(keys []string, prefix, help string) key.Binding {
 	)
 }
 
+// leaderChords builds the trie of key sequences after the leader. Each
+// binding is a key ("d") or a space-separated sequence of keys ("w h").
+func (c *Config) leaderChords() *chordNode {
+	root := &chordNode{}
+	for _, b := range []struct {
+		action string
+		keys   []string
+	}{
+		{"toggle_debug", c.Keys.ToggleDebug},
+		{"toggle_stack", c.Keys.ToggleStack},
+		{"toggle_locals", c.Keys.ToggleLocals},
+		{"toggle_breakpoint", c.Keys.ToggleBreakpoint},
+		{"reconnect", c.Keys.Reconnect},
+		{"command_palette", c.Keys.CommandPalette},
+		{"pane_move_mode", c.Keys.PaneMoveMode},
+		{"cycle_pane", c.Keys.CyclePane},
+		{"quit", c.Keys.Quit},
+		{"show_keys", c.Keys.ShowKeys},
+		{"doc_symbol", c.Keys.DocSymbol},
+		{"jump_definition", c.Keys.JumpDefinition},
+		{"focus_left", c.Keys.FocusLeft},
+		{"focus_right", c.Keys.FocusRight},
+		{"focus_up", c.Keys.FocusUp},
+		{"focus_down", c.Keys.FocusDown},
+	} {
+		for _, seq := range b.keys {
+			root.add(seq, b.action)
+		}
+	}
+	return root
+}
+
 // bindingWithLeader creates a key binding with leader prefix in help text
 func (c *Config) bindingWithLeader(keys []string, help string) key.Binding {
 	if len(keys) == 0 {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// configPollInterval is how often the active config file is checked for changes
+const configPollInterval = 2 * time.Second
+
+// configTickMsg triggers a check of the config file
+type configTickMsg struct{}
+
+// configTick schedules the next config file check after d
+func configTick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg { return configTickMsg{} })
+}
+
+// configModTime returns when path was last modified (zero for the embedded
+// default or a file that's gone)
+func configModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// checkConfig reloads the config if the active file has changed or another
+// file has taken its place
+func (m *Model) checkConfig() tea.Cmd {
+	path := findConfig()
+	if path == m.configPath && configModTime(path).Equal(m.configMod) {
+		return nil
+	}
+	return m.ReloadConfig()
+}
+
+// ReloadConfig re-reads the active config file and re-applies key bindings
+// and the theme; the RIDE connection is untouched. Invalid JSON keeps the
+// previous config. Open panes are restyled for the new theme.
+func (m *Model) ReloadConfig() tea.Cmd {
+	path := findConfig()
+	m.configPath, m.configMod = path, configModTime(path)
+
+	var cfg Config
+	var warnings []error
+	var err error
+	if path == "" {
+		err = json.Unmarshal(defaultConfigJSON, &cfg)
+	} else {
+		cfg, warnings, err = loadConfigFile(path)
+	}
+	if err != nil {
+		m.log("Config reload failed, keeping the previous config: %v", err)
+		m.statusMsg = "Config error in " + path + ", kept the previous config"
+		return nil
+	}
+
+	wasStatusLine := m.config.StatusLine
+	if cfg.WrapLines != m.config.WrapLines {
+		m.wrap = cfg.WrapLines
+	}
+	m.config = cfg
+	m.keys = cfg.ToKeyMap()
+	setTheme(newTheme(m.colorProfile, cfg.ResolvedTheme()))
+	m.panes.ApplyTheme()
+	if m.width > 0 {
+		m.panes.UpdateSize(m.width, m.height-m.statusHeight())
+	}
+
+	if path == "" {
+		path = "embedded config"
+	}
+	m.log("Config reloaded from %s", path)
+	m.statusMsg = "Reloaded " + path
+	for _, w := range warnings {
+		m.log("Config warning: %v", w)
+		m.statusMsg = "Reloaded " + path + " with warnings (see debug log)"
+	}
+
+	// The status line's latency probe only runs while it's shown. A tick
+	// from before it was turned off may still be pending, so start a new
+	// chain and let that one lapse.
+	if cfg.StatusLine && !wasStatusLine {
+		m.statusGen++
+		return statusTick(0, m.statusGen)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+func TestReloadConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	saved := theme
+	t.Cleanup(func() { setTheme(saved) })
+
+	m := newRideTestModel()
+	m.configPath = ""
+
+	// A new gritt.json is picked up
+	write := func(text string, mod time.Time) {
+		t.Helper()
+		if err := os.WriteFile("gritt.json", []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes("gritt.json", mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(`{"theme": {"preset": "light"}, "keys": {"leader": ["ctrl+a"]}}`, now)
+	m.checkConfig()
+	if m.configPath != "gritt.json" || m.config.Theme.Preset != "light" || m.statusMsg != "Reloaded gritt.json" {
+		t.Fatalf("reload: path %q, preset %q, status %q", m.configPath, m.config.Theme.Preset, m.statusMsg)
+	}
+	if theme.Cursor != lipgloss.Color("0") || !strings.Contains(m.keys.Leader.Help().Key, "ctrl+a") {
+		t.Errorf("reload didn't apply: cursor %v, leader %q", theme.Cursor, m.keys.Leader.Help().Key)
+	}
+
+	// Unchanged file: nothing to do
+	m.statusMsg = ""
+	m.checkConfig()
+	if m.statusMsg != "" {
+		t.Errorf("unchanged file reloaded: %q", m.statusMsg)
+	}
+
+	// Invalid JSON keeps the previous config and logs the error
+	write(`{"theme": `, now.Add(time.Second))
+	m.checkConfig()
+	if m.config.Theme.Preset != "light" || !strings.HasPrefix(m.statusMsg, "Config error in gritt.json") {
+		t.Errorf("invalid JSON: preset %q, status %q", m.config.Theme.Preset, m.statusMsg)
+	}
+	if !strings.Contains(strings.Join(m.debugLog.Lines, "\n"), "Config reload failed") {
+		t.Error("parse error not logged")
+	}
+
+	// Removing the file falls back to the embedded default
+	os.Remove("gritt.json")
+	m.checkConfig()
+	if m.configPath != "" || m.config.Theme.Preset != "default" || m.statusMsg != "Reloaded embedded config" {
+		t.Errorf("fallback: path %q, preset %q, status %q", m.configPath, m.config.Theme.Preset, m.statusMsg)
+	}
+}
+
+func TestReloadConfigRestartsStatusTicks(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	saved := theme
+	t.Cleanup(func() { setTheme(saved) })
+
+	m := newRideTestModel()
+	m.config.StatusLine = true
+	stack := NewStackPane(func() []StackFrame { return nil }, nil)
+	m.panes.Add(NewPane("stack", stack, 0, 0, 20, 10))
+
+	// Off and on again within one interval: the old chain's tick is still due
+	reload := func(text string) tea.Cmd {
+		t.Helper()
+		if err := os.WriteFile("gritt.json", []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return m.ReloadConfig()
+	}
+	if cmd := reload(`{"status_line": false}`); cmd != nil {
+		t.Error("turning the status line off started ticks")
+	}
+	if cmd := reload(`{"status_line": true, "theme": {"preset": "light"}}`); cmd == nil {
+		t.Fatal("turning the status line on didn't start ticks")
+	}
+	if _, cmd := m.Update(statusTickMsg{0}); cmd != nil {
+		t.Error("the old chain's tick carried on")
+	}
+	if _, cmd := m.Update(statusTickMsg{m.statusGen}); cmd == nil {
+		t.Error("the new chain's tick didn't schedule the next")
+	}
+
+	// Open panes take the new theme
+	if got := stack.selectedStyle.GetBackground(); got != theme.Selection {
+		t.Errorf("stack selection = %v, want %v", got, theme.Selection)
+	}
+}
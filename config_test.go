@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inConfigDir runs the test in an empty directory with an empty HOME, so only
+// the config files it writes are found
+func inConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	return dir
+}
+
+func TestLoadConfigMalformed(t *testing.T) {
+	dir := inConfigDir(t)
+	bad := "{\n  \"accent\": \"#808080\",\n  \"keys\": {\n    \"leader\": [\"ctrl+]\"],\n  }\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "gritt.json"), []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, path, warnings := LoadConfigPath()
+	if path != "" || cfg.Accent != "" || len(cfg.Keys.Leader) == 0 {
+		t.Errorf("expected the embedded default, got path %q, accent %q", path, cfg.Accent)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0].Error(), "gritt.json:5:3: ") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestLoadConfigWrongType(t *testing.T) {
+	dir := inConfigDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "gritt.json"), []byte("{\n  \"status_line\": \"yes\"\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, warnings := LoadConfig()
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0].Error(), "gritt.json:2:") || !strings.Contains(warnings[0].Error(), "status_line") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	dir := inConfigDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "gritt.json"), []byte(`{"acent": "#808080", "status_line": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is still used; the typo is a warning
+	cfg, path, warnings := LoadConfigPath()
+	if path != "gritt.json" || !cfg.StatusLine {
+		t.Errorf("path %q, status_line %v", path, cfg.StatusLine)
+	}
+	if len(warnings) != 1 || warnings[0].Error() != `gritt.json: unknown field "acent"` {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	inConfigDir(t)
+	if _, path, warnings := LoadConfigPath(); path != "" || len(warnings) != 0 {
+		t.Errorf("no config files: path %q, warnings %v", path, warnings)
+	}
+}
+
+func TestLoadConfigBacktick(t *testing.T) {
+	dir := inConfigDir(t)
+	json := `{"backtick": {"w": "ω", "q": "⍺", ";": "", "ab": "x", "k": "xy"}}`
+	if err := os.WriteFile(filepath.Join(dir, "gritt.json"), []byte(json), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, warnings := LoadConfig()
+	want := []string{
+		`gritt.json: backtick: key "ab" isn't a single character`,
+		`gritt.json: backtick: "xy" for ` + "`k isn't a single character",
+		"gritt.json: backtick: `a, `q all type ⍺",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	for i, w := range warnings {
+		if w.Error() != want[i] {
+			t.Errorf("warning %d = %q, want %q", i, w, want[i])
+		}
+	}
+
+	// Overrides merge over the default layout; invalid ones are ignored
+	for _, tt := range []struct {
+		key  rune
+		want rune
+		ok   bool
+	}{
+		{'w', 'ω', true},
+		{'q', '⍺', true},
+		{'a', '⍺', true},
+		{'i', '⍳', true},
+		{';', 0, false},
+		{'k', backtickMap['k'], true},
+	} {
+		if sym, ok := cfg.BacktickSymbol(tt.key); sym != tt.want || ok != tt.ok {
+			t.Errorf("`%c = %c, %v, want %c, %v", tt.key, sym, ok, tt.want, tt.ok)
+		}
+	}
+
+	// The symbol search pane shows the keys of the merged layout
+	codes := cfg.backtickKeycodes()
+	if codes['⍺'] != "`a" || codes['⍳'] != "`i" || codes['ω'] != "`w" || codes['⍵'] != "" {
+		t.Errorf("keycodes ⍺ %q, ⍳ %q, ω %q, ⍵ %q", codes['⍺'], codes['⍳'], codes['ω'], codes['⍵'])
+	}
+}
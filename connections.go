@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cursork/gritt/ride"
+)
+
+// ConnectionConfig is a named interpreter in the "connections" section of
+// gritt.json, picked with -c name or the palette's connect command
+type ConnectionConfig struct {
+	Addr   string     `json:"addr"`   // host:port
+	TLS    *TLSConfig `json:"tls"`    // Connect over TLS if set
+	Launch bool       `json:"launch"` // Start a local interpreter instead of connecting to addr
+	Dyalog string     `json:"dyalog"` // Interpreter to launch (default -dyalog)
+	Args   string     `json:"args"`   // Extra arguments for the launched interpreter
+}
+
+// TLSConfig holds the certificates for a TLS connection. Without a CA the
+// system roots verify the server.
+type TLSConfig struct {
+	CA         string `json:"ca"`          // PEM file of CAs that sign the server's certificate
+	Cert       string `json:"cert"`        // PEM client certificate, if the server asks for one
+	Key        string `json:"key"`         // PEM key for cert
+	SkipVerify bool   `json:"skip_verify"` // Don't verify the server (testing only)
+}
+
+// clientConfig builds the tls.Config for connecting to addr (nil for a
+// plain connection)
+func (t *TLSConfig) clientConfig(addr string) (*tls.Config, error) {
+	if t == nil {
+		return nil, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	cfg := &tls.Config{ServerName: host, InsecureSkipVerify: t.SkipVerify}
+	if t.CA != "" {
+		pem, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", t.CA)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.Cert != "" || t.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// connectionNames lists the configured connections, sorted
+func (c Config) connectionNames() []string {
+	names := make([]string, 0, len(c.Connections))
+	for name := range c.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// connection looks up a named connection
+func (c Config) connection(name string) (ConnectionConfig, error) {
+	conn, ok := c.Connections[name]
+	if !ok {
+		if len(c.Connections) == 0 {
+			return conn, fmt.Errorf("no connection %q: gritt.json has no connections", name)
+		}
+		return conn, fmt.Errorf("no connection %q (have %s)", name, strings.Join(c.connectionNames(), ", "))
+	}
+	if !conn.Launch && conn.Addr == "" {
+		return conn, fmt.Errorf("connection %q has no addr", name)
+	}
+	return conn, nil
+}
+
+// namedConnection looks up -c's connection in the loaded config. A miss
+// says why if a config file failed to load, rather than just that it's
+// unknown.
+func namedConnection(name string) (ConnectionConfig, error) {
+	cfg, warnings := LoadConfig()
+	conn, err := cfg.connection(name)
+	if err != nil && len(warnings) > 0 {
+		err = fmt.Errorf("%w; config: %w", err, errors.Join(warnings...))
+	}
+	return conn, err
+}
+
+// connectProfile switches the session to a named connection. The current
+// one is only closed once the new one is up. Launching needs gritt to own
+// the interpreter's process, so it's only done at startup with -c.
+func (m Model) connectProfile(name string) (tea.Model, tea.Cmd) {
+	conn, err := m.config.connection(name)
+	if err == nil && conn.Launch {
+		err = fmt.Errorf("connection %q launches an interpreter; start gritt with -c %s", name, name)
+	}
+	var tlsConfig *tls.Config
+	if err == nil {
+		tlsConfig, err = conn.TLS.clientConfig(conn.Addr)
+	}
+	if err != nil {
+		m.log("Connect: %v", err)
+		m.statusMsg = err.Error()
+		return m, nil
+	}
+
+	m.log("Connecting to %s (%s)...", name, conn.Addr)
+	client, err := ride.ConnectTLS(conn.Addr, tlsConfig)
+	if err != nil {
+		m.log("Connecting to %s failed: %v", conn.Addr, err)
+		m.statusMsg = "Couldn't connect to " + name + ", see the debug log"
+		return m, nil
+	}
+	if m.client != nil {
+		m.client.Close()
+	}
+	m.addr, m.tls = conn.Addr, tlsConfig
+	return m.useClient(client)
+}
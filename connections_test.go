@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestConnectionLookup(t *testing.T) {
+	cfg := Config{Connections: map[string]ConnectionConfig{
+		"work":  {Addr: "apl.example.com:4502", TLS: &TLSConfig{}},
+		"local": {Launch: true},
+		"bad":   {},
+	}}
+	if conn, err := cfg.connection("work"); err != nil || conn.Addr != "apl.example.com:4502" {
+		t.Errorf("work = %+v, %v", conn, err)
+	}
+	if _, err := cfg.connection("local"); err != nil {
+		t.Errorf("launch profile without addr: %v", err)
+	}
+	if _, err := cfg.connection("bad"); err == nil {
+		t.Error("profile with no addr accepted")
+	}
+	_, err := cfg.connection("home")
+	if err == nil || !strings.Contains(err.Error(), "bad, local, work") {
+		t.Errorf("unknown profile error = %v, want the known names", err)
+	}
+}
+
+func TestNamedConnectionBrokenConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := os.WriteFile("gritt.json", []byte(`{"connections": {"work": {"addr": "x:1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := namedConnection("work")
+	if err == nil || !strings.Contains(err.Error(), "gritt.json") {
+		t.Errorf("error = %v, want the parse error in gritt.json", err)
+	}
+}
+
+func TestTLSClientConfig(t *testing.T) {
+	var none *TLSConfig
+	if cfg, err := none.clientConfig("host:4502"); cfg != nil || err != nil {
+		t.Errorf("no tls section = %v, %v, want a plain connection", cfg, err)
+	}
+
+	cfg, err := (&TLSConfig{SkipVerify: true}).clientConfig("apl.example.com:4502")
+	if err != nil || cfg.ServerName != "apl.example.com" || !cfg.InsecureSkipVerify {
+		t.Errorf("clientConfig = %+v, %v", cfg, err)
+	}
+
+	if _, err := (&TLSConfig{CA: "/nonexistent/ca.pem"}).clientConfig("host:1"); err == nil {
+		t.Error("missing CA file accepted")
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0644)
+	if _, err := (&TLSConfig{CA: notPEM}).clientConfig("host:1"); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Errorf("CA without certificates: %v", err)
+	}
+}
+
+func TestConnectProfileErrors(t *testing.T) {
+	m := newRideTestModel()
+	m.config.Connections = map[string]ConnectionConfig{"local": {Launch: true}}
+
+	result, _ := m.connectProfile("local")
+	if got := result.(Model).statusMsg; !strings.Contains(got, "-c local") {
+		t.Errorf("launch profile status = %q, want a hint to use -c", got)
+	}
+	result, _ = m.connectProfile("nope")
+	if got := result.(Model).statusMsg; !strings.Contains(got, "no connection") {
+		t.Errorf("unknown profile status = %q", got)
+	}
+	if result.(Model).addr != m.addr {
+		t.Error("failed switch changed the address")
+	}
+
+	// A connection that can't be made leaves the current one alone
+	m.config.Connections["down"] = ConnectionConfig{Addr: "127.0.0.1:1"}
+	m.client, m.connected = &ride.Client{}, true
+	result, _ = m.connectProfile("down")
+	if mm := result.(Model); mm.client != m.client || !mm.connected || mm.addr != m.addr {
+		t.Errorf("failed switch: client kept %v, connected %v, addr %q", mm.client == m.client, mm.connected, mm.addr)
+	}
+	if got := result.(Model).statusMsg; !strings.Contains(got, "Couldn't connect to down") {
+		t.Errorf("failed switch status = %q", got)
+	}
+}
+
+func TestStaleRideEventIgnored(t *testing.T) {
+	m := newRideTestModel()
+	m.client = &ride.Client{}
+	m.connected = true
+
+	// The replaced connection's loop ends with an error after a switch
+	result, _ := m.handleRide(rideEvent{err: io.EOF, client: &ride.Client{}})
+	if !result.(Model).connected {
+		t.Error("error from the old connection disconnected the new one")
+	}
+	result, _ = m.handleRide(rideEvent{err: io.EOF, client: m.client})
+	if result.(Model).connected {
+		t.Error("error from the current connection ignored")
+	}
+}
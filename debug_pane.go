@@ -1,10 +1,17 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
 )
 
 // LogBuffer is a shared buffer that survives Model copies
@@ -12,31 +19,219 @@ type LogBuffer struct {
 	Lines []string
 }
 
-// DebugPane displays the debug log using a viewport
+// DebugPane displays the debug log using a viewport, colored by direction
+// and optionally narrowed to lines containing a filter (&) and searched (/,
+// n/N)
 type DebugPane struct {
 	viewport    viewport.Model
 	log         *LogBuffer
 	lastContent string // Track content to detect changes
+
+	filter  string // Only lines containing this are shown
+	search  string // Lines containing this are matches
+	match   int    // Line of the current match in the shown lines, -1 if none
+	jump    bool   // Scroll to the current match on the next Render
+	prompt  rune   // '&' or '/' while a prompt is taking input, else 0
+	editBuf []rune
+	compact bool // Received messages show their argument names, not values
+
+	// Set by HandleKey for the TUI to save the log (Dump)
+	DumpRequested bool
+}
+
+// debugDumpDir is where saved copies of the debug log go
+func debugDumpDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "gritt", "reports")
+}
+
+// Dump writes the whole log, unfiltered, to a timestamped file in dir for
+// attaching to a bug report, returning its path
+func (d *DebugPane) Dump(dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "debug-"+now.Format("20060102-150405")+".log")
+	data := strings.Join(d.log.Lines, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 // NewDebugPane creates a debug pane backed by the given log buffer
 func NewDebugPane(log *LogBuffer) *DebugPane {
 	vp := viewport.New(0, 0)
 	vp.MouseWheelEnabled = true
-	return &DebugPane{viewport: vp, log: log}
+	return &DebugPane{viewport: vp, log: log, match: -1}
 }
 
 func (d *DebugPane) Title() string {
 	return "debug"
 }
 
+// Editing reports whether the filter or search prompt is taking text
+func (d *DebugPane) Editing() bool {
+	return d.prompt != 0
+}
+
+// Filtered reports whether a filter or search is set, which Esc clears
+// before closing the pane
+func (d *DebugPane) Filtered() bool {
+	return d.filter != "" || d.search != ""
+}
+
+// insertChar adds a character to the open prompt (APL input via backtick)
+func (d *DebugPane) insertChar(r rune) {
+	d.editBuf = append(d.editBuf, r)
+}
+
+// styleLine colors a log line by where it came from: sent to the
+// interpreter (→), received from it (←), or gritt's own
+func (d *DebugPane) styleLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "→ "):
+		return lipgloss.NewStyle().Foreground(theme.Success).Render(line)
+	case strings.HasPrefix(line, "← "):
+		if d.compact {
+			line = compactArgs(line)
+		}
+		return lipgloss.NewStyle().Foreground(theme.Info).Render(line)
+	}
+	return lipgloss.NewStyle().Foreground(theme.Comment).Render(line)
+}
+
+// compactArgs shortens a received message's JSON arguments to their names:
+// "← SetPromptType {"type":1}" becomes "← SetPromptType {type}"
+func compactArgs(line string) string {
+	i := strings.Index(line, " {")
+	if i < 0 {
+		return line
+	}
+	var args map[string]json.RawMessage
+	if json.Unmarshal([]byte(line[i+1:]), &args) != nil {
+		return line
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return line[:i] + " {" + strings.Join(names, ", ") + "}"
+}
+
+// containsFold reports whether s contains sub, ignoring case
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+// shown returns the log lines passing the filter
+func (d *DebugPane) shown() []string {
+	if d.filter == "" {
+		return d.log.Lines
+	}
+	var lines []string
+	for _, line := range d.log.Lines {
+		if containsFold(line, d.filter) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// matches returns the indexes of the shown lines containing the search
+func (d *DebugPane) matches(lines []string) []int {
+	if d.search == "" {
+		return nil
+	}
+	var idx []int
+	for i, line := range lines {
+		if containsFold(line, d.search) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// nextMatch moves to the next (dir 1) or previous (dir -1) match, wrapping,
+// starting from the top of the view when there's no current match
+func (d *DebugPane) nextMatch(dir int) {
+	idx := d.matches(d.shown())
+	if len(idx) == 0 {
+		d.match = -1
+		return
+	}
+	from := d.match
+	if from < 0 {
+		from = d.viewport.YOffset - dir // So the first line in view can match
+	}
+	next := -1
+	if dir > 0 {
+		for _, i := range idx {
+			if i > from {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = idx[0]
+		}
+	} else {
+		for j := len(idx) - 1; j >= 0; j-- {
+			if idx[j] < from {
+				next = idx[j]
+				break
+			}
+		}
+		if next < 0 {
+			next = idx[len(idx)-1]
+		}
+	}
+	d.match, d.jump = next, true
+}
+
 func (d *DebugPane) Render(w, h int) string {
+	// The prompt, or the filter and search in force, take the first line
+	var header string
+	lines := d.shown()
+	idx := d.matches(lines)
+	if d.prompt != 0 {
+		header = string(d.prompt) + string(d.editBuf) + "█"
+	} else if d.Filtered() {
+		var parts []string
+		if d.filter != "" {
+			parts = append(parts, fmt.Sprintf("&%s (%d/%d lines)", d.filter, len(lines), len(d.log.Lines)))
+		}
+		if d.search != "" {
+			pos := 0
+			for i, line := range idx {
+				if line == d.match {
+					pos = i + 1
+				}
+			}
+			parts = append(parts, fmt.Sprintf("/%s (%d/%d)", d.search, pos, len(idx)))
+		}
+		header = strings.Join(parts, "  ")
+	}
+	if header != "" {
+		h = max(h-1, 1)
+		header = lipgloss.NewStyle().Foreground(theme.Accent).Render(truncateRunes(header, w))
+	}
+
 	// Update viewport dimensions
 	d.viewport.Width = w
 	d.viewport.Height = h
 
-	// Build and set content every time
-	content := strings.Join(d.log.Lines, "\n")
+	// Build and set content every time: colored by direction, with the
+	// current match highlighted and shown in full
+	styled := make([]string, len(lines))
+	for i, line := range lines {
+		if i == d.match {
+			styled[i] = selectionStyle.Render(line)
+		} else {
+			styled[i] = d.styleLine(line)
+		}
+	}
+	content := strings.Join(styled, "\n")
 
 	// Check if content changed for auto-scroll decision
 	contentChanged := content != d.lastContent
@@ -45,20 +240,102 @@ func (d *DebugPane) Render(w, h int) string {
 	d.viewport.SetContent(content)
 	d.lastContent = content
 
-	// Auto-scroll if content changed and we were at bottom (or content fits)
-	if contentChanged && (wasAtBottom || d.viewport.TotalLineCount() <= h) {
+	if d.jump {
+		// Bring the current match into the middle of the view
+		d.jump = false
+		d.viewport.SetYOffset(d.match - h/2)
+	} else if contentChanged && d.match < 0 && (wasAtBottom || d.viewport.TotalLineCount() <= h) {
+		// Auto-scroll if content changed and we were at bottom (or content
+		// fits), unless a match is being looked at
 		d.viewport.GotoBottom()
 	}
 
+	if header != "" {
+		return header + "\n" + d.viewport.View()
+	}
 	return d.viewport.View()
 }
 
 func (d *DebugPane) HandleKey(msg tea.KeyMsg) bool {
+	if d.prompt != 0 {
+		return d.handlePromptKey(msg)
+	}
+	switch msg.Type {
+	case tea.KeyEscape:
+		// Esc clears the search, then the filter, then the TUI closes the pane
+		if d.search != "" {
+			d.search, d.match = "", -1
+			return true
+		}
+		if d.filter != "" {
+			d.setFilter("")
+			return true
+		}
+		return false
+	case tea.KeyRunes:
+		if len(msg.Runes) == 1 {
+			switch msg.Runes[0] {
+			case '&', '/':
+				d.prompt, d.editBuf = msg.Runes[0], nil
+				return true
+			case 's':
+				d.DumpRequested = true
+				return true
+			case 'c':
+				d.compact = !d.compact
+				return true
+			case 'n':
+				d.nextMatch(1)
+				return true
+			case 'N':
+				d.nextMatch(-1)
+				return true
+			}
+		}
+	}
 	var cmd tea.Cmd
 	d.viewport, cmd = d.viewport.Update(msg)
 	return cmd != nil
 }
 
+// setFilter changes the filter, which also moves the shown lines under any
+// current match
+func (d *DebugPane) setFilter(filter string) {
+	d.filter, d.match = filter, -1
+	d.lastContent = "" // Start again at the bottom
+	d.viewport.GotoBottom()
+}
+
+// handlePromptKey handles keys while the filter or search prompt is open.
+// Enter applies it (an empty filter shows the whole log again); Esc
+// cancels.
+func (d *DebugPane) handlePromptKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter:
+		text := string(d.editBuf)
+		if d.prompt == '&' {
+			d.setFilter(text)
+		} else {
+			d.search, d.match = text, -1
+			d.nextMatch(1)
+		}
+		d.prompt, d.editBuf = 0, nil
+	case tea.KeyEscape:
+		d.prompt, d.editBuf = 0, nil
+	case tea.KeyBackspace:
+		if len(d.editBuf) > 0 {
+			d.editBuf = d.editBuf[:len(d.editBuf)-1]
+		} else {
+			d.prompt = 0
+		}
+	case tea.KeySpace:
+		d.editBuf = append(d.editBuf, ' ')
+	case tea.KeyRunes:
+		d.editBuf = append(d.editBuf, msg.Runes...)
+	}
+	return true
+}
+
 func (d *DebugPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 	var cmd tea.Cmd
 	d.viewport, cmd = d.viewport.Update(msg)
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeDebug(d *DebugPane, keys ...tea.KeyMsg) {
+	for _, k := range keys {
+		d.HandleKey(k)
+	}
+}
+
+func runeKeys(s string) []tea.KeyMsg {
+	var keys []tea.KeyMsg
+	for _, r := range s {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+func TestDebugPaneFilter(t *testing.T) {
+	log := &LogBuffer{Lines: []string{
+		"→ Execute 1+1",
+		"← AppendSessionOutput 2",
+		"→ Execute ⍳3",
+		"← SetPromptType",
+	}}
+	d := NewDebugPane(log)
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	typeDebug(d, runeKeys("&execute")...)
+	if !d.Editing() {
+		t.Fatal("& didn't open the filter prompt")
+	}
+	typeDebug(d, enter)
+	view := strings.Split(stripANSI(d.Render(40, 5)), "\n")
+	if !strings.HasPrefix(view[0], "&execute (2/4 lines)") {
+		t.Errorf("header = %q", view[0])
+	}
+	if strings.TrimSpace(view[1]) != "→ Execute 1+1" || strings.TrimSpace(view[2]) != "→ Execute ⍳3" {
+		t.Errorf("filtered = %q", view[1:])
+	}
+
+	// Backtick input reaches the prompt, so glyphs can be filtered on
+	typeDebug(d, runeKeys("&")...)
+	d.insertChar('←')
+	typeDebug(d, enter)
+	if got := d.shown(); len(got) != 2 || !strings.HasPrefix(got[0], "←") {
+		t.Errorf("filter on ← = %q", got)
+	}
+
+	// Esc clears the filter and says so, so the pane stays open
+	if !d.HandleKey(tea.KeyMsg{Type: tea.KeyEscape}) || d.Filtered() {
+		t.Error("Esc didn't clear the filter")
+	}
+	if len(d.shown()) != 4 {
+		t.Errorf("cleared filter shows %d lines", len(d.shown()))
+	}
+	if d.HandleKey(tea.KeyMsg{Type: tea.KeyEscape}) {
+		t.Error("Esc with nothing to clear was handled by the pane")
+	}
+}
+
+func TestDebugPaneSearch(t *testing.T) {
+	log := &LogBuffer{}
+	for i := range 20 {
+		if i%5 == 0 {
+			log.Lines = append(log.Lines, "needle")
+		} else {
+			log.Lines = append(log.Lines, "hay")
+		}
+	}
+	d := NewDebugPane(log)
+	d.Render(20, 4) // Scrolled to the bottom
+
+	typeDebug(d, runeKeys("/needle")...)
+	typeDebug(d, tea.KeyMsg{Type: tea.KeyEnter})
+	if d.match != 0 {
+		t.Errorf("first match = %d, want 0 (wrapped from the bottom)", d.match)
+	}
+	view := stripANSI(d.Render(20, 4))
+	if !strings.HasPrefix(view, "/needle (1/4)") || !strings.Contains(view, "\nneedle ") {
+		t.Errorf("view = %q", view)
+	}
+
+	typeDebug(d, runeKeys("nn")...)
+	if d.match != 10 {
+		t.Errorf("after n n: match = %d, want 10", d.match)
+	}
+	typeDebug(d, runeKeys("N")...)
+	if d.match != 5 {
+		t.Errorf("after N: match = %d, want 5", d.match)
+	}
+	d.Render(20, 4)
+	if off := d.viewport.YOffset; off > 5 || off+3 <= 5 {
+		t.Errorf("match line 5 not in view at offset %d", off)
+	}
+
+	// Esc clears the search before the filter
+	d.HandleKey(tea.KeyMsg{Type: tea.KeyEscape})
+	if d.search != "" || d.match != -1 {
+		t.Errorf("search = %q, match = %d after Esc", d.search, d.match)
+	}
+}
+
+func TestDebugPaneDump(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	m := newRideTestModel()
+	m.debugLog.Lines = []string{"→ Execute 1+1", "← SetPromptType"}
+	m.toggleDebugPane()
+	m.panes.Focus("debug")
+
+	// s saves the whole log, filter or not
+	m.debugPane.filter = "Execute"
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = result.(Model)
+	files, _ := filepath.Glob(filepath.Join(home, ".config", "gritt", "reports", "debug-*.log"))
+	if len(files) != 1 {
+		t.Fatalf("saved files = %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if string(data) != "→ Execute 1+1\n← SetPromptType\n" {
+		t.Errorf("saved log = %q", data)
+	}
+	if !strings.Contains(m.statusMsg, files[0]) {
+		t.Errorf("status = %q, want the path", m.statusMsg)
+	}
+
+	// A directory that can't be made is reported, not fatal
+	blocked := filepath.Join(home, "file")
+	os.WriteFile(blocked, nil, 0644)
+	if _, err := m.debugPane.Dump(filepath.Join(blocked, "reports"), time.Now()); err == nil {
+		t.Error("Dump into a file succeeded")
+	}
+}
+
+func TestDebugPaneColors(t *testing.T) {
+	d := NewDebugPane(&LogBuffer{})
+	sent := d.styleLine("→ Execute 1+1")
+	recv := d.styleLine("← Execute 1+1")
+	own := d.styleLine("Connected to localhost:4502")
+	if stripANSI(sent) != "→ Execute 1+1" || sent == recv || recv == own || sent == own {
+		t.Errorf("sent %q, received %q and internal %q should differ only in color", sent, recv, own)
+	}
+
+	// c shows received arguments by name; the current match stays in full
+	line := `← AppendSessionOutput {"type":2,"result":"1 2 3\n"}`
+	d.log.Lines = []string{line, line}
+	typeDebug(d, runeKeys("c")...)
+	d.match = 1
+	view := strings.Split(stripANSI(d.Render(60, 2)), "\n")
+	if strings.TrimSpace(view[0]) != "← AppendSessionOutput {result, type}" {
+		t.Errorf("compact = %q", view[0])
+	}
+	if strings.TrimSpace(view[1]) != line {
+		t.Errorf("current match = %q, want it in full", view[1])
+	}
+	if got := compactArgs("← raw: not json {"); got != "← raw: not json {" {
+		t.Errorf("compactArgs changed a non-JSON line: %q", got)
+	}
+}
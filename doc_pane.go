@@ -25,11 +25,29 @@ type DocPane struct {
 	db       *sql.DB
 	width    int
 	history  []docState
+	version  string // Docs database version for the footer
+
+	// Set when Enter is on an external link, for the TUI to open
+	OpenURL string
+
+	// Table of contents (t toggles)
+	headings []docHeading
+	tocOpen  bool
+	tocIdx   int
 }
 
 type docLink struct {
 	display string
 	file    string // resolved file path relative to repo root
+	anchor  string // section slug after #, if any
+	url     string // http(s) target of an external link, opened in the browser
+}
+
+type docHeading struct {
+	text  string
+	level int // 1 for #, 2 for ##, ...
+	line  int // rendered line index
+	src   int // markdown line index
 }
 
 type docState struct {
@@ -40,8 +58,8 @@ type docState struct {
 
 var mdLinkRe = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
 
-// processLinks extracts internal markdown links, replacing them with «Text»
-// markers and returning the resolved link targets.
+// processLinks extracts markdown links, replacing them with «Text» markers
+// and returning the resolved link targets. External links keep their URL.
 func processLinks(markdown, currentFile string) (string, []docLink) {
 	dir := path.Dir(currentFile)
 	var links []docLink
@@ -50,22 +68,23 @@ func processLinks(markdown, currentFile string) (string, []docLink) {
 		m := mdLinkRe.FindStringSubmatch(match)
 		text, target := m[1], m[2]
 
-		// Skip external links
 		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
-			return match
+			links = append(links, docLink{display: text, url: target})
+			return fmt.Sprintf("«%s»", text)
 		}
 
-		// Strip anchor
+		// Split off the anchor; anchor-only links are to this doc
+		var anchor string
 		if i := strings.Index(target, "#"); i >= 0 {
-			target = target[:i]
+			target, anchor = target[:i], target[i+1:]
 		}
-		if target == "" {
-			return text // anchor-only link, just show text
+		resolved := currentFile
+		if target != "" {
+			resolved = path.Clean(path.Join(dir, target))
+		} else if anchor == "" {
+			return text
 		}
-
-		// Resolve relative path
-		resolved := path.Clean(path.Join(dir, target))
-		links = append(links, docLink{display: text, file: resolved})
+		links = append(links, docLink{display: text, file: resolved, anchor: anchor})
 		return fmt.Sprintf("«%s»", text)
 	})
 
@@ -108,6 +127,59 @@ func NewDocPane(navPath, file, rendered string, links []docLink, db *sql.DB, wid
 	return dp
 }
 
+var mdHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// markdownHeadings returns the ATX headings in markdown, skipping code blocks
+func markdownHeadings(markdown string) []docHeading {
+	var headings []docHeading
+	inFence := false
+	for i, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+			text := strings.Trim(strings.NewReplacer("`", "", "*", "", "«", "", "»", "").Replace(m[2]), " ")
+			headings = append(headings, docHeading{text: text, level: len(m[1]), src: i})
+		}
+	}
+	return headings
+}
+
+// findHeadingPositions sets the rendered line of each heading, matching in
+// order like findLinkPositions. Headings that can't be found are dropped.
+func findHeadingPositions(lines []string, headings []docHeading) []docHeading {
+	var found []docHeading
+	next := 0
+	for _, h := range headings {
+		// Match a prefix so headings glamour wrapped still match
+		key := []rune(h.text)
+		if len(key) > 30 {
+			key = key[:30]
+		}
+		for i := next; i < len(lines); i++ {
+			plain := strings.ReplaceAll(stripANSI(lines[i]), "«", "")
+			if strings.Contains(strings.ReplaceAll(plain, "»", ""), string(key)) {
+				h.line = i
+				found = append(found, h)
+				next = i + 1
+				break
+			}
+		}
+	}
+	return found
+}
+
+// indexHeadings builds the table of contents from the source markdown
+func (d *DocPane) indexHeadings(markdown string) {
+	d.headings = findHeadingPositions(d.rawLines, markdownHeadings(markdown))
+	d.tocOpen = false
+	d.tocIdx = 0
+}
+
 func findLinkPositions(lines []string, links []docLink) []int {
 	linkPos := make([]int, len(links))
 	linkFound := 0
@@ -130,12 +202,13 @@ func stripANSI(s string) string {
 var (
 	docLinkStyle    = lipgloss.NewStyle().Underline(true)
 	docSelectedStyle = lipgloss.NewStyle().Underline(true).Bold(true).Reverse(true)
+	docExternalStyle = lipgloss.NewStyle().Underline(true).Italic(true)
 )
 
 // styleLinks rebuilds d.lines from d.rawLines, replacing «Text» markers
 // with styled link text.
 func (d *DocPane) styleLinks() {
-	linkStyle := docLinkStyle.Foreground(AccentColor)
+	linkStyle := docLinkStyle.Foreground(theme.Link)
 	selectedStyle := docSelectedStyle
 
 	// Copy raw lines
@@ -144,11 +217,18 @@ func (d *DocPane) styleLinks() {
 
 	for i, link := range d.links {
 		marker := fmt.Sprintf("«%s»", link.display)
+		display := link.display
+		if link.url != "" {
+			display += "↗" // Opens in the browser
+		}
 		var styled string
-		if i == d.linkIdx {
-			styled = selectedStyle.Render(link.display)
-		} else {
-			styled = linkStyle.Render(link.display)
+		switch {
+		case i == d.linkIdx:
+			styled = selectedStyle.Render(display)
+		case link.url != "":
+			styled = docExternalStyle.Foreground(theme.Link).Render(display)
+		default:
+			styled = linkStyle.Render(display)
 		}
 		for j, line := range d.lines {
 			if strings.Contains(line, marker) {
@@ -167,6 +247,10 @@ func (d *DocPane) Title() string {
 }
 
 func (d *DocPane) Render(w, h int) string {
+	if d.tocOpen {
+		return d.renderTOC(w, h)
+	}
+
 	var sb strings.Builder
 	end := d.scroll + h
 	if end > len(d.lines) {
@@ -180,21 +264,85 @@ func (d *DocPane) Render(w, h int) string {
 		}
 	}
 
-	// Scroll position indicator on last line
-	if len(d.lines) > h {
+	// Scroll position indicator on last line, after the docs version
+	if len(d.lines) > h || (d.version != "" && len(d.lines) < h) {
 		sb.WriteRune('\n')
-		pos := fmt.Sprintf(" %d/%d ", d.scroll+1, len(d.lines))
-		pad := w - len(pos)
-		if pad > 0 {
-			sb.WriteString(strings.Repeat("─", pad))
+		left := ""
+		if d.version != "" {
+			left = "─ " + d.version + " "
 		}
-		sb.WriteString(pos)
+		pos := ""
+		if len(d.lines) > h {
+			pos = fmt.Sprintf(" %d/%d ", d.scroll+1, len(d.lines))
+		}
+		pad := w - lipgloss.Width(left) - len(pos)
+		if pad < 0 {
+			left, pad = "", max(w-len(pos), 0)
+		}
+		sb.WriteString(left + strings.Repeat("─", pad) + pos)
 	}
 
 	return sb.String()
 }
 
+// renderTOC renders the heading list, indented by level
+func (d *DocPane) renderTOC(w, h int) string {
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	selectedStyle := lipgloss.NewStyle().Background(theme.Accent).Foreground(theme.CursorText)
+
+	lines := []string{titleStyle.Render("Contents") + " (Enter jump, t close)"}
+	if len(d.headings) == 0 {
+		lines = append(lines, "  (no headings)")
+		return strings.Join(lines, "\n")
+	}
+
+	// Keep selection visible
+	listH := max(h-1, 1)
+	start := 0
+	if d.tocIdx >= listH {
+		start = d.tocIdx - listH + 1
+	}
+	for i := start; i < len(d.headings) && i < start+listH; i++ {
+		hd := d.headings[i]
+		line := truncateRunes(strings.Repeat("  ", hd.level-1)+hd.text, w-2)
+		if i == d.tocIdx {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, "  "+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleTOCKey handles keys while the heading list is open
+func (d *DocPane) handleTOCKey(msg tea.KeyMsg) bool {
+	switch {
+	case msg.Type == tea.KeyUp:
+		if d.tocIdx > 0 {
+			d.tocIdx--
+		}
+	case msg.Type == tea.KeyDown:
+		if d.tocIdx < len(d.headings)-1 {
+			d.tocIdx++
+		}
+	case msg.Type == tea.KeyEnter:
+		if d.tocIdx < len(d.headings) {
+			d.scroll = 0
+			d.scrollDown(d.headings[d.tocIdx].line)
+		}
+		d.tocOpen = false
+	case len(msg.Runes) == 1 && msg.Runes[0] == 't':
+		d.tocOpen = false
+	default:
+		return false
+	}
+	return true
+}
+
 func (d *DocPane) HandleKey(msg tea.KeyMsg) bool {
+	if d.tocOpen {
+		return d.handleTOCKey(msg)
+	}
+
 	switch msg.Type {
 	case tea.KeyUp:
 		d.scrollUp(1)
@@ -221,6 +369,15 @@ func (d *DocPane) HandleKey(msg tea.KeyMsg) bool {
 				d.scrollUp(1)
 			case 'b':
 				d.goBack()
+			case 't':
+				// Open the contents list at the heading above the view
+				d.tocOpen = true
+				d.tocIdx = 0
+				for i, hd := range d.headings {
+					if hd.line <= d.scroll {
+						d.tocIdx = i
+					}
+				}
 			default:
 				return false
 			}
@@ -301,25 +458,85 @@ func (d *DocPane) scrollToLink() {
 }
 
 func (d *DocPane) followLink() {
-	if d.linkIdx < 0 || d.linkIdx >= len(d.links) || d.db == nil {
+	if d.linkIdx < 0 || d.linkIdx >= len(d.links) {
 		return
 	}
 	link := d.links[d.linkIdx]
-
-	var navPath, content string
-	err := d.db.QueryRow("SELECT path, content FROM docs WHERE file = ?", link.file).Scan(&navPath, &content)
-	if err != nil {
+	if link.url != "" {
+		d.OpenURL = link.url
 		return
 	}
-
-	// Push current state
-	d.history = append(d.history, docState{
+	if d.db == nil {
+		return
+	}
+	state := docState{
 		navPath: d.navPath,
 		file:    d.file,
 		scroll:  d.scroll,
-	})
+	}
+
+	// A section of this doc needs no reload
+	if link.file == d.file && link.anchor != "" {
+		d.history = append(d.history, state)
+		d.scrollToAnchor(link.anchor)
+		return
+	}
+
+	navPath, file, content, ok := d.resolveLink(link)
+	if !ok {
+		return
+	}
+
+	// Push current state
+	d.history = append(d.history, state)
+
+	d.loadContent(navPath, file, content)
+	if link.anchor != "" {
+		d.scrollToAnchor(link.anchor)
+	}
+}
+
+// resolveLink finds the doc a link is to: by file, or failing that as a
+// symbol in help_urls, named by the link's text (⍳, ⎕NGET) or its target's
+// base name. Cross-references to primitives often point at pages that only
+// help_urls knows.
+func (d *DocPane) resolveLink(link docLink) (navPath, file, content string, ok bool) {
+	err := d.db.QueryRow("SELECT path, content FROM docs WHERE file = ?", link.file).Scan(&navPath, &content)
+	if err == nil {
+		return navPath, link.file, content, true
+	}
+	for _, symbol := range []string{
+		strings.Trim(link.display, "`*_ "),
+		strings.TrimSuffix(path.Base(link.file), ".md"),
+	} {
+		if symbol == "" {
+			continue
+		}
+		if d.db.QueryRow("SELECT path FROM help_urls WHERE symbol = ? COLLATE NOCASE", symbol).Scan(&navPath) != nil {
+			continue
+		}
+		if d.db.QueryRow("SELECT file, content FROM docs WHERE path = ?", navPath).Scan(&file, &content) == nil {
+			return navPath, file, content, true
+		}
+	}
+	return "", "", "", false
+}
 
-	d.loadContent(navPath, link.file, content)
+// scrollToAnchor scrolls to the heading a link's #anchor names, looked up in
+// the anchors table bundle-docs records. Databases without it stay at the top.
+func (d *DocPane) scrollToAnchor(anchor string) {
+	var src int
+	err := d.db.QueryRow("SELECT line FROM anchors WHERE path = ? AND slug = ?", d.navPath, anchor).Scan(&src)
+	if err != nil {
+		return
+	}
+	for _, h := range d.headings {
+		if h.src == src {
+			d.scroll = 0
+			d.scrollDown(h.line)
+			return
+		}
+	}
 }
 
 func (d *DocPane) goBack() {
@@ -351,4 +568,5 @@ func (d *DocPane) loadContent(navPath, file, content string) {
 	d.linkPos = findLinkPositions(rawLines, links)
 	d.scroll = 0
 	d.styleLinks()
+	d.indexHeadings(processed)
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -140,14 +141,21 @@ func TestDocsDatabase(t *testing.T) {
 		}
 	}
 
+	// A link to a symbol whose file isn't in docs resolves via help_urls
+	dp := NewDocPane("Test", "test.md", "", []docLink{{display: "⍳", file: "no-such-dir/iota.md"}}, db, 60)
+	dp.linkIdx = 0
+	dp.followLink()
+	if !strings.Contains(dp.navPath, "Iota") {
+		t.Errorf("⍳ link opened %q", dp.navPath)
+	}
 }
 
 func TestProcessLinks(t *testing.T) {
 	md := "See [Transpose](../primitive-functions/transpose.md) and [external](https://example.com).\n"
 	processed, links := processLinks(md, "language-reference-guide/docs/symbols/circle-backslash.md")
 
-	if len(links) != 1 {
-		t.Fatalf("got %d links, want 1", len(links))
+	if len(links) != 2 {
+		t.Fatalf("got %d links, want 2", len(links))
 	}
 	if links[0].display != "Transpose" {
 		t.Errorf("link display = %q, want %q", links[0].display, "Transpose")
@@ -158,9 +166,156 @@ func TestProcessLinks(t *testing.T) {
 	if !strings.Contains(processed, "«Transpose»") {
 		t.Errorf("processed missing marker: %q", processed)
 	}
-	// External link should be preserved
-	if !strings.Contains(processed, "https://example.com") {
-		t.Errorf("external link removed: %q", processed)
+	// External links keep their URL, to open in the browser
+	if want := (docLink{display: "external", url: "https://example.com"}); links[1] != want {
+		t.Errorf("external link = %+v, want %+v", links[1], want)
+	}
+	if !strings.Contains(processed, "«external»") {
+		t.Errorf("processed missing external marker: %q", processed)
+	}
+
+	// Anchors are kept; anchor-only links go to a section of this doc
+	_, links = processLinks("[Dyadic](rho.md#dyadic) and [below](#options)", "ref/iota.md")
+	want := []docLink{
+		{display: "Dyadic", file: "ref/rho.md", anchor: "dyadic"},
+		{display: "below", file: "ref/iota.md", anchor: "options"},
+	}
+	if len(links) != 2 || links[0] != want[0] || links[1] != want[1] {
+		t.Errorf("links = %+v, want %+v", links, want)
+	}
+}
+
+func TestDocPaneAnchors(t *testing.T) {
+	filler := strings.Repeat("filler\n\n", 30)
+	iota := "See [dyadic](rho.md#dyadic) and [options](#options).\n\n" + filler + "## Options\n\nMore\n\n" + filler
+	rho := "# Rho\n\n" + filler + "## Dyadic\n\nReshape\n\n" + filler
+	line := func(md, heading string) int {
+		for i, l := range strings.Split(md, "\n") {
+			if l == heading {
+				return i
+			}
+		}
+		t.Fatalf("no %q", heading)
+		return 0
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE docs (path TEXT, file TEXT, content TEXT)",
+		"CREATE TABLE anchors (path TEXT, slug TEXT, line INTEGER)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Exec("INSERT INTO docs VALUES ('Ref / Iota', 'iota.md', ?), ('Ref / Rho', 'rho.md', ?)", iota, rho)
+	db.Exec("INSERT INTO anchors VALUES ('Ref / Iota', 'options', ?), ('Ref / Rho', 'dyadic', ?)",
+		line(iota, "## Options"), line(rho, "## Dyadic"))
+
+	processed, links := processLinks(iota, "iota.md")
+	dp := NewDocPane("Ref / Iota", "iota.md", RenderMarkdown(processed, 60), links, db, 60)
+	dp.indexHeadings(processed)
+	at := func() string { return stripANSI(dp.lines[dp.scroll]) }
+
+	// A section of the same doc
+	dp.linkIdx = 1
+	dp.followLink()
+	if !strings.Contains(at(), "Options") {
+		t.Errorf("#options scrolled to %q", at())
+	}
+
+	// A section of another doc
+	dp.linkIdx = 0
+	dp.followLink()
+	if dp.navPath != "Ref / Rho" || !strings.Contains(at(), "Dyadic") {
+		t.Errorf("rho.md#dyadic opened %s at %q", dp.navPath, at())
+	}
+
+	// Back retraces both
+	dp.goBack()
+	if !strings.Contains(at(), "Options") {
+		t.Errorf("back went to %q, want the Options section", at())
+	}
+	dp.goBack()
+	if dp.scroll != 0 {
+		t.Errorf("second back at scroll %d, want 0", dp.scroll)
+	}
+}
+
+func TestDocPaneSymbolLinks(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE docs (path TEXT, file TEXT, content TEXT)",
+		"CREATE TABLE help_urls (symbol TEXT, path TEXT)",
+		"INSERT INTO docs VALUES ('Ref / Iota', 'ref/symbols/iota.md', '# Iota'), ('Ref / Native File Get', 'ref/nget.md', '# NGET')",
+		"INSERT INTO help_urls VALUES ('⍳', 'Ref / Iota'), ('⎕NGET', 'Ref / Native File Get'), ('rho', 'Ref / Missing')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		link docLink
+		want string // Nav path opened, "" if none
+	}{
+		{docLink{display: "Iota", file: "ref/symbols/iota.md"}, "Ref / Iota"},  // By file
+		{docLink{display: "⍳", file: "ref/primitives/index.md"}, "Ref / Iota"}, // By link text
+		{docLink{display: "`⎕NGET`", file: "ref/system/nget.md"}, "Ref / Native File Get"},
+		{docLink{display: "see here", file: "ref/other/⎕nget.md"}, "Ref / Native File Get"}, // By target name
+		{docLink{display: "rho", file: "ref/rho.md"}, ""},                                   // Symbol with no doc
+		{docLink{display: "nowhere", file: "ref/nowhere.md"}, ""},
+	}
+	for _, tt := range tests {
+		dp := NewDocPane("Start", "start.md", "", []docLink{tt.link}, db, 60)
+		dp.linkIdx = 0
+		dp.followLink()
+		got := dp.navPath
+		if got == "Start" {
+			got = ""
+		}
+		if got != tt.want {
+			t.Errorf("link %+v opened %q, want %q", tt.link, got, tt.want)
+		}
+		if tt.want != "" && len(dp.history) != 1 {
+			t.Errorf("link %+v: history %d, want 1", tt.link, len(dp.history))
+		}
+	}
+}
+
+func TestDocPaneExternalLinks(t *testing.T) {
+	processed, links := processLinks("See [Dyalog](https://dyalog.com) and [Rho](rho.md).\n", "ref/iota.md")
+	dp := NewDocPane("Ref / Iota", "ref/iota.md", RenderMarkdown(processed, 60), links, nil, 60)
+	if out := stripANSI(strings.Join(dp.lines, "\n")); !strings.Contains(out, "Dyalog↗") || strings.Contains(out, "Rho↗") {
+		t.Errorf("external link not marked:\n%s", out)
+	}
+
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if dp.OpenURL != "https://dyalog.com" || len(dp.history) != 0 {
+		t.Errorf("OpenURL = %q, history %d", dp.OpenURL, len(dp.history))
+	}
+
+	// With no browser the URL is copied instead
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("PATH", "")
+	m := newRideTestModel()
+	pane := NewPane("docs", dp, 0, 0, 60, 20)
+	m.panes.Add(pane)
+	m.panes.Focus("docs")
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if cmd == nil || !strings.Contains(m.statusMsg, "copied https://dyalog.com") || dp.OpenURL != "" {
+		t.Errorf("no browser: status %q, cmd %v", m.statusMsg, cmd != nil)
 	}
 }
 
@@ -189,3 +344,114 @@ func TestSymbolAtCursor(t *testing.T) {
 		}
 	}
 }
+
+func TestDocSymbolsAtCursor(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want []string
+	}{
+		{"      x←⍳10", 9, []string{"←⍳", "⍳"}},
+		{"      ⎕NGET 'f'", 9, []string{"⎕NGET"}},
+		{"      ⎕NGET 'f'", 11, []string{"⎕NGET"}},
+		{":If x", 3, []string{":If"}},
+		{"      ]link.create x", 9, []string{"]link.create", "]link"}},
+		{"      a∘.×b", 8, []string{"∘.", "∘"}},
+		{"      a∘.×b", 9, []string{"∘.", ".×", "."}},
+		{"      x", 7, nil},
+	}
+
+	for _, tt := range tests {
+		m := Model{lines: []Line{{Text: tt.line}}, cursorCol: tt.col}
+		got := m.docSymbolsAtCursor()
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%q col=%d: docSymbolsAtCursor()=%q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestOpenDocHelpLookup(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE docs (path TEXT, file TEXT, content TEXT)",
+		"CREATE TABLE help_urls (symbol TEXT, path TEXT)",
+		"INSERT INTO docs VALUES ('Ref / Outer Product', 'outer.md', '# Outer Product')",
+		"INSERT INTO help_urls VALUES ('∘.', 'Ref / Outer Product')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newModel := func(line string, col int) Model {
+		return Model{
+			lines:     []Line{{Text: line}},
+			cursorCol: col,
+			panes:     NewPaneManager(100, 40),
+			docs:      openedDocs(db),
+			debugLog:  &LogBuffer{},
+			width:     100,
+			height:    40,
+		}
+	}
+
+	// Multi-glyph operator resolves to its page
+	m := newModel("      a∘.×b", 8)
+	m.openDocHelp()
+	if m.panes.Get("docs") == nil {
+		t.Error("docs pane not opened for ∘.")
+	}
+
+	// Unknown symbol reports no docs
+	m = newModel("      ⍳5", 7)
+	m.openDocHelp()
+	if m.panes.Get("docs") != nil {
+		t.Error("docs pane opened for symbol without help_urls entry")
+	}
+	if m.statusMsg != "No docs for ⍳" {
+		t.Errorf("statusMsg = %q, want %q", m.statusMsg, "No docs for ⍳")
+	}
+}
+
+func TestDocPaneHeadings(t *testing.T) {
+	md := "# Iota\n\nIntro text.\n\n```apl\n# not a heading\n```\n\n## Monadic `⍳`\n\nBody\n\n## Dyadic\n\nMore body\n"
+	var sb strings.Builder
+	sb.WriteString(md)
+	for i := 0; i < 30; i++ {
+		sb.WriteString("\nfiller\n")
+	}
+	md = sb.String()
+
+	rendered := RenderMarkdown(md, 60)
+	dp := NewDocPane("Ref / Iota", "iota.md", rendered, nil, nil, 60)
+	dp.indexHeadings(md)
+
+	if len(dp.headings) != 3 {
+		t.Fatalf("got %d headings, want 3: %+v", len(dp.headings), dp.headings)
+	}
+	if dp.headings[1].text != "Monadic ⍳" || dp.headings[1].level != 2 {
+		t.Errorf("heading[1] = %+v, want Monadic ⍳ level 2", dp.headings[1])
+	}
+
+	// t opens the list, Down+Enter jumps to the heading
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if !dp.tocOpen {
+		t.Fatal("t did not open contents")
+	}
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	dp.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if dp.tocOpen {
+		t.Error("Enter should close contents")
+	}
+	if dp.scroll != dp.headings[2].line {
+		t.Errorf("scroll = %d, want heading line %d", dp.scroll, dp.headings[2].line)
+	}
+	if !strings.Contains(stripANSI(dp.lines[dp.scroll]), "Dyadic") {
+		t.Errorf("line at scroll is %q, want Dyadic heading", stripANSI(dp.lines[dp.scroll]))
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// docSearchLimit caps the number of results returned per query
+const docSearchLimit = 100
+
+// docSearchMinQuery is the shortest query that triggers a search
+const docSearchMinQuery = 3
+
+// DocSearchResult is one page matching a docs search
+type DocSearchResult struct {
+	Path    string // Nav path (docs.path)
+	Snippet string // Text around the first match
+}
+
+// SearchDocs returns pages matching query, using the docs_fts index when
+// bundle-docs built one and a case-insensitive substring scan otherwise
+func SearchDocs(db *sql.DB, query string, limit int) ([]DocSearchResult, error) {
+	// FTS5 tokenizes on words, so glyph-only queries find nothing there -
+	// treat an error (no index / no fts5 module) or no hits as "use LIKE"
+	if results, err := searchDocsFTS(db, query, limit); err == nil && len(results) > 0 {
+		return results, nil
+	}
+
+	// Escape LIKE wildcards so the query matches literally
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	rows, err := db.Query(`SELECT path, content FROM docs WHERE content LIKE ? ESCAPE '\' LIMIT ?`,
+		"%"+escaped+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDocResults(rows, query)
+}
+
+// searchDocsFTS queries the docs_fts index as a prefix phrase, best match first
+func searchDocsFTS(db *sql.DB, query string, limit int) ([]DocSearchResult, error) {
+	phrase := `"` + strings.ReplaceAll(query, `"`, `""`) + `"*`
+	rows, err := db.Query(`SELECT path, content FROM docs_fts WHERE docs_fts MATCH ? ORDER BY rank LIMIT ?`,
+		phrase, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDocResults(rows, query)
+}
+
+// scanDocResults reads (path, content) rows into results with snippets
+func scanDocResults(rows *sql.Rows, query string) ([]DocSearchResult, error) {
+	defer rows.Close()
+
+	var results []DocSearchResult
+	for rows.Next() {
+		var path, content string
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, err
+		}
+		results = append(results, DocSearchResult{Path: path, Snippet: docSnippet(content, query, 30)})
+	}
+	return results, rows.Err()
+}
+
+// docSnippet returns the text around the first case-insensitive match of
+// query in content, with up to context runes either side and whitespace
+// collapsed onto one line
+func docSnippet(content, query string, context int) string {
+	lower := strings.ToLower(content)
+	idx := strings.Index(lower, strings.ToLower(query))
+	if idx < 0 || len(lower) != len(content) {
+		// No match, or lowercasing changed byte offsets - use the start
+		idx = 0
+	}
+
+	before := []rune(content[:idx])
+	after := []rune(content[idx:])
+	start := max(len(before)-context, 0)
+	end := min(utf8.RuneCountInString(query)+context, len(after))
+
+	snippet := string(before[start:]) + string(after[:end])
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(after) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// DocSearch is a full-text search pane over the docs database
+type DocSearch struct {
+	db           *sql.DB
+	query        string
+	results      []DocSearchResult
+	err          error
+	selected     int
+	scroll       int
+	height       int    // List height from last render
+	SelectedPath string // Set when Enter pressed
+}
+
+// NewDocSearch creates a docs search pane
+func NewDocSearch(db *sql.DB) *DocSearch {
+	return &DocSearch{db: db, height: 10}
+}
+
+func (d *DocSearch) search() {
+	d.results = nil
+	d.err = nil
+	d.selected = 0
+	d.scroll = 0
+	if utf8.RuneCountInString(d.query) < docSearchMinQuery {
+		return
+	}
+	d.results, d.err = SearchDocs(d.db, d.query, docSearchLimit)
+}
+
+func (d *DocSearch) Title() string {
+	if len(d.results) > 0 {
+		return "Search Docs (" + strconv.Itoa(len(d.results)) + ")"
+	}
+	return "Search Docs"
+}
+
+func (d *DocSearch) Render(w, h int) string {
+	var sb strings.Builder
+
+	// Query line
+	promptStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+	sb.WriteString(promptStyle.Render("/ "))
+	sb.WriteString(d.query)
+	sb.WriteString(cursorStyle.Render(" "))
+	sb.WriteString("\n")
+
+	// Separator
+	sb.WriteString(strings.Repeat("─", w))
+	sb.WriteString("\n")
+
+	dimStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+	switch {
+	case d.err != nil:
+		sb.WriteString(dimStyle.Render("Error: " + d.err.Error()))
+		return sb.String()
+	case utf8.RuneCountInString(d.query) < docSearchMinQuery:
+		sb.WriteString(dimStyle.Render("Type at least 3 characters to search"))
+		return sb.String()
+	case len(d.results) == 0:
+		sb.WriteString(dimStyle.Render("No matches"))
+		return sb.String()
+	}
+
+	// Results: nav path, then snippet
+	selectedStyle := lipgloss.NewStyle().Background(theme.Accent).Foreground(theme.CursorText)
+	pathStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	snippetStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	d.height = max((h-2)/2, 1)
+	for i := d.scroll; i < len(d.results) && i < d.scroll+d.height; i++ {
+		r := d.results[i]
+		path := truncateRunes(r.Path, w)
+		snippet := truncateRunes(r.Snippet, w-2)
+
+		if i == d.selected {
+			sb.WriteString(selectedStyle.Render(path + strings.Repeat(" ", max(w-utf8.RuneCountInString(path), 0))))
+		} else {
+			sb.WriteString(pathStyle.Render(path))
+		}
+		sb.WriteString("\n  ")
+		sb.WriteString(snippetStyle.Render(snippet))
+
+		if i < len(d.results)-1 && i < d.scroll+d.height-1 {
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// truncateRunes shortens s to at most w runes, marking the cut with …
+func truncateRunes(s string, w int) string {
+	runes := []rune(s)
+	if len(runes) <= w || w < 1 {
+		return s
+	}
+	return string(runes[:w-1]) + "…"
+}
+
+func (d *DocSearch) HandleKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyUp:
+		if d.selected > 0 {
+			d.selected--
+			if d.selected < d.scroll {
+				d.scroll = d.selected
+			}
+		}
+		return true
+
+	case tea.KeyDown:
+		if d.selected < len(d.results)-1 {
+			d.selected++
+			if d.selected >= d.scroll+d.height {
+				d.scroll = d.selected - d.height + 1
+			}
+		}
+		return true
+
+	case tea.KeyEnter:
+		if d.selected >= 0 && d.selected < len(d.results) {
+			d.SelectedPath = d.results[d.selected].Path
+		}
+		return true
+
+	case tea.KeyBackspace:
+		if len(d.query) > 0 {
+			runes := []rune(d.query)
+			d.query = string(runes[:len(runes)-1])
+			d.search()
+		}
+		return true
+
+	case tea.KeySpace:
+		d.query += " "
+		d.search()
+		return true
+
+	default:
+		if len(msg.Runes) > 0 {
+			d.query += string(msg.Runes)
+			d.search()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *DocSearch) HandleMouse(x, y int, msg tea.MouseMsg) bool {
+	if msg.Type == tea.MouseLeft && y >= 2 {
+		idx := d.scroll + (y-2)/2
+		if idx >= 0 && idx < len(d.results) {
+			d.selected = idx
+			d.SelectedPath = d.results[idx].Path
+			return true
+		}
+	}
+	return false
+}
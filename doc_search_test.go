@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestDocsDB creates a docs database with a few pages
+func newTestDocsDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE docs (path TEXT, file TEXT, content TEXT)",
+		"CREATE TABLE help_urls (symbol TEXT, path TEXT)",
+		"INSERT INTO docs VALUES ('Ref / Iota', 'iota.md', '# Index Generator\n\nReturns the first N integers.')",
+		"INSERT INTO docs VALUES ('Ref / Rho', 'rho.md', '# Reshape\n\nReturns an array with the given shape.')",
+		"INSERT INTO docs VALUES ('Ref / Percent', 'pct.md', 'Matches 100% literally')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestSearchDocs(t *testing.T) {
+	db := newTestDocsDB(t)
+
+	results, err := SearchDocs(db, "returns", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results for 'returns', want 2", len(results))
+	}
+	if !strings.Contains(results[0].Snippet, "Returns") {
+		t.Errorf("snippet missing match: %q", results[0].Snippet)
+	}
+
+	// LIKE wildcards are matched literally
+	results, err = SearchDocs(db, "0%", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != "Ref / Percent" {
+		t.Errorf("'0%%' search = %+v, want only Ref / Percent", results)
+	}
+}
+
+func TestDocSnippet(t *testing.T) {
+	content := strings.Repeat("x ", 50) + "needle" + strings.Repeat(" y", 50)
+	got := docSnippet(content, "NEEDLE", 10)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet should be elided both sides: %q", got)
+	}
+	if !strings.Contains(got, "needle") {
+		t.Errorf("snippet missing match: %q", got)
+	}
+}
+
+func TestDocSearchSelect(t *testing.T) {
+	ds := NewDocSearch(newTestDocsDB(t))
+	for _, r := range "shape" {
+		ds.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(ds.results) != 1 {
+		t.Fatalf("got %d results, want 1", len(ds.results))
+	}
+	ds.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if ds.SelectedPath != "Ref / Rho" {
+		t.Errorf("SelectedPath = %q, want %q", ds.SelectedPath, "Ref / Rho")
+	}
+}
+
+func TestSearchDocsFTS(t *testing.T) {
+	db := newTestDocsDB(t)
+	if _, err := db.Exec(`
+		CREATE VIRTUAL TABLE docs_fts USING fts5(content, path UNINDEXED);
+		INSERT INTO docs_fts (content, path) SELECT content, path FROM docs;
+	`); err != nil {
+		t.Skipf("sqlite built without FTS5 (use -tags sqlite_fts5): %v", err)
+	}
+
+	// Prefix match via the index
+	results, err := searchDocsFTS(db, "integ", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != "Ref / Iota" {
+		t.Errorf("FTS 'integ' = %+v, want only Ref / Iota", results)
+	}
+
+	// Queries the tokenizer drops still work through the LIKE fallback
+	results, err = SearchDocs(db, "0%", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Errorf("'0%%' search = %+v, want 1 result", results)
+	}
+}
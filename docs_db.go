@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// docsDBPath returns where gritt looks for the docs database
+func docsDBPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "gritt", "dyalog-docs.db")
+}
+
+// DocsDB is the docs database, opened read-only the first time a docs
+// feature needs it rather than at startup. Shared by pointer so it survives
+// Model copies; doc panes borrow the one *sql.DB, which is safe for
+// concurrent queries, and are handed the new one after a rebuild.
+type DocsDB struct {
+	path string
+
+	mu     sync.Mutex
+	db     *sql.DB
+	err    error // Why there's no database, once opening has been tried
+	opened bool  // Opening has been tried
+	meta   docsMeta
+
+	// Whether the file was there, before it's opened (nil until looked at,
+	// as the status line asks on every render)
+	fileMissing *bool
+}
+
+// NewDocsDB returns the docs database at path, not yet opened
+func NewDocsDB(path string) *DocsDB {
+	return &DocsDB{path: path}
+}
+
+// Path is where the database is, or would be
+func (d *DocsDB) Path() string {
+	return d.path
+}
+
+// open returns the database, opening it on first use; first reports whether
+// this call tried to, and err why there's no database
+func (d *DocsDB) open() (db *sql.DB, first bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.opened {
+		d.opened, first = true, true
+		d.db, d.err = openDocsFile(d.path)
+	}
+	return d.db, first, d.err
+}
+
+// openDocsFile opens a docs database read-only, checking it's usable
+func openDocsFile(path string) (*sql.DB, error) {
+	// A file: URI, or the driver ignores mode and creates an empty database
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	// There, and not an empty one left by an older gritt
+	var tables int
+	err = db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'docs'").Scan(&tables)
+	if err == nil && tables == 0 {
+		err = fmt.Errorf("no docs table")
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Missing reports whether there's no usable database. Until it's been
+// opened, that's a guess from whether the file exists.
+func (d *DocsDB) Missing() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.opened {
+		return d.db == nil
+	}
+	if d.fileMissing == nil {
+		_, err := os.Stat(d.path)
+		missing := err != nil
+		d.fileMissing = &missing
+	}
+	return *d.fileMissing
+}
+
+// Meta is where the database came from, once it's been opened
+func (d *DocsDB) Meta() docsMeta {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.meta
+}
+
+func (d *DocsDB) setMeta(meta docsMeta) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.meta = meta
+}
+
+// Close closes the database; the next use opens it again
+func (d *DocsDB) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		d.db.Close()
+	}
+	d.db, d.err, d.opened = nil, nil, false
+	d.meta = docsMeta{}
+	d.fileMissing = nil
+}
+
+// docsDB returns the docs database for a docs feature, opening it on first
+// use, or nil without one
+func (m *Model) docsDB() *sql.DB {
+	if m.docs == nil {
+		return nil
+	}
+	db, first, err := m.docs.open()
+	if first {
+		if err != nil {
+			m.log("No docs database at %s (build-docs makes one): %v", m.docs.Path(), err)
+		} else {
+			m.log("Docs database loaded: %s", m.docs.Path())
+			m.checkDocsMeta(db, time.Now())
+		}
+	}
+	return db
+}
+
+// noDocs explains a docs feature that needs the missing database
+func (m *Model) noDocs() {
+	path := ""
+	if m.docs != nil {
+		path = m.docs.Path()
+	}
+	m.log("No docs database at %s: run build-docs, or bundle-docs -o %s", path, path)
+	m.statusMsg = "No docs database: the build-docs command makes one"
+}
+
+// docsBuiltMsg reports bundle-docs finishing, run from build-docs
+type docsBuiltMsg struct {
+	tmp string // Where it wrote the database
+	err error
+}
+
+// findBundleDocs looks for bundle-docs on PATH, then next to gritt
+func findBundleDocs() (string, error) {
+	if path, err := exec.LookPath("bundle-docs"); err == nil {
+		return path, nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(filepath.Dir(self), "bundle-docs")
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// buildDocs runs bundle-docs in the foreground to build the docs database
+// (palette "build-docs"). It clones the docs repo, so it takes a while; the
+// database is written beside the old one and only replaces it on success.
+func (m *Model) buildDocs() tea.Cmd {
+	if m.docs == nil {
+		return nil
+	}
+	bin, err := findBundleDocs()
+	if err != nil {
+		m.log("bundle-docs not found: %v", err)
+		m.statusMsg = "bundle-docs not found: go build ./cmd/bundle-docs and put it on PATH"
+		return nil
+	}
+	path := m.docs.Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		m.statusMsg = "Can't build docs: " + err.Error()
+		return nil
+	}
+	tmp := path + ".new"
+	m.log("Building docs: %s -o %s", bin, tmp)
+	cmd := exec.Command(bin, "-o", tmp)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return docsBuiltMsg{tmp: tmp, err: err}
+	})
+}
+
+// docsBuilt swaps in the database bundle-docs built and reopens it
+func (m *Model) docsBuilt(msg docsBuiltMsg) {
+	if m.docs == nil {
+		return
+	}
+	if msg.err == nil {
+		msg.err = os.Rename(msg.tmp, m.docs.Path())
+	}
+	if msg.err != nil {
+		os.Remove(msg.tmp)
+		m.log("Building docs failed: %v", msg.err)
+		m.statusMsg = "Building docs failed, see the debug log"
+		return
+	}
+	m.docs.Close()
+	db := m.docsDB()
+
+	// Open doc panes still hold the closed handle
+	for _, pane := range m.panes.panes {
+		switch p := pane.Content.(type) {
+		case *DocPane:
+			p.db = db
+		case *DocSearch:
+			p.db = db
+		}
+	}
+	if db != nil {
+		m.statusMsg = "Docs database built"
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// openedDocs is a docs database already opened, for tests
+func openedDocs(db *sql.DB) *DocsDB {
+	return &DocsDB{db: db, opened: true}
+}
+
+func TestDocsMissing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dyalog-docs.db")
+
+	m := newRideTestModel()
+	m.docs = NewDocsDB(path)
+	if !m.docs.Missing() || m.docsDB() != nil {
+		t.Fatal("missing database found")
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("looking for a missing database created it")
+	}
+	m.openDocHelp()
+	if !strings.Contains(m.statusMsg, "build-docs") {
+		t.Errorf("F1 without docs: status %q doesn't say how to get them", m.statusMsg)
+	}
+
+	// A failed build leaves nothing behind
+	tmp := path + ".new"
+	os.WriteFile(tmp, []byte("partial"), 0644)
+	m.docsBuilt(docsBuiltMsg{tmp: tmp, err: errTest})
+	if _, err := os.Stat(tmp); err == nil || m.docsDB() != nil || !strings.Contains(m.statusMsg, "failed") {
+		t.Errorf("failed build: status %q", m.statusMsg)
+	}
+
+	// A successful one is moved into place and opened
+	db, err := sql.Open("sqlite3", tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("CREATE TABLE docs (path TEXT)")
+	db.Close()
+	m.docsBuilt(docsBuiltMsg{tmp: tmp})
+	if m.docsDB() == nil || m.docs.Missing() || m.statusMsg != "Docs database built" {
+		t.Fatalf("built: status %q", m.statusMsg)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database not moved into place: %v", err)
+	}
+
+	// Rebuilt again: open doc panes go on working with the new database
+	search := NewDocSearch(m.docsDB())
+	doc := NewDocPane("Test", "test.md", "", nil, m.docsDB(), 40)
+	m.panes.Add(NewPane("docsearch", search, 0, 0, 40, 10))
+	m.panes.Add(NewPane("docs", doc, 0, 0, 40, 10))
+	db, err = sql.Open("sqlite3", tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("CREATE TABLE docs (path TEXT)")
+	db.Close()
+	m.docsBuilt(docsBuiltMsg{tmp: tmp})
+	for name, db := range map[string]*sql.DB{"search": search.db, "doc": doc.db} {
+		var n int
+		if err := db.QueryRow("SELECT count(*) FROM docs").Scan(&n); err != nil {
+			t.Errorf("%s pane after a rebuild: %v", name, err)
+		}
+	}
+	m.docs.Close()
+}
+
+func TestDocsDBLazy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dyalog-docs.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("CREATE TABLE docs (path TEXT)")
+	db.Close()
+
+	// Not opened until a docs feature asks, then opened once and shared
+	d := NewDocsDB(path)
+	if d.opened || d.Missing() {
+		t.Fatalf("opened = %v, missing = %v", d.opened, d.Missing())
+	}
+	m := newRideTestModel()
+	m.docs = d
+	copied := m
+	first := m.docsDB()
+	if first == nil || copied.docsDB() != first {
+		t.Error("Model copies don't share the database")
+	}
+
+	// Panes can query it at the same time
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int
+			if err := m.docsDB().QueryRow("SELECT count(*) FROM docs").Scan(&n); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Closed on quit; the next use would open it again
+	d.Close()
+	if d.opened || d.db != nil {
+		t.Error("Close left the database open")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// docsStaleAge is how old a docs database gets before gritt suggests
+// rebuilding it
+const docsStaleAge = 180 * 24 * time.Hour
+
+// docsMeta is where a docs database came from, as bundle-docs records it in
+// the meta table
+type docsMeta struct {
+	commit  string // Docs repo SHA
+	builtAt time.Time
+	bundler string // bundle-docs version
+	docs    int
+}
+
+// loadDocsMeta reads the meta table. Databases from before it existed fail.
+func loadDocsMeta(db *sql.DB) (docsMeta, error) {
+	var meta docsMeta
+	rows, err := db.Query("SELECT key, value FROM meta")
+	if err != nil {
+		return meta, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return meta, err
+		}
+		switch key {
+		case "commit":
+			meta.commit = value
+		case "built_at":
+			meta.builtAt, _ = time.Parse(time.RFC3339, value)
+		case "bundler":
+			meta.bundler = value
+		case "docs":
+			meta.docs, _ = strconv.Atoi(value)
+		}
+	}
+	return meta, rows.Err()
+}
+
+// label is the short version shown in the docs pane footer
+func (d docsMeta) label() string {
+	commit := d.commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	switch {
+	case commit != "" && !d.builtAt.IsZero():
+		return fmt.Sprintf("docs %s, %s", commit, d.builtAt.Format("2006-01-02"))
+	case commit != "":
+		return "docs " + commit
+	case !d.builtAt.IsZero():
+		return "docs " + d.builtAt.Format("2006-01-02")
+	}
+	return ""
+}
+
+// stale reports whether the database was built more than docsStaleAge
+// before now
+func (d docsMeta) stale(now time.Time) bool {
+	return !d.builtAt.IsZero() && now.Sub(d.builtAt) > docsStaleAge
+}
+
+// checkDocsMeta loads the docs database's provenance, warning if it's old
+func (m *Model) checkDocsMeta(db *sql.DB, now time.Time) {
+	meta, err := loadDocsMeta(db)
+	if err != nil {
+		m.log("Docs database has no build info (rebuild with bundle-docs): %v", err)
+		return
+	}
+	m.docs.setMeta(meta)
+	m.log("Docs: %d pages from %s, built %s by bundle-docs %s",
+		meta.docs, meta.commit, meta.builtAt.Format(time.RFC3339), meta.bundler)
+	if meta.stale(now) {
+		days := int(now.Sub(meta.builtAt).Hours() / 24)
+		m.log("Docs database is %d days old, rebuild with bundle-docs", days)
+		if m.statusMsg == "" {
+			m.statusMsg = fmt.Sprintf("Docs are %d days old, rebuild with bundle-docs", days)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocsMeta(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m := newRideTestModel()
+	m.docs = openedDocs(db)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// A database from before the meta table
+	m.checkDocsMeta(db, now)
+	if m.docs.Meta().label() != "" || m.statusMsg != "" {
+		t.Errorf("no meta: label %q, status %q", m.docs.Meta().label(), m.statusMsg)
+	}
+
+	db.Exec("CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)")
+	db.Exec(`INSERT INTO meta VALUES ('commit', '0123456789abcdef'), ('built_at', '2026-09-01T08:00:00Z'),
+		('bundler', '(devel)'), ('docs', '1234')`)
+	m.checkDocsMeta(db, now)
+	if got := m.docs.Meta().label(); got != "docs 0123456, 2026-09-01" {
+		t.Errorf("label = %q", got)
+	}
+	if m.docs.Meta().docs != 1234 || m.statusMsg != "" {
+		t.Errorf("meta %+v, status %q, want 1234 docs and no warning", m.docs.Meta(), m.statusMsg)
+	}
+
+	db.Exec("UPDATE meta SET value = '2025-09-01T08:00:00Z' WHERE key = 'built_at'")
+	m.checkDocsMeta(db, now)
+	if !strings.Contains(m.statusMsg, "395 days old") {
+		t.Errorf("old docs status = %q", m.statusMsg)
+	}
+}
+
+func TestDocPaneVersionFooter(t *testing.T) {
+	dp := NewDocPane("Test", "test.md", RenderMarkdown("# Title\n\nShort.\n", 40), nil, nil, 40)
+	dp.version = "docs 0123456, 2026-09-01"
+	lines := strings.Split(dp.Render(40, 20), "\n")
+	last := stripANSI(lines[len(lines)-1])
+	if !strings.HasPrefix(last, "─ docs 0123456, 2026-09-01 ─") {
+		t.Errorf("footer = %q", last)
+	}
+
+	// Too narrow for the version, the position still shows
+	if got := stripANSI(dp.Render(12, 2)); !strings.HasSuffix(got, " 1/"+strconv.Itoa(len(dp.lines))+" ") || strings.Contains(got, "docs") {
+		t.Errorf("narrow footer = %q", got)
+	}
+}
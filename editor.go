@@ -1,120 +1,90 @@
 package main
 
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cursork/gritt/ride"
+)
+
 // EditorWindow holds state for an open editor/tracer window from Dyalog
 type EditorWindow struct {
-	Token      int      // Unique window identifier from Dyalog
-	Name       string   // Function/operator name
-	Text       []string // Lines of text
-	EntityType int      // Type: 1=function, 256=namespace, etc.
-	Stop       []int    // Breakpoint line numbers (0-based)
-	Monitor    []int    // Monitored lines
-	Trace      []int    // Trace points
-	CurrentRow int      // Initial cursor position
-	ReadOnly   bool     // Whether editor is read-only
-	Debugger   bool     // True if this is a tracer window
+	Token        int      // Unique window identifier from Dyalog
+	Name         string   // Function/operator name
+	Text         []string // Lines of text
+	OriginalText []string // Text as last received from / saved to the interpreter
+	EntityType   int      // Type: 1=function, 256=namespace, etc.
+	Stop         []int    // Breakpoint line numbers (0-based)
+	Monitor      []int    // Monitored lines
+	Trace        []int    // Trace points
+	CurrentRow   int      // Initial cursor position
+	ReadOnly     bool     // Whether editor is read-only
+	Debugger     bool     // True if this is a tracer window
+	Thread       int      // Thread id of a tracer (&0 is the root thread)
+	ThreadName   string   // Thread name, if set with ⎕TNAME
 
 	// Editor state (local to gritt)
 	Modified     bool
 	PendingClose bool // True if we're waiting for ReplySaveChanges before closing
+	PendingApply bool // True if a tracer edit returns to tracing on ReplySaveChanges
+	ShowThread   bool // Several threads are suspended, so the title says which this is
 	CursorRow    int
 	CursorCol    int
-}
-
-// NewEditorWindow creates an EditorWindow from OpenWindow/UpdateWindow message args
-func NewEditorWindow(args map[string]any) *EditorWindow {
-	w := &EditorWindow{}
-
-	if token, ok := args["token"].(float64); ok {
-		w.Token = int(token)
-	}
-	if name, ok := args["name"].(string); ok {
-		w.Name = name
-	}
-	if entityType, ok := args["entityType"].(float64); ok {
-		w.EntityType = int(entityType)
-	}
-	if currentRow, ok := args["currentRow"].(float64); ok {
-		w.CurrentRow = int(currentRow)
-		w.CursorRow = int(currentRow)
-	}
-	// debugger is 0/1 integer, not boolean
-	if debugger, ok := args["debugger"].(float64); ok {
-		w.Debugger = debugger != 0
-	}
-	// readOnly is 0/1 integer, not boolean
-	if readOnly, ok := args["readOnly"].(float64); ok {
-		w.ReadOnly = readOnly != 0
-	}
-
-	// Parse text array
-	if text, ok := args["text"].([]any); ok {
-		w.Text = make([]string, len(text))
-		for i, line := range text {
-			if s, ok := line.(string); ok {
-				w.Text[i] = s
-			}
-		}
-	}
 
-	// Parse stop array (breakpoints)
-	if stop, ok := args["stop"].([]any); ok {
-		w.Stop = make([]int, len(stop))
-		for i, line := range stop {
-			if n, ok := line.(float64); ok {
-				w.Stop[i] = int(n)
-			}
-		}
-	}
-
-	// Parse monitor array
-	if monitor, ok := args["monitor"].([]any); ok {
-		w.Monitor = make([]int, len(monitor))
-		for i, line := range monitor {
-			if n, ok := line.(float64); ok {
-				w.Monitor[i] = int(n)
-			}
-		}
-	}
-
-	// Parse trace array
-	if trace, ok := args["trace"].([]any); ok {
-		w.Trace = make([]int, len(trace))
-		for i, line := range trace {
-			if n, ok := line.(float64); ok {
-				w.Trace[i] = int(n)
-			}
-		}
-	}
+	// ChangedLines' last answer, and the texts it was for
+	changed                  []bool
+	changedText, changedOrig []string
+}
 
+// NewEditorWindow creates an EditorWindow from an OpenWindow message
+func NewEditorWindow(args ride.OpenWindow) *EditorWindow {
+	w := &EditorWindow{
+		Token:      args.Token,
+		Name:       args.Name,
+		Text:       args.Text,
+		EntityType: args.EntityType,
+		Stop:       args.Stop,
+		Monitor:    args.Monitor,
+		Trace:      args.Trace,
+		CurrentRow: args.CurrentRow,
+		CursorRow:  args.CurrentRow,
+		ReadOnly:   bool(args.ReadOnly),
+		Debugger:   bool(args.Debugger),
+		Thread:     args.Tid,
+		ThreadName: args.TName,
+	}
+	w.OriginalText = append([]string(nil), w.Text...)
 	return w
 }
 
-// Update refreshes window content from UpdateWindow message args
-func (w *EditorWindow) Update(args map[string]any) {
-	if text, ok := args["text"].([]any); ok {
-		w.Text = make([]string, len(text))
-		for i, line := range text {
-			if s, ok := line.(string); ok {
-				w.Text[i] = s
-			}
-		}
+// Update refreshes window content from an UpdateWindow message
+func (w *EditorWindow) Update(args ride.UpdateWindow) {
+	if args.Text != nil {
+		w.Text = args.Text
+		w.OriginalText = append([]string(nil), w.Text...)
 	}
-	if currentRow, ok := args["currentRow"].(float64); ok {
-		w.CurrentRow = int(currentRow)
+	if args.CurrentRow != nil {
+		w.CurrentRow = *args.CurrentRow
 	}
-	if debugger, ok := args["debugger"].(float64); ok {
-		w.Debugger = debugger != 0
+	if args.Debugger != nil {
+		w.Debugger = bool(*args.Debugger)
 	}
-	if stop, ok := args["stop"].([]any); ok {
-		w.Stop = make([]int, len(stop))
-		for i, line := range stop {
-			if n, ok := line.(float64); ok {
-				w.Stop[i] = int(n)
-			}
-		}
+	if args.Stop != nil {
+		w.Stop = args.Stop
 	}
 }
 
+// threadLabel names a thread as the interpreter does: &1, or &1 worker if
+// it has a name
+func threadLabel(tid int, name string) string {
+	label := "&" + strconv.Itoa(tid)
+	if name != "" {
+		label += " " + name
+	}
+	return label
+}
+
 // HasStop returns true if the given line has a breakpoint
 func (w *EditorWindow) HasStop(line int) bool {
 	for _, s := range w.Stop {
@@ -141,3 +111,113 @@ func (w *EditorWindow) ToggleStop(line int) {
 	w.Stop = append(w.Stop, line)
 	w.Modified = true
 }
+
+// IsTradfn returns true if the window holds a traditional function, whose
+// first line is its header (r←Foo x;local, {r}←Foo x, r←{x} Foo y). A dfn's
+// first line opens its body instead (Foo←{ or {⍺+⍵}), so has no header.
+func (w *EditorWindow) IsTradfn() bool {
+	if w.EntityType != 1 || len(w.Text) == 0 {
+		return false
+	}
+	line := strings.TrimSpace(w.Text[0])
+	if name, rest, ok := strings.Cut(line, "←"); ok && isAPLName(strings.TrimSpace(name)) {
+		line = strings.TrimSpace(rest) // Past the result (r←) or the dfn's name (Foo←)
+	}
+	if !strings.HasPrefix(line, "{") {
+		return true
+	}
+	// Braces round a single name are an optional result or left argument,
+	// followed by ← or the function name
+	inner, rest, ok := strings.Cut(line[1:], "}")
+	return ok && isAPLName(strings.TrimSpace(inner)) && strings.TrimSpace(rest) != ""
+}
+
+// isAPLName returns true if s is a single (non-system) APL name
+func isAPLName(s string) bool {
+	if s == "" || strings.HasPrefix(s, "⎕") || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, r := range s {
+		if !isAPLNameChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// LineChanged returns true if the line differs from the original text
+func (w *EditorWindow) LineChanged(line int) bool {
+	changed := w.ChangedLines()
+	return line >= 0 && line < len(changed) && changed[line]
+}
+
+// ChangedLines marks the lines added or edited since the original text.
+// Lines are matched up by a diff, so inserting or deleting one doesn't mark
+// all those below it. Worked out again only when either text changes.
+func (w *EditorWindow) ChangedLines() []bool {
+	if w.changed == nil || !slices.Equal(w.changedText, w.Text) || !slices.Equal(w.changedOrig, w.OriginalText) {
+		w.changed = changedLines(w.OriginalText, w.Text)
+		w.changedText, w.changedOrig = slices.Clone(w.Text), slices.Clone(w.OriginalText)
+	}
+	return w.changed
+}
+
+// maxLineDiff caps the lines × lines table changedLines builds; past it,
+// every line between the unchanged ends counts as changed
+const maxLineDiff = 4_000_000
+
+// changedLines marks each line of text that isn't in a longest common
+// subsequence of orig and text
+func changedLines(orig, text []string) []bool {
+	changed := make([]bool, len(text))
+
+	// Most edits leave the ends alone, which keeps the table small
+	pre := 0
+	for pre < len(orig) && pre < len(text) && orig[pre] == text[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(orig)-pre && suf < len(text)-pre && orig[len(orig)-1-suf] == text[len(text)-1-suf] {
+		suf++
+	}
+	a, b := orig[pre:len(orig)-suf], text[pre:len(text)-suf]
+	if len(a)*len(b) > maxLineDiff {
+		for j := range b {
+			changed[pre+j] = true
+		}
+		return changed
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; j < len(b); {
+		switch {
+		case i < len(a) && a[i] == b[j]:
+			i, j = i+1, j+1
+		case i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			i++ // Deleted from orig
+		default:
+			changed[pre+j] = true
+			j++
+		}
+	}
+	return changed
+}
+
+// MarkSaved records the current text as the original after a successful save
+func (w *EditorWindow) MarkSaved() {
+	w.OriginalText = append([]string(nil), w.Text...)
+	w.Modified = false
+}
@@ -19,6 +19,9 @@ type EditorPane struct {
 	// Tracer key bindings (single characters)
 	tracerKeys TracerKeysConfig
 
+	// Editor settings (indent width etc.)
+	editorCfg EditorConfig
+
 	// Callbacks
 	onSave  func()
 	onClose func()
@@ -31,30 +34,45 @@ type EditorPane struct {
 	onResumeAll  func()
 	onBackward   func()
 	onForward    func()
+	onApply      func()
+	onCutback    func()
 
 	// Styles
 	cursorStyle      lipgloss.Style
 	lineNumStyle     lipgloss.Style
 	breakpointStyle  lipgloss.Style
+	changedStyle     lipgloss.Style // Gutter bar for lines edited since open/save
 	tracerLineStyle  lipgloss.Style // Bold for current line in tracer
+	pausedLineStyle  lipgloss.Style // Background of the line the interpreter is paused on
+	headerStyle      lipgloss.Style // Underlines a tradfn header, separating it from the body
 	highlightLine    int            // -1 = none, otherwise 0-based line for tracer highlight
 }
 
 // NewEditorPane creates an editor pane for the given window
-func NewEditorPane(w *EditorWindow, tracerKeys TracerKeysConfig, onSave, onClose func()) *EditorPane {
-	return &EditorPane{
-		window:     w,
-		tracerKeys: tracerKeys,
-		onSave:     onSave,
-		onClose:    onClose,
-		cursorStyle: lipgloss.NewStyle().
-			Background(lipgloss.Color("255")).
-			Foreground(lipgloss.Color("0")),
-		lineNumStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
-		breakpointStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("9")), // Red
-		tracerLineStyle: lipgloss.NewStyle().Foreground(AccentColor),
-		highlightLine:   -1,
+func NewEditorPane(w *EditorWindow, tracerKeys TracerKeysConfig, editorCfg EditorConfig, onSave, onClose func()) *EditorPane {
+	e := &EditorPane{
+		window:        w,
+		tracerKeys:    tracerKeys,
+		editorCfg:     editorCfg,
+		onSave:        onSave,
+		onClose:       onClose,
+		headerStyle:   lipgloss.NewStyle().Underline(true),
+		highlightLine: -1,
 	}
+	e.ApplyTheme()
+	return e
+}
+
+// ApplyTheme rebuilds the pane's styles from the theme
+func (e *EditorPane) ApplyTheme() {
+	e.cursorStyle = lipgloss.NewStyle().
+		Background(theme.Cursor).
+		Foreground(theme.CursorText)
+	e.lineNumStyle = lipgloss.NewStyle().Foreground(theme.Dim)
+	e.breakpointStyle = lipgloss.NewStyle().Foreground(theme.Breakpoint)
+	e.changedStyle = lipgloss.NewStyle().Foreground(theme.Changed)
+	e.tracerLineStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	e.pausedLineStyle = lipgloss.NewStyle().Foreground(theme.Accent).Background(theme.Selection)
 }
 
 // SetWindow switches the pane to display a different window (for tracer switching)
@@ -85,6 +103,9 @@ func (e *EditorPane) Title() string {
 	} else {
 		suffix = " [edit]"
 	}
+	if e.window.ShowThread {
+		suffix = " " + threadLabel(e.window.Thread, e.window.ThreadName) + suffix
+	}
 	return prefix + e.window.Name + suffix
 }
 
@@ -110,6 +131,8 @@ func (e *EditorPane) Render(w, h int) string {
 		e.scrollY = e.window.CursorRow - h + 1
 	}
 
+	paused := e.pausedLine()
+	changedLines := e.window.ChangedLines()
 	var lines []string
 	for i := 0; i < h; i++ {
 		lineIdx := e.scrollY + i
@@ -125,6 +148,12 @@ func (e *EditorPane) Render(w, h int) string {
 			bp = e.breakpointStyle.Render("●")
 		}
 
+		// Changed-line marker (between breakpoint and line number)
+		changed := " "
+		if changedLines[lineIdx] {
+			changed = e.changedStyle.Render("▎")
+		}
+
 		// Line number
 		lineNum := e.lineNumStyle.Render(fmt.Sprintf("[%*d]", numWidth-2, lineIdx))
 
@@ -141,34 +170,57 @@ func (e *EditorPane) Render(w, h int) string {
 		// Render line with cursor if on this line
 		var lineContent string
 		isCurrentLine := lineIdx == e.window.CursorRow
+		isPaused := lineIdx == paused
+		isHeader := lineIdx == 0 && e.window.IsTradfn()
 		if isCurrentLine {
 			// Pass tracer style if in tracer mode
 			var lineStyle *lipgloss.Style
-			if e.InTracerMode() {
+			if isPaused {
+				lineStyle = &e.pausedLineStyle
+			} else if e.InTracerMode() {
 				lineStyle = &e.tracerLineStyle
+			} else if isHeader {
+				lineStyle = &e.headerStyle
 			}
 			lineContent = e.renderLineWithCursor(textRunes, e.window.CursorCol, contentW, lineStyle)
+		} else if isPaused {
+			lineContent = e.pausedLineStyle.Render(e.renderLine(textRunes, contentW))
+		} else if isHeader {
+			lineContent = e.headerStyle.Render(e.renderLine(textRunes, contentW))
 		} else {
 			lineContent = e.renderLine(textRunes, contentW)
 		}
 
-		// In tracer mode, highlight entire current line including line number
-		if isCurrentLine && e.InTracerMode() {
+		// Highlight the paused line's number too, so it shows even when
+		// the cursor is elsewhere; in tracer mode the cursor's line number
+		// is highlighted as well
+		if isPaused {
+			lineNum = e.pausedLineStyle.Render(fmt.Sprintf("[%*d]", numWidth-2, lineIdx))
+		} else if isCurrentLine && e.InTracerMode() {
 			lineNum = e.tracerLineStyle.Render(fmt.Sprintf("[%*d]", numWidth-2, lineIdx))
 		}
 
-		lines = append(lines, bp+" "+lineNum+" "+lineContent)
+		lines = append(lines, bp+changed+lineNum+" "+lineContent)
 	}
 
 	return strings.Join(lines, "\n")
 }
 
-// renderLine renders a line without cursor, padded/truncated to width
+// CursorPos returns the cursor position relative to the content area as of
+// the last Render (used to anchor the autocomplete popup)
+func (e *EditorPane) CursorPos() (x, y int) {
+	numWidth := len(fmt.Sprintf("[%d]", max(len(e.window.Text)-1, 0)))
+	runes := []rune(e.currentLine())
+	col := min(max(e.window.CursorCol, 0), len(runes))
+	return numWidth + 3 + lipgloss.Width(string(runes[:col])), e.window.CursorRow - e.scrollY
+}
+
+// renderLine renders a line without cursor, padded/truncated to w display
+// columns
 func (e *EditorPane) renderLine(runes []rune, w int) string {
-	if len(runes) >= w {
-		return string(runes[:w])
-	}
-	return string(runes) + strings.Repeat(" ", w-len(runes))
+	_, b, _ := visibleRunes(runes, 0, w)
+	text := string(runes[:b])
+	return text + strings.Repeat(" ", w-lipgloss.Width(text))
 }
 
 // renderLineWithCursor renders a line with cursor highlight at col position
@@ -182,20 +234,32 @@ func (e *EditorPane) renderLineWithCursor(runes []rune, col, w int, lineStyle *l
 		col = 0
 	}
 
-	// Build parts: before cursor, cursor char, after cursor
+	// Only what fits in w display columns is drawn, the cursor included: past
+	// the end of the visible text it needs a column of its own
+	_, end, _ := visibleRunes(runes, 0, w)
+	if col >= end {
+		_, end, _ = visibleRunes(runes, 0, w-1)
+	}
+	col = min(col, end)
+
+	// Build parts: before cursor, cursor char (with any combining marks
+	// after it), after cursor
 	before := string(runes[:col])
 
+	next := col
 	var cursorChar string
-	if col < len(runes) {
-		cursorChar = e.cursorStyle.Render(string(runes[col]))
+	if col < end {
+		next++
+		for next < end && runeWidth(runes[next]) == 0 {
+			next++
+		}
+		cursorChar = string(runes[col:next])
 	} else {
-		cursorChar = e.cursorStyle.Render(" ")
-	}
-
-	var after string
-	if col+1 < len(runes) {
-		after = string(runes[col+1:])
+		cursorChar = " "
 	}
+	after := string(runes[next:end])
+	used := lipgloss.Width(before + cursorChar + after)
+	cursorChar = e.cursorStyle.Render(cursorChar)
 
 	// Apply line style to non-cursor parts if provided
 	if lineStyle != nil {
@@ -205,10 +269,9 @@ func (e *EditorPane) renderLineWithCursor(runes []rune, col, w int, lineStyle *l
 
 	line := before + cursorChar + after
 
-	// Pad to width (approximate due to ANSI codes)
-	visibleLen := len(runes)
-	if visibleLen < w {
-		pad := strings.Repeat(" ", w-visibleLen-1)
+	// Pad to width by display columns
+	if used < w {
+		pad := strings.Repeat(" ", w-used)
 		if lineStyle != nil {
 			pad = lineStyle.Render(pad)
 		}
@@ -275,6 +338,10 @@ func (e *EditorPane) HandleKey(msg tea.KeyMsg) bool {
 					if e.onForward != nil {
 						e.onForward()
 					}
+				case e.matchKey(r, e.tracerKeys.Cutback):
+					if e.onCutback != nil {
+						e.onCutback()
+					}
 				case e.matchKey(r, e.tracerKeys.EditMode):
 					e.editMode = true
 				default:
@@ -335,9 +402,19 @@ func (e *EditorPane) HandleKey(msg tea.KeyMsg) bool {
 	case tea.KeyDelete:
 		e.deleteCharForward()
 	case tea.KeyCtrlS:
-		if e.onSave != nil {
+		// In a tracer, saving applies the fix and goes back to tracing
+		if e.window.Debugger && e.editMode && e.onApply != nil {
+			e.onApply()
+		} else if e.onSave != nil {
 			e.onSave()
 		}
+	case tea.KeyCtrlUnderscore:
+		// Ctrl+/ arrives as Ctrl+_ in most terminals
+		e.toggleComment()
+	case tea.KeyTab:
+		e.insertIndent()
+	case tea.KeyShiftTab:
+		e.outdentLine()
 	case tea.KeyEscape:
 		// If in edit mode of a tracer, just exit edit mode (don't save yet)
 		// Changes stay pending until the window actually closes
@@ -378,15 +455,9 @@ func (e *EditorPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 			targetRow := e.scrollY + y
 			if targetRow >= 0 && targetRow < len(e.window.Text) {
 				e.window.CursorRow = targetRow
-				// Approximate col from x (subtract line number width estimate)
-				e.window.CursorCol = x - 5 // rough estimate
-				if e.window.CursorCol < 0 {
-					e.window.CursorCol = 0
-				}
-				lineLen := len([]rune(e.currentLine()))
-				if e.window.CursorCol > lineLen {
-					e.window.CursorCol = lineLen
-				}
+				// Col from x past the gutter, by display columns
+				numWidth := len(fmt.Sprintf("[%d]", max(len(e.window.Text)-1, 0)))
+				e.window.CursorCol = runeAtColumn([]rune(e.currentLine()), max(x-numWidth-3, 0))
 			}
 			return true
 		}
@@ -462,6 +533,9 @@ func (e *EditorPane) insertChar(r rune) {
 	e.window.Text[e.window.CursorRow] = string(newRunes)
 	e.window.CursorCol++
 	e.window.Modified = true
+
+	// Typing a block closer (} or :EndIf etc.) outdents the line
+	e.outdentCloser()
 }
 
 func (e *EditorPane) deleteCharBack() {
@@ -475,10 +549,17 @@ func (e *EditorPane) deleteCharBack() {
 		newRunes = append(newRunes, runes[:col-1]...)
 		newRunes = append(newRunes, runes[col:]...)
 
+		if e.guardsHeader(e.window.CursorRow, string(newRunes)) {
+			return
+		}
 		e.window.Text[e.window.CursorRow] = string(newRunes)
 		e.window.CursorCol--
 		e.window.Modified = true
 	} else if e.window.CursorRow > 0 {
+		if e.window.CursorRow == 1 && e.window.IsTradfn() {
+			return // Would merge the first body line into the header
+		}
+		e.shiftCurrentLine(e.window.CursorRow, -1)
 		// Join with previous line
 		prevLine := e.window.Text[e.window.CursorRow-1]
 		currLine := e.currentLine()
@@ -503,9 +584,16 @@ func (e *EditorPane) deleteCharForward() {
 		newRunes = append(newRunes, runes[:col]...)
 		newRunes = append(newRunes, runes[col+1:]...)
 
+		if e.guardsHeader(e.window.CursorRow, string(newRunes)) {
+			return
+		}
 		e.window.Text[e.window.CursorRow] = string(newRunes)
 		e.window.Modified = true
 	} else if e.window.CursorRow < len(e.window.Text)-1 {
+		if e.window.CursorRow == 0 && e.window.IsTradfn() {
+			return // Would merge the first body line into the header
+		}
+		e.shiftCurrentLine(e.window.CursorRow+1, -1)
 		// Join with next line
 		nextLine := e.window.Text[e.window.CursorRow+1]
 		e.window.Text[e.window.CursorRow] = line + nextLine
@@ -514,6 +602,12 @@ func (e *EditorPane) deleteCharForward() {
 	}
 }
 
+// guardsHeader returns true if changing line row to text would blank out a
+// tradfn header, so the edit should be refused
+func (e *EditorPane) guardsHeader(row int, text string) bool {
+	return row == 0 && e.window.IsTradfn() && strings.TrimSpace(text) == ""
+}
+
 func (e *EditorPane) insertNewline() {
 	line := e.currentLine()
 	runes := []rune(line)
@@ -524,22 +618,194 @@ func (e *EditorPane) insertNewline() {
 
 	// Split line at cursor
 	before := string(runes[:col])
-	after := string(runes[col:])
+	after := strings.TrimLeft(string(runes[col:]), " ")
+	if e.guardsHeader(e.window.CursorRow, before) {
+		return // Would push the header down below a blank line
+	}
+
+	// Splitting at the start moves the whole line down
+	if col == 0 {
+		e.shiftCurrentLine(e.window.CursorRow, 1)
+	} else {
+		e.shiftCurrentLine(e.window.CursorRow+1, 1)
+	}
+
+	// New line carries the current indent, plus a level after a block opener
+	indent := leadingIndent(before)
+	if opensBlock(before) {
+		indent += strings.Repeat(" ", e.indentWidth())
+	}
 
 	e.window.Text[e.window.CursorRow] = before
 
 	// Insert new line after
 	newText := make([]string, 0, len(e.window.Text)+1)
 	newText = append(newText, e.window.Text[:e.window.CursorRow+1]...)
-	newText = append(newText, after)
+	newText = append(newText, indent+after)
 	newText = append(newText, e.window.Text[e.window.CursorRow+1:]...)
 
 	e.window.Text = newText
 	e.window.CursorRow++
-	e.window.CursorCol = 0
+	e.window.CursorCol = len(indent)
+	e.window.Modified = true
+}
+
+// toggleComment comments out the current line by inserting ⍝ after its
+// indentation, or uncomments it if it already starts with ⍝
+func (e *EditorPane) toggleComment() {
+	line := e.currentLine()
+	indent := len(leadingIndent(line))
+	runes := []rune(line)
+	rest := string(runes[indent:])
+
+	if strings.HasPrefix(rest, "⍝") {
+		e.window.Text[e.window.CursorRow] = line[:indent] + strings.TrimPrefix(rest, "⍝")
+		if e.window.CursorCol > indent {
+			e.window.CursorCol--
+		}
+	} else {
+		e.window.Text[e.window.CursorRow] = line[:indent] + "⍝" + rest
+		if e.window.CursorCol >= indent {
+			e.window.CursorCol++
+		}
+	}
 	e.window.Modified = true
 }
 
+// insertIndent inserts one indent level of spaces at the cursor
+func (e *EditorPane) insertIndent() {
+	for i := 0; i < e.indentWidth(); i++ {
+		e.insertChar(' ')
+	}
+}
+
+// outdentLine removes up to one indent level of leading spaces from the
+// current line, keeping the cursor on the same character
+func (e *EditorPane) outdentLine() {
+	line := e.currentLine()
+	n := min(len(leadingIndent(line)), e.indentWidth())
+	if n == 0 {
+		return
+	}
+	e.window.Text[e.window.CursorRow] = line[n:]
+	e.window.CursorCol = max(e.window.CursorCol-n, 0)
+	e.window.Modified = true
+}
+
+// defaultIndentWidth is used when the config doesn't set editor.indent_width
+const defaultIndentWidth = 4
+
+// indentWidth returns the number of spaces per indent level
+func (e *EditorPane) indentWidth() int {
+	if e.editorCfg.IndentWidth > 0 {
+		return e.editorCfg.IndentWidth
+	}
+	return defaultIndentWidth
+}
+
+// outdentCloser removes one indent level from the current line while the
+// user is typing a block closer at its start. The target indent is derived
+// from the previous non-blank line so repeated matches (":End" then ":EndIf")
+// only outdent once.
+func (e *EditorPane) outdentCloser() {
+	line := e.currentLine()
+	runes := []rune(line)
+	col := min(e.window.CursorCol, len(runes))
+	typed := strings.TrimLeft(string(runes[:col]), " ")
+	if typed == "" || strings.Contains(typed, " ") || !closesBlock(typed) {
+		return
+	}
+
+	prev := ""
+	for i := e.window.CursorRow - 1; i >= 0; i-- {
+		if strings.TrimSpace(e.window.Text[i]) != "" {
+			prev = e.window.Text[i]
+			break
+		}
+	}
+	target := len(leadingIndent(prev))
+	if !opensBlock(prev) {
+		target -= e.indentWidth()
+	}
+	target = max(target, 0)
+
+	current := len(leadingIndent(line))
+	if current <= target {
+		return
+	}
+	e.window.Text[e.window.CursorRow] = line[current-target:]
+	e.window.CursorCol -= current - target
+}
+
+// blockOpeners are control keywords whose following lines are indented
+var blockOpeners = []string{
+	":if", ":else", ":elseif", ":for", ":while", ":repeat", ":select",
+	":case", ":caselist", ":trap", ":with", ":hold", ":section",
+	":namespace", ":class", ":interface", ":property", ":disposable",
+}
+
+// blockClosers are control keywords that sit one level left of the block body
+var blockClosers = []string{
+	":end", ":until", ":else", ":elseif", ":case", ":caselist",
+}
+
+// leadingIndent returns the leading spaces of a line
+func leadingIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " "))]
+}
+
+// firstKeyword returns the lowercased first word of a line if it is a control keyword
+func firstKeyword(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, ":") {
+		return ""
+	}
+	if i := strings.IndexAny(trimmed, " ⍝"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	return strings.ToLower(trimmed)
+}
+
+// opensBlock returns true if the line starts a dfn or control structure
+func opensBlock(line string) bool {
+	if strings.HasSuffix(strings.TrimSpace(line), "{") {
+		return true
+	}
+	kw := firstKeyword(line)
+	for _, o := range blockOpeners {
+		if kw == o {
+			return true
+		}
+	}
+	return false
+}
+
+// closesBlock returns true if the line starts with } or a closing control keyword
+func closesBlock(line string) bool {
+	if strings.HasPrefix(strings.TrimSpace(line), "}") {
+		return true
+	}
+	kw := firstKeyword(line)
+	for _, c := range blockClosers {
+		if kw == c || (c == ":end" && strings.HasPrefix(kw, c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pausedLine is the line the interpreter will run next: the last
+// SetHighlightLine, or a tracer's current line. -1 if there's none.
+func (e *EditorPane) pausedLine() int {
+	if e.highlightLine >= 0 {
+		return e.highlightLine
+	}
+	if e.window.Debugger {
+		return e.window.CurrentRow
+	}
+	return -1
+}
+
 // SetHighlightLine sets the tracer highlight line (for SetHighlightLine message)
 func (e *EditorPane) SetHighlightLine(line int) {
 	e.highlightLine = line
@@ -559,6 +825,8 @@ type TracerCallbacks struct {
 	ResumeAll func()
 	Backward  func()
 	Forward   func()
+	Apply     func() // Save edit-mode changes and return to tracing
+	Cutback   func() // Abandon the current function, back to its caller
 }
 
 // SetTracerCallbacks sets all tracer control callbacks at once
@@ -570,6 +838,29 @@ func (e *EditorPane) SetTracerCallbacks(cb TracerCallbacks) {
 	e.onResumeAll = cb.ResumeAll
 	e.onBackward = cb.Backward
 	e.onForward = cb.Forward
+	e.onApply = cb.Apply
+	e.onCutback = cb.Cutback
+}
+
+// ExitEditMode returns a tracer to trace mode with the cursor on the
+// current line, which may have moved if lines were added or removed above it
+func (e *EditorPane) ExitEditMode() {
+	e.editMode = false
+	if row := e.window.CurrentRow; row >= 0 && row < len(e.window.Text) {
+		e.window.CursorRow = row
+		e.window.CursorCol = 0
+	}
+}
+
+// shiftCurrentLine keeps the tracer's current line on the same code when
+// delta lines are inserted (or removed, if negative) at row
+func (e *EditorPane) shiftCurrentLine(row, delta int) {
+	if e.highlightLine >= row {
+		e.highlightLine = max(e.highlightLine+delta, 0)
+	}
+	if e.window.Debugger && e.window.CurrentRow >= row {
+		e.window.CurrentRow = max(e.window.CurrentRow+delta, 0)
+	}
 }
 
 
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+func newTestEditor(lines ...string) *EditorPane {
+	w := &EditorWindow{Text: lines}
+	return NewEditorPane(w, TracerKeysConfig{}, EditorConfig{IndentWidth: 2}, nil, nil)
+}
+
+func typeRunes(e *EditorPane, s string) {
+	for _, r := range s {
+		e.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestEditorAutoIndent(t *testing.T) {
+	e := newTestEditor("foo←{")
+	e.window.CursorCol = len([]rune("foo←{"))
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := e.window.Text[1]; got != "  " {
+		t.Errorf("after dfn opener: line 1 = %q, want %q", got, "  ")
+	}
+	if e.window.CursorCol != 2 {
+		t.Errorf("cursor col = %d, want 2", e.window.CursorCol)
+	}
+
+	typeRunes(e, "⍵+1")
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := e.window.Text[2]; got != "  " {
+		t.Errorf("plain line should keep indent: line 2 = %q, want %q", got, "  ")
+	}
+
+	typeRunes(e, "}")
+	if got := e.window.Text[2]; got != "}" {
+		t.Errorf("closing brace should outdent: line 2 = %q, want %q", got, "}")
+	}
+	if e.window.CursorCol != 1 {
+		t.Errorf("cursor col after outdent = %d, want 1", e.window.CursorCol)
+	}
+}
+
+func TestEditorControlStructureIndent(t *testing.T) {
+	e := newTestEditor(" :If x")
+	e.window.CursorCol = len(" :If x")
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := e.window.Text[1]; got != "   " {
+		t.Errorf("after :If: line 1 = %q, want %q", got, "   ")
+	}
+
+	typeRunes(e, "y")
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	typeRunes(e, ":EndIf")
+	if got := e.window.Text[2]; got != " :EndIf" {
+		t.Errorf(":EndIf should align with :If: line 2 = %q, want %q", got, " :EndIf")
+	}
+}
+
+func TestEditorEnterMidLineTrimsIndent(t *testing.T) {
+	e := newTestEditor("  a   b")
+	e.window.CursorCol = 3
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := e.window.Text[0]; got != "  a" {
+		t.Errorf("line 0 = %q, want %q", got, "  a")
+	}
+	if got := e.window.Text[1]; got != "  b" {
+		t.Errorf("line 1 = %q, want %q", got, "  b")
+	}
+}
+
+func TestEditorToggleComment(t *testing.T) {
+	e := newTestEditor("  x←1")
+	e.window.CursorCol = 3
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	if got := e.window.Text[0]; got != "  ⍝x←1" {
+		t.Errorf("comment: line = %q, want %q", got, "  ⍝x←1")
+	}
+	if e.window.CursorCol != 4 {
+		t.Errorf("comment: cursor col = %d, want 4", e.window.CursorCol)
+	}
+	if !e.window.Modified {
+		t.Error("comment: window not marked modified")
+	}
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	if got := e.window.Text[0]; got != "  x←1" {
+		t.Errorf("uncomment: line = %q, want %q", got, "  x←1")
+	}
+	if e.window.CursorCol != 3 {
+		t.Errorf("uncomment: cursor col = %d, want 3", e.window.CursorCol)
+	}
+}
+
+func TestEditorTabIndent(t *testing.T) {
+	e := newTestEditor("x←1")
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	if got := e.window.Text[0]; got != "  x←1" {
+		t.Errorf("after Tab: line = %q, want %q", got, "  x←1")
+	}
+	if e.window.CursorCol != 2 {
+		t.Errorf("after Tab: cursor col = %d, want 2", e.window.CursorCol)
+	}
+
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if got := e.window.Text[0]; got != "x←1" {
+		t.Errorf("after Shift+Tab: line = %q, want %q", got, "x←1")
+	}
+	if e.window.CursorCol != 0 {
+		t.Errorf("after Shift+Tab: cursor col = %d, want 0", e.window.CursorCol)
+	}
+
+	// Shift+Tab with no indent is a no-op
+	e.window.Modified = false
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if e.window.Modified {
+		t.Error("Shift+Tab on unindented line should not modify")
+	}
+}
+
+func TestEditorCursorScreenPos(t *testing.T) {
+	e := newTestEditor("foo←{", "  ⍵+bar")
+	e.window.Token = 7
+	e.window.CursorRow = 1
+	e.window.CursorCol = 7
+
+	m := Model{panes: NewPaneManager(80, 24)}
+	m.panes.Add(NewPane("editor:7", e, 10, 4, 40, 10))
+	e.Render(38, 8)
+
+	// Border (1) + gutter "● ▎[1] " (bp, marker, 3-wide number, space) + col 7
+	x, y, ok := m.editorCursorScreenPos(7)
+	if !ok {
+		t.Fatal("editor pane not found for token 7")
+	}
+	if x != 10+1+6+7 || y != 4+1+1 {
+		t.Errorf("cursor screen pos = (%d,%d), want (%d,%d)", x, y, 10+1+6+7, 4+1+1)
+	}
+
+	if _, _, ok := m.editorCursorScreenPos(0); ok {
+		t.Error("session token should not resolve to an editor pane")
+	}
+}
+
+func TestEditorTradfnHeader(t *testing.T) {
+	e := newTestEditor("r←Foo x", "r←x")
+	e.window.EntityType = 1
+	if !e.window.IsTradfn() {
+		t.Fatal("r←Foo x should be a tradfn")
+	}
+
+	// Backspace at the start of the body doesn't join it into the header
+	e.window.CursorRow, e.window.CursorCol = 1, 0
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if len(e.window.Text) != 2 || e.window.Text[0] != "r←Foo x" {
+		t.Errorf("backspace joined into header: %q", e.window.Text)
+	}
+
+	// Delete at the end of the header doesn't pull the body up
+	e.window.CursorRow, e.window.CursorCol = 0, len([]rune("r←Foo x"))
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyDelete})
+	if len(e.window.Text) != 2 {
+		t.Errorf("delete joined into header: %q", e.window.Text)
+	}
+
+	// Enter at the start of the header doesn't leave a blank header
+	e.window.CursorCol = 0
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if e.window.Text[0] != "r←Foo x" {
+		t.Errorf("enter pushed header down: %q", e.window.Text)
+	}
+
+	// Editing the header is fine, but not deleting its last character
+	e.window.Text[0] = "Z"
+	e.window.CursorCol = 1
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if e.window.Text[0] != "Z" {
+		t.Errorf("header blanked: %q", e.window.Text[0])
+	}
+
+	// Headers with an optional result or left argument
+	for _, header := range []string{"{r}←Foo x", "r←{x} Foo y", " {r}←{x}Foo y;t", "Foo", "(a b)←Foo"} {
+		e.window.Text[0] = header
+		if !e.window.IsTradfn() {
+			t.Errorf("%q should be a tradfn header", header)
+		}
+	}
+	for _, line := range []string{"{⍺+⍵}", " Foo←{", "Foo←{x}", "Foo ← {⍵}"} {
+		e.window.Text[0] = line
+		if e.window.IsTradfn() {
+			t.Errorf("%q should start a dfn", line)
+		}
+	}
+
+	// Dfns have no header to protect
+	d := newTestEditor("Foo←{", "⍵", "}")
+	d.window.EntityType = 1
+	if d.window.IsTradfn() {
+		t.Fatal("Foo←{ should be a dfn")
+	}
+	d.window.CursorRow, d.window.CursorCol = 1, 0
+	d.HandleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if d.window.Text[0] != "Foo←{⍵" {
+		t.Errorf("dfn join: %q", d.window.Text)
+	}
+}
+
+func TestEditorShiftCurrentLine(t *testing.T) {
+	e := newTestEditor("a", "b", "c", "d")
+	e.window.Debugger = true
+	e.editMode = true
+	e.window.CurrentRow = 2
+
+	// Joining line 1 into line 0 moves the current line up
+	e.window.CursorRow, e.window.CursorCol = 1, 0
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if e.window.CurrentRow != 1 {
+		t.Errorf("after join above: current = %d, want 1", e.window.CurrentRow)
+	}
+
+	// Splitting at the start of the current line moves it down
+	e.window.CursorRow, e.window.CursorCol = 1, 0
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if e.window.CurrentRow != 2 {
+		t.Errorf("after split at start: current = %d, want 2", e.window.CurrentRow)
+	}
+
+	// Edits below don't move it
+	e.window.CursorRow, e.window.CursorCol = 3, 1
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if e.window.CurrentRow != 2 {
+		t.Errorf("after edit below: current = %d, want 2", e.window.CurrentRow)
+	}
+}
+
+func TestEditorWideGlyphs(t *testing.T) {
+	e := newTestEditor("a全b", "x")
+	e.window.CursorCol = 2 // On b, after the two-column glyph
+
+	// Gutter " ▎[1] " is 6 wide, leaving 6 columns of text
+	lines := strings.Split(stripANSI(e.Render(12, 2)), "\n")
+	if lines[0] != " ▎[0] a全b  " || lines[1] != " ▎[1] x     " {
+		t.Errorf("render = %q", lines)
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w != 12 {
+			t.Errorf("line %d is %d columns, want 12", i, w)
+		}
+	}
+	if x, _ := e.CursorPos(); x != 6+3 {
+		t.Errorf("cursor x = %d, want 9 (after the wide glyph)", x)
+	}
+
+	// Truncation counts display columns and doesn't split the glyph
+	if got := e.renderLine([]rune("abcde全"), 6); got != "abcde " {
+		t.Errorf("truncated = %q", got)
+	}
+
+	// A cursor past the visible text keeps a column for itself
+	for _, tt := range []struct {
+		line string
+		col  int
+		want string
+	}{
+		{"abcdef", 6, "abcde "}, // Line exactly as wide as the pane
+		{"abcdefgh", 8, "abcde "},
+		{"abcd全", 5, "abcd  "},
+		{"abcdef", 5, "abcdef"}, // On the last character
+	} {
+		got := stripANSI(e.renderLineWithCursor([]rune(tt.line), tt.col, 6, nil))
+		if got != tt.want {
+			t.Errorf("%q, cursor at %d: rendered %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+
+	// A click on either half of the glyph lands on it
+	e.HandleMouse(6+2, 0, tea.MouseMsg{Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	if e.window.CursorCol != 1 {
+		t.Errorf("click on wide glyph: col = %d, want 1", e.window.CursorCol)
+	}
+}
+
+func TestEditorPausedLine(t *testing.T) {
+	e := newTestEditor("Foo", "a←1", "b←2", "a+b")
+	e.window.Debugger = true
+	e.window.CurrentRow = 1
+	num := func(i int) string { return fmt.Sprintf("[%d]", i) }
+	paused := func(lines []string, i int) bool {
+		return strings.Contains(lines[i], e.pausedLineStyle.Render(num(i)))
+	}
+
+	// The tracer's current line is highlighted with the cursor elsewhere
+	e.window.CursorRow = 3
+	lines := strings.Split(e.Render(20, 4), "\n")
+	if !paused(lines, 1) || paused(lines, 3) {
+		t.Errorf("cursor moved away: paused line not highlighted, or cursor line is")
+	}
+
+	// SetHighlightLine moves both the highlight and the cursor; moving the
+	// cursor on leaves the highlight behind
+	e.SetHighlightLine(2)
+	e.HandleKey(tea.KeyMsg{Type: tea.KeyUp})
+	lines = strings.Split(e.Render(20, 4), "\n")
+	if !paused(lines, 2) || paused(lines, 1) || e.window.CursorRow != 1 {
+		t.Errorf("after SetHighlightLine(2) and Up: cursor row %d, highlights %v %v", e.window.CursorRow, paused(lines, 1), paused(lines, 2))
+	}
+
+	// A plain editor has no paused line
+	e = newTestEditor("a", "b")
+	lines = strings.Split(e.Render(20, 2), "\n")
+	if paused(lines, 0) || paused(lines, 1) {
+		t.Error("plain editor has a highlighted line")
+	}
+}
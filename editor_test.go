@@ -0,0 +1,80 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestEditorWindowLineChanged(t *testing.T) {
+	w := NewEditorWindow(ride.OpenWindow{
+		Token: 1,
+		Text:  []string{"r←Double x", "r←2×x"},
+	})
+
+	if w.LineChanged(0) || w.LineChanged(1) {
+		t.Fatal("freshly opened window should have no changed lines")
+	}
+
+	w.Text[1] = "r←x+x"
+	w.Text = append(w.Text, "⍝ new")
+	if w.LineChanged(0) {
+		t.Error("line 0 unchanged but reported changed")
+	}
+	if !w.LineChanged(1) {
+		t.Error("edited line 1 not reported changed")
+	}
+	if !w.LineChanged(2) {
+		t.Error("added line 2 not reported changed")
+	}
+
+	w.Modified = true
+	w.MarkSaved()
+	if w.Modified {
+		t.Error("MarkSaved should clear Modified")
+	}
+	for i := range w.Text {
+		if w.LineChanged(i) {
+			t.Errorf("line %d still changed after MarkSaved", i)
+		}
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+	orig := []string{"r←Foo x", "a←1", "b←2", "c←3", "r←a+b+c"}
+	tests := []struct {
+		name string
+		text []string
+		want []int // Changed lines
+	}{
+		{"unchanged", orig, nil},
+		{"inserted", []string{"r←Foo x", "a←1", "⍝ new", "b←2", "c←3", "r←a+b+c"}, []int{2}},
+		{"deleted", []string{"r←Foo x", "b←2", "c←3", "r←a+b+c"}, nil},
+		{"edited", []string{"r←Foo x", "a←1", "b←20", "c←3", "r←a+b+c"}, []int{2}},
+		{"inserted and edited", []string{"⍝ top", "r←Foo x", "a←1", "b←2", "c←30", "r←a+b+c"}, []int{0, 4}},
+		{"appended", append(slices.Clone(orig), "⍝ end"), []int{5}},
+		{"moved", []string{"r←Foo x", "b←2", "a←1", "c←3", "r←a+b+c"}, []int{2}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for i, c := range changedLines(orig, tt.text) {
+			if c {
+				got = append(got, i)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: changed %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Worked out again when the text changes in place
+	w := NewEditorWindow(ride.OpenWindow{Text: slices.Clone(orig)})
+	if w.LineChanged(2) {
+		t.Fatal("unchanged line 2 reported changed")
+	}
+	w.Text[2] = "b←20"
+	if !w.LineChanged(2) || w.LineChanged(3) {
+		t.Error("edit in place not picked up")
+	}
+}
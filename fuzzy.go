@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore scores how well query matches name as a case-insensitive
+// subsequence. Returns 0 if query's characters don't all appear in order.
+// Contiguous runs, a match at the start and matches at word starts score
+// higher, so "trans" ranks "transpose" above "match transform".
+func fuzzyScore(query, name string) int {
+	q := []rune(strings.ToLower(query))
+	n := []rune(strings.ToLower(name))
+	if len(q) == 0 {
+		return 1
+	}
+
+	score := 0
+	qi := 0
+	run := 0 // Length of the current contiguous match
+	for ni := 0; ni < len(n) && qi < len(q); ni++ {
+		if n[ni] != q[qi] {
+			run = 0
+			continue
+		}
+		points := 1
+		if ni == 0 {
+			points += 8 // Prefix
+		} else if !unicode.IsLetter(n[ni-1]) && !unicode.IsDigit(n[ni-1]) {
+			points += 4 // Word start
+		}
+		run++
+		points += 2 * (run - 1) // Contiguous
+		score += points
+		qi++
+	}
+	if qi < len(q) {
+		return 0
+	}
+
+	// Prefer shorter names among otherwise equal matches
+	return score*4 + max(4-(len(n)-len(q))/8, 0)
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	if fuzzyScore("trn", "transpose") == 0 {
+		t.Error("trn should match transpose")
+	}
+	if fuzzyScore("xyz", "transpose") != 0 {
+		t.Error("xyz should not match transpose")
+	}
+	if fuzzyScore("rt", "transpose") != 0 {
+		t.Error("out-of-order letters should not match")
+	}
+
+	// Prefix and contiguous matches beat scattered ones
+	if fuzzyScore("tra", "transpose") <= fuzzyScore("tra", "tally reshape all") {
+		t.Error("prefix contiguous match should outrank scattered match")
+	}
+	// Word starts beat mid-word matches
+	if fuzzyScore("sh", "re shape") <= fuzzyScore("sh", "push") {
+		t.Error("word-start match should outrank mid-word match")
+	}
+}
+
+func TestSymbolSearchFuzzy(t *testing.T) {
+	s := NewSymbolSearch()
+	s.query = "trn"
+	s.filter()
+	if len(s.filtered) == 0 || s.filtered[0].Char != '⍉' {
+		t.Errorf("top match for trn = %q, want ⍉", string(s.filtered[0].Char))
+	}
+
+	s.query = "iota"
+	s.selected = 3
+	s.filter()
+	if s.selected != 0 {
+		t.Errorf("selected = %d after filter, want 0", s.selected)
+	}
+	if s.filtered[0].Char != '⍳' {
+		t.Errorf("top match for iota = %q, want ⍳", string(s.filtered[0].Char))
+	}
+}
@@ -0,0 +1,1260 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/cursork/gritt/ride"
+)
+
+// newRideTestModel returns a Model with no client, ready at an empty prompt.
+// It is marked disconnected so anything applyRide tries to send is dropped.
+func newRideTestModel() Model {
+	cfg, _ := LoadConfig()
+	return Model{
+		lines:     []Line{{Text: aplIndent}},
+		cursorCol: len(aplIndent),
+		ready:     true,
+		width:     80,
+		height:    24,
+		panes:     NewPaneManager(80, 24),
+		editors:   make(map[int]*EditorWindow),
+		keys:      cfg.ToKeyMap(),
+		config:    cfg,
+		debugLog:  &LogBuffer{},
+	}
+}
+
+// rideMsg builds a rideEvent for a JSON message
+func rideMsg(cmd string, args map[string]any) rideEvent {
+	return rideEvent{msg: &ride.Message{Command: cmd, Args: args}}
+}
+
+func applyAll(m Model, events ...rideEvent) Model {
+	for _, ev := range events {
+		m, _ = m.applyRide(ev)
+	}
+	return m
+}
+
+func sessionText(m Model) string {
+	var texts []string
+	for _, l := range m.lines {
+		texts = append(texts, l.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestApplyRideSessionOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		sent          []string // Input sent via Execute
+		internalQuery string
+		args          map[string]any
+		want          []string // Lines after the initial input line
+		wantInternal  []string
+	}{
+		{
+			name: "result",
+			args: map[string]any{"result": "1 2 3\n", "type": float64(2)},
+			want: []string{"1 2 3"},
+		},
+		{
+			name: "multi-line result",
+			args: map[string]any{"result": "1 2\n3 4\n", "type": float64(2)},
+			want: []string{"1 2", "3 4"},
+		},
+		{
+			name: "our echo skipped",
+			sent: []string{"      ⍳3\n"},
+			args: map[string]any{"result": "      ⍳3\n", "type": float64(14)},
+		},
+		{
+			name: "external input shown",
+			sent: []string{"      ⍳3\n"},
+			args: map[string]any{"result": "      ⍳4\n", "type": float64(14)},
+			want: []string{"      ⍳4"},
+		},
+		{
+			name: "echo with trailing whitespace skipped",
+			sent: []string{"      ⍳3  \n"},
+			args: map[string]any{"result": "      ⍳3\n", "type": float64(14)},
+		},
+		{
+			name: "echo with different indent skipped",
+			sent: []string{"      ⍳3\n"},
+			args: map[string]any{"result": "⍳3\r\n", "type": float64(14)},
+		},
+		{
+			name: "multi-line echo skipped",
+			sent: []string{"      a←1\n      b←2\n"},
+			args: map[string]any{"result": "      a←1\n      b←2\n", "type": float64(14)},
+		},
+		{
+			name: "echo of one line of several skipped",
+			sent: []string{"      a←1\n      b←2\n"},
+			args: map[string]any{"result": "      a←1\n", "type": float64(14)},
+		},
+		{
+			name: "later echo skipped when earlier input wasn't echoed",
+			sent: []string{"      ⍳3\n", "      ⍳4\n"},
+			args: map[string]any{"result": "      ⍳4\n", "type": float64(14)},
+		},
+		{
+			name: "partial match shown",
+			sent: []string{"      ⍳3\n"},
+			args: map[string]any{"result": "      ⍳3\n      ⍳4\n", "type": float64(14)},
+			want: []string{"      ⍳3", "      ⍳4"},
+		},
+		{
+			name: "external )off skipped",
+			args: map[string]any{"result": "      )off\n", "type": float64(14)},
+		},
+		{
+			name:          "internal query echo skipped",
+			internalQuery: "⎕IO",
+			args:          map[string]any{"result": "⎕IO\n", "type": float64(14)},
+		},
+		{
+			name:          "internal query output captured",
+			internalQuery: "⎕IO",
+			args:          map[string]any{"result": "1\n", "type": float64(2)},
+			wantInternal:  []string{"1\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newRideTestModel()
+			for _, text := range tt.sent {
+				m.expectEcho(text)
+			}
+			m.internalQuery = tt.internalQuery
+			m = applyAll(m, rideMsg("AppendSessionOutput", tt.args))
+
+			got := m.lines[1:]
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines = %q, want %q", sessionText(m), tt.want)
+			}
+			for i, w := range tt.want {
+				if got[i].Text != w {
+					t.Errorf("line %d = %q, want %q", i+1, got[i].Text, w)
+				}
+			}
+			if strings.Join(m.internalOutputs, "|") != strings.Join(tt.wantInternal, "|") {
+				t.Errorf("internal outputs = %q, want %q", m.internalOutputs, tt.wantInternal)
+			}
+		})
+	}
+}
+
+func TestApplyRideEchoQueue(t *testing.T) {
+	m := newRideTestModel()
+	m.expectEcho("      ⍳3\n")
+	m.expectEcho("      ⍳4\n")
+
+	// Both echoes arrive after both executions
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "      ⍳3\n", "type": float64(14)}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "1 2 3\n", "type": float64(2)}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "      ⍳4\n", "type": float64(14)}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "1 2 3 4\n", "type": float64(2)}),
+	)
+	if got := m.lines[1:]; len(got) != 2 || got[0].Text != "1 2 3" || got[1].Text != "1 2 3 4" {
+		t.Fatalf("lines = %q", sessionText(m))
+	}
+	if len(m.sentInput) != 0 {
+		t.Errorf("sentInput = %q after both echoes", m.sentInput)
+	}
+
+	// Each echo is used once: the same input again, from elsewhere, is shown
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": "      ⍳3\n", "type": float64(14)}))
+	if got := m.lines[len(m.lines)-1]; got.Text != "      ⍳3" || !got.Input {
+		t.Errorf("repeated echo: last line = %+v", got)
+	}
+}
+
+func TestExpectEchoBounded(t *testing.T) {
+	m := newRideTestModel()
+	for i := range maxSentInput + 5 {
+		m.expectEcho(fmt.Sprintf("      %d\n", i))
+	}
+	if len(m.sentInput) != maxSentInput {
+		t.Fatalf("len(sentInput) = %d, want %d", len(m.sentInput), maxSentInput)
+	}
+	if m.sentInput[0] != "5" {
+		t.Errorf("oldest kept = %q, want %q", m.sentInput[0], "5")
+	}
+}
+
+func TestApplyRidePromptType(t *testing.T) {
+	m := newRideTestModel()
+
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(0)}))
+	if m.ready || len(m.lines) != 1 {
+		t.Fatalf("busy: ready = %v, lines = %d", m.ready, len(m.lines))
+	}
+
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if !m.ready || len(m.lines) != 2 || m.lines[1].Text != aplIndent {
+		t.Fatalf("ready: ready = %v, lines = %q", m.ready, sessionText(m))
+	}
+	if m.cursorRow != 1 || m.cursorCol != len(aplIndent) {
+		t.Errorf("cursor = %d,%d", m.cursorRow, m.cursorCol)
+	}
+
+	// Completing an internal query calls back and adds no input line
+	var got []string
+	m.ready = false
+	m.internalQuery = "⎕IO"
+	m.internalOutputs = []string{"1\n"}
+	m.internalCallback = func(_ *Model, outputs []string) { got = outputs }
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if len(got) != 1 || got[0] != "1\n" {
+		t.Errorf("callback got %q", got)
+	}
+	if m.internalQuery != "" || len(m.lines) != 2 {
+		t.Errorf("internalQuery = %q, lines = %d", m.internalQuery, len(m.lines))
+	}
+}
+
+func TestRunInSessionKeepsInput(t *testing.T) {
+	m := newRideTestModel()
+	m.lines[0].Text = aplIndent + "1+2"
+	m.cursorCol = len(aplIndent) + 1
+	r, _ := m.runInSession("⍳3")
+	m = r.(Model)
+	if got := m.lines[0].Text; got != aplIndent+"⍳3" {
+		t.Fatalf("ran %q", got)
+	}
+
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "1 2 3\n", "type": 2.0}),
+		rideMsg("SetPromptType", map[string]any{"type": 1.0}),
+	)
+	last := len(m.lines) - 1
+	if got := m.lines[last].Text; got != aplIndent+"1+2" {
+		t.Errorf("input line = %q, want the typed input back", got)
+	}
+	if m.cursorRow != last || m.cursorCol != len(aplIndent)+1 {
+		t.Errorf("cursor = %d,%d, want %d,%d", m.cursorRow, m.cursorCol, last, len(aplIndent)+1)
+	}
+
+	// Only the once
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": 1.0}))
+	if got := m.lines[len(m.lines)-1].Text; got != aplIndent {
+		t.Errorf("next input line = %q, want it empty", got)
+	}
+
+	// Busy: the last line is output, left alone, and nothing runs
+	m.ready = false
+	m.lines = append(m.lines, Line{Text: "partial output"})
+	r, _ = m.runInSession("⎕←⎕WA")
+	m = r.(Model)
+	if got := m.lines[len(m.lines)-1].Text; got != "partial output" || m.heldInput != "" {
+		t.Errorf("busy: last line = %q, held %q", got, m.heldInput)
+	}
+	if !strings.Contains(m.statusMsg, "busy") {
+		t.Errorf("busy: status = %q", m.statusMsg)
+	}
+}
+
+func TestApplyRideWindows(t *testing.T) {
+	m := newRideTestModel()
+
+	m = applyAll(m, rideMsg("OpenWindow", map[string]any{
+		"token": float64(1), "name": "Foo", "text": []any{"r←Foo", "r←1"}, "debugger": float64(0),
+	}))
+	if m.editors[1] == nil || m.panes.Get("editor:1") == nil {
+		t.Fatal("editor window not opened")
+	}
+	if fp := m.panes.FocusedPane(); fp == nil || fp.ID != "editor:1" {
+		t.Error("editor pane not focused")
+	}
+
+	m = applyAll(m, rideMsg("UpdateWindow", map[string]any{
+		"token": float64(1), "name": "Foo", "text": []any{"r←Foo", "r←2"},
+	}))
+	if got := m.editors[1].Text[1]; got != "r←2" {
+		t.Errorf("after update: line 1 = %q", got)
+	}
+
+	m = applyAll(m, rideMsg("CloseWindow", map[string]any{"win": float64(1)}))
+	if m.editors[1] != nil || m.panes.Get("editor:1") != nil {
+		t.Error("editor window not closed")
+	}
+}
+
+func TestApplyRideTracerStack(t *testing.T) {
+	tracer := func(token int, name string) rideEvent {
+		return rideMsg("OpenWindow", map[string]any{
+			"token": float64(token), "name": name, "text": []any{name, "x"}, "debugger": float64(1),
+		})
+	}
+
+	m := applyAll(newRideTestModel(), tracer(1, "Outer"), tracer(2, "Inner"))
+	if len(m.tracerStack) != 2 || m.tracerCurrent != 2 || m.panes.Get("tracer") == nil {
+		t.Fatalf("stack = %v, current = %d", m.tracerStack, m.tracerCurrent)
+	}
+
+	m = applyAll(m, rideMsg("SetHighlightLine", map[string]any{"win": float64(2), "line": float64(1)}))
+	if m.editors[2].CurrentRow != 1 {
+		t.Errorf("highlight = %d, want 1", m.editors[2].CurrentRow)
+	}
+
+	// Closing the top frame shows the one below
+	m = applyAll(m, rideMsg("CloseWindow", map[string]any{"win": float64(2)}))
+	if len(m.tracerStack) != 1 || m.tracerCurrent != 1 || m.panes.Get("tracer") == nil {
+		t.Errorf("after close: stack = %v, current = %d", m.tracerStack, m.tracerCurrent)
+	}
+
+	// Closing the last frame removes the tracer pane
+	m = applyAll(m, rideMsg("CloseWindow", map[string]any{"win": float64(1)}))
+	if len(m.tracerStack) != 0 || m.tracerCurrent != 0 || m.panes.Get("tracer") != nil {
+		t.Errorf("after last close: stack = %v, current = %d", m.tracerStack, m.tracerCurrent)
+	}
+}
+
+func TestApplyRideDisconnect(t *testing.T) {
+	m, cmd := newRideTestModel().applyRide(rideEvent{err: errTest})
+	if m.connected || m.ready || !strings.Contains(sessionText(m), "Disconnected") || cmd != nil {
+		t.Errorf("disconnect: connected = %v, ready = %v, cmd = %v", m.connected, m.ready, cmd)
+	}
+	if strings.Contains(sessionText(m), "corrupt") {
+		t.Error("a reset connection reported as corrupt")
+	}
+	m, _ = newRideTestModel().applyRide(rideEvent{err: fmt.Errorf("recv: %w", &ride.FrameError{Length: 3, Reason: "short"})})
+	if !strings.Contains(sessionText(m), "Disconnected (corrupt RIDE data)") {
+		t.Errorf("bad frame session = %q", sessionText(m))
+	}
+
+	m = newRideTestModel()
+	m.pendingQuit = true
+	if _, cmd := m.applyRide(rideEvent{err: errTest}); cmd == nil {
+		t.Error(")off disconnect should quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error(")off disconnect should return tea.Quit")
+	}
+}
+
+func TestHandleRideDrainsQueue(t *testing.T) {
+	m := newRideTestModel()
+	ch := make(chan rideEvent, rideQueue)
+	m.msgs = ch
+	out := func(s string) rideEvent {
+		return rideMsg("AppendSessionOutput", map[string]any{"result": s + "\n", "type": float64(1)})
+	}
+
+	// Everything queued is applied in one update
+	ch <- out("b")
+	ch <- out("c")
+	next, cmd := m.handleRide(out("a"))
+	m = next.(Model)
+	if got := sessionText(m); !strings.HasSuffix(got, "a\nb\nc") || len(ch) != 0 || cmd == nil {
+		t.Errorf("session %q, %d left queued", got, len(ch))
+	}
+
+	// Draining stops at a disconnect, which ends the receive loop
+	ch <- rideEvent{err: errTest}
+	ch <- out("lost")
+	next, _ = m.handleRide(out("d"))
+	m = next.(Model)
+	if got := sessionText(m); !strings.Contains(got, "d\n⍝ Disconnected") || len(ch) != 1 {
+		t.Errorf("session %q, %d left queued", got, len(ch))
+	}
+}
+
+// A 10k-line result in one message, and the same result a line at a time as
+// it might arrive from a loop printing with ⎕←: updates/op counts the
+// Model updates, each followed by a redraw
+func BenchmarkAppendOutput10k(b *testing.B) {
+	lines := make([]string, 10000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+	whole := rideMsg("AppendSessionOutput", map[string]any{"result": strings.Join(lines, "\n") + "\n", "type": float64(1)})
+	for b.Loop() {
+		m := newRideTestModel()
+		m.handleRide(whole)
+	}
+}
+
+func BenchmarkAppendOutput10kMessages(b *testing.B) {
+	events := make([]rideEvent, 10000)
+	for i := range events {
+		events[i] = rideMsg("AppendSessionOutput", map[string]any{"result": strconv.Itoa(i) + "\n", "type": float64(1)})
+	}
+	updates := 0
+	for b.Loop() {
+		m := newRideTestModel()
+		ch := make(chan rideEvent, len(events))
+		m.msgs = ch
+		for _, ev := range events {
+			ch <- ev
+		}
+		for len(ch) > 0 {
+			next, _ := m.handleRide(<-ch)
+			m = next.(Model)
+			updates++
+		}
+	}
+	b.ReportMetric(float64(updates)/float64(b.N), "updates/op")
+}
+
+var errTest = errors.New("connection reset")
+
+func TestTracerApplyEdit(t *testing.T) {
+	m := applyAll(newRideTestModel(), rideMsg("OpenWindow", map[string]any{
+		"token": float64(3), "name": "Foo", "entityType": float64(1), "debugger": float64(1),
+		"currentRow": float64(1), "text": []any{"r←Foo", "r←÷0"},
+	}))
+	ep := m.panes.Get("tracer").Content.(*EditorPane)
+	w := m.editors[3]
+
+	// Edit mode: add a line above the suspended line
+	ep.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	w.CursorRow, w.CursorCol = 0, len([]rune("r←Foo"))
+	ep.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	typeRunes(ep, "⎕←'fixing'")
+	if w.CurrentRow != 2 {
+		t.Fatalf("current line = %d after inserting above it, want 2", w.CurrentRow)
+	}
+
+	// Ctrl+S waits for the interpreter before leaving edit mode
+	ep.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !w.PendingApply || !ep.editMode {
+		t.Fatalf("pendingApply = %v, editMode = %v", w.PendingApply, ep.editMode)
+	}
+
+	m = applyAll(m, rideMsg("ReplySaveChanges", map[string]any{"win": float64(3), "err": float64(0)}))
+	if w.PendingApply || ep.editMode || w.Modified {
+		t.Errorf("after save: pendingApply = %v, editMode = %v, modified = %v", w.PendingApply, ep.editMode, w.Modified)
+	}
+	if w.CursorRow != 2 {
+		t.Errorf("tracer cursor on line %d, want the shifted current line 2", w.CursorRow)
+	}
+
+	// A rejected fix stays in edit mode
+	ep.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	typeRunes(ep, "x")
+	ep.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = applyAll(m, rideMsg("ReplySaveChanges", map[string]any{"win": float64(3), "err": float64(1)}))
+	if !ep.editMode || w.PendingApply || m.statusMsg == "" {
+		t.Errorf("after failure: editMode = %v, pendingApply = %v, status = %q", ep.editMode, w.PendingApply, m.statusMsg)
+	}
+}
+
+func TestTracerCutback(t *testing.T) {
+	tracer := func(token int, name string) rideEvent {
+		return rideMsg("OpenWindow", map[string]any{
+			"token": float64(token), "name": name, "text": []any{name, "x"}, "debugger": float64(1),
+		})
+	}
+	m := applyAll(newRideTestModel(), tracer(1, "Outer"), tracer(2, "Inner"))
+
+	next, _ := m.dispatchCommand("cutback", "")
+	m = next.(Model)
+	if len(m.tracerStack) != 1 || m.tracerStack[0] != 1 || m.tracerCurrent != 1 {
+		t.Fatalf("after cutback: stack = %v, current = %d", m.tracerStack, m.tracerCurrent)
+	}
+	if ep := m.panes.Get("tracer").Content.(*EditorPane); ep.window.Token != 1 {
+		t.Errorf("tracer shows token %d, want caller 1", ep.window.Token)
+	}
+
+	// The interpreter's CloseWindow for the abandoned frame is harmless
+	m = applyAll(m, rideMsg("CloseWindow", map[string]any{"win": float64(2)}))
+	if len(m.tracerStack) != 1 || m.tracerCurrent != 1 {
+		t.Errorf("after CloseWindow: stack = %v, current = %d", m.tracerStack, m.tracerCurrent)
+	}
+}
+
+func TestTracerHelp(t *testing.T) {
+	cfg, _ := LoadConfig()
+	help := tracerHelp(cfg.TracerKeys)
+	for _, want := range []string{"r resume all", "u cutback", "esc close"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("tracer help %q missing %q", help, want)
+		}
+	}
+}
+
+func TestBreakpointsTracked(t *testing.T) {
+	m := newRideTestModel()
+	m.breakpoints = &Breakpoints{}
+	m = applyAll(m,
+		rideMsg("OpenWindow", map[string]any{
+			"token": float64(1), "name": "Foo", "text": []any{"Foo", "a", "b"}, "stop": []any{float64(2)},
+		}),
+		rideMsg("UpdateWindow", map[string]any{
+			"token": float64(1), "name": "Foo", "text": []any{"Foo", "a", "b"}, "stop": []any{float64(1), float64(2)},
+		}),
+	)
+	if got := m.breakpoints.List(); len(got) != 1 || len(got[0].Lines) != 2 {
+		t.Fatalf("breakpoints after UpdateWindow = %v", got)
+	}
+
+	m.toggleBreakpointsPane()
+	next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if fp := m.panes.FocusedPane(); fp == nil || fp.ID != "editor:1" {
+		t.Errorf("Enter should focus Foo's editor, focused = %v", fp)
+	}
+	if w := m.editors[1]; w.CursorRow != 1 {
+		t.Errorf("cursor row = %d, want first breakpoint 1", w.CursorRow)
+	}
+
+	m.panes.Focus("breakpoints")
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = next.(Model)
+	if len(m.breakpoints.List()) != 0 || len(m.editors[1].Stop) != 0 {
+		t.Errorf("after clear: registry = %v, editor stops = %v", m.breakpoints.List(), m.editors[1].Stop)
+	}
+}
+
+func TestBreakCommand(t *testing.T) {
+	m := newRideTestModel()
+	m.breakpoints = &Breakpoints{}
+	m = applyAll(m, rideMsg("OpenWindow", map[string]any{
+		"token": float64(1), "name": "Foo", "text": []any{"Foo", "a", "b"},
+	}))
+
+	next, _ := m.dispatchCommand("break", "Foo 2")
+	m = next.(Model)
+	if !strings.Contains(m.internalQuery, "⎕STOP'Foo'") {
+		t.Fatalf("internal query = %q", m.internalQuery)
+	}
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "2\n", "type": float64(2)}),
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	if got := m.editors[1].Stop; !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("open editor stops = %v, want [2]", got)
+	}
+	if got := m.breakpoints.List(); len(got) != 1 || got[0].Name != "Foo" {
+		t.Errorf("registry = %v", got)
+	}
+
+	// The interpreter's UpdateWindow for the editor is authoritative
+	m = applyAll(m, rideMsg("UpdateWindow", map[string]any{
+		"token": float64(1), "name": "Foo", "text": []any{"Foo", "a", "b"}, "stop": []any{float64(1), float64(2)},
+	}))
+	if got := m.editors[1].Stop; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("stops after UpdateWindow = %v", got)
+	}
+
+	next, _ = m.dispatchCommand("break", "Foo")
+	if m = next.(Model); m.statusMsg == "" {
+		t.Error("break without a line should show usage")
+	}
+
+	// Another internal query's reply is still to come: it isn't replaced
+	m.internalQuery = "⎕IO"
+	next, _ = m.dispatchCommand("break", "Foo 3")
+	if m = next.(Model); m.internalQuery != "⎕IO" || !strings.Contains(m.statusMsg, "try again") {
+		t.Errorf("busy: query %q, status %q", m.internalQuery, m.statusMsg)
+	}
+	(&m).clearBreakpoints([]string{"Foo"})
+	if m.internalQuery != "⎕IO" || len(m.editors[1].Stop) == 0 {
+		t.Errorf("busy clear: query %q, stops %v", m.internalQuery, m.editors[1].Stop)
+	}
+}
+
+func TestVariablesInlineEdit(t *testing.T) {
+	m := newRideTestModel()
+	m.toggleVariablesPane()
+	vp := m.panes.Get("variables").Content.(*VariablesPane)
+	vp.SetVars([]LocalVar{{Name: "x", Value: "1 2 3"}, {Name: "big", Value: "1 2 3..."}})
+
+	key := func(msg tea.KeyMsg) {
+		next, _ := m.handleKey(msg)
+		m = next.(Model)
+	}
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if !vp.Editing() {
+		t.Fatal("Enter on a short value should edit it inline")
+	}
+	typeKeys := func(s string) {
+		for _, r := range s {
+			key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	typeKeys("1 +")
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.HasPrefix(m.internalQuery, "x←1 +⋄") {
+		t.Fatalf("internal query = %q", m.internalQuery)
+	}
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "SYNTAX ERROR: Missing right argument\n", "type": float64(5)}),
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	if !vp.Editing() || vp.editErr != "SYNTAX ERROR: Missing right argument" {
+		t.Fatalf("after error: editing = %v, err = %q", vp.Editing(), vp.editErr)
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyBackspace})
+	key(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKeys("⍳2")
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "x=1 2\n", "type": float64(2)}),
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	if vp.Editing() || vp.vars[0].Value != "1 2" {
+		t.Errorf("after assignment: editing = %v, x = %q", vp.Editing(), vp.vars[0].Value)
+	}
+
+	// Another internal query's reply is still to come: the edit stays open
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys("4")
+	m.internalQuery = "⎕IO"
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if !vp.Editing() || m.internalQuery != "⎕IO" || !strings.Contains(vp.editErr, "try again") {
+		t.Errorf("busy: editing = %v, query = %q, err = %q", vp.Editing(), m.internalQuery, vp.editErr)
+	}
+	m.internalQuery = ""
+
+	// Esc cancels an edit without closing the pane; truncated values don't edit inline
+	key(tea.KeyMsg{Type: tea.KeyEscape})
+	if vp.Editing() || m.panes.Get("variables") == nil {
+		t.Errorf("Esc: editing = %v, pane open = %v", vp.Editing(), m.panes.Get("variables") != nil)
+	}
+	key(tea.KeyMsg{Type: tea.KeyDown})
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if vp.Editing() {
+		t.Error("truncated value should open in the editor, not inline")
+	}
+}
+
+func TestVariablesWaitForInternalQuery(t *testing.T) {
+	m := newRideTestModel()
+	m.client = &ride.Client{}
+	m.internalQuery = "⎕IO"
+	m.toggleVariablesPane()
+	vp := m.panes.Get("variables").Content.(*VariablesPane)
+	if m.internalQuery != "⎕IO" || !vp.loading {
+		t.Fatalf("opening the pane: query = %q, loading = %v", m.internalQuery, vp.loading)
+	}
+
+	// Fetched once the waiting query's reply is in
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if !strings.Contains(m.internalQuery, "⎕NL 2") {
+		t.Errorf("variables not fetched after the query: %q", m.internalQuery)
+	}
+}
+
+func TestVariablesFilter(t *testing.T) {
+	m := newRideTestModel()
+	m.toggleVariablesPane()
+	vp := m.panes.Get("variables").Content.(*VariablesPane)
+	vp.SetMode(VarsModeAll)
+	vp.SetVars([]LocalVar{
+		{Name: "count", Value: "3", IsLocal: true},
+		{Name: "data", Value: "1 2 3"},
+		{Name: "maxCount", Value: "10"},
+	})
+
+	key := func(msg tea.KeyMsg) {
+		next, _ := m.handleKey(msg)
+		m = next.(Model)
+	}
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	for _, r := range "cou" {
+		key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := vp.shown(); len(got) != 2 || got[0].Name != "count" || got[1].Name != "maxCount" {
+		t.Fatalf("filter cou shows %v", got)
+	}
+	out := vp.Render(40, 6)
+	if !strings.Contains(out, "/cou") || !strings.Contains(out, "• count") || strings.Contains(out, "data") {
+		t.Errorf("filtered render:\n%s", out)
+	}
+
+	// Enter keeps the filter; selection moves within the matches
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	key(tea.KeyMsg{Type: tea.KeyDown})
+	key(tea.KeyMsg{Type: tea.KeyDown})
+	if vp.Editing() || vp.selected != 1 {
+		t.Errorf("after Enter/Down: editing = %v, selected = %d", vp.Editing(), vp.selected)
+	}
+
+	// Esc clears the filter, a second Esc closes the pane
+	key(tea.KeyMsg{Type: tea.KeyEscape})
+	if vp.Filtered() || m.panes.Get("variables") == nil {
+		t.Fatalf("first Esc: filtered = %v, pane open = %v", vp.Filtered(), m.panes.Get("variables") != nil)
+	}
+	key(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.panes.Get("variables") != nil {
+		t.Error("second Esc should close the pane")
+	}
+}
+
+func TestJumpToDefinition(t *testing.T) {
+	tests := []struct {
+		nc     string
+		status string
+		opens  bool
+	}{
+		{"3.1", "", true},
+		{"4.2", "", true},
+		{"2.1", "Foo is a variable", false},
+		{"0", "Foo is not defined", false},
+		{"9.1", "Foo is not a function", false},
+	}
+	for _, tt := range tests {
+		m := newRideTestModel()
+		m.lines = []Line{{Text: aplIndent + "Foo 3"}}
+		m.cursorCol = len(aplIndent) + 2
+		(&m).jumpToDefinition()
+		if m.internalQuery != "⎕NC⊂'Foo'" {
+			t.Fatalf("internal query = %q", m.internalQuery)
+		}
+		m.ready = false
+		m = applyAll(m,
+			rideMsg("AppendSessionOutput", map[string]any{"result": tt.nc + "\n", "type": float64(2)}),
+			rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+		)
+		opened := strings.Contains(strings.Join(m.debugLog.Lines, "\n"), "→ Execute )ed Foo")
+		if m.statusMsg != tt.status || opened != tt.opens {
+			t.Errorf("⎕NC %s: status = %q, opened = %v", tt.nc, m.statusMsg, opened)
+		}
+	}
+
+	m := newRideTestModel()
+	(&m).jumpToDefinition()
+	if m.statusMsg != "No name at cursor" || m.internalQuery != "" {
+		t.Errorf("empty line: status = %q, query = %q", m.statusMsg, m.internalQuery)
+	}
+
+	// Another internal query's reply is still to come: it isn't replaced
+	m.lines = []Line{{Text: aplIndent + "Foo 3"}}
+	m.cursorCol = len(aplIndent) + 2
+	m.internalQuery = "⎕IO"
+	(&m).jumpToDefinition()
+	if m.internalQuery != "⎕IO" || !strings.Contains(m.statusMsg, "try again") {
+		t.Errorf("busy: status = %q, query = %q", m.statusMsg, m.internalQuery)
+	}
+}
+
+func TestOpenStackFrame(t *testing.T) {
+	m := newRideTestModel()
+	(&m).openStackFrame(StackFrame{Name: "Foo", Line: 2})
+	if m.internalQuery != ")ed Foo" {
+		t.Fatalf("internal query = %q, want )ed Foo", m.internalQuery)
+	}
+	m.ready = false
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if m.internalQuery != "" || len(m.lines) != 1 {
+		t.Errorf("after the prompt: query = %q, session = %q", m.internalQuery, sessionText(m))
+	}
+
+	// Another internal query's reply is still to come: it isn't replaced
+	m.internalQuery = "⎕IO"
+	(&m).openStackFrame(StackFrame{Name: "Foo", Line: 2})
+	if m.internalQuery != "⎕IO" || !strings.Contains(m.statusMsg, "try again") {
+		t.Errorf("busy: status = %q, query = %q", m.statusMsg, m.internalQuery)
+	}
+}
+
+func TestAutocompleteClasses(t *testing.T) {
+	m := newRideTestModel()
+	m.acPending, m.acPos = true, m.cursorCol
+	m = applyAll(m, rideMsg("ReplyGetAutocomplete", map[string]any{
+		"token": float64(0), "skip": float64(0),
+		"options": []any{"Foo", "⎕IO", "bar", ":If", "ns", "Op"},
+	}))
+	if m.acPopup == nil {
+		t.Fatal("no popup")
+	}
+	if want := "⎕NC,(⊂,'Foo'),(⊂,'bar'),(⊂,'ns'),(⊂,'Op')"; m.internalQuery != want {
+		t.Fatalf("internal query = %q, want %q", m.internalQuery, want)
+	}
+
+	m.ready = false
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "3.2 2.1 9.1\n", "type": float64(2)}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "4.2\n", "type": float64(2)}), // Wrapped at ⎕PW
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	want := []NameClass{ClassFunction, ClassSystem, ClassVariable, ClassKeyword, ClassNamespace, ClassOperator}
+	for i, opt := range m.acPopup.Options {
+		if opt.Class != want[i] {
+			t.Errorf("%s: class %d, want %d", opt.Text, opt.Class, want[i])
+		}
+	}
+
+	// Another query waiting isn't displaced
+	m = newRideTestModel()
+	m.internalQuery = "⎕IO"
+	m.acPending, m.acPos = true, m.cursorCol
+	m = applyAll(m, rideMsg("ReplyGetAutocomplete", map[string]any{
+		"token": float64(0), "options": []any{"Foo", "bar"},
+	}))
+	if m.acPopup == nil || m.internalQuery != "⎕IO" {
+		t.Errorf("popup = %v, internal query = %q", m.acPopup, m.internalQuery)
+	}
+}
+
+func TestDescribeCompletion(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "docs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE help_urls (symbol TEXT, path TEXT)",
+		"INSERT INTO help_urls VALUES ('⎕NGET', 'Language Reference / System Functions / Native File Get')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newRideTestModel()
+	m.docs = openedDocs(db)
+	m.acPending, m.acPos = true, m.cursorCol
+	m = applyAll(m, rideMsg("ReplyGetAutocomplete", map[string]any{
+		"token": float64(0), "options": []any{"⎕NGET", "⎕NPUT", "Foo"},
+	}))
+	if note, _ := m.acPopup.Note(); note != "Native File Get" {
+		t.Errorf("⎕NGET note = %q", note)
+	}
+
+	// No help link: looked up, nothing to show
+	m.acPopup.CycleNext()
+	m.describeCompletion()
+	if note, ok := m.acPopup.Note(); note != "" || !ok {
+		t.Errorf("⎕NPUT note = %q, %v", note, ok)
+	}
+
+	// A function's first comment comes from the interpreter, once it's
+	// known to be a function
+	m.acPopup.CycleNext()
+	m.ready = false
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "3.1\n", "type": float64(2)}),
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	if want := firstCommentExpr("Foo"); m.internalQuery != want {
+		t.Fatalf("internal query = %q, want %q", m.internalQuery, want)
+	}
+	m.ready = false
+	m = applyAll(m,
+		rideMsg("AppendSessionOutput", map[string]any{"result": "⍝ Adds one\n", "type": float64(2)}),
+		rideMsg("SetPromptType", map[string]any{"type": float64(1)}),
+	)
+	if note, _ := m.acPopup.Note(); note != "⍝ Adds one" {
+		t.Errorf("Foo note = %q", note)
+	}
+}
+
+func TestSessionClick(t *testing.T) {
+	m := newRideTestModel()
+	m.lines = nil
+	for i := range 40 {
+		m.lines = append(m.lines, Line{Text: fmt.Sprintf("line %d", i)})
+	}
+	m.cursorRow, m.cursorCol = 39, 0
+	h := m.sessionHeight()
+
+	click := func(x, y int) {
+		t.Helper()
+		next, _ := m.handleMouse(tea.MouseMsg{X: x, Y: y, Type: tea.MouseLeft})
+		m = next.(Model)
+	}
+
+	// Top line of the viewport; the view mustn't jump
+	start := 40 - h
+	click(3, 1)
+	if m.cursorRow != start || m.cursorCol != 2 || m.sessionStart(h) != start {
+		t.Errorf("click top: cursor = %d,%d, start = %d", m.cursorRow, m.cursorCol, m.sessionStart(h))
+	}
+
+	// Past the end of the line clamps to its length
+	click(60, 2)
+	if m.cursorRow != start+1 || m.cursorCol != len("line 20") {
+		t.Errorf("click past end: cursor = %d,%d", m.cursorRow, m.cursorCol)
+	}
+
+	// Border clicks don't move the cursor
+	click(5, 0)
+	if m.cursorRow != start+1 {
+		t.Errorf("click border: cursor row = %d", m.cursorRow)
+	}
+
+	// Moving the cursor within the view keeps it still
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = next.(Model)
+	if m.sessionStart(h) != start {
+		t.Errorf("after down: start = %d, want %d", m.sessionStart(h), start)
+	}
+
+	// Clicks below the last line are ignored
+	m.lines = m.lines[:start+3]
+	click(5, 10)
+	if m.cursorRow != start+2 {
+		t.Errorf("click below text: cursor row = %d", m.cursorRow)
+	}
+}
+
+func TestSessionMultiClick(t *testing.T) {
+	m := newRideTestModel()
+	m.config.CopyOnSelect = true
+	m.lines = []Line{{Text: aplIndent + "r←ns.Foo ¯3.5E2"}, {Text: "DOMAIN ERROR"}}
+
+	click := func(x, y int) tea.Cmd {
+		t.Helper()
+		next, cmd := m.handleMouse(tea.MouseMsg{X: x, Y: y, Type: tea.MouseLeft})
+		m = next.(Model)
+		return cmd
+	}
+	selected := func() string {
+		if m.selection == nil {
+			return ""
+		}
+		return string([]rune(m.lines[m.selection.row].Text)[m.selection.start:m.selection.end])
+	}
+
+	// Double click on the name and on the number
+	x := 1 + len(aplIndent) + 4 // The "F" of Foo
+	click(x, 1)
+	if cmd := click(x, 1); selected() != "ns.Foo" || cmd == nil || m.statusMsg != "Copied ns.Foo to clipboard" {
+		t.Errorf("double click name: selected %q, status %q", selected(), m.statusMsg)
+	}
+	click(x+8, 1)
+	click(x+8, 1)
+	if selected() != "¯3.5E2" {
+		t.Errorf("double click number: selected %q", selected())
+	}
+
+	// Triple click selects the line without its indent; a fourth starts over
+	click(x+8, 1)
+	if selected() != "r←ns.Foo ¯3.5E2" {
+		t.Errorf("triple click: selected %q", selected())
+	}
+	click(x+8, 1)
+	if m.selection != nil {
+		t.Errorf("fourth click: selected %q", selected())
+	}
+
+	// Clicks elsewhere don't count towards a double click
+	click(3, 2)
+	click(x, 1)
+	if m.selection != nil {
+		t.Errorf("click at new position: selected %q", selected())
+	}
+
+	// Whitespace selects nothing; a key press clears the selection
+	click(1, 1)
+	if cmd := click(1, 1); m.selection != nil || cmd != nil {
+		t.Errorf("double click on indent: selected %q", selected())
+	}
+	click(2, 2)
+	click(2, 2)
+	if selected() != "DOMAIN" {
+		t.Errorf("double click output: selected %q", selected())
+	}
+	if got := stripANSI(m.renderSession(40, 2)); !strings.Contains(got, "DOMAIN ERROR") {
+		t.Errorf("selection changed the text: %q", got)
+	}
+	next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRight})
+	if next.(Model).selection != nil {
+		t.Error("key press kept the selection")
+	}
+
+	// Without copy_on_select nothing is copied
+	m.config.CopyOnSelect = false
+	click(4, 2)
+	if cmd := click(4, 2); cmd != nil || selected() != "DOMAIN" {
+		t.Errorf("copy_on_select off: selected %q, cmd %v", selected(), cmd != nil)
+	}
+}
+
+func TestRenderSessionWideGlyphs(t *testing.T) {
+	m := newRideTestModel()
+	m.lines = []Line{{Text: aplIndent + "日本←⍳3"}, {Text: "e\u0301x"}}
+	m.cursorRow, m.cursorCol = 0, 8 // On ←, after two double-width glyphs
+
+	lines := strings.Split(m.renderSession(20, 2), "\n")
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w != 20 {
+			t.Errorf("line %d is %d cells wide, want 20: %q", i, w, stripANSI(line))
+		}
+	}
+	if want := aplIndent + "日本" + cursorStyle.Render("←") + "⍳3"; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("cursor line = %q, want prefix %q", lines[0], want)
+	}
+
+	// The cursor covers a character and its combining mark together
+	m.cursorRow, m.cursorCol = 1, 0
+	lines = strings.Split(m.renderSession(20, 2), "\n")
+	if want := cursorStyle.Render("e\u0301") + "x"; !strings.HasPrefix(lines[1], want) {
+		t.Errorf("combining line = %q, want prefix %q", lines[1], want)
+	}
+
+	// Both cells of a wide glyph map to it; truncation doesn't split one
+	runes := []rune(aplIndent + "日本←⍳3")
+	for x, want := range map[int]int{6: 6, 7: 6, 8: 7, 10: 8, 12: 10, 13: 11} {
+		if got := runeAtColumn(runes, x); got != want {
+			t.Errorf("runeAtColumn(%d) = %d, want %d", x, got, want)
+		}
+	}
+	if a, b, pad := visibleRunes(runes, 0, 9); a != 0 || b != 7 || pad != 0 {
+		t.Errorf("visibleRunes(0, 9) = %d, %d, %d, want 0, 7, 0", a, b, pad)
+	}
+	if a, b, pad := visibleRunes(runes, 7, 20); a != 7 || b != 11 || pad != 1 {
+		t.Errorf("visibleRunes(7, 20) = %d, %d, %d, want 7, 11, 1 (half of 日 blank)", a, b, pad)
+	}
+}
+
+func TestSessionHorizontalScroll(t *testing.T) {
+	m := newRideTestModel()
+	m.width = 22 // 20 columns inside the border
+	long := aplIndent + "abcdefghijklmnopqrstuvwxyz"
+	m.lines = []Line{{Text: "0123456789012345678901234"}, {Text: long}}
+	m.cursorRow, m.cursorCol = 1, 0
+
+	view := func() []string {
+		return strings.Split(stripANSI(m.renderSession(20, 2)), "\n")
+	}
+	if got := view(); got[0] != "0123456789012345678›" || got[1] != "      abcdefghijklm›" {
+		t.Errorf("unscrolled = %q", got)
+	}
+
+	// Following the cursor to the end of the line scrolls right
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = next.(Model)
+	if m.colOffset != 14 {
+		t.Errorf("colOffset at end = %d, want 14", m.colOffset)
+	}
+	if got := view(); got[0] != "‹5678901234         " || got[1] != "‹jklmnopqrstuvwxyz  " {
+		t.Errorf("scrolled = %q", got)
+	}
+
+	// Clicks land on the scrolled text
+	m.clickSession(2, 2) // Second column inside the border
+	if m.cursorCol != 15 {
+		t.Errorf("click after scroll: cursorCol = %d, want 15 (j)", m.cursorCol)
+	}
+
+	// Back to the start scrolls home
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyHome})
+	m = next.(Model)
+	if m.colOffset != 0 {
+		t.Errorf("colOffset at start = %d, want 0", m.colOffset)
+	}
+
+	// A short prompt after a long line is shown from its first column
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = next.(Model)
+	m.ready = false
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if m.colOffset != 0 {
+		t.Errorf("colOffset at the new prompt = %d, want 0", m.colOffset)
+	}
+	if got := view(); got[1] != aplIndent+"              " {
+		t.Errorf("new prompt = %q", got)
+	}
+
+	// Typing there keeps it unscrolled, whatever offset was left over
+	m.colOffset = 164
+	next, _ = m.Update(runeKey('x'))
+	m = next.(Model)
+	if m.colOffset != 0 {
+		t.Errorf("colOffset after typing = %d, want 0", m.colOffset)
+	}
+}
+
+func TestWrapRunes(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"", []int{0}},
+		{"abcd", []int{0}},
+		{"abcdefghij", []int{0, 4, 8}},
+		{"ab⍳⍴cd", []int{0, 4}},
+		{"abc全d", []int{0, 3}},        // The wide glyph isn't split
+		{"abcde\u0301f", []int{0, 4}}, // The accent stays with e
+	}
+	for _, tt := range tests {
+		if got := wrapRunes([]rune(tt.text), 4); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrapRunes(%q, 4) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSessionWrap(t *testing.T) {
+	m := newRideTestModel()
+	m.width = 22 // 20 columns inside the border, 19 per wrapped row
+	m.wrap = true
+	long := aplIndent + "abcdefghijklmnopqrstuvwxyz"
+	m.lines = []Line{{Text: "0123456789012345678901234"}, {Text: long}}
+	m.cursorRow, m.cursorCol = 1, 0
+
+	view := func() []string {
+		return strings.Split(stripANSI(m.renderSession(20, 3)), "\n")
+	}
+	want := []string{"0123456789012345678 ", "901234              ", "      abcdefghijklm "}
+	if got := view(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrapped = %q, want %q", got, want)
+	}
+
+	// The cursor's row at the end of the input line stays in view
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = next.(Model)
+	if m.colOffset != 0 {
+		t.Errorf("colOffset = %d while wrapping", m.colOffset)
+	}
+	want = []string{"      abcdefghijklm ", "nopqrstuvwxyz       ", "                    "}
+	if got := view(); !reflect.DeepEqual(got, want) {
+		t.Errorf("at end = %q, want %q", got, want)
+	}
+
+	// Clicks on a continuation row land in the right place (the full-height
+	// session shows both lines from the top)
+	m.clickSession(2, 4)
+	if m.cursorRow != 1 || m.cursorCol != 20 {
+		t.Errorf("click on continuation: cursor = %d,%d, want 1,20 (o)", m.cursorRow, m.cursorCol)
+	}
+
+	m.toggleWrap()
+	if m.wrap {
+		t.Error("toggleWrap didn't turn wrapping off")
+	}
+}
+
+func TestLeaderChords(t *testing.T) {
+	m := newRideTestModel()
+	press := func(keys ...tea.KeyMsg) tea.Cmd {
+		t.Helper()
+		var cmd tea.Cmd
+		for _, k := range keys {
+			var next tea.Model
+			next, cmd = m.Update(k)
+			m = next.(Model)
+		}
+		return cmd
+	}
+	leader := tea.KeyMsg{Type: tea.KeyCtrlCloseBracket}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// One-key bindings work as before
+	press(leader, runes("d"))
+	if m.panes.Get("debug") == nil || m.chord != nil {
+		t.Fatalf("C-] d: debug pane %v, chord %v", m.panes.Get("debug") != nil, m.chord)
+	}
+
+	// Two-key chord: C-] t s toggles the stack pane
+	m.config.Keys.ToggleStack = []string{"t s"}
+	m.keys = m.config.ToKeyMap()
+	if cmd := press(leader, runes("t")); cmd == nil || m.chord == nil {
+		t.Fatal("C-] t should wait for another key")
+	}
+	if got := stripANSI(m.View()); !strings.Contains(got, "C-] t ...") {
+		t.Errorf("chord in progress not shown:\n%s", got)
+	}
+	press(runes("s"))
+	if m.panes.Get("stack") == nil || m.chord != nil {
+		t.Errorf("C-] t s: stack pane %v, chord %v", m.panes.Get("stack") != nil, m.chord)
+	}
+	m.panes.Remove("stack")
+
+	// Unknown keys and timeouts end the chord
+	press(leader, runes("t"), runes("x"))
+	if m.chord != nil {
+		t.Error("unknown key kept the chord")
+	}
+	press(leader, runes("t"))
+	next, _ := m.Update(chordTimeoutMsg{seq: m.chordSeq})
+	m = next.(Model)
+	if m.chord != nil {
+		t.Error("timeout kept the chord")
+	}
+
+	// A bound prefix of a longer chord runs on timeout; stale timeouts don't count
+	m.config.Keys.ToggleStack = []string{"t", "t s"}
+	m.keys = m.config.ToKeyMap()
+	press(leader, runes("t"))
+	next, _ = m.Update(chordTimeoutMsg{seq: m.chordSeq - 1})
+	m = next.(Model)
+	if m.chord == nil {
+		t.Fatal("stale timeout ended the chord")
+	}
+	next, _ = m.Update(chordTimeoutMsg{seq: m.chordSeq})
+	m = next.(Model)
+	if m.panes.Get("stack") == nil {
+		t.Error("C-] t then timeout didn't run the prefix's action")
+	}
+}
+
+func TestFocusChords(t *testing.T) {
+	m := newRideTestModel()
+	m.panes.Add(NewPane("left", nil, 0, 1, 10, 5))
+	m.panes.Add(NewPane("right", nil, 60, 1, 10, 5))
+	leader := tea.KeyMsg{Type: tea.KeyCtrlCloseBracket}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	for _, step := range []struct {
+		keys, want string
+	}{
+		{"w h", "left"},
+		{"w l", "right"},
+		{"w l", ""}, // Nothing further right: back to the session
+	} {
+		for _, k := range []tea.KeyMsg{leader, runes("w"), runes(step.keys[2:])} {
+			next, _ := m.Update(k)
+			m = next.(Model)
+		}
+		if m.panes.focusedID != step.want {
+			t.Errorf("C-] %s: focused %q, want %q", step.keys, m.panes.focusedID, step.want)
+		}
+	}
+}
+
+func TestApplyRideMalformed(t *testing.T) {
+	// Each of these panicked on a missing or mistyped field
+	m := applyAll(newRideTestModel(),
+		rideMsg("CloseWindow", map[string]any{}),
+		rideMsg("UpdateWindow", map[string]any{"token": "1"}),
+		rideMsg("SetHighlightLine", map[string]any{"win": float64(1)}),
+		rideMsg("WindowTypeChanged", map[string]any{"win": float64(1), "tracer": nil}),
+		rideMsg("ReplyGetAutocomplete", map[string]any{"options": []any{"x"}}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": float64(3)}),
+	)
+	log := strings.Join(m.debugLog.Lines, "\n")
+	for _, want := range []string{"CloseWindow: missing win", "UpdateWindow: json", "ReplyGetAutocomplete: missing token", "AppendSessionOutput: json"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("debug log missing %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestApplyRideMalformedWindows(t *testing.T) {
+	m := applyAll(newRideTestModel(), rideMsg("OpenWindow", map[string]any{
+		"token": float64(1), "name": "Foo", "text": []any{"r←Foo", "r←1"},
+	}))
+
+	// An update that doesn't say which window is dropped, not applied to one
+	m = applyAll(m, rideMsg("UpdateWindow", map[string]any{"name": "Foo", "text": []any{"r←Foo", "r←2"}}))
+	if got := m.editors[1].Text[1]; got != "r←1" {
+		t.Errorf("tokenless update changed window 1: line 1 = %q", got)
+	}
+
+	// A string token opens nothing
+	m = applyAll(m, rideMsg("OpenWindow", map[string]any{"token": "2", "name": "Bar", "text": []any{"Bar"}}))
+	if len(m.editors) != 1 || m.panes.Get("editor:2") != nil || m.panes.Get("editor:0") != nil {
+		t.Errorf("string token opened a window: editors = %v", m.editors)
+	}
+
+	log := strings.Join(m.debugLog.Lines, "\n")
+	if !strings.Contains(log, "ignored: UpdateWindow: missing token") || !strings.Contains(log, "ignored: OpenWindow: json") {
+		t.Errorf("malformed windows not logged:\n%s", log)
+	}
+}
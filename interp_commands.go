@@ -0,0 +1,74 @@
+package main
+
+import "strings"
+
+// systemCommands are the argument-free )commands offered in the palette.
+// Ones that lose work, like )clear, are left out: the palette runs a command
+// without asking, and a short fuzzy query can land on it.
+var systemCommands = []Command{
+	{Name: ")fns", Help: "List functions", Interp: true},
+	{Name: ")vars", Help: "List variables", Interp: true},
+	{Name: ")ops", Help: "List operators", Interp: true},
+	{Name: ")obs", Help: "List namespaces", Interp: true},
+	{Name: ")si", Help: "State indicator", Interp: true},
+	{Name: ")sinl", Help: "State indicator with name lists", Interp: true},
+	{Name: ")reset", Help: "Clear the state indicator", Interp: true},
+	{Name: ")wsid", Help: "Show workspace name", Interp: true},
+}
+
+// InterpCommands caches the user commands (]commands) the interpreter has
+// loaded. Shared by pointer so it survives Model copies.
+type InterpCommands struct {
+	Commands []Command
+	Loaded   bool // Commands reflects the interpreter (may be empty)
+	Pending  bool // Query in flight
+}
+
+// parseUserCommands extracts ]commands from the output of ]?, which lists
+// them in a "Group  Commands" table. A row whose group column is blank
+// continues the previous group.
+func parseUserCommands(outputs []string) []Command {
+	var cmds []Command
+	groupCol, cmdCol := -1, -1
+	group := ""
+
+	for _, out := range outputs {
+		for _, line := range strings.Split(out, "\n") {
+			if cmdCol < 0 {
+				// Find the table header to learn the column positions
+				g := strings.Index(line, "Group")
+				c := strings.Index(line, "Commands")
+				if g >= 0 && c > g {
+					groupCol, cmdCol = g, c
+				}
+				continue
+			}
+
+			if strings.TrimSpace(line) == "" || len(line) <= groupCol {
+				continue
+			}
+			if strings.Trim(line, " -=") == "" {
+				continue // Underline below the header
+			}
+
+			names := ""
+			if len(line) > cmdCol {
+				names = line[cmdCol:]
+			}
+			if g := strings.TrimSpace(line[groupCol:min(cmdCol, len(line))]); g != "" {
+				group = g
+			}
+			if group == "" {
+				continue
+			}
+			for _, name := range strings.Fields(names) {
+				cmds = append(cmds, Command{
+					Name:   "]" + strings.ToLower(group+"."+name),
+					Help:   "User command " + group + "." + name,
+					Interp: true,
+				})
+			}
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseUserCommands(t *testing.T) {
+	output := ` Type "]?+" for a summary of all commands
+ Type "]??" for general help
+
+ Group    Commands
+ =====    ========
+ ARRAY    Compare   Edit
+ LINK     Add       Break    Create
+          Status
+`
+	cmds := parseUserCommands([]string{output})
+	want := []string{"]array.compare", "]array.edit", "]link.add", "]link.break", "]link.create", "]link.status"}
+	if len(cmds) != len(want) {
+		t.Fatalf("got %d commands %v, want %v", len(cmds), cmds, want)
+	}
+	for i, w := range want {
+		if cmds[i].Name != w || !cmds[i].Interp {
+			t.Errorf("cmds[%d] = %+v, want interpreter command %s", i, cmds[i], w)
+		}
+	}
+
+	// No user commands loaded (or an error) gives none
+	if cmds := parseUserCommands([]string{"VALUE ERROR\n"}); len(cmds) != 0 {
+		t.Errorf("error output parsed as %v", cmds)
+	}
+}
+
+func TestPaletteInterpCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, _ := LoadConfig()
+	m := Model{
+		lines:      []Line{{Text: aplIndent}},
+		cursorCol:  len(aplIndent),
+		ready:      true,
+		width:      80,
+		height:     24,
+		panes:      NewPaneManager(80, 24),
+		keys:       cfg.ToKeyMap(),
+		config:     cfg,
+		debugLog:   &LogBuffer{},
+		interpCmds: &InterpCommands{Loaded: true, Commands: []Command{{Name: "]link.status", Help: "User command LINK.Status", Interp: true}}},
+	}
+	m.openCommandPalette()
+	cp := m.panes.Get("commands").Content.(*CommandPalette)
+
+	found := map[string]bool{}
+	for _, cmd := range cp.commands {
+		found[cmd.Name] = true
+	}
+	if !found[")fns"] || !found["]link.status"] {
+		t.Errorf("palette missing interpreter commands: %v", found)
+	}
+	if found[")clear"] {
+		t.Error("palette offers )clear, which runs without asking")
+	}
+
+	cp.query = "]link.status"
+	cp.filter()
+	m.panes.Focus("commands")
+	next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if got := m.currentLine(); got != aplIndent+"]link.status" {
+		t.Errorf("input line = %q, want ]link.status run in session", got)
+	}
+}
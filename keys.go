@@ -9,6 +9,9 @@ type KeyMap struct {
 
 	// Actions (some require leader prefix)
 	Execute          key.Binding
+	ExecuteBlock     key.Binding // Run a block of lines joined with ⋄
+	NewLine          key.Binding // Start another line of a block
+	InsertResult     key.Binding // Type the name of the variable holding the last result
 	ToggleDebug      key.Binding // After leader
 	ToggleStack      key.Binding // After leader
 	ToggleLocals     key.Binding // After leader - show local variables in tracer
@@ -22,6 +25,16 @@ type KeyMap struct {
 	ShowKeys         key.Binding // After leader
 	Autocomplete     key.Binding // Trigger code completion
 	DocHelp          key.Binding // Context-sensitive documentation
+	DocSymbol        key.Binding // After leader - docs for symbol at cursor
+	JumpDefinition   key.Binding // After leader - open the function named at the cursor
+	FocusLeft        key.Binding // After leader - focus the pane to the left
+	FocusRight       key.Binding // After leader
+	FocusUp          key.Binding // After leader
+	FocusDown        key.Binding // After leader
+
+	// Chords is the trie of key sequences after the leader, built from the
+	// "After leader" bindings above
+	Chords *chordNode
 
 	// Navigation
 	Up    key.Binding
@@ -7,20 +7,110 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
 )
 
-// KeysPane displays all key mappings
+// keyEntry is a row of the keys pane: a key and what it does
+type keyEntry struct {
+	keys, action string
+}
+
+// keyGroup is a category of bindings, shown under a header
+type keyGroup struct {
+	category string
+	entries  []keyEntry
+}
+
+// KeysPane displays all key mappings by category, with a "/" filter
 type KeysPane struct {
 	viewport viewport.Model
-	keys     KeyMap
+	groups   []keyGroup
+
+	// Filter ("/" prompt) on key or action
+	query     string
+	filtering bool // Prompt is taking input
 }
 
-// NewKeysPane creates a key mappings pane
-func NewKeysPane(keys KeyMap) *KeysPane {
+// NewKeysPane creates a key mappings pane, grouping the bindings
+func NewKeysPane(keys KeyMap, tracer TracerKeysConfig) *KeysPane {
 	vp := viewport.New(0, 0)
 	return &KeysPane{
 		viewport: vp,
-		keys:     keys,
+		groups:   keyGroups(keys, tracer),
+	}
+}
+
+// keyGroups sorts the bindings into categories, leaving out unbound ones
+func keyGroups(keys KeyMap, tracer TracerKeysConfig) []keyGroup {
+	bound := func(bindings ...key.Binding) []keyEntry {
+		var entries []keyEntry
+		for _, b := range bindings {
+			if b.Enabled() {
+				entries = append(entries, keyEntry{b.Help().Key, b.Help().Desc})
+			}
+		}
+		return entries
+	}
+	var tracerEntries []keyEntry
+	for _, t := range []keyEntry{
+		{tracer.StepOver, "step over"},
+		{tracer.StepInto, "step into"},
+		{tracer.StepOut, "step out"},
+		{tracer.Continue, "continue"},
+		{tracer.ResumeAll, "resume all threads"},
+		{tracer.Cutback, "cutback"},
+		{tracer.Backward, "trace backward"},
+		{tracer.Forward, "trace forward"},
+		{tracer.EditMode, "edit mode"},
+	} {
+		if t.keys != "" {
+			tracerEntries = append(tracerEntries, t)
+		}
+	}
+	tracerEntries = append(tracerEntries, bound(keys.ToggleBreakpoint)...)
+
+	return []keyGroup{
+		{"Session", bound(
+			keys.Execute,
+			keys.ExecuteBlock,
+			keys.NewLine,
+			keys.InsertResult,
+			keys.Autocomplete,
+			keys.DocHelp,
+			keys.DocSymbol,
+			keys.JumpDefinition,
+			keys.CommandPalette,
+			keys.Reconnect,
+			keys.ShowKeys,
+			keys.Quit,
+		)},
+		{"Panes", bound(
+			keys.ToggleDebug,
+			keys.ToggleStack,
+			keys.ToggleLocals,
+			keys.CyclePane,
+			keys.FocusLeft,
+			keys.FocusRight,
+			keys.FocusUp,
+			keys.FocusDown,
+			keys.PaneMoveMode,
+			keys.ClosePane,
+		)},
+		{"Tracer", tracerEntries},
+		{"Navigation", bound(
+			keys.Up,
+			keys.Down,
+			keys.Left,
+			keys.Right,
+			keys.Home,
+			keys.End,
+			keys.PgUp,
+			keys.PgDn,
+		)},
+		{"Editing", bound(
+			keys.Backspace,
+			keys.Delete,
+		)},
 	}
 }
 
@@ -28,67 +118,129 @@ func (k *KeysPane) Title() string {
 	return "key mappings"
 }
 
+// Editing reports whether the filter prompt is taking input
+func (k *KeysPane) Editing() bool {
+	return k.filtering
+}
+
+// Filtered reports whether a filter is set
+func (k *KeysPane) Filtered() bool {
+	return k.query != ""
+}
+
+// setQuery changes the filter, scrolling back to the top
+func (k *KeysPane) setQuery(query string) {
+	k.query = query
+	k.viewport.GotoTop()
+}
+
+// shown returns the groups with the entries matching the filter
+// (case-insensitive substring of the key or the action), dropping empty ones
+func (k *KeysPane) shown() []keyGroup {
+	if k.query == "" {
+		return k.groups
+	}
+	query := strings.ToLower(k.query)
+	var groups []keyGroup
+	for _, g := range k.groups {
+		var entries []keyEntry
+		for _, e := range g.entries {
+			if strings.Contains(strings.ToLower(e.keys), query) || strings.Contains(strings.ToLower(e.action), query) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) > 0 {
+			groups = append(groups, keyGroup{g.category, entries})
+		}
+	}
+	return groups
+}
+
 func (k *KeysPane) Render(w, h int) string {
+	// Filter prompt takes the first line
+	var header string
+	if k.filtering || k.query != "" {
+		prompt := "/" + k.query
+		if k.filtering {
+			prompt += "█"
+		}
+		header = lipgloss.NewStyle().Foreground(theme.Accent).Render(prompt) + "\n"
+		h--
+	}
 	k.viewport.Width = w
-	k.viewport.Height = h
+	k.viewport.Height = max(h, 1)
 	k.viewport.SetContent(k.buildContent(w))
-	return k.viewport.View()
+	return header + k.viewport.View()
 }
 
 func (k *KeysPane) buildContent(width int) string {
 	var sb strings.Builder
 
-	bindings := []struct {
-		category string
-		keys     []key.Binding
-	}{
-		{"Actions", []key.Binding{
-			k.keys.Execute,
-			k.keys.ToggleDebug,
-			k.keys.CyclePane,
-			k.keys.ClosePane,
-			k.keys.ShowKeys,
-			k.keys.Quit,
-		}},
-		{"Navigation", []key.Binding{
-			k.keys.Up,
-			k.keys.Down,
-			k.keys.Left,
-			k.keys.Right,
-			k.keys.Home,
-			k.keys.End,
-			k.keys.PgUp,
-			k.keys.PgDn,
-		}},
-		{"Editing", []key.Binding{
-			k.keys.Backspace,
-			k.keys.Delete,
-		}},
+	groups := k.shown()
+	if len(groups) == 0 {
+		sb.WriteString("  (no matches)\n\n")
 	}
-
-	for _, cat := range bindings {
-		sb.WriteString(fmt.Sprintf("─── %s ───\n", cat.category))
-		for _, b := range cat.keys {
-			help := b.Help()
-			keyStr := help.Key
-			desc := help.Desc
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("─── %s ───\n", g.category))
+		for _, e := range g.entries {
 			// Pad for alignment
-			line := fmt.Sprintf("  %-12s %s\n", keyStr, desc)
+			line := fmt.Sprintf("  %-12s %s\n", e.keys, e.action)
 			sb.WriteString(line)
 		}
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString("Press Esc to close")
+	sb.WriteString("/ to filter, Esc to close")
 	return sb.String()
 }
 
 func (k *KeysPane) HandleKey(msg tea.KeyMsg) bool {
+	if k.filtering {
+		return k.handleFilterKey(msg)
+	}
+	switch msg.Type {
+	case tea.KeyEscape:
+		// Esc clears the filter first, then the TUI closes the pane
+		if k.query != "" {
+			k.setQuery("")
+			return true
+		}
+		return false
+	case tea.KeyRunes:
+		// '/' opens the filter prompt
+		if len(msg.Runes) == 1 && msg.Runes[0] == '/' {
+			k.filtering = true
+			return true
+		}
+	}
 	var cmd tea.Cmd
 	k.viewport, cmd = k.viewport.Update(msg)
 	return cmd != nil
 }
 
+// handleFilterKey handles keys while the filter prompt is open
+func (k *KeysPane) handleFilterKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter, tea.KeyUp, tea.KeyDown:
+		// Keep the filter, back to scrolling
+		k.filtering = false
+	case tea.KeyEscape:
+		k.filtering = false
+		k.setQuery("")
+	case tea.KeyBackspace:
+		if q := []rune(k.query); len(q) > 0 {
+			k.setQuery(string(q[:len(q)-1]))
+		} else {
+			k.filtering = false
+		}
+	case tea.KeySpace:
+		k.setQuery(k.query + " ")
+	case tea.KeyRunes:
+		k.setQuery(k.query + string(msg.Runes))
+	}
+	return true
+}
+
 func (k *KeysPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 	var cmd tea.Cmd
 	k.viewport, cmd = k.viewport.Update(msg)
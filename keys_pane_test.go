@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestKeysPane(t *testing.T) *KeysPane {
+	t.Helper()
+	cfg, _ := LoadConfig()
+	cfg.Keys.Reconnect = nil // Unbound keys aren't listed
+	return NewKeysPane(cfg.ToKeyMap(), cfg.TracerKeys)
+}
+
+func TestKeysPaneGroups(t *testing.T) {
+	k := newTestKeysPane(t)
+	out := stripANSI(k.Render(60, 100))
+
+	last := -1
+	for _, header := range []string{"─── Session ───", "─── Panes ───", "─── Tracer ───", "─── Navigation ───", "─── Editing ───"} {
+		i := strings.Index(out, header)
+		if i < 0 || i < last {
+			t.Fatalf("%s missing or out of order in\n%s", header, out)
+		}
+		last = i
+	}
+	for _, want := range []string{"alt+enter", "step over", "ctrl+] b", "focus left"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q missing", want)
+		}
+	}
+	if strings.Contains(out, "reconnect") {
+		t.Error("unbound reconnect listed")
+	}
+}
+
+func TestKeysPaneFilter(t *testing.T) {
+	k := newTestKeysPane(t)
+	typ := func(s string) {
+		for _, r := range s {
+			k.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	typ("/step")
+	if !k.Editing() || k.query != "step" {
+		t.Fatalf("editing = %v, query = %q", k.Editing(), k.query)
+	}
+	out := stripANSI(k.Render(60, 100))
+	if !strings.HasPrefix(out, "/step█") || !strings.Contains(out, "step into") {
+		t.Errorf("filtered by action:\n%s", out)
+	}
+	if strings.Contains(out, "Session") || strings.Contains(out, "execute") {
+		t.Errorf("groups without matches shown:\n%s", out)
+	}
+
+	// Enter keeps the filter; Esc clears it before the pane closes
+	k.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if k.Editing() || !k.Filtered() {
+		t.Errorf("after Enter: editing = %v, filtered = %v", k.Editing(), k.Filtered())
+	}
+	if !k.HandleKey(tea.KeyMsg{Type: tea.KeyEscape}) || k.Filtered() {
+		t.Error("Esc didn't clear the filter")
+	}
+	if k.HandleKey(tea.KeyMsg{Type: tea.KeyEscape}) {
+		t.Error("Esc with no filter consumed")
+	}
+
+	// Keys match too
+	typ("/ctrl+j")
+	if out := stripANSI(k.Render(60, 100)); !strings.Contains(out, "new line") || strings.Contains(out, "execute joined") {
+		t.Errorf("filtered by key:\n%s", out)
+	}
+	typ("zzz")
+	if out := stripANSI(k.Render(60, 100)); !strings.Contains(out, "(no matches)") {
+		t.Errorf("no matches:\n%s", out)
+	}
+}
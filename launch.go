@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// launchOptions configures launchDyalog
+type launchOptions struct {
+	Binary  string        // Interpreter to run ("" = dyalog)
+	Args    []string      // Extra arguments after +s -q
+	Port    int           // RIDE port (0 = random)
+	Timeout time.Duration // How long to wait for RIDE to accept connections
+}
+
+// launchCommand builds the command that starts the interpreter serving RIDE
+// on port, in its own process group so cleanup can kill its helpers too
+func launchCommand(opts launchOptions, port int) *exec.Cmd {
+	binary := opts.Binary
+	if binary == "" {
+		binary = "dyalog"
+	}
+	cmd := exec.Command(binary, append([]string{"+s", "-q"}, opts.Args...)...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("RIDE_INIT=SERVE:*:%d", port))
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// launchAttempts is how many random ports launchDyalog tries
+const launchAttempts = 3
+
+// findDyalog resolves the interpreter binary on PATH, explaining what to do
+// if it isn't there
+func findDyalog(binary string) (string, error) {
+	if binary == "" {
+		binary = "dyalog"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("can't find the Dyalog interpreter %q.\n"+
+			"Install Dyalog APL (https://www.dyalog.com/download-zone.htm), or point gritt at it:\n"+
+			"  gritt -l -dyalog /path/to/dyalog\n"+
+			"  GRITT_DYALOG=/path/to/dyalog gritt -l", binary)
+	}
+	return path, nil
+}
+
+// randomPort picks a port in 10000–60000 that's free right now
+func randomPort() int {
+	port := 10000 + rand.Intn(50000)
+	for range 10 {
+		if l, err := net.Listen("tcp", fmt.Sprintf(":%d", port)); err == nil {
+			l.Close()
+			return port
+		}
+		port = 10000 + rand.Intn(50000)
+	}
+	return port
+}
+
+// launchDyalog starts Dyalog APL with RIDE on opts.Port, or a random port.
+// If RIDE doesn't come up on a random port (usually because something else
+// grabbed it), it tries another.
+func launchDyalog(opts launchOptions) (*exec.Cmd, int) {
+	binary, err := findDyalog(opts.Binary)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts.Binary = binary
+
+	for attempt := 1; ; attempt++ {
+		port := opts.Port
+		if port == 0 {
+			port = randomPort()
+		}
+		cmd, err := startDyalog(opts, port)
+		if err == nil {
+			return cmd, port
+		}
+		if opts.Port != 0 || attempt == launchAttempts {
+			log.Fatal(err)
+		}
+		log.Printf("%v; trying another port", err)
+	}
+}
+
+// startDyalog runs the interpreter and waits for RIDE to accept connections
+// on port. On failure the interpreter is killed.
+func startDyalog(opts launchOptions, port int) (*exec.Cmd, error) {
+	cmd := launchCommand(opts, port)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start Dyalog: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// Poll for RIDE to be ready
+	addr := fmt.Sprintf("localhost:%d", port)
+	for deadline := time.Now().Add(opts.Timeout); time.Now().Before(deadline); {
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("Dyalog exited before RIDE was ready on port %d (%v)", port, err)
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return cmd, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	return nil, fmt.Errorf("Dyalog did not start RIDE on port %d within %v (see -launch-timeout)", port, opts.Timeout)
+}
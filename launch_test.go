@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLaunchCommand(t *testing.T) {
+	cmd := launchCommand(launchOptions{}, 4502)
+	if want := []string{"dyalog", "+s", "-q"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("default args = %q, want %q", cmd.Args, want)
+	}
+	if !slices.Contains(cmd.Env, "RIDE_INIT=SERVE:*:4502") {
+		t.Error("RIDE_INIT not set")
+	}
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		t.Error("not started in its own process group")
+	}
+
+	cmd = launchCommand(launchOptions{Binary: "/opt/mdyalog/19.0/64/unicode/dyalog", Args: []string{"-b", "LOAD=x.dws"}}, 10000)
+	if want := []string{"/opt/mdyalog/19.0/64/unicode/dyalog", "+s", "-q", "-b", "LOAD=x.dws"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("custom args = %q, want %q", cmd.Args, want)
+	}
+}
+
+func TestFindDyalog(t *testing.T) {
+	_, err := findDyalog("/nonexistent/dyalog")
+	if err == nil || !strings.Contains(err.Error(), "GRITT_DYALOG") {
+		t.Errorf("missing binary error = %v, want a hint about GRITT_DYALOG", err)
+	}
+	if path, err := findDyalog("sh"); err != nil || !filepath.IsAbs(path) {
+		t.Errorf("findDyalog(sh) = %q, %v", path, err)
+	}
+}
+
+func TestStartDyalogExitsEarly(t *testing.T) {
+	// An interpreter that dies before RIDE listens (e.g. the port was taken)
+	bin := filepath.Join(t.TempDir(), "dyalog")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err := startDyalog(launchOptions{Binary: bin, Timeout: 10 * time.Second}, randomPort())
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("err = %v, want an early exit", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("waited for the timeout instead of noticing the exit")
+	}
+}
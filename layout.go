@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// layoutPath returns where the pane layout is kept between runs
+func layoutPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "gritt", "layout.json")
+}
+
+// layoutPanes are the panes whose layout is kept, and how to open them.
+// Editors, popups and searches are transient and aren't restored.
+var layoutPanes = map[string]func(*Model){
+	"debug":       (*Model).toggleDebugPane,
+	"stack":       (*Model).toggleStackPane,
+	"variables":   (*Model).toggleVariablesPane,
+	"breakpoints": (*Model).toggleBreakpointsPane,
+	"keys":        (*Model).toggleKeysPane,
+}
+
+// restoreLayout reopens the panes in the layout file, as they were when gritt
+// last quit. The session keeps focus. Panes that query the interpreter
+// (variables, breakpoints) take turns, each query going once the one
+// before has its reply.
+func (m *Model) restoreLayout(path string) {
+	layout, err := m.panes.LoadLayout(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			m.log("Pane layout not restored: %v", err)
+		}
+		return
+	}
+	for _, l := range layout {
+		open := layoutPanes[l.ID]
+		if open == nil || m.panes.Get(l.ID) != nil {
+			continue
+		}
+		open(m)
+		if p := m.panes.Get(l.ID); p != nil {
+			p.X, p.Y, p.Width, p.Height = l.X, l.Y, l.Width, l.Height
+		}
+	}
+	m.panes.Focus("")
+}
+
+// saveLayout records which panes are open and where, for restoreLayout
+func (m *Model) saveLayout(path string) {
+	keep := func(id string) bool { return layoutPanes[id] != nil }
+	if err := m.panes.SaveLayout(path, keep); err != nil {
+		m.log("Pane layout not saved: %v", err)
+	}
+}
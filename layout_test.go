@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestLayoutSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gritt", "layout.json")
+	pm := NewPaneManager(120, 40)
+	pm.Add(NewPane("stack", nil, 80, 2, 30, 15))
+	pm.Add(NewPane("symbols", nil, 10, 5, 50, 20))
+	pm.Add(NewPane("debug", nil, 60, 1, 50, 35))
+	keep := func(id string) bool { return id != "symbols" }
+	if err := pm.SaveLayout(path, keep); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size: as saved, bottom to top, without the transient pane
+	got, err := NewPaneManager(120, 40).LoadLayout(path)
+	want := []PaneLayout{{"stack", 80, 2, 30, 15}, {"debug", 60, 1, 50, 35}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadLayout = %v, %v, want %v", got, err, want)
+	}
+
+	// Smaller terminal: panes fit above the help line
+	got, _ = NewPaneManager(80, 24).LoadLayout(path)
+	want = []PaneLayout{{"stack", 50, 2, 30, 15}, {"debug", 30, 0, 50, 23}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shrunk LoadLayout = %v, want %v", got, want)
+	}
+}
+
+func TestRestoreLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layout.json")
+	os.WriteFile(path, []byte(`[{"id":"keys","x":1,"y":2,"width":30,"height":10},{"id":"aplcart","x":0,"y":0,"width":9,"height":9}]`), 0644)
+
+	m := newRideTestModel()
+	m.restoreLayout(path)
+	p := m.panes.Get("keys")
+	if p == nil || p.X != 1 || p.Y != 2 || p.Width != 30 || p.Height != 10 {
+		t.Fatalf("keys pane = %+v, want it at its saved geometry", p)
+	}
+	if m.panes.Get("aplcart") != nil {
+		t.Error("transient pane restored")
+	}
+	if m.panes.FocusedPane() != nil {
+		t.Error("restored pane took focus from the session")
+	}
+}
+
+func TestRestoreLayoutQueriesInTurn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layout.json")
+	os.WriteFile(path, []byte(`[{"id":"variables","x":40,"y":2,"width":35,"height":12},{"id":"breakpoints","x":40,"y":15,"width":35,"height":8}]`), 0644)
+	conn, err := ride.NewReplayConn(strings.NewReader(socketSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ride.NewClient(conn)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	m := newRideTestModel()
+	m.client, m.connected = client, true
+	m.breakpoints = &Breakpoints{}
+	m.restoreLayout(path)
+	if !strings.Contains(m.internalQuery, "⎕NL 2") || !m.breakpointsWaiting {
+		t.Fatalf("after restore: query = %q, breakpoints waiting = %v", m.internalQuery, m.breakpointsWaiting)
+	}
+
+	// The variables' reply lets the breakpoints' query go
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	if !strings.Contains(m.internalQuery, "⎕STOP") || m.breakpointsWaiting {
+		t.Errorf("after the variables' reply: query = %q, breakpoints waiting = %v", m.internalQuery, m.breakpointsWaiting)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +13,73 @@ type LocalVar struct {
 	Name    string
 	Value   string // Preview value (may be truncated)
 	IsLocal bool   // True if declared as local in function header
+	Shape   string // ⍴ as APL prints it, "" for a scalar
+	DR      int    // ⎕DR data representation, 0 if unknown
+	Depth   int    // ≡
+}
+
+// TypeInfo is a compact annotation such as "⍴3 4 ⌶645", with "≡2" added
+// for nested arrays. Empty if the type wasn't fetched.
+func (vr LocalVar) TypeInfo() string {
+	if vr.DR == 0 {
+		return ""
+	}
+	var parts []string
+	if vr.Shape != "" {
+		parts = append(parts, "⍴"+vr.Shape)
+	}
+	parts = append(parts, "⌶"+strconv.Itoa(vr.DR))
+	if vr.Depth > 1 || vr.Depth < -1 {
+		parts = append(parts, "≡"+strings.ReplaceAll(strconv.Itoa(vr.Depth), "-", "¯"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// varInfoFn prints "name⍴shape⌶dr≡depth=value" for the variable named by its
+// argument, batched with ¨ over names. The value is the first line of its
+// display, with "..." if there's more; names without a value print "name=".
+const varInfoFn = "{0::⎕←⍵,'=' ⋄ v←⍎⍵ ⋄ f←↓1/⍕v ⋄ ⎕←⍵,'⍴',(⍕⍴v),'⌶',(⍕⎕DR v),'≡',(⍕≡v),'=',(⊃f),(1<≢f)/'...'}"
+
+// varInfoExpr prints varInfoFn lines for each of names (an APL expression
+// giving a vector of names)
+func varInfoExpr(names string) string {
+	return "{}" + varInfoFn + "¨" + names
+}
+
+// parseVarInfo parses a varInfoFn line. The type fields stay zero when the
+// interpreter didn't send them.
+func parseVarInfo(line string) (LocalVar, bool) {
+	head, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+	if !ok {
+		return LocalVar{}, false
+	}
+	vr := LocalVar{Value: strings.TrimSpace(value)}
+	name, info, typed := strings.Cut(head, "⍴")
+	vr.Name = strings.TrimSpace(name)
+	if vr.Name == "" {
+		return LocalVar{}, false
+	}
+	if typed {
+		shape, rest, _ := strings.Cut(info, "⌶")
+		dr, depth, _ := strings.Cut(rest, "≡")
+		vr.Shape = strings.TrimSpace(shape)
+		vr.DR, _ = strconv.Atoi(strings.TrimSpace(dr))
+		vr.Depth, _ = strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(depth), "¯", "-"))
+	}
+	return vr, true
+}
+
+// parseVarInfoLines parses every varInfoFn line in a query's outputs
+func parseVarInfoLines(outputs []string) []LocalVar {
+	var vars []LocalVar
+	for _, output := range outputs {
+		for _, line := range strings.Split(output, "\n") {
+			if vr, ok := parseVarInfo(line); ok {
+				vars = append(vars, vr)
+			}
+		}
+	}
+	return vars
 }
 
 // VarsMode determines which variables are shown
@@ -31,20 +99,113 @@ type VariablesPane struct {
 	onOpen   func(name string)    // Called when user wants to open variable with )ed
 	onToggle func(mode VarsMode)  // Called when user toggles mode
 
+	// Name filter ("/" prompt); narrows the list in both modes
+	query     string
+	filtering bool // Prompt is taking input
+
+	// Inline edit of the selected variable's value
+	editing bool
+	editBuf []rune
+	editErr string // Error from the last attempt, shown under the variable
+
+	// Set by HandleKey for the TUI to send name←value
+	AssignName  string
+	AssignValue string
+
 	// Styles
 	selectedStyle lipgloss.Style // Orange for selected line
 	normalStyle   lipgloss.Style // Gray for non-selected lines
+	errorStyle    lipgloss.Style
+	typeStyle     lipgloss.Style // Dim gray for the type column
+}
+
+// inlineValueMax is the longest value preview edited in the pane; longer or
+// truncated values open in an editor
+const inlineValueMax = 40
+
+// isInlineEditable reports whether a value preview is a short one-line
+// value (a scalar or short vector) rather than something needing an editor
+func isInlineEditable(value string) bool {
+	return value != "" && !strings.HasSuffix(value, "...") && len([]rune(value)) <= inlineValueMax
 }
 
 // NewVariablesPane creates a variables pane
 func NewVariablesPane(onOpen func(name string), onToggle func(mode VarsMode)) *VariablesPane {
-	return &VariablesPane{
-		onOpen:        onOpen,
-		onToggle:      onToggle,
-		mode:          VarsModeLocals,
-		selectedStyle: lipgloss.NewStyle().Foreground(AccentColor),
-		normalStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+	v := &VariablesPane{
+		onOpen:   onOpen,
+		onToggle: onToggle,
+		mode:     VarsModeLocals,
+	}
+	v.ApplyTheme()
+	return v
+}
+
+// ApplyTheme rebuilds the pane's styles from the theme
+func (v *VariablesPane) ApplyTheme() {
+	v.selectedStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	v.normalStyle = lipgloss.NewStyle().Foreground(theme.Comment)
+	v.errorStyle = lipgloss.NewStyle().Foreground(theme.Error)
+	v.typeStyle = lipgloss.NewStyle().Foreground(theme.Dim)
+}
+
+// Editing reports whether the pane is taking text: an inline edit or the
+// filter prompt
+func (v *VariablesPane) Editing() bool {
+	return v.editing || v.filtering
+}
+
+// Filtered reports whether a name filter is set
+func (v *VariablesPane) Filtered() bool {
+	return v.query != ""
+}
+
+// insertChar adds a character to the inline edit or filter (APL input via backtick)
+func (v *VariablesPane) insertChar(r rune) {
+	if v.editing {
+		v.editBuf = append(v.editBuf, r)
+		return
+	}
+	v.setQuery(v.query + string(r))
+}
+
+// setQuery changes the filter, selecting the first match
+func (v *VariablesPane) setQuery(query string) {
+	v.query = query
+	v.selected = 0
+}
+
+// shown returns the variables matching the filter (case-insensitive
+// substring of the name)
+func (v *VariablesPane) shown() []LocalVar {
+	if v.query == "" {
+		return v.vars
+	}
+	query := strings.ToLower(v.query)
+	var vars []LocalVar
+	for _, vr := range v.vars {
+		if strings.Contains(strings.ToLower(vr.Name), query) {
+			vars = append(vars, vr)
+		}
+	}
+	return vars
+}
+
+// AssignDone reports the result of an inline assignment: the variable's new
+// value and type, or the interpreter's error, which keeps the edit open
+func (v *VariablesPane) AssignDone(info LocalVar, errMsg string) {
+	if errMsg != "" {
+		v.editErr = errMsg
+		return
+	}
+	for i := range v.vars {
+		if v.vars[i].Name == info.Name {
+			info.IsLocal = v.vars[i].IsLocal
+			v.vars[i] = info
+		}
 	}
+	v.editing = false
+	v.editBuf = nil
+	v.editErr = ""
 }
 
 // SetVars updates the list of variables and clears loading state
@@ -70,6 +231,8 @@ func (v *VariablesPane) Clear() {
 	v.vars = nil
 	v.selected = 0
 	v.loading = false
+	v.editing = false
+	v.editBuf = nil
 }
 
 // Mode returns the current display mode
@@ -97,11 +260,29 @@ func (v *VariablesPane) Render(w, h int) string {
 		return v.normalStyle.Render("  (no variables)")
 	}
 
+	// Filter prompt takes the first line
+	var header []string
+	if v.filtering || v.query != "" {
+		prompt := "/" + v.query
+		if v.filtering {
+			prompt += "█"
+		}
+		header = append(header, v.selectedStyle.Render(prompt))
+		h--
+	}
+	vars := v.shown()
+	if len(vars) == 0 {
+		return strings.Join(append(header, v.normalStyle.Render("  (no matches)")), "\n")
+	}
+	if v.selected >= len(vars) {
+		v.selected = len(vars) - 1
+	}
+
 	var lines []string
 
 	// Calculate max name width for alignment
 	maxNameWidth := 0
-	for _, vr := range v.vars {
+	for _, vr := range vars {
 		if len(vr.Name) > maxNameWidth {
 			maxNameWidth = len(vr.Name)
 		}
@@ -110,7 +291,14 @@ func (v *VariablesPane) Render(w, h int) string {
 		maxNameWidth = w / 3
 	}
 
-	for i, vr := range v.vars {
+	// Type annotations ("⍴3 4 ⌶645") share a right-aligned column
+	typeWidth := 0
+	for _, vr := range vars {
+		typeWidth = max(typeWidth, len([]rune(vr.TypeInfo())))
+	}
+	typeWidth = min(typeWidth, w/3)
+
+	for i, vr := range vars {
 		// Format: "• name = value" (• for locals in all mode)
 		prefix := "  " // 2 spaces by default
 		if v.mode == VarsModeAll && vr.IsLocal {
@@ -125,16 +313,41 @@ func (v *VariablesPane) Render(w, h int) string {
 		// Pad name for alignment
 		namePadded := name + strings.Repeat(" ", maxNameWidth-len(name))
 
-		// Calculate available space for value (account for prefix)
-		valueWidth := w - maxNameWidth - 3 - len(prefix) // prefix + " = "
+		// Calculate available space for value (account for prefix and type)
+		valueWidth := w - maxNameWidth - 3 - len([]rune(prefix)) // prefix + " = "
+		if typeWidth > 0 {
+			valueWidth -= typeWidth + 1
+		}
 		value := vr.Value
-		if len(value) > valueWidth && valueWidth > 3 {
-			value = value[:valueWidth-3] + "..."
+		if len([]rune(value)) > valueWidth && valueWidth > 3 {
+			value = string([]rune(value)[:valueWidth-3]) + "..."
+		}
+
+		// Right-align the type, truncated to the column
+		typeInfo := []rune(vr.TypeInfo())
+		if len(typeInfo) > typeWidth {
+			typeInfo = typeInfo[:typeWidth]
+		}
+		typeCol := ""
+		if typeWidth > 0 {
+			typeCol = " " + strings.Repeat(" ", typeWidth-len(typeInfo)) + string(typeInfo)
 		}
 
 		// Build plain text line
 		plainLine := prefix + namePadded + " = " + value
-		plainLen := len(plainLine)
+		if i == v.selected && v.editing {
+			// Old value stays as a placeholder until typing replaces it
+			edit := string(v.editBuf) + "█"
+			if len(v.editBuf) == 0 {
+				edit = "█" + v.normalStyle.Render(vr.Value)
+			}
+			lines = append(lines, v.selectedStyle.Render(prefix+namePadded+" ← ")+edit)
+			if v.editErr != "" {
+				lines = append(lines, v.errorStyle.Render("  "+v.editErr))
+			}
+			continue
+		}
+		plainLen := len([]rune(plainLine)) + len([]rune(typeCol))
 		if plainLen < w {
 			plainLine = plainLine + strings.Repeat(" ", w-plainLen)
 		}
@@ -146,6 +359,7 @@ func (v *VariablesPane) Render(w, h int) string {
 		} else {
 			line = v.normalStyle.Render(plainLine)
 		}
+		line += v.typeStyle.Render(typeCol)
 
 		lines = append(lines, line)
 	}
@@ -164,10 +378,18 @@ func (v *VariablesPane) Render(w, h int) string {
 		lines = lines[start : start+h]
 	}
 
-	return strings.Join(lines[:h], "\n")
+	return strings.Join(append(header, lines[:h]...), "\n")
 }
 
 func (v *VariablesPane) HandleKey(msg tea.KeyMsg) bool {
+	if v.editing {
+		return v.handleEditKey(msg)
+	}
+	if v.filtering {
+		return v.handleFilterKey(msg)
+	}
+
+	vars := v.shown()
 	switch msg.Type {
 	case tea.KeyUp:
 		if v.selected > 0 {
@@ -175,17 +397,41 @@ func (v *VariablesPane) HandleKey(msg tea.KeyMsg) bool {
 		}
 		return true
 	case tea.KeyDown:
-		if v.selected < len(v.vars)-1 {
+		if v.selected < len(vars)-1 {
 			v.selected++
 		}
 		return true
 	case tea.KeyEnter:
-		// Open selected variable with )ed
-		if v.selected >= 0 && v.selected < len(v.vars) && v.onOpen != nil {
-			v.onOpen(v.vars[v.selected].Name)
+		// Edit short values inline, open anything else with )ed
+		if v.selected >= 0 && v.selected < len(vars) {
+			if isInlineEditable(vars[v.selected].Value) {
+				v.editing = true
+				v.editBuf = nil
+				v.editErr = ""
+			} else if v.onOpen != nil {
+				v.onOpen(vars[v.selected].Name)
+			}
 		}
 		return true
+	case tea.KeyEscape:
+		// Esc clears the filter first, then the TUI closes the pane
+		if v.query != "" {
+			v.setQuery("")
+			return true
+		}
 	case tea.KeyRunes:
+		// '/' opens the filter prompt
+		if len(msg.Runes) == 1 && msg.Runes[0] == '/' {
+			v.filtering = true
+			return true
+		}
+		// 'e' always opens the editor
+		if len(msg.Runes) == 1 && msg.Runes[0] == 'e' {
+			if v.selected >= 0 && v.selected < len(vars) && v.onOpen != nil {
+				v.onOpen(vars[v.selected].Name)
+			}
+			return true
+		}
 		// '~' toggles mode - but DON'T call onToggle callback here
 		// The TUI handles the refresh to avoid stale Model reference
 		if len(msg.Runes) == 1 && msg.Runes[0] == '~' {
@@ -202,13 +448,64 @@ func (v *VariablesPane) HandleKey(msg tea.KeyMsg) bool {
 	return false
 }
 
+// handleEditKey handles keys while a value is edited inline
+func (v *VariablesPane) handleEditKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if vars := v.shown(); len(v.editBuf) > 0 && v.selected < len(vars) {
+			v.AssignName = vars[v.selected].Name
+			v.AssignValue = string(v.editBuf)
+		}
+	case tea.KeyEscape:
+		v.editing = false
+		v.editBuf = nil
+		v.editErr = ""
+	case tea.KeyBackspace:
+		if len(v.editBuf) > 0 {
+			v.editBuf = v.editBuf[:len(v.editBuf)-1]
+		}
+	case tea.KeySpace:
+		v.editBuf = append(v.editBuf, ' ')
+	case tea.KeyRunes:
+		v.editBuf = append(v.editBuf, msg.Runes...)
+	}
+	return true
+}
+
+// handleFilterKey handles keys while the filter prompt is open
+func (v *VariablesPane) handleFilterKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter, tea.KeyUp, tea.KeyDown:
+		// Keep the filter, back to the list
+		v.filtering = false
+	case tea.KeyEscape:
+		v.filtering = false
+		v.setQuery("")
+	case tea.KeyBackspace:
+		if q := []rune(v.query); len(q) > 0 {
+			v.setQuery(string(q[:len(q)-1]))
+		} else {
+			v.filtering = false
+		}
+	case tea.KeySpace:
+		// Names have no spaces
+	case tea.KeyRunes:
+		v.setQuery(v.query + string(msg.Runes))
+	}
+	return true
+}
+
 func (v *VariablesPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
-	if len(v.vars) == 0 {
+	vars := v.shown()
+	if len(vars) == 0 {
 		return false
 	}
+	if v.filtering || v.query != "" {
+		y-- // Filter prompt line
+	}
 
 	if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
-		if y >= 0 && y < len(v.vars) {
+		if y >= 0 && y < len(vars) {
 			v.selected = y
 		}
 		return true
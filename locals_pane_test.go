@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVarInfo(t *testing.T) {
+	tests := []struct {
+		line string
+		want LocalVar
+		ok   bool
+	}{
+		{"mat   ⍴3 4⌶83≡1=1 2 3 4...", LocalVar{Name: "mat", Value: "1 2 3 4...", Shape: "3 4", DR: 83, Depth: 1}, true},
+		{"n⍴⌶645≡0=3.5", LocalVar{Name: "n", Value: "3.5", DR: 645}, true},
+		{"nest⍴2⌶326≡¯2= 1 2  3", LocalVar{Name: "nest", Value: "1 2  3", Shape: "2", DR: 326, Depth: -2}, true},
+		{"eq⍴5⌶80≡1=a=b=c", LocalVar{Name: "eq", Value: "a=b=c", Shape: "5", DR: 80, Depth: 1}, true},
+		{"unset=", LocalVar{Name: "unset"}, true},
+		{"x=1 2", LocalVar{Name: "x", Value: "1 2"}, true},
+		{"VALUE ERROR", LocalVar{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVarInfo(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseVarInfo(%q) = %+v, %v; want %+v", tt.line, got, ok, tt.want)
+		}
+	}
+}
+
+func TestVarTypeInfo(t *testing.T) {
+	tests := []struct {
+		v    LocalVar
+		want string
+	}{
+		{LocalVar{Shape: "3 4", DR: 645, Depth: 1}, "⍴3 4 ⌶645"},
+		{LocalVar{DR: 83}, "⌶83"},
+		{LocalVar{Shape: "2", DR: 326, Depth: -2}, "⍴2 ⌶326 ≡¯2"},
+		{LocalVar{Value: "1"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.v.TypeInfo(); got != tt.want {
+			t.Errorf("TypeInfo(%+v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestVariablesPaneTypeColumn(t *testing.T) {
+	v := NewVariablesPane(nil, nil)
+	v.SetVars([]LocalVar{
+		{Name: "mat", Value: "1 2 3 4", Shape: "3 4", DR: 83, Depth: 1},
+		{Name: "s", Value: "hi", Shape: "2", DR: 80, Depth: 1},
+	})
+	lines := strings.Split(stripANSI(v.Render(36, 2)), "\n")
+	if !strings.Contains(lines[0], "⍴3 4 ⌶83") || !strings.Contains(lines[1], "⍴2 ⌶80") {
+		t.Errorf("type column missing:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.HasSuffix(lines[1], "  ⍴2 ⌶80") || len([]rune(lines[1])) != 36 {
+		t.Errorf("type should be right-aligned in 36 columns: %q", lines[1])
+	}
+}
+
+func TestVarInfoExpr(t *testing.T) {
+	expr := varInfoExpr(aplNames([]string{"abc"}))
+	if !strings.HasPrefix(expr, "{}{0::") || !strings.HasSuffix(expr, "¨,(⊂,'abc')") {
+		t.Errorf("varInfoExpr = %q", expr)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestJSONProtocolLog(t *testing.T) {
+	var buf bytes.Buffer
+	ride.Logger, ride.LogJSON = &buf, true
+	defer func() { ride.Logger, ride.LogJSON = nil, false }()
+
+	if err := ride.Send(&bytes.Buffer{}, "Execute", map[string]any{"text": "1+1\n", "trace": 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry ride.LogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line %q is not JSON: %v", buf.String(), err)
+	}
+	if entry.Dir != "send" || entry.Cmd != "Execute" || entry.TS == "" {
+		t.Errorf("entry = %+v", entry)
+	}
+	var args map[string]any
+	if err := json.Unmarshal(entry.Args, &args); err != nil || args["text"] != "1+1\n" {
+		t.Errorf("args = %s", entry.Args)
+	}
+}
+
+func TestJSONLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &jsonLogWriter{w: &buf}
+	if _, err := w.Write([]byte("[12:00:00.000] Connected to localhost:4502\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Error("missing newline")
+	}
+	var entry struct{ Dir, TS, Msg string }
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Dir != "log" || entry.Msg != "Connected to localhost:4502" {
+		t.Errorf("entry = %+v", entry)
+	}
+}
@@ -2,16 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
-	"net"
 	"os"
 	"os/exec"
-	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -40,29 +41,6 @@ const splash = `
 ⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠈⠈⠈⠛⠞⠟⡻⠛⠛⠛⠛⠛⠉⠁⠠⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀
 `
 
-// launchDyalog starts Dyalog APL with RIDE on a random port
-func launchDyalog() (*exec.Cmd, int) {
-	port := 10000 + rand.Intn(50000)
-	cmd := exec.Command("dyalog", "+s", "-q")
-	cmd.Env = append(os.Environ(), fmt.Sprintf("RIDE_INIT=SERVE:*:%d", port))
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	if err := cmd.Start(); err != nil {
-		log.Fatalf("Failed to start Dyalog: %v", err)
-	}
-	// Poll for RIDE to be ready
-	addr := fmt.Sprintf("localhost:%d", port)
-	for i := 0; i < 50; i++ { // 5 second timeout
-		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
-		if err == nil {
-			conn.Close()
-			return cmd, port
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	log.Fatalf("Dyalog did not start on port %d", port)
-	return nil, 0
-}
-
 // multiFlag allows a flag to be specified multiple times, collecting all values
 type multiFlag []string
 
@@ -74,21 +52,67 @@ func (m *multiFlag) Set(value string) error {
 
 func main() {
 	addr := flag.String("addr", "localhost:4502", "Dyalog RIDE address")
+	connName := flag.String("c", "", "Use a named connection from gritt.json")
 	logFile := flag.String("log", "", "Log protocol messages to file")
+	logJSON := flag.Bool("logjson", false, "Write the -log file as JSON lines")
+	record := flag.String("record", "", "Record the RIDE session to file for replay")
 	var exprs multiFlag
 	flag.Var(&exprs, "e", "Execute expression and exit (can be repeated)")
 	stdin := flag.Bool("stdin", false, "Read expressions from stdin")
+	strict := flag.Bool("strict", false, "With -e or -stdin, carry on after APL errors and exit 0 (by default the first stops them and exits 1)")
+	script := flag.String("f", "", "Execute an APL script file line by line, stopping at the first error")
 	sock := flag.String("sock", "", "Unix socket path for APL server")
+	sockJSON := flag.Bool("sock-json", false, "Reply to -sock requests with newline-delimited JSON")
 	link := flag.String("link", "", "Link directory (path or ns:path)")
 	launch := flag.Bool("launch", false, "Launch Dyalog automatically (alias: -l)")
 	flag.BoolVar(launch, "l", false, "Launch Dyalog automatically")
+	var launchOpts launchOptions
+	flag.StringVar(&launchOpts.Binary, "dyalog", os.Getenv("GRITT_DYALOG"), "Interpreter to launch (default $GRITT_DYALOG or dyalog)")
+	launchArgs := flag.String("launch-args", "", "Extra arguments for the launched interpreter")
+	flag.IntVar(&launchOpts.Port, "port", 0, "RIDE port for the launched interpreter (default random)")
+	flag.DurationVar(&launchOpts.Timeout, "launch-timeout", 5*time.Second, "How long to wait for the launched interpreter")
+	tutorial := flag.Bool("tutorial", false, "Start with the guided tutorial pane")
+	replay := flag.String("replay", "", "Run a script file in the session a line at a time, pausing at errors")
+	flag.DurationVar(&execTimeout, "timeout", 0, "With -e, -stdin, -f or -sock, interrupt an expression running longer than this (0 for no limit)")
 	flag.Parse()
 
+	// Exit status for APL errors; deferred first so it runs after the cleanup
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// A named connection sets the address, TLS and launch options
+	var tlsConfig *tls.Config
+	if *connName != "" {
+		conn, err := namedConnection(*connName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if conn.Launch {
+			*launch = true
+			if conn.Dyalog != "" {
+				launchOpts.Binary = conn.Dyalog
+			}
+			if conn.Args != "" {
+				*launchArgs = conn.Args
+			}
+		} else {
+			*addr = conn.Addr
+			if tlsConfig, err = conn.TLS.clientConfig(conn.Addr); err != nil {
+				log.Fatalf("Connection %q: %v", *connName, err)
+			}
+		}
+	}
+
 	// Launch Dyalog if requested
 	var dyalogCmd *exec.Cmd
 	if *launch {
 		var port int
-		dyalogCmd, port = launchDyalog()
+		launchOpts.Args = strings.Fields(*launchArgs)
+		dyalogCmd, port = launchDyalog(launchOpts)
 		*addr = fmt.Sprintf("localhost:%d", port)
 		defer func() {
 			if dyalogCmd.Process != nil {
@@ -108,14 +132,40 @@ func main() {
 		}
 		defer logWriter.Close()
 		ride.Logger = logWriter
+		ride.LogJSON = *logJSON
+	}
+
+	// Set up session recording if requested
+	if *record != "" {
+		recordWriter, err := os.Create(*record)
+		if err != nil {
+			log.Fatalf("Failed to open record file: %v", err)
+		}
+		defer recordWriter.Close()
+		ride.Record = recordWriter
 	}
 
 	// Non-interactive mode
-	if len(exprs) > 0 && *stdin {
-		log.Fatal("-e and -stdin are mutually exclusive")
+	if (len(exprs) > 0 && *stdin) || (*script != "" && (len(exprs) > 0 || *stdin)) {
+		log.Fatal("-e, -stdin and -f are mutually exclusive")
+	}
+	if *script != "" {
+		client, err := ride.ConnectTLS(*addr, tlsConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer client.Close()
+		if *link != "" {
+			runLink(client, *link)
+		}
+		if err := runScript(client, *script); err != nil {
+			fmt.Fprintf(os.Stderr, "gritt: %v\n", err)
+			exitCode = 1
+		}
+		return
 	}
 	if len(exprs) > 0 {
-		client, err := ride.Connect(*addr)
+		client, err := ride.ConnectTLS(*addr, tlsConfig)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -124,12 +174,22 @@ func main() {
 			runLink(client, *link)
 		}
 		for _, expr := range exprs {
-			runExpr(client, expr)
+			errNum, err := runExpr(client, expr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "gritt: %v in: %s\n", err, expr)
+				exitCode = 1
+				return
+			}
+			if errNum != 0 && !*strict {
+				reportError(expr, errNum)
+				exitCode = 1
+				return
+			}
 		}
 		return
 	}
 	if *stdin {
-		client, err := ride.Connect(*addr)
+		client, err := ride.ConnectTLS(*addr, tlsConfig)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -139,7 +199,17 @@ func main() {
 		}
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
-			runExpr(client, scanner.Text())
+			errNum, err := runExpr(client, scanner.Text())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "gritt: %v in: %s\n", err, scanner.Text())
+				exitCode = 1
+				return
+			}
+			if errNum != 0 && !*strict {
+				reportError(scanner.Text(), errNum)
+				exitCode = 1
+				return
+			}
 		}
 		if err := scanner.Err(); err != nil {
 			log.Fatal(err)
@@ -147,7 +217,7 @@ func main() {
 		return
 	}
 	if *sock != "" {
-		client, err := ride.Connect(*addr)
+		client, err := ride.ConnectTLS(*addr, tlsConfig)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -155,7 +225,9 @@ func main() {
 		if *link != "" {
 			runLink(client, *link)
 		}
-		runSocket(client, *sock)
+		runSocket(client, func() (*ride.Client, error) {
+			return ride.ConnectTLS(*addr, tlsConfig)
+		}, *sock, *sockJSON)
 		return
 	}
 
@@ -166,156 +238,169 @@ func main() {
 		colorProfile = colorprofile.TrueColor
 	}
 
+	var replayScript *scriptReplay
+	if *replay != "" {
+		var err error
+		if replayScript, err = loadScriptReplay(*replay); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	fmt.Print(splash)
-	fmt.Printf("\n  gritt - Go RIDE Terminal\n  Connecting to %s...\n", *addr)
-	client, err := ride.Connect(*addr)
+	fmt.Printf("\n  gritt - Go RIDE Terminal\n")
+	_, warnings := LoadConfig()
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "  config: %v\n", w)
+	}
+	fmt.Printf("  Connecting to %s...\n", *addr)
+	client, err := ride.ConnectTLS(*addr, tlsConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer client.Close()
 
-	p := tea.NewProgram(NewModel(client, *addr, logWriter, colorProfile), tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
+	var modelLog io.Writer = logWriter
+	if *logJSON && logWriter != nil {
+		modelLog = &jsonLogWriter{w: logWriter}
+	}
+	model := NewModel(client, *addr, modelLog, colorProfile)
+	model.tls = tlsConfig
+	model.tutorialPending = *tutorial
+	model.layoutFile = layoutPath()
+	model.replay = replayScript
+	p := tea.NewProgram(model, tea.WithOutput(programOutput), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	final, err := p.Run()
+	if err != nil {
 		log.Fatal(err)
 	}
+	if fm, ok := final.(Model); ok {
+		fm.saveLayout(layoutPath())
+		fm.docs.Close()
+	}
+}
+
+// jsonLogWriter wraps gritt's own "[time] message" log lines as
+// {"dir":"log",...} objects so a -logjson file is JSON lines throughout
+type jsonLogWriter struct {
+	w io.Writer
+}
+
+func (j *jsonLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	if strings.HasPrefix(msg, "[") {
+		if i := strings.Index(msg, "] "); i >= 0 {
+			msg = msg[i+2:]
+		}
+	}
+	data, err := json.Marshal(struct {
+		Dir string `json:"dir"`
+		TS  string `json:"ts"`
+		Msg string `json:"msg"`
+	}{"log", time.Now().Format(time.RFC3339Nano), msg})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := j.w.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 // runLink runs ]link.create with the given spec
 func runLink(client *ride.Client, spec string) {
-	var cmd string
+	if _, err := runExpr(client, linkCreateExpr(spec)); err != nil {
+		log.Fatalf("Link failed: %v", err)
+	}
+}
+
+// linkCreateExpr builds the ]link.create command for a [ns:]path spec
+func linkCreateExpr(spec string) string {
 	if idx := strings.Index(spec, ":"); idx >= 0 {
 		// ns:path -> ]link.create ns path
 		ns := spec[:idx]
 		path := spec[idx+1:]
-		cmd = fmt.Sprintf("]link.create %s %s", ns, path)
-	} else {
-		// path -> ]link.create path
-		cmd = fmt.Sprintf("]link.create %s", spec)
+		return fmt.Sprintf("]link.create %s %s", ns, path)
 	}
-	runExpr(client, cmd)
+	// path -> ]link.create path
+	return fmt.Sprintf("]link.create %s", spec)
 }
 
-// runSocket starts a Unix domain socket server for APL expressions
-func runSocket(client *ride.Client, sockPath string) {
-	// Remove stale socket
-	os.Remove(sockPath)
+// reportError tells stderr which expression failed, unless -strict
+func reportError(expr string, errNum int) {
+	fmt.Fprintf(os.Stderr, "gritt: APL error %d in: %s\n", errNum, expr)
+}
 
-	listener, err := net.Listen("unix", sockPath)
+// runScript executes an APL script file a line at a time, as if typed into
+// the session, so a multi-line dfn needs ⎕FIX rather than a bare {. Stops at
+// the first APL error, naming its line.
+func runScript(client *ride.Client, path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Failed to create socket: %v", err)
+		return err
 	}
-	defer listener.Close()
-	defer os.Remove(sockPath)
-
-	// Handle signals for cleanup
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigCh
-		listener.Close()
-		os.Remove(sockPath)
-		os.Exit(0)
-	}()
-
-	fmt.Printf("Listening on %s\n", sockPath)
-
-	var mu sync.Mutex
-	for {
-		conn, err := listener.Accept()
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		errNum, err := runExpr(client, line)
 		if err != nil {
-			// Listener closed (signal handler)
-			return
+			return fmt.Errorf("%s:%d: %v in: %s", path, i+1, err, strings.TrimSpace(line))
+		}
+		if errNum != 0 {
+			return fmt.Errorf("%s:%d: APL error %d in: %s", path, i+1, errNum, strings.TrimSpace(line))
 		}
-
-		go func(c net.Conn) {
-			defer c.Close()
-
-			scanner := bufio.NewScanner(c)
-			for scanner.Scan() {
-				expr := strings.TrimSpace(scanner.Text())
-				if expr == "" {
-					continue
-				}
-
-				// Serialize execution (RIDE is single-threaded)
-				mu.Lock()
-				result := execCapture(client, expr)
-				mu.Unlock()
-
-				c.Write([]byte(result))
-			}
-		}(conn)
 	}
+	return nil
 }
 
-// execCapture executes an expression and returns the result as a string
-func execCapture(client *ride.Client, expr string) string {
-	var buf strings.Builder
+// execTimeout is how long an expression may run in the non-interactive
+// modes before it's interrupted (-timeout); 0 is no limit
+var execTimeout time.Duration
 
-	if err := client.Send("Execute", map[string]any{
-		"trace": 0,
-		"text":  expr + "\n",
-	}); err != nil {
-		return fmt.Sprintf("Execute failed: %v\n", err)
+// execContext is the context one expression runs in, ending at execTimeout
+func execContext() (context.Context, context.CancelFunc) {
+	if execTimeout > 0 {
+		return context.WithTimeout(context.Background(), execTimeout)
 	}
+	return context.WithCancel(context.Background())
+}
 
-	for {
-		msg, _, err := client.Recv()
-		if err != nil {
-			return buf.String() + fmt.Sprintf("Recv failed: %v\n", err)
-		}
-
-		switch msg.Command {
-		case "AppendSessionOutput":
-			if t, ok := msg.Args["type"].(float64); ok && t == 14 {
-				continue
-			}
-			if result, ok := msg.Args["result"].(string); ok {
-				buf.WriteString(result)
-			}
-		case "SetPromptType":
-			// Return on type > 0:
-			// - type 1: ready for input (expression complete)
-			// - type 2: quad input (⎕:)
-			// - type 3: quote-quad input (⍞)
-			// - type 0: no prompt (processing) - keep waiting
-			if t, ok := msg.Args["type"].(float64); ok && t > 0 {
-				return buf.String()
-			}
-		}
+// execError describes why an expression didn't run to its prompt
+func execError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("interrupted after %v", execTimeout)
 	}
+	return err
 }
 
-// runExpr executes an expression and prints the result
-func runExpr(client *ride.Client, expr string) {
-	// Send execute
-	if err := client.Send("Execute", map[string]any{
-		"text":  expr + "\n",
-		"trace": 0,
-	}); err != nil {
-		log.Fatalf("Execute failed: %v", err)
-	}
+// runExpr executes an expression and prints the result. Returns the APL
+// error number if it failed (from HadError), otherwise 0, and an error if
+// the expression timed out or the connection failed.
+func runExpr(client *ride.Client, expr string) (int, error) {
+	ctx, cancel := execContext()
+	defer cancel()
 
 	// Read until we get SetPromptType with type:1 (ready)
-	for {
-		msg, _, err := client.Recv()
-		if err != nil {
-			log.Fatalf("Recv failed: %v", err)
-		}
-
+	errNum := 0
+	err := client.ExecuteFunc(ctx, expr, func(msg *ride.Message) bool {
 		switch msg.Command {
 		case "AppendSessionOutput":
-			// type:14 is input echo, skip it
-			if t, ok := msg.Args["type"].(float64); ok && t == 14 {
-				continue
+			// Skip input echo
+			var out ride.AppendSessionOutput
+			if msg.Unmarshal(&out) == nil && out.Type != ride.OutputEcho {
+				fmt.Print(out.Result)
 			}
-			if result, ok := msg.Args["result"].(string); ok {
-				fmt.Print(result)
+		case "HadError":
+			var e ride.HadError
+			if msg.Unmarshal(&e) == nil {
+				errNum = e.Error
 			}
 		case "SetPromptType":
-			if t, ok := msg.Args["type"].(float64); ok && t == 1 {
-				return // Ready for next input
-			}
+			var p ride.SetPromptType
+			return msg.Unmarshal(&p) == nil && p.Type == 1 // Ready for next input
 		}
-	}
+		return false
+	})
+	return errNum, execError(err)
 }
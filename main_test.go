@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestRunExprError(t *testing.T) {
+	conn, err := ride.NewReplayConn(strings.NewReader(socketSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ride.NewClient(conn)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	if errNum, err := runExpr(client, "⍳5"); errNum != 0 || err != nil {
+		t.Errorf("⍳5: error %d, %v", errNum, err)
+	}
+	if errNum, err := runExpr(client, "1÷0"); errNum != 11 || err != nil {
+		t.Errorf("1÷0: error %d, %v, want 11", errNum, err)
+	}
+}
+
+// hungConn is a ReplayConn whose interpreter stops answering once the
+// recording runs out: reads block until a read deadline is set, then fail
+// as if it had passed
+type hungConn struct {
+	*ride.ReplayConn
+	deadline chan time.Time
+}
+
+func (c *hungConn) Read(p []byte) (int, error) {
+	n, err := c.ReplayConn.Read(p)
+	if err != io.EOF {
+		return n, err
+	}
+	for t := range c.deadline {
+		if !t.IsZero() {
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	return 0, io.EOF
+}
+
+func (c *hungConn) SetReadDeadline(t time.Time) error {
+	c.deadline <- t
+	return nil
+}
+
+func TestRunExprTimeout(t *testing.T) {
+	replay, err := ride.NewReplayConn(strings.NewReader(socketSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &hungConn{ReplayConn: replay, deadline: make(chan time.Time, 2)}
+	client, err := ride.NewClient(conn)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	runExpr(client, "⍳5")
+	runExpr(client, "1÷0")
+
+	defer func(d time.Duration) { execTimeout = d }(execTimeout)
+	execTimeout = 50 * time.Millisecond
+	_, err = runExpr(client, "⎕DL 1000")
+	if err == nil || err.Error() != "interrupted after 50ms" {
+		t.Errorf("hung expression: %v", err)
+	}
+	sent := conn.Sent()
+	if len(sent) == 0 || !strings.Contains(sent[len(sent)-1], "WeakInterrupt") {
+		t.Errorf("last sent %q, want a WeakInterrupt", sent[len(sent)-1])
+	}
+
+	// It never got back to a prompt, so the connection isn't used again
+	if _, err := runExpr(client, "⍳5"); !errors.Is(err, ride.ErrAbandoned) {
+		t.Errorf("after the timeout: %v, want ErrAbandoned", err)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	conn, err := ride.NewReplayConn(strings.NewReader(socketSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ride.NewClient(conn)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	// Blank lines are skipped; the line after the error never runs
+	path := filepath.Join(t.TempDir(), "script.apl")
+	if err := os.WriteFile(path, []byte("⍳5\r\n\n  1÷0\nNever\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = runScript(client, path)
+	if err == nil || err.Error() != path+":3: APL error 11 in: 1÷0" {
+		t.Errorf("runScript error = %v", err)
+	}
+	for _, sent := range conn.Sent() {
+		if strings.Contains(sent, "Never") {
+			t.Error("kept going after the error")
+		}
+	}
+
+	if err := runScript(client, filepath.Join(t.TempDir(), "missing.apl")); err == nil {
+		t.Error("missing script: no error")
+	}
+}
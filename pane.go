@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -59,6 +62,12 @@ type PaneContent interface {
 	Title() string
 }
 
+// Themed is pane content that keeps styles built from the theme; ApplyTheme
+// rebuilds them after the theme changes
+type Themed interface {
+	ApplyTheme()
+}
+
 // Pane represents a floating pane in the TUI
 type Pane struct {
 	ID      string
@@ -78,6 +87,15 @@ type Pane struct {
 	dragStartY  int
 	dragOffsetX int
 	dragOffsetY int
+
+	// Snap state
+	snapped *paneRect // Geometry of the last snap
+	restore *paneRect // Geometry before snapping
+}
+
+// paneRect is a pane's position and size
+type paneRect struct {
+	x, y, w, h int
 }
 
 // NewPane creates a new pane with sensible defaults
@@ -244,9 +262,9 @@ func (p *Pane) Render() string {
 		h, v = "─", "│"
 	}
 
-	// Style borders with Dyalog orange
-	borderStyle := lipgloss.NewStyle().Foreground(AccentColor)
-	titleStyle := lipgloss.NewStyle().Foreground(AccentColor).Bold(true)
+	// Style borders from the theme
+	borderStyle := lipgloss.NewStyle().Foreground(theme.Border)
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
 
 	contentW := p.Width - 2
 	contentH := p.Height - 2
@@ -277,11 +295,15 @@ func (p *Pane) Render() string {
 	if padding < 0 {
 		runes := []rune(title)
 		if len(runes) > contentW-2 {
-			title = string(runes[:contentW-2])
+			title = string(runes[:max(contentW-2, 0)])
 		}
 		padding = 0
 	}
 	topBar := borderStyle.Render(tl+" ") + titleStyle.Render(title) + borderStyle.Render(" "+strings.Repeat(h, padding)+tr)
+	if contentW < 2 {
+		// No room for the spaces round a title
+		topBar = borderStyle.Render(tl + strings.Repeat(h, contentW) + tr)
+	}
 	lines = append(lines, topBar)
 
 	// Content lines
@@ -419,6 +441,37 @@ func (pm *PaneManager) FocusPrev() {
 	}
 }
 
+// FocusDirection moves focus to the nearest pane whose centre lies within 45°
+// of direction (dx, dy), e.g. (-1, 0) for left, measured from the focused
+// pane's centre or, from the session, the screen's. With no pane that way,
+// focus goes back to the session.
+func (pm *PaneManager) FocusDirection(dx, dy int) {
+	centre := func(p *Pane) (int, int) { return 2*p.X + p.Width, 2*p.Y + p.Height } // Doubled to stay in ints
+	fx, fy := pm.screenW, pm.screenH
+	from := pm.FocusedPane()
+	if from != nil {
+		fx, fy = centre(from)
+	}
+	best, bestDist := "", 0
+	for _, id := range pm.zOrder {
+		p := pm.panes[id]
+		if p == nil || p == from {
+			continue
+		}
+		px, py := centre(p)
+		along := (px-fx)*dx + (py-fy)*dy                            // Distance in the wanted direction
+		across := max(px-fx, fx-px)*dy*dy + max(py-fy, fy-py)*dx*dx // Distance to the side
+		if along <= 0 || across > along {
+			continue // Behind, or more to the side than ahead
+		}
+		// Prefer panes straight ahead over ones off to the side
+		if dist := along + 2*across; best == "" || dist < bestDist {
+			best, bestDist = id, dist
+		}
+	}
+	pm.Focus(best)
+}
+
 // FocusedPane returns the currently focused pane
 func (pm *PaneManager) FocusedPane() *Pane {
 	return pm.panes[pm.focusedID]
@@ -436,22 +489,158 @@ func (pm *PaneManager) PaneAt(x, y int) *Pane {
 	return nil
 }
 
-// UpdateSize updates the screen dimensions
+// UpdateSize updates the screen dimensions and refits panes so that after a
+// shrink none is lost off-screen, collapsed, or left over the help line
 func (pm *PaneManager) UpdateSize(w, h int) {
 	pm.screenW = w
 	pm.screenH = h
 
-	// Adjust pane positions to stay visible
+	// Bottom row is the help line
+	availH := max(h-1, 1)
+	for _, pane := range pm.panes {
+		pane.FitTo(max(w, 1), availH)
+	}
+}
+
+// ApplyTheme restyles the panes that keep their own styles, after setTheme
+func (pm *PaneManager) ApplyTheme() {
 	for _, pane := range pm.panes {
-		// Ensure at least 5 chars visible horizontally
-		if pane.X > w-5 {
-			pane.X = w - 5
+		if t, ok := pane.Content.(Themed); ok {
+			t.ApplyTheme()
 		}
-		// Ensure title bar visible
-		if pane.Y >= h {
-			pane.Y = h - 1
+	}
+}
+
+// minPaneSize is the smallest a pane can be drawn: its border round one cell
+const minPaneSize = 3
+
+// FitTo shrinks and moves the pane to lie entirely within a w x h area. It
+// stays at least minPaneSize square, so on a tinier area it hangs off the
+// bottom right.
+func (p *Pane) FitTo(w, h int) {
+	p.Width = clamp(p.Width, minPaneSize, max(w, minPaneSize))
+	p.Height = clamp(p.Height, minPaneSize, max(h, minPaneSize))
+	p.X = clamp(p.X, 0, max(w-p.Width, 0))
+	p.Y = clamp(p.Y, 0, max(h-p.Height, 0))
+}
+
+// PaneLayout is a pane's saved position and size
+type PaneLayout struct {
+	ID     string `json:"id"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// SaveLayout writes the geometry of the open panes that keep accepts to
+// path, bottom to top, creating the directory
+func (pm *PaneManager) SaveLayout(path string, keep func(id string) bool) error {
+	layout := []PaneLayout{}
+	for _, id := range pm.zOrder {
+		if p := pm.panes[id]; p != nil && keep(id) {
+			layout = append(layout, PaneLayout{ID: id, X: p.X, Y: p.Y, Width: p.Width, Height: p.Height})
 		}
 	}
+	data, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadLayout reads a layout saved by SaveLayout, fitting each pane to the
+// current screen in case the terminal has shrunk since
+func (pm *PaneManager) LoadLayout(path string) ([]PaneLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var layout []PaneLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, err
+	}
+	availH := max(pm.screenH-1, 1) // Bottom row is the help line
+	for i, l := range layout {
+		p := Pane{X: l.X, Y: l.Y, Width: l.Width, Height: l.Height}
+		p.FitTo(max(pm.screenW, 1), availH)
+		layout[i] = PaneLayout{ID: l.ID, X: p.X, Y: p.Y, Width: p.Width, Height: p.Height}
+	}
+	return layout, nil
+}
+
+// rect returns the pane's geometry
+func (p *Pane) rect() paneRect {
+	return paneRect{p.X, p.Y, p.Width, p.Height}
+}
+
+// isSnapped reports whether the pane is where it was last snapped to, i.e.
+// hasn't been moved or resized by hand since
+func (p *Pane) isSnapped() bool {
+	return p.snapped != nil && *p.snapped == p.rect()
+}
+
+// snap moves the pane to r, remembering its unsnapped geometry for Restore
+func (p *Pane) snap(r paneRect) {
+	if !p.isSnapped() {
+		before := p.rect()
+		p.restore = &before
+	}
+	p.X, p.Y, p.Width, p.Height = r.x, r.y, r.w, r.h
+	p.snapped = &r
+}
+
+// Restore returns a snapped pane to where it was before snapping
+func (p *Pane) Restore() {
+	if p.restore == nil || !p.isSnapped() {
+		return
+	}
+	r := *p.restore
+	p.X, p.Y, p.Width, p.Height = r.x, r.y, r.w, r.h
+	p.snapped, p.restore = nil, nil
+}
+
+// area returns the space panes can use: the screen above the help line
+func (pm *PaneManager) area() (w, h int) {
+	return max(pm.screenW, 1), max(pm.screenH-1, 1)
+}
+
+// SnapLeft fills the left half of the screen with the pane
+func (pm *PaneManager) SnapLeft(p *Pane) {
+	w, h := pm.area()
+	p.snap(paneRect{0, 0, w / 2, h})
+}
+
+// SnapRight fills the right half of the screen with the pane
+func (pm *PaneManager) SnapRight(p *Pane) {
+	w, h := pm.area()
+	p.snap(paneRect{w / 2, 0, w - w/2, h})
+}
+
+// SnapTop fills the top half of the screen with the pane
+func (pm *PaneManager) SnapTop(p *Pane) {
+	w, h := pm.area()
+	p.snap(paneRect{0, 0, w, h / 2})
+}
+
+// SnapBottom fills the bottom half of the screen with the pane
+func (pm *PaneManager) SnapBottom(p *Pane) {
+	w, h := pm.area()
+	p.snap(paneRect{0, h / 2, w, h - h/2})
+}
+
+// Maximize fills the screen with the pane, or restores a maximized pane
+func (pm *PaneManager) Maximize(p *Pane) {
+	w, h := pm.area()
+	full := paneRect{0, 0, w, h}
+	if p.isSnapped() && *p.snapped == full {
+		p.Restore()
+		return
+	}
+	p.snap(full)
 }
 
 // HasPanes returns true if there are any panes
@@ -486,26 +675,41 @@ func (pm *PaneManager) Render(base string) string {
 		paneBuf := cellbuf.NewBuffer(pane.Width, pane.Height)
 		cellbuf.SetContent(paneBuf, paneStr)
 
-		// Copy cells from pane buffer to main buffer at pane position
-		for dy := 0; dy < pane.Height; dy++ {
-			y := pane.Y + dy
-			if y < 0 || y >= baseH {
+		overlayCells(buf, paneBuf, pane.X, pane.Y)
+	}
+
+	return cellbuf.Render(buf)
+}
+
+// overlayCells copies src onto dst with its top-left corner at (x0, y0),
+// clipped to dst. Wide glyphs are copied whole: their zero-width trailing
+// cells are skipped, since writing one would blank the glyph, and dst blanks
+// any wide glyph of its own that's partly covered.
+func overlayCells(dst, src *cellbuf.Buffer, x0, y0 int) {
+	for dy := 0; dy < src.Height(); dy++ {
+		y := y0 + dy
+		if y < 0 || y >= dst.Height() {
+			continue
+		}
+		for dx := 0; dx < src.Width(); dx++ {
+			x := x0 + dx
+			if x < 0 || x >= dst.Width() {
 				continue
 			}
-			for dx := 0; dx < pane.Width; dx++ {
-				x := pane.X + dx
-				if x < 0 || x >= baseW {
-					continue
-				}
-				cell := paneBuf.Cell(dx, dy)
-				if cell != nil {
-					buf.SetCell(x, y, cell)
+			cell := src.Cell(dx, dy)
+			if cell == nil {
+				continue
+			}
+			if cell.Width == 0 {
+				if x > 0 {
+					continue // Covered by the wide glyph just copied
 				}
+				// The wide glyph is off the left edge: show a blank
+				cell = cell.Clone().Blank()
 			}
+			dst.SetCell(x, y, cell)
 		}
 	}
-
-	return cellbuf.Render(buf)
 }
 
 // zoneToDragMode converts a hit zone to a drag mode
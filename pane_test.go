@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+func TestUpdateSizeShrink(t *testing.T) {
+	pm := NewPaneManager(120, 40)
+	pm.Add(NewPane("big", nil, 10, 5, 100, 30))
+	pm.Add(NewPane("corner", nil, 90, 30, 25, 8))
+	pm.Add(NewPane("offscreen", nil, -50, 2, 60, 10))
+
+	pm.UpdateSize(60, 20)
+
+	for _, id := range []string{"big", "corner", "offscreen"} {
+		p := pm.Get(id)
+		if p == nil {
+			t.Fatalf("pane %q lost after resize", id)
+		}
+		if p.Width < 1 || p.Height < 1 {
+			t.Errorf("pane %q collapsed to %dx%d", id, p.Width, p.Height)
+		}
+		if p.X < 0 || p.X+p.Width > 60 {
+			t.Errorf("pane %q x range [%d,%d) outside screen width 60", id, p.X, p.X+p.Width)
+		}
+		// Row 19 is the help line
+		if p.Y < 0 || p.Y+p.Height > 19 {
+			t.Errorf("pane %q y range [%d,%d) overlaps help line", id, p.Y, p.Y+p.Height)
+		}
+	}
+}
+
+func TestUpdateSizeKeepsFittingPanes(t *testing.T) {
+	pm := NewPaneManager(80, 24)
+	pm.Add(NewPane("p", nil, 5, 3, 30, 10))
+
+	pm.UpdateSize(100, 30)
+
+	p := pm.Get("p")
+	if p.X != 5 || p.Y != 3 || p.Width != 30 || p.Height != 10 {
+		t.Errorf("pane moved/resized on grow: got (%d,%d %dx%d)", p.X, p.Y, p.Width, p.Height)
+	}
+}
+
+func TestUpdateSizeTinyScreen(t *testing.T) {
+	pm := NewPaneManager(80, 24)
+	pm.Add(NewPane("p", NewStackPane(func() []StackFrame { return nil }, nil), 10, 10, 40, 12))
+
+	// Panes stay big enough to draw their border, and draw without their title
+	for _, size := range [][2]int{{3, 2}, {1, 1}} {
+		pm.UpdateSize(size[0], size[1])
+		p := pm.Get("p")
+		if p.Width != 3 || p.Height != 3 || p.X != 0 || p.Y != 0 {
+			t.Errorf("%dx%d screen: got (%d,%d %dx%d), want (0,0 3x3)", size[0], size[1], p.X, p.Y, p.Width, p.Height)
+		}
+		lines := strings.Split(stripANSI(p.Render()), "\n")
+		if len(lines) != 3 || lines[0] != "┌─┐" || lines[2] != "└─┘" {
+			t.Errorf("%dx%d screen: rendered %q", size[0], size[1], lines)
+		}
+	}
+}
+
+func TestFocusDirection(t *testing.T) {
+	pm := NewPaneManager(120, 40)
+	pm.Add(NewPane("left", nil, 0, 10, 20, 10))
+	pm.Add(NewPane("mid", nil, 40, 10, 20, 10))
+	pm.Add(NewPane("right", nil, 80, 12, 20, 10))
+	pm.Add(NewPane("far-up-left", nil, 0, 0, 10, 5))
+	pm.Add(NewPane("below", nil, 42, 25, 20, 10))
+
+	steps := []struct {
+		dx, dy int
+		want   string
+	}{
+		{0, 1, "below"}, // From the session: the screen centre (60, 20)
+		{0, -1, "mid"},
+		{1, 0, "right"},
+		{1, 0, ""}, // Nothing further right: back to the session
+		{1, 0, "right"},
+		{-1, 0, "mid"},
+		{-1, 0, "left"}, // Straight ahead beats the pane up and to the side
+		{0, -1, "far-up-left"},
+		{0, -1, ""},
+	}
+	for i, s := range steps {
+		pm.FocusDirection(s.dx, s.dy)
+		if pm.focusedID != s.want {
+			t.Fatalf("step %d (%d,%d): focused %q, want %q", i, s.dx, s.dy, pm.focusedID, s.want)
+		}
+	}
+}
+
+func TestPaneSnap(t *testing.T) {
+	pm := NewPaneManager(81, 25) // 24 rows above the help line
+	p := NewPane("stack", nil, 50, 2, 30, 15)
+	pm.Add(p)
+
+	geom := func() [4]int { return [4]int{p.X, p.Y, p.Width, p.Height} }
+	steps := []struct {
+		name string
+		do   func()
+		want [4]int
+	}{
+		{"left", func() { pm.SnapLeft(p) }, [4]int{0, 0, 40, 24}},
+		{"right", func() { pm.SnapRight(p) }, [4]int{40, 0, 41, 24}},
+		{"top", func() { pm.SnapTop(p) }, [4]int{0, 0, 81, 12}},
+		{"bottom", func() { pm.SnapBottom(p) }, [4]int{0, 12, 81, 12}},
+		{"restore", p.Restore, [4]int{50, 2, 30, 15}}, // Where it was before the first snap
+		{"maximize", func() { pm.Maximize(p) }, [4]int{0, 0, 81, 24}},
+		{"unmaximize", func() { pm.Maximize(p) }, [4]int{50, 2, 30, 15}},
+	}
+	for _, s := range steps {
+		s.do()
+		if got := geom(); got != s.want {
+			t.Errorf("%s: geometry %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	// Moving a snapped pane by hand makes that the geometry to restore
+	pm.SnapLeft(p)
+	p.X = 5
+	pm.Maximize(p)
+	pm.Maximize(p)
+	if got := geom(); got != [4]int{5, 0, 40, 24} {
+		t.Errorf("after moving a snapped pane: geometry %v, want the moved one", got)
+	}
+}
+
+// textContent is a pane showing fixed text
+type textContent string
+
+func (t textContent) Render(w, h int) string                      { return string(t) }
+func (t textContent) HandleKey(tea.KeyMsg) bool                   { return false }
+func (t textContent) HandleMouse(x, y int, msg tea.MouseMsg) bool { return false }
+func (t textContent) Title() string                               { return "t" }
+
+func TestRenderWideGlyphs(t *testing.T) {
+	pm := NewPaneManager(20, 6)
+	base := strings.Repeat("日本語日本語日本語日本語\n", 5) + "abcdefghijklmnopqrst"
+	// Odd x: both pane edges cut a wide glyph of the base in half
+	pm.Add(NewPane("p", textContent("中文ab"), 3, 1, 10, 4))
+
+	lines := strings.Split(stripANSI(pm.Render(base)), "\r\n")
+	want := []string{
+		"日本語日本語日本語日",
+		"日 ┌ t ─────┐ 本語日",
+		"日 │中文ab  │ 本語日",
+		"日 │        │ 本語日",
+		"日 └────────┘ 本語日",
+		"abcdefghijklmnopqrst",
+	}
+	for i, line := range lines {
+		if lipgloss.Width(line) != 20 {
+			t.Errorf("line %d is %d cells wide: %q", i, lipgloss.Width(line), line)
+		}
+		if i < len(want) && line != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line, want[i])
+		}
+	}
+}
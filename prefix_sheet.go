@@ -0,0 +1,146 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/cellbuf"
+)
+
+// sheetSymbols are the symbols on the prefix cheat sheet, most used first
+var sheetSymbols = []rune(
+	"⍺⍵←→⋄⍝⍳⍴∊⍸⊂⊃⊆↑↓⌽⊖⍉≢≡⌈⌊×÷∣⍟○≠≤≥∧∨⍲⍱⊢⊣⍪⍋⍒⍕⍎∪∩~¨⍨∘⍤⍥⍣⍀⌿⌸⎕∇⍬")
+
+// sheetColumns is how many key → symbol pairs a row of the sheet holds
+const sheetColumns = 8
+
+// sheetEntry is a key and the symbol it types after the prefix
+type sheetEntry struct {
+	key, sym rune
+}
+
+// prefixSheet returns the sheet's entries for the configured layout: the
+// common symbols that have a key, then any other symbols the backtick
+// section adds
+func (c Config) prefixSheet() []sheetEntry {
+	codes := c.backtickKeycodes()
+	var entries []sheetEntry
+	add := func(sym rune) {
+		if code := []rune(codes[sym]); len(code) == 2 {
+			entries = append(entries, sheetEntry{code[1], sym})
+			delete(codes, sym)
+		}
+	}
+	for _, sym := range sheetSymbols {
+		add(sym)
+	}
+	for _, k := range slices.Sorted(maps.Keys(c.Backtick)) {
+		if r, ok := singleRune(k); ok {
+			if sym, ok := c.BacktickSymbol(r); ok {
+				add(sym)
+			}
+		}
+	}
+	return entries
+}
+
+// renderPrefixSheet draws the entries in a bordered grid, each as the key
+// then its symbol
+func renderPrefixSheet(entries []sheetEntry) string {
+	borderStyle := lipgloss.NewStyle().Foreground(theme.Border)
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+	symStyle := lipgloss.NewStyle().Foreground(theme.Symbols).Bold(true)
+
+	// Each cell is " k ⍳ ": the key and symbol, a column wide each
+	const cellW = 5
+	cols := min(sheetColumns, max(len(entries), 1))
+	w := cols * cellW
+
+	lines := []string{borderStyle.Render("┌" + strings.Repeat("─", w) + "┐")}
+	for row := range slices.Chunk(entries, cols) {
+		var sb strings.Builder
+		for _, e := range row {
+			sb.WriteString(" " + keyStyle.Render(string(e.key)) + " " + symStyle.Render(string(e.sym)) + " ")
+		}
+		sb.WriteString(strings.Repeat(" ", (cols-len(row))*cellW))
+		lines = append(lines, borderStyle.Render("│")+sb.String()+borderStyle.Render("│"))
+	}
+	lines = append(lines, borderStyle.Render("└"+strings.Repeat("─", w)+"┘"))
+	return strings.Join(lines, "\n")
+}
+
+// renderPrefixSheetOverlay draws the cheat sheet under the cursor (above it
+// if there's no room below) while the prefix is active
+func (m *Model) renderPrefixSheetOverlay(base string, screenW, screenH int) string {
+	sheet := renderPrefixSheet(m.config.prefixSheet())
+	sheetLines := strings.Split(sheet, "\n")
+	sheetH := len(sheetLines)
+	sheetW := lipgloss.Width(sheetLines[0])
+
+	// Under the cursor of the editor or session being typed in, else
+	// top-right like the autocomplete popup (the palette, and the variables
+	// and debug panes' prompts); symbols go where handleKey's insertTarget
+	// puts them
+	x, y, ok := m.sessionCursorScreenPos()
+	if fp := m.panes.FocusedPane(); fp != nil {
+		switch p := fp.Content.(type) {
+		case *EditorPane:
+			if !p.InTracerMode() {
+				x, y, ok = m.editorCursorScreenPos(p.window.Token)
+			}
+		case *VariablesPane:
+			ok = ok && !p.Editing()
+		case *DebugPane:
+			ok = ok && !p.Editing()
+		case *CommandPalette:
+			ok = false
+		}
+	}
+	sheetX, sheetY := screenW-sheetW-2, 2
+	if ok {
+		sheetX, sheetY = x-1, y+1
+		if sheetY+sheetH > screenH {
+			sheetY = y - sheetH
+		}
+	}
+	sheetX = clamp(sheetX, 0, max(screenW-sheetW, 0))
+	sheetY = max(sheetY, 0)
+
+	buf := cellbuf.NewBuffer(screenW, len(strings.Split(base, "\n")))
+	cellbuf.SetContent(buf, base)
+	sheetBuf := cellbuf.NewBuffer(sheetW, sheetH)
+	cellbuf.SetContent(sheetBuf, sheet)
+	overlayCells(buf, sheetBuf, sheetX, sheetY)
+	return cellbuf.Render(buf)
+}
+
+// sessionCursorScreenPos returns where the session's cursor is drawn, or
+// false if it's scrolled out of view
+func (m *Model) sessionCursorScreenPos() (x, y int, ok bool) {
+	if m.cursorRow < 0 || m.cursorRow >= len(m.lines) {
+		return 0, 0, false
+	}
+	h := m.sessionHeight()
+	runes := []rune(m.lines[m.cursorRow].Text)
+	col := min(m.cursorCol, len(runes))
+
+	// +1s for the session border
+	left := 1 + m.gutterWidth()
+	if m.wrap {
+		row := -1
+		for i, r := range m.wrappedRows(h, wrapWidth(m.sessionWidth())) {
+			if r.line == m.cursorRow && col >= r.a {
+				row, x = i, left+lipgloss.Width(string(runes[r.a:col]))
+			}
+		}
+		return x, 1 + row, row >= 0
+	}
+	row := m.cursorRow - m.sessionStart(h)
+	if row >= h {
+		return 0, 0, false
+	}
+	x = left + lipgloss.Width(string(runes[:col])) - m.sessionColOffset(m.sessionWidth())
+	return x, 1 + row, true
+}
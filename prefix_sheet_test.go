@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrefixSheet(t *testing.T) {
+	cfg := Config{}
+	entries := cfg.prefixSheet()
+	if len(entries) == 0 || entries[0] != (sheetEntry{'a', '⍺'}) {
+		t.Fatalf("entries = %q", entries)
+	}
+
+	// Overrides move symbols to their new keys; added ones go at the end
+	cfg.Backtick = map[string]string{"a": "", "q": "⍺", "w": "ω"}
+	entries = cfg.prefixSheet()
+	if entries[0] != (sheetEntry{'q', '⍺'}) || entries[len(entries)-1] != (sheetEntry{'w', 'ω'}) {
+		t.Errorf("overridden: first %q, last %q", entries[0], entries[len(entries)-1])
+	}
+	for _, e := range entries {
+		if e.sym == '⍵' {
+			t.Errorf("⍵ has no key but is on the sheet as %q", e)
+		}
+	}
+
+	// A grid of sheetColumns per row, with the last row padded
+	lines := strings.Split(stripANSI(renderPrefixSheet(entries)), "\n")
+	if len(lines) != 2+(len(entries)+sheetColumns-1)/sheetColumns {
+		t.Errorf("%d lines for %d entries", len(lines), len(entries))
+	}
+	if !strings.HasPrefix(lines[1], "│ q ⍺  ") {
+		t.Errorf("first row = %q", lines[1])
+	}
+	for _, l := range lines {
+		if w := len([]rune(l)); w != 2+5*sheetColumns {
+			t.Errorf("row %q is %d wide", l, w)
+		}
+	}
+}
+
+func TestPrefixSheetOverlay(t *testing.T) {
+	m := newRideTestModel()
+	m.config.PrefixSheet = true
+	if x, y, ok := m.sessionCursorScreenPos(); !ok || x != 1+len(aplIndent) || y != 1 {
+		t.Errorf("session cursor at %d, %d, %v", x, y, ok)
+	}
+
+	// Shown under the cursor while the prefix is on, gone after the symbol
+	m, _ = typeKeys(m, runeKey('`'))
+	view := strings.Split(stripANSI(m.View()), "\n")
+	if !strings.HasPrefix(view[2], "│"+strings.Repeat(" ", len(aplIndent)-1)+"┌─") || !strings.Contains(view[3], " a ⍺ ") {
+		t.Errorf("sheet not under the cursor:\n%s", strings.Join(view[:4], "\n"))
+	}
+	m, _ = typeKeys(m, runeKey('i'))
+	if strings.Contains(stripANSI(m.View()), " a ⍺ ") {
+		t.Error("sheet still shown after the symbol")
+	}
+
+	// Off, only the help line says the prefix is on
+	m.config.PrefixSheet = false
+	m, _ = typeKeys(m, runeKey('`'))
+	if view := stripANSI(m.View()); strings.Contains(view, " a ⍺ ") || !strings.Contains(view, "` APL symbol") {
+		t.Error("sheet shown with prefix_sheet off")
+	}
+}
@@ -2,28 +2,70 @@ package ride
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Client is a RIDE protocol client connected to Dyalog APL.
+// Client is a RIDE protocol client connected to Dyalog APL. Its methods
+// can be called from several goroutines: each message is written whole,
+// never interleaved with another.
 type Client struct {
 	conn   net.Conn
 	reader *bufio.Reader
 	writer io.Writer
 	mu     sync.Mutex // Protects reads
+	wmu    sync.Mutex // Protects writes
+
+	abandoned atomic.Bool // Closed after an interrupt didn't reach a prompt
 }
 
+// ErrAbandoned is returned by ExecuteFunc once an interrupted execution has
+// failed to reach a prompt within interruptGrace. Its late output, or the
+// rest of a half-read message, would otherwise be taken for the next
+// execution's, so the connection is closed instead; reconnect to go on.
+var ErrAbandoned = errors.New("connection abandoned after an interrupted execution")
+
 // Connect connects to a Dyalog interpreter in SERVE mode and performs handshake.
 func Connect(addr string) (*Client, error) {
 	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("dial: %w", err)
 	}
+	return newRecordedClient(conn)
+}
+
+// ConnectTLS is Connect for an interpreter serving RIDE over TLS. A nil
+// config connects in the clear.
+func ConnectTLS(addr string, config *tls.Config) (*Client, error) {
+	if config == nil {
+		return Connect(addr)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	return newRecordedClient(conn)
+}
+
+// newRecordedClient is NewClient, recording the session if Record is set
+func newRecordedClient(conn net.Conn) (*Client, error) {
+	if Record != nil {
+		conn = RecordConn(conn, Record)
+	}
+	return NewClient(conn)
+}
 
+// NewClient performs the handshake over an existing connection, such as a
+// ReplayConn in tests.
+func NewClient(conn net.Conn) (*Client, error) {
 	c := &Client{
 		conn:   conn,
 		reader: bufio.NewReader(conn),
@@ -48,10 +90,10 @@ func (c *Client) handshake() error {
 	}
 
 	// Send our handshake
-	if err := sendRaw(c.writer, "SupportedProtocols=2"); err != nil {
+	if err := c.SendRaw("SupportedProtocols=2"); err != nil {
 		return err
 	}
-	if err := sendRaw(c.writer, "UsingProtocol=2"); err != nil {
+	if err := c.SendRaw("UsingProtocol=2"); err != nil {
 		return err
 	}
 
@@ -63,10 +105,10 @@ func (c *Client) handshake() error {
 	}
 
 	// Send Identify and Connect
-	if err := Send(c.writer, "Identify", map[string]any{"apiVersion": 1, "identity": 1}); err != nil {
+	if err := c.Send("Identify", map[string]any{"apiVersion": 1, "identity": 1}); err != nil {
 		return err
 	}
-	if err := Send(c.writer, "Connect", map[string]any{"remoteId": 2}); err != nil {
+	if err := c.Send("Connect", map[string]any{"remoteId": 2}); err != nil {
 		return err
 	}
 
@@ -77,25 +119,37 @@ func (c *Client) handshake() error {
 			return fmt.Errorf("waiting for ready: %w", err)
 		}
 		if msg != nil && msg.Command == "SetPromptType" {
-			if t, ok := msg.Args["type"].(float64); ok && t > 0 {
+			var p SetPromptType
+			if msg.Unmarshal(&p) == nil && p.Type > 0 {
 				return nil
 			}
 		}
 	}
 }
 
+// Abandoned reports whether ExecuteFunc gave up on the connection after an
+// interrupt; a new Client is needed to go on.
+func (c *Client) Abandoned() bool {
+	return c.abandoned.Load()
+}
+
 // Close closes the connection.
 func (c *Client) Close() error {
 	return c.conn.Close()
 }
 
-// Send sends a command to the interpreter.
+// Send sends a command to the interpreter. Safe to call concurrently.
 func (c *Client) Send(cmd string, args map[string]any) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 	return Send(c.writer, cmd, args)
 }
 
-// SendRaw sends a raw JSON message to the interpreter.
+// SendRaw sends a raw JSON message to the interpreter. Safe to call
+// concurrently.
 func (c *Client) SendRaw(json string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 	return sendRaw(c.writer, json)
 }
 
@@ -108,35 +162,82 @@ func (c *Client) Recv() (*Message, string, error) {
 	return Recv(c.reader)
 }
 
+// interruptGrace is how long an execution that's been given up on has to
+// reach a prompt after the WeakInterrupt, so the connection is left ready
+// for the next one. Past that the connection is abandoned.
+var interruptGrace = 2 * time.Second
+
 // Execute runs APL code and returns the output.
 // Skips input echo (type 14) and waits for SetPromptType.
 func (c *Client) Execute(code string) ([]string, error) {
-	if err := Send(c.writer, "Execute", map[string]any{"text": code + "\n", "trace": 0}); err != nil {
-		return nil, err
-	}
+	return c.ExecuteContext(context.Background(), code)
+}
 
+// ExecuteContext is Execute, giving up when ctx is done: the interpreter
+// is sent a WeakInterrupt and ctx's error is returned with the output so
+// far.
+func (c *Client) ExecuteContext(ctx context.Context, code string) ([]string, error) {
 	var outputs []string
-	for {
-		msg, _, err := c.Recv()
-		if err != nil {
-			return outputs, err
-		}
-		if msg == nil {
-			continue
-		}
-
+	err := c.ExecuteFunc(ctx, code, func(msg *Message) bool {
 		switch msg.Command {
 		case "AppendSessionOutput":
-			// type 14 is input echo - skip it
-			if t, ok := msg.Args["type"].(float64); ok && t == 14 {
-				continue
-			}
-			if result, ok := msg.Args["result"].(string); ok {
-				outputs = append(outputs, result)
+			// Skip input echo
+			var out AppendSessionOutput
+			if msg.Unmarshal(&out) == nil && out.Type != OutputEcho {
+				outputs = append(outputs, out.Result)
 			}
 		case "SetPromptType":
-			if t, ok := msg.Args["type"].(float64); ok && t > 0 {
-				return outputs, nil
+			var p SetPromptType
+			return msg.Unmarshal(&p) == nil && p.Type > 0
+		}
+		return false
+	})
+	return outputs, err
+}
+
+// ExecuteFunc runs APL code, passing each message that follows to handle
+// until it returns true. When ctx is done first the interpreter is sent a
+// WeakInterrupt, and has interruptGrace to get back to a prompt before the
+// connection is abandoned; either way ctx's error is returned.
+func (c *Client) ExecuteFunc(ctx context.Context, code string, handle func(*Message) bool) error {
+	if c.abandoned.Load() {
+		return ErrAbandoned
+	}
+	if err := c.Send("Execute", map[string]any{"text": code + "\n", "trace": 0}); err != nil {
+		return err
+	}
+
+	fired := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(fired)
+		c.Send("WeakInterrupt", map[string]any{})
+		c.conn.SetReadDeadline(time.Now().Add(interruptGrace))
+	})
+	defer func() {
+		if !stop() {
+			<-fired
+			c.conn.SetReadDeadline(time.Time{})
+		}
+	}()
+
+	for {
+		msg, _, err := c.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				// No prompt in the grace time, maybe mid-message: the
+				// stream can't be trusted for the next execution
+				c.abandoned.Store(true)
+				c.conn.Close()
+				return ctx.Err()
+			}
+			return err
+		}
+		if msg != nil && handle(msg) {
+			select {
+			case <-fired:
+				return ctx.Err() // Interrupted
+			default:
+				return nil
 			}
 		}
 	}
@@ -0,0 +1,124 @@
+package ride
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// exclusiveWriter fails the test if two writes overlap, and is slow enough
+// that they would
+type exclusiveWriter struct {
+	t      *testing.T
+	active atomic.Int32
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (w *exclusiveWriter) Write(p []byte) (int, error) {
+	if w.active.Add(1) > 1 {
+		w.t.Error("concurrent writes")
+	}
+	defer w.active.Add(-1)
+	time.Sleep(100 * time.Microsecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestClientConcurrentSend(t *testing.T) {
+	w := &exclusiveWriter{t: t}
+	c := &Client{writer: w}
+
+	const senders, each = 8, 20
+	var wg sync.WaitGroup
+	for i := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range each {
+				if j%2 == 0 {
+					c.Send("Execute", map[string]any{"text": fmt.Sprintf("%d %d\n", i, j)})
+				} else {
+					c.SendRaw(fmt.Sprintf(`["Echo",{"n":"%d %d"}]`, i, j))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every frame arrives whole
+	seen := make(map[string]bool)
+	for range senders * each {
+		msg, raw, err := Recv(&w.buf)
+		if err != nil || msg == nil {
+			t.Fatalf("frame %d: %v, %q", len(seen), err, raw)
+		}
+		key, _ := msg.Args["text"].(string)
+		if n, ok := msg.Args["n"].(string); ok {
+			key = n
+		}
+		seen[key] = true
+	}
+	if len(seen) != senders*each {
+		t.Errorf("got %d distinct messages", len(seen))
+	}
+	if w.buf.Len() != 0 {
+		t.Errorf("%d bytes left over", w.buf.Len())
+	}
+}
+
+// silentServer does the SERVE side of the handshake over a pipe, then reads
+// everything sent and never answers
+func silentServer(t *testing.T) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		sendRaw(server, "SupportedProtocols=2")
+		for range 2 {
+			Recv(server)
+		}
+		sendRaw(server, "UsingProtocol=2")
+		for range 2 {
+			Recv(server)
+		}
+		Send(server, "SetPromptType", map[string]any{"type": 1})
+		for {
+			if _, _, err := Recv(server); err != nil {
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { server.Close() })
+	return client
+}
+
+func TestExecuteAbandonsAfterGrace(t *testing.T) {
+	saved := interruptGrace
+	interruptGrace = 20 * time.Millisecond
+	t.Cleanup(func() { interruptGrace = saved })
+
+	c, err := NewClient(silentServer(t))
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.ExecuteContext(ctx, "⎕DL 10"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timed out execute: err = %v", err)
+	}
+	if !c.Abandoned() {
+		t.Fatal("no prompt in the grace time, but the connection wasn't abandoned")
+	}
+
+	// Later executions fail rather than read the interrupted one's output
+	if _, err := c.Execute("1+1"); !errors.Is(err, ErrAbandoned) {
+		t.Errorf("after abandoning: err = %v, want ErrAbandoned", err)
+	}
+}
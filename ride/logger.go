@@ -1,6 +1,7 @@
 package ride
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -10,11 +11,28 @@ import (
 // Set this before creating a Client to enable logging.
 var Logger io.Writer
 
+// LogJSON makes Logger write one JSON object per message (see LogEntry)
+// instead of the human-readable format.
+var LogJSON bool
+
+// LogEntry is one protocol message in a JSON log
+type LogEntry struct {
+	Dir  string          `json:"dir"` // "send" or "recv"
+	TS   string          `json:"ts"`  // RFC 3339 with nanoseconds
+	Cmd  string          `json:"cmd,omitempty"`
+	Args json.RawMessage `json:"args,omitempty"`
+	Raw  string          `json:"raw,omitempty"` // Payload verbatim: handshake messages, or every message in a recording
+}
+
 // logSend logs an outgoing message if Logger is set.
 func logSend(cmd string, payload string) {
 	if Logger == nil {
 		return
 	}
+	if LogJSON {
+		logEntry("→", payload)
+		return
+	}
 	ts := time.Now().Format("15:04:05.000")
 	fmt.Fprintf(Logger, "[%s] → %s %s\n", ts, cmd, payload)
 }
@@ -24,6 +42,10 @@ func logRecv(payload string) {
 	if Logger == nil {
 		return
 	}
+	if LogJSON {
+		logEntry("←", payload)
+		return
+	}
 	ts := time.Now().Format("15:04:05.000")
 	fmt.Fprintf(Logger, "[%s] ← %s\n", ts, payload)
 }
@@ -33,6 +55,35 @@ func logRaw(direction, msg string) {
 	if Logger == nil {
 		return
 	}
+	if LogJSON {
+		logEntry(direction, msg)
+		return
+	}
 	ts := time.Now().Format("15:04:05.000")
 	fmt.Fprintf(Logger, "[%s] %s %s\n", ts, direction, msg)
 }
+
+// logEntry writes payload as a LogEntry line. JSON payloads are split into
+// cmd and args; anything else is kept as raw.
+func logEntry(direction, payload string) {
+	entry := LogEntry{Dir: "recv", TS: time.Now().Format(time.RFC3339Nano)}
+	if direction == "→" {
+		entry.Dir = "send"
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &parts); err == nil && len(parts) > 0 &&
+		json.Unmarshal(parts[0], &entry.Cmd) == nil {
+		if len(parts) > 1 {
+			entry.Args = parts[1]
+		}
+	} else {
+		entry.Raw = payload
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	Logger.Write(append(data, '\n'))
+}
@@ -0,0 +1,157 @@
+package ride
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Typed arguments for the commands gritt handles, filled by
+// Message.Unmarshal. Fields tagged ride:"required" must be in the message;
+// the rest are left zero when missing. Commands without a type here are
+// still in Message.Args.
+
+// OutputEcho is the AppendSessionOutput type of echoed input
+const OutputEcho = 14
+
+// Flag is one of RIDE's 0/1 integer flags (true and false are accepted too)
+type Flag bool
+
+func (f *Flag) UnmarshalJSON(data []byte) error {
+	switch s := string(data); s {
+	case "true", "false":
+		*f = s == "true"
+	case "null":
+	default:
+		var n float64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("flag %s: not a number or boolean", s)
+		}
+		*f = n != 0
+	}
+	return nil
+}
+
+// AppendSessionOutput is output (or echoed input) for the session
+type AppendSessionOutput struct {
+	Result string `json:"result" ride:"required"`
+	Type   int    `json:"type"` // OutputEcho for input
+}
+
+// SetPromptType says whether the interpreter is ready: 0 busy, 1 ready,
+// 2 ⎕ input, 3 and 4 ⍞ input
+type SetPromptType struct {
+	Type int `json:"type" ride:"required"`
+}
+
+// HadError follows the output of an expression that failed
+type HadError struct {
+	Error int `json:"error" ride:"required"`
+	DMX   int `json:"dmx"`
+}
+
+// OpenWindow opens an editor, or a tracer when Debugger is set
+type OpenWindow struct {
+	Token      int      `json:"token" ride:"required"`
+	Name       string   `json:"name"`
+	Text       []string `json:"text"`
+	EntityType int      `json:"entityType"`
+	CurrentRow int      `json:"currentRow"`
+	Debugger   Flag     `json:"debugger"`
+	ReadOnly   Flag     `json:"readOnly"`
+	Stop       []int    `json:"stop"`
+	Monitor    []int    `json:"monitor"`
+	Trace      []int    `json:"trace"`
+	Tid        int      `json:"tid"`   // Thread of a tracer's suspended function
+	TName      string   `json:"tname"` // Its name, if it has one
+}
+
+// UpdateWindow refreshes an open window. Fields it leaves out are nil and
+// keep their values.
+type UpdateWindow struct {
+	Token      int      `json:"token" ride:"required"`
+	Text       []string `json:"text"`
+	CurrentRow *int     `json:"currentRow"`
+	Debugger   *Flag    `json:"debugger"`
+	Stop       []int    `json:"stop"`
+}
+
+// CloseWindow closes an editor or tracer window
+type CloseWindow struct {
+	Win int `json:"win" ride:"required"`
+}
+
+// ReplySaveChanges answers SaveChanges; Err is 0 on success
+type ReplySaveChanges struct {
+	Win int `json:"win" ride:"required"`
+	Err int `json:"err"`
+}
+
+// SetHighlightLine moves a tracer's current line
+type SetHighlightLine struct {
+	Win  int `json:"win" ride:"required"`
+	Line int `json:"line" ride:"required"`
+}
+
+// WindowTypeChanged turns an editor into a tracer or back
+type WindowTypeChanged struct {
+	Win    int  `json:"win" ride:"required"`
+	Tracer Flag `json:"tracer" ride:"required"`
+}
+
+// ReplyIdentify answers Identify
+type ReplyIdentify struct {
+	Version string `json:"version"`
+}
+
+// ReplyGetSIStack answers GetSIStack, innermost frame first
+type ReplyGetSIStack struct {
+	Stack []SIStackEntry `json:"stack"`
+}
+
+// SIStackEntry is a frame of the state indicator, e.g. "#.Foo[3]*"
+type SIStackEntry struct {
+	Description string `json:"description"`
+}
+
+// ReplyGetAutocomplete answers GetAutocomplete: Options complete the
+// Skip characters before the cursor
+type ReplyGetAutocomplete struct {
+	Token   int      `json:"token" ride:"required"`
+	Skip    int      `json:"skip"`
+	Options []string `json:"options"`
+}
+
+// Unmarshal fills v, a pointer to one of the typed messages (or any struct
+// of the same form), from the message's arguments. A required field that's
+// missing, or any field of the wrong type, is an error.
+func (m *Message) Unmarshal(v any) error {
+	raw := m.rawArgs
+	if raw == nil {
+		var err error
+		if raw, err = json.Marshal(m.Args); err != nil {
+			return fmt.Errorf("%s: %w", m.Command, err)
+		}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: %w", m.Command, err)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Elem().Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if f.Tag.Get("ride") != "required" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if arg, ok := m.Args[name]; !ok || arg == nil {
+			return fmt.Errorf("%s: missing %s", m.Command, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package ride
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recv decodes a message as it would arrive on the wire
+func recv(t *testing.T, payload string) *Message {
+	t.Helper()
+	var stream bytes.Buffer
+	sendRaw(&stream, payload)
+	msg, _, err := Recv(&stream)
+	if err != nil || msg == nil {
+		t.Fatalf("Recv(%s) = %v, %v", payload, msg, err)
+	}
+	return msg
+}
+
+func TestMessageUnmarshal(t *testing.T) {
+	var open OpenWindow
+	msg := recv(t, `["OpenWindow",{"token":3,"name":"Foo","text":["Foo","1"],"debugger":1,"readOnly":false,"stop":[1],"extra":"ignored"}]`)
+	if err := msg.Unmarshal(&open); err != nil {
+		t.Fatal(err)
+	}
+	want := OpenWindow{Token: 3, Name: "Foo", Text: []string{"Foo", "1"}, Debugger: true, Stop: []int{1}}
+	if !reflect.DeepEqual(open, want) {
+		t.Errorf("OpenWindow = %+v, want %+v", open, want)
+	}
+
+	// Fields UpdateWindow leaves out stay nil
+	var update UpdateWindow
+	if err := recv(t, `["UpdateWindow",{"token":3,"stop":[]}]`).Unmarshal(&update); err != nil {
+		t.Fatal(err)
+	}
+	if update.Text != nil || update.CurrentRow != nil || update.Debugger != nil || update.Stop == nil {
+		t.Errorf("UpdateWindow = %+v", update)
+	}
+
+	// Built by hand, as in tests, rather than received
+	var hl SetHighlightLine
+	built := &Message{Command: "SetHighlightLine", Args: map[string]any{"win": float64(2), "line": float64(5)}}
+	if err := built.Unmarshal(&hl); err != nil || hl != (SetHighlightLine{Win: 2, Line: 5}) {
+		t.Errorf("SetHighlightLine = %+v, %v", hl, err)
+	}
+}
+
+func TestMessageUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		payload string
+		v       any
+		want    string
+	}{
+		{`["CloseWindow",{}]`, &CloseWindow{}, "missing win"},
+		{`["CloseWindow",{"win":null}]`, &CloseWindow{}, "missing win"},
+		{`["SetHighlightLine",{"win":1}]`, &SetHighlightLine{}, "missing line"},
+		{`["CloseWindow",{"win":"3"}]`, &CloseWindow{}, "cannot unmarshal"},
+		{`["SetPromptType",{"type":1.5}]`, &SetPromptType{}, "cannot unmarshal"},
+		{`["WindowTypeChanged",{"win":1,"tracer":"yes"}]`, &WindowTypeChanged{}, "not a number or boolean"},
+		{`["ReplyGetAutocomplete",{"token":1,"options":["a",2]}]`, &ReplyGetAutocomplete{}, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		err := recv(t, tt.payload).Unmarshal(tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v, want %q", tt.payload, err, tt.want)
+		}
+	}
+}
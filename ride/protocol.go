@@ -19,50 +19,82 @@ import (
 
 const rideHeader = "RIDE"
 
-// sendRaw writes a raw RIDE message (payload includes "RIDE" prefix for handshake messages).
-func sendRaw(w io.Writer, payload string) error {
+// maxFrameLength caps the length a frame can claim, so a corrupt one can't
+// make recvRaw allocate gigabytes
+const maxFrameLength = 10 * 1024 * 1024
+
+// FrameError is a frame that isn't RIDE: its length is impossible or its
+// header missing. The stream is out of step after one, so the connection
+// is no use; a clean close is io.EOF instead.
+type FrameError struct {
+	Length uint32
+	Reason string
+}
+
+func (e *FrameError) Error() string {
+	return fmt.Sprintf("bad RIDE frame (length %d): %s", e.Length, e.Reason)
+}
+
+// frame encodes a payload as it appears on the wire
+func frame(payload string) []byte {
 	data := []byte(rideHeader + payload)
-	length := uint32(len(data) + 4)
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)+4))
+	return append(out, data...)
+}
 
-	if err := binary.Write(w, binary.BigEndian, length); err != nil {
-		return fmt.Errorf("write length: %w", err)
-	}
-	if _, err := w.Write(data); err != nil {
-		return fmt.Errorf("write payload: %w", err)
+// sendRaw writes a raw RIDE message (payload includes "RIDE" prefix for handshake messages).
+// The frame goes in one Write, so a writer that serializes writes never
+// interleaves two frames.
+func sendRaw(w io.Writer, payload string) error {
+	if _, err := w.Write(frame(payload)); err != nil {
+		return fmt.Errorf("write: %w", err)
 	}
 	logRaw("→", payload)
 	return nil
 }
 
 // recvRaw reads a raw RIDE message, returning the payload (without "RIDE" prefix).
+// A connection closed between frames gives io.EOF, and one closed partway
+// through a frame io.ErrUnexpectedEOF, both wrapped; a malformed frame
+// gives a *FrameError.
 func recvRaw(r io.Reader) (string, error) {
 	var length uint32
 	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
 		return "", fmt.Errorf("read length: %w", err)
 	}
 
-	// Sanity check - messages shouldn't be huge
-	if length < 8 || length > 10*1024*1024 {
-		return "", fmt.Errorf("invalid length: %d", length)
+	// The length counts itself and the header, and messages shouldn't be huge
+	switch {
+	case length < 8:
+		return "", &FrameError{length, "shorter than its header"}
+	case length > maxFrameLength:
+		return "", &FrameError{length, fmt.Sprintf("over the %d byte limit", maxFrameLength)}
 	}
 
 	buf := make([]byte, length-4)
 	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF // The length was read, so the frame is cut short
+		}
 		return "", fmt.Errorf("read payload: %w", err)
 	}
 
 	s := string(buf)
-	if strings.HasPrefix(s, rideHeader) {
-		s = s[4:]
+	if !strings.HasPrefix(s, rideHeader) {
+		return "", &FrameError{length, fmt.Sprintf("no %q header", rideHeader)}
 	}
+	s = s[len(rideHeader):]
 	logRecv(s)
 	return s, nil
 }
 
-// Message is a RIDE protocol message: ["Command", {args}]
+// Message is a RIDE protocol message: ["Command", {args}]. Args holds the
+// arguments for any command; Unmarshal decodes them into a typed message.
 type Message struct {
 	Command string
 	Args    map[string]any
+
+	rawArgs json.RawMessage // As received, for Unmarshal
 }
 
 // Send writes a JSON command message.
@@ -105,5 +137,5 @@ func Recv(r io.Reader) (*Message, string, error) {
 		return nil, payload, nil
 	}
 
-	return &Message{Command: cmd, Args: args}, "", nil
+	return &Message{Command: cmd, Args: args, rawArgs: arr[1]}, "", nil
 }
@@ -0,0 +1,66 @@
+package ride
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// rawFrame is a frame claiming length, followed by body
+func rawFrame(length uint32, body string) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, length), body...)
+}
+
+func TestRecvFrames(t *testing.T) {
+	var stream bytes.Buffer
+	Send(&stream, "Execute", map[string]any{"text": "⍳5\n"})
+	sendRaw(&stream, "UsingProtocol=2")
+
+	msg, _, err := Recv(&stream)
+	if err != nil || msg == nil || msg.Command != "Execute" || msg.Args["text"] != "⍳5\n" {
+		t.Fatalf("Recv = %+v, %v", msg, err)
+	}
+	if _, raw, err := Recv(&stream); err != nil || raw != "UsingProtocol=2" {
+		t.Fatalf("handshake Recv = %q, %v", raw, err)
+	}
+	if _, _, err := Recv(&stream); err == nil || !errors.Is(err, io.EOF) {
+		t.Errorf("Recv at the end = %v, want EOF", err)
+	}
+
+	// An empty payload is a valid, if useless, frame
+	if raw, err := recvRaw(bytes.NewReader(rawFrame(8, "RIDE"))); err != nil || raw != "" {
+		t.Errorf("empty frame = %q, %v", raw, err)
+	}
+}
+
+func TestRecvMalformedFrames(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		frame bool // A *FrameError, rather than a cut-short stream
+	}{
+		{"length 0", rawFrame(0, ""), true},
+		{"length 3", rawFrame(3, "RID"), true},
+		{"length 7", rawFrame(7, "RIDE"), true},
+		{"oversized", rawFrame(maxFrameLength+1, "RIDE"), true},
+		{"huge", rawFrame(0xffffffff, "RIDE"), true},
+		{"no header", rawFrame(12, "JSON[12]"), true},
+		{"partial length", []byte{0, 0}, false},
+		{"no payload", rawFrame(12, ""), false},
+		{"truncated payload", rawFrame(20, "RIDE[\"Ex"), false},
+	}
+	for _, tt := range tests {
+		_, err := recvRaw(bytes.NewReader(tt.data))
+		var fe *FrameError
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error", tt.name)
+		case tt.frame && !errors.As(err, &fe):
+			t.Errorf("%s: %v, want a FrameError", tt.name, err)
+		case !tt.frame && !errors.Is(err, io.ErrUnexpectedEOF):
+			t.Errorf("%s: %v, want unexpected EOF", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package ride
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is an optional writer for recording sessions. When set, Connect
+// wraps the connection with RecordConn so the session can be replayed.
+var Record io.Writer
+
+// frameReader splits a RIDE byte stream into message payloads
+type frameReader struct {
+	buf []byte
+}
+
+// feed adds bytes and returns the payloads of any complete messages
+// (without the "RIDE" prefix)
+func (f *frameReader) feed(p []byte) []string {
+	f.buf = append(f.buf, p...)
+	var payloads []string
+	for len(f.buf) >= 4 {
+		length := int(binary.BigEndian.Uint32(f.buf))
+		if length < 4 || len(f.buf) < length {
+			break
+		}
+		payloads = append(payloads, strings.TrimPrefix(string(f.buf[4:length]), rideHeader))
+		f.buf = f.buf[length:]
+	}
+	return payloads
+}
+
+// recordConn is a net.Conn that records every message passing through it
+type recordConn struct {
+	net.Conn
+	mu         sync.Mutex
+	w          io.Writer
+	recv, sent frameReader
+}
+
+// RecordConn wraps conn, writing each message sent or received to w as a
+// LogEntry line with the payload verbatim in Raw. ReplayConn replays it.
+func RecordConn(conn net.Conn, w io.Writer) net.Conn {
+	return &recordConn{Conn: conn, w: w}
+}
+
+func (r *recordConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	if n > 0 {
+		r.record("recv", r.recv.feed(p[:n]))
+	}
+	return n, err
+}
+
+func (r *recordConn) Write(p []byte) (int, error) {
+	n, err := r.Conn.Write(p)
+	if n > 0 {
+		r.record("send", r.sent.feed(p[:n]))
+	}
+	return n, err
+}
+
+func (r *recordConn) record(dir string, payloads []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, payload := range payloads {
+		data, err := json.Marshal(LogEntry{Dir: dir, TS: time.Now().Format(time.RFC3339Nano), Raw: payload})
+		if err != nil {
+			continue
+		}
+		r.w.Write(append(data, '\n'))
+	}
+}
+
+// ReplayConn is a net.Conn that plays back the received messages of a
+// recorded session, for testing without an interpreter. Reads return the
+// recorded messages in order, then io.EOF. Writes are kept for inspection
+// with Sent.
+type ReplayConn struct {
+	mu     sync.Mutex
+	recv   bytes.Buffer
+	sent   frameReader
+	frames []string
+	closed bool
+}
+
+// NewReplayConn reads a JSON-lines recording (from RecordConn or -logjson)
+// and returns a connection that replays its received messages
+func NewReplayConn(r io.Reader) (*ReplayConn, error) {
+	c := &ReplayConn{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 10*1024*1024) // Messages can be large
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if entry.Dir != "recv" {
+			continue
+		}
+		payload, err := entry.payload()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c.recv.Write(frame(payload))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// payload rebuilds the message payload an entry was logged from
+func (e LogEntry) payload() (string, error) {
+	if e.Raw != "" || e.Cmd == "" {
+		return e.Raw, nil
+	}
+	args := e.Args
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	data, err := json.Marshal([]any{e.Cmd, args})
+	return string(data), err
+}
+
+func (c *ReplayConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.recv.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.recv.Read(p)
+}
+
+func (c *ReplayConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.frames = append(c.frames, c.sent.feed(p)...)
+	return len(p), nil
+}
+
+// Sent returns the payloads written so far, in order
+func (c *ReplayConn) Sent() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.frames...)
+}
+
+func (c *ReplayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *ReplayConn) LocalAddr() net.Addr                { return replayAddr{} }
+func (c *ReplayConn) RemoteAddr() net.Addr               { return replayAddr{} }
+func (c *ReplayConn) SetDeadline(t time.Time) error      { return nil }
+func (c *ReplayConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *ReplayConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// replayAddr is the address of a ReplayConn
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
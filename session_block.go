@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A block is several lines of input composed before running any of them:
+// new_line starts another line under the input line, and the lines above it
+// wait, unsent, until Enter sends them in turn or execute_block joins them
+// with ⋄ into one statement. Either way each stays in the session as its own
+// line of history. Enter with a { still open starts a new line too, so a
+// dfn can be typed over several lines; it's sent joined once it's closed.
+
+// newBlockLine starts another line of a block under the input line
+func (m *Model) newBlockLine() {
+	last := len(m.lines) - 1
+	if m.cursorRow != last || last < 0 {
+		m.statusMsg = "New lines go after the input line"
+		return
+	}
+	if strings.TrimSpace(m.lines[last].Text) == "" {
+		return
+	}
+	m.lines[last].Input = true
+	m.lines = append(m.lines, Line{Text: aplIndent})
+	m.blockLines++
+	m.cursorRow = len(m.lines) - 1
+	m.cursorCol = len(aplIndent)
+}
+
+// inBlock reports whether the cursor is on a line of the pending block
+func (m Model) inBlock() bool {
+	return m.blockLines > 0 && m.cursorRow >= len(m.lines)-1-m.blockLines
+}
+
+// braceDepth is how many more { than } a line of APL has, outside strings
+// and comments
+func braceDepth(code string) int {
+	runes := []rune(code)
+	depth := 0
+	for _, tok := range lexAPL(runes) {
+		if tok.kind != TokenPrimitive {
+			continue
+		}
+		switch runes[tok.start] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// openBraces is the brace depth at the end of the block and input line
+func (m Model) openBraces() int {
+	depth := 0
+	for _, line := range m.lines[len(m.lines)-1-m.blockLines:] {
+		depth += braceDepth(line.Text)
+	}
+	return depth
+}
+
+// spansLines reports whether a dfn is open across a line break, so the
+// lines can't be run one at a time
+func spansLines(codes []string) bool {
+	depth := 0
+	for _, code := range codes[:len(codes)-1] {
+		if depth += braceDepth(code); depth > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComment drops a trailing ⍝ comment, which would swallow the
+// statements joined after it
+func stripComment(code string) string {
+	runes := []rune(code)
+	tokens := lexAPL(runes)
+	if n := len(tokens); n > 0 && tokens[n-1].kind == TokenComment {
+		return strings.TrimSpace(string(runes[:tokens[n-1].start]))
+	}
+	return code
+}
+
+// joinBlock joins lines of input into one ⋄-separated statement, or fails if
+// one of them is a )command or ]command, which must be alone on its line.
+// There's no ⋄ just inside a dfn's braces: f←{, ⍵+1 and } join as f←{ ⍵+1 }.
+func joinBlock(codes []string) (string, bool) {
+	var sb strings.Builder
+	for _, code := range codes {
+		if tokens := lexAPL([]rune(code)); len(tokens) > 0 && tokens[0].kind == TokenCommand {
+			return "", false
+		}
+		if code = stripComment(code); code == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			if strings.HasSuffix(sb.String(), "{") || strings.HasPrefix(code, "}") {
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString(" ⋄ ")
+			}
+		}
+		sb.WriteString(code)
+	}
+	return sb.String(), true
+}
+
+// executeBlock runs the pending block and the input line under it: as one
+// statement if joined or if a dfn spans its lines, otherwise a line at a time
+func (m Model) executeBlock(joined bool) (tea.Model, tea.Cmd) {
+	if !m.ready {
+		m.log("Execute blocked: not ready")
+		return m, nil
+	}
+	last := len(m.lines) - 1
+	start := last - m.blockLines
+	var codes []string
+	for _, line := range m.lines[start:] {
+		if code := strings.TrimSpace(line.Text); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return m, nil
+	}
+
+	var text string
+	if joined || spansLines(codes) {
+		stmt, ok := joinBlock(codes)
+		if !ok {
+			m.statusMsg = "Commands can't be joined with ⋄; Enter runs the lines in turn"
+			return m, nil
+		}
+		text = aplIndent + stmt + "\n"
+	} else {
+		for _, code := range codes {
+			text += aplIndent + code + "\n"
+		}
+	}
+
+	for i := start; i <= last; i++ {
+		m.lines[i].Input = true
+		m.lines[i].Edited = false
+		m.lines[i].Original = ""
+	}
+	m.blockLines = 0
+	m.cursorRow = last
+	m.cursorCol = len([]rune(m.lines[last].Text))
+
+	m.ready = false
+	m.expectEcho(text)
+	m.pendingQuit = slices.Contains(codes, ")off")
+	m.log("→ Execute %q", text)
+	m.tutorialEvent(TutorialExecute)
+	m.send("Execute", map[string]any{"text": text, "trace": 0}) // Disconnect handled by send()
+	return m, nil
+}
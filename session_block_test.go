@@ -0,0 +1,167 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeBlock types lines into the session, starting a new line of the block
+// between them with Ctrl+J
+func typeBlock(t *testing.T, m Model, lines ...string) Model {
+	t.Helper()
+	for i, line := range lines {
+		if i > 0 {
+			next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyCtrlJ})
+			m = next.(Model)
+		}
+		for _, r := range line {
+			m.insertChar(r)
+		}
+	}
+	return m
+}
+
+func TestExecuteBlockJoined(t *testing.T) {
+	m := typeBlock(t, newRideTestModel(), "a←1", "b←2 ⍝ two", "a+b")
+	if m.blockLines != 2 || m.cursorRow != 2 {
+		t.Fatalf("blockLines = %d, cursorRow = %d", m.blockLines, m.cursorRow)
+	}
+
+	next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	m = next.(Model)
+
+	if want := []string{"a←1 ⋄ b←2 ⋄ a+b"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sent %q, want %q", m.sentInput, want)
+	}
+	// Each line stays in the session as history
+	for i, want := range []string{aplIndent + "a←1", aplIndent + "b←2 ⍝ two", aplIndent + "a+b"} {
+		if m.lines[i].Text != want || !m.lines[i].Input || m.lines[i].Edited {
+			t.Errorf("line %d = %+v, want input %q", i, m.lines[i], want)
+		}
+	}
+	if m.blockLines != 0 || m.ready {
+		t.Errorf("after execute: blockLines = %d, ready = %v", m.blockLines, m.ready)
+	}
+
+	// The echo of the joined statement isn't shown
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": aplIndent + "a←1 ⋄ b←2 ⋄ a+b\n", "type": float64(14)}))
+	if len(m.lines) != 3 {
+		t.Errorf("echo shown: %q", sessionText(m))
+	}
+}
+
+func TestExecuteBlockLines(t *testing.T) {
+	m := typeBlock(t, newRideTestModel(), "a←1", "a+1")
+
+	// Enter on a line of the block runs every line, in turn
+	m.cursorRow = 0
+	next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+
+	if want := []string{"a←1", "a+1"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sent %q, want %q", m.sentInput, want)
+	}
+	if m.cursorRow != len(m.lines)-1 || m.blockLines != 0 {
+		t.Errorf("cursorRow = %d, blockLines = %d", m.cursorRow, m.blockLines)
+	}
+}
+
+func TestExecuteBlockCommand(t *testing.T) {
+	m := typeBlock(t, newRideTestModel(), ")clear", "a←1")
+	next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	m = next.(Model)
+
+	if len(m.sentInput) != 0 || !m.ready || m.blockLines != 1 {
+		t.Errorf("command joined: sent %q, ready = %v, blockLines = %d", m.sentInput, m.ready, m.blockLines)
+	}
+	if m.statusMsg == "" {
+		t.Error("no message explaining why the block wasn't run")
+	}
+}
+
+func TestNewBlockLine(t *testing.T) {
+	m := newRideTestModel()
+
+	// Nothing to continue from an empty input line
+	next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	m = next.(Model)
+	if len(m.lines) != 1 || m.blockLines != 0 {
+		t.Fatalf("empty line: lines = %d, blockLines = %d", len(m.lines), m.blockLines)
+	}
+
+	// Only the input line starts a new one
+	m = typeBlock(t, m, "a←1")
+	m.lines = append([]Line{{Text: aplIndent + "⍳3", Input: true}}, m.lines...)
+	m.cursorRow = 0
+	next, _ = m.handleSessionKey(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	m = next.(Model)
+	if len(m.lines) != 2 || m.blockLines != 0 || m.statusMsg == "" {
+		t.Errorf("history line: lines = %d, blockLines = %d, status %q", len(m.lines), m.blockLines, m.statusMsg)
+	}
+}
+
+func TestJoinBlock(t *testing.T) {
+	tests := []struct {
+		codes []string
+		want  string
+		ok    bool
+	}{
+		{[]string{"a←1", "a+1"}, "a←1 ⋄ a+1", true},
+		{[]string{"a←1 ⍝ one", "⍝ just a comment", "a"}, "a←1 ⋄ a", true},
+		{[]string{"s←'⍝ not a comment'", "s"}, "s←'⍝ not a comment' ⋄ s", true},
+		{[]string{"f←{", "⍵+1", "}"}, "f←{ ⍵+1 }", true},
+		{[]string{"a←1", "]link.status"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := joinBlock(tt.codes)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("joinBlock(%q) = %q, %v, want %q, %v", tt.codes, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExecuteDfnOverLines(t *testing.T) {
+	m := newRideTestModel()
+	enter := func(line string) {
+		t.Helper()
+		m = typeBlock(t, m, line)
+		next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyEnter})
+		m = next.(Model)
+	}
+
+	enter("f←{")
+	enter("a←⍵+1 ⍝ '}'")
+	enter("{⍵×2}a")
+	if len(m.sentInput) != 0 || m.blockLines != 3 {
+		t.Fatalf("open dfn sent: %q, blockLines = %d", m.sentInput, m.blockLines)
+	}
+
+	enter("}")
+	if want := []string{"f←{ a←⍵+1 ⋄ {⍵×2}a }"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sent %q, want %q", m.sentInput, want)
+	}
+	if len(m.lines) != 4 || m.blockLines != 0 {
+		t.Errorf("lines = %q, blockLines = %d", sessionText(m), m.blockLines)
+	}
+}
+
+func TestBraceDepth(t *testing.T) {
+	tests := []struct {
+		code string
+		want int
+	}{
+		{"f←{⍵+1}", 0},
+		{"f←{", 1},
+		{"{⍺{", 2},
+		{"}", -1},
+		{"s←'{'", 0},
+		{"x ⍝ {", 0},
+	}
+	for _, tt := range tests {
+		if got := braceDepth(tt.code); got != tt.want {
+			t.Errorf("braceDepth(%q) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
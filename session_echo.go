@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+)
+
+// maxSentInput bounds the lines kept waiting for their echo, in case the
+// interpreter never echoes some of them
+const maxSentInput = 32
+
+// echoLines splits input or an echo of it into logical lines: trimmed, with
+// the trailing newline dropped
+func echoLines(text string) []string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+	}
+	return lines
+}
+
+// expectEcho records input sent via Execute, so the interpreter's echo of it
+// (AppendSessionOutput type 14) isn't shown twice
+func (m *Model) expectEcho(text string) {
+	m.sentInput = append(m.sentInput, echoLines(text)...)
+	if n := len(m.sentInput) - maxSentInput; n > 0 {
+		m.sentInput = m.sentInput[n:]
+	}
+}
+
+// matchEcho reports whether an echo is of input we sent, consuming the lines
+// it covers. Echoes may be of several lines at once or one at a time; lines
+// sent before the match were never echoed and are dropped.
+func (m *Model) matchEcho(result string) bool {
+	lines := echoLines(result)
+	for i := 0; i+len(lines) <= len(m.sentInput); i++ {
+		match := true
+		for j, line := range lines {
+			if m.sentInput[i+j] != line {
+				match = false
+				break
+			}
+		}
+		if match {
+			m.sentInput = m.sentInput[i+len(lines):]
+			return true
+		}
+	}
+	return false
+}
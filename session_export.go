@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+)
+
+// exportFormat is a way of saving the session
+type exportFormat int
+
+const (
+	exportTranscript exportFormat = iota // Everything, as shown
+	exportScript                         // Input lines only, unindented, to re-run with -f
+	exportMarkdown                       // Input and output in fenced blocks
+	exportFormatCount
+)
+
+func (f exportFormat) String() string {
+	switch f {
+	case exportScript:
+		return "script"
+	case exportMarkdown:
+		return "markdown"
+	}
+	return "transcript"
+}
+
+// ext is the file extension for the format
+func (f exportFormat) ext() string {
+	switch f {
+	case exportScript:
+		return ".apl"
+	case exportMarkdown:
+		return ".md"
+	}
+	return ".txt"
+}
+
+// exportSession renders session lines in the given format
+func exportSession(lines []Line, format exportFormat) string {
+	var sb strings.Builder
+	switch format {
+	case exportScript:
+		for _, line := range lines {
+			if line.Input {
+				sb.WriteString(strings.TrimPrefix(line.Text, aplIndent))
+				sb.WriteString("\n")
+			}
+		}
+
+	case exportMarkdown:
+		// Runs of input and of output each become a block
+		type block struct {
+			input bool
+			lines []string
+		}
+		var blocks []block
+		for _, line := range lines {
+			text := line.Text
+			if line.Input {
+				text = strings.TrimPrefix(text, aplIndent)
+			}
+			if n := len(blocks); n > 0 && blocks[n-1].input == line.Input {
+				blocks[n-1].lines = append(blocks[n-1].lines, text)
+			} else {
+				blocks = append(blocks, block{input: line.Input, lines: []string{text}})
+			}
+		}
+		first := true
+		for _, b := range blocks {
+			// Blank lines and empty prompts aren't worth a block
+			text := strings.TrimLeft(strings.TrimRight(strings.Join(b.lines, "\n"), " \n"), "\n")
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			if !first {
+				sb.WriteString("\n")
+			}
+			first = false
+			fence := "```"
+			if b.input {
+				fence = "```apl"
+			}
+			sb.WriteString(fence + "\n" + text + "\n```\n")
+		}
+
+	default:
+		for _, line := range lines {
+			sb.WriteString(line.Text)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportSession(t *testing.T) {
+	m := newRideTestModel()
+	r, _ := m.runInSession("⍳3")
+	m = applyAll(r.(Model),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "1 2 3\n", "type": 2.0}),
+		rideMsg("SetPromptType", map[string]any{"type": 1.0}),
+		rideMsg("AppendSessionOutput", map[string]any{"result": "      x←5\n", "type": 14.0}), // From another client
+		rideMsg("SetPromptType", map[string]any{"type": 1.0}),
+	)
+
+	tests := []struct {
+		format exportFormat
+		want   string
+	}{
+		{exportTranscript, "      ⍳3\n1 2 3\n      \n      x←5\n      \n"},
+		{exportScript, "⍳3\nx←5\n"},
+		{exportMarkdown, "```apl\n⍳3\n```\n\n```\n1 2 3\n```\n\n```apl\nx←5\n```\n"},
+	}
+	for _, tt := range tests {
+		if got := exportSession(m.lines, tt.format); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestSaveFormatCycle(t *testing.T) {
+	m := newRideTestModel()
+	m.saveSession()
+	if !strings.HasSuffix(m.savePromptFilename, ".txt") {
+		t.Fatalf("default filename = %q, want a .txt transcript", m.savePromptFilename)
+	}
+	base := strings.TrimSuffix(m.savePromptFilename, ".txt")
+	for _, want := range []string{".apl", ".md", ".txt"} {
+		m.cycleSaveFormat()
+		if m.savePromptFilename != base+want {
+			t.Errorf("after tab: %q, want %q", m.savePromptFilename, base+want)
+		}
+	}
+
+	// A typed extension is left alone
+	m.savePromptFilename = "demo.log"
+	m.cycleSaveFormat()
+	if m.savePromptFilename != "demo.log" || m.savePromptFormat != exportScript {
+		t.Errorf("custom name = %q (%s)", m.savePromptFilename, m.savePromptFormat)
+	}
+}
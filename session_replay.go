@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replayDelay is the pause before each line of a replayed script, so it
+// can be followed as it runs
+const replayDelay = 300 * time.Millisecond
+
+// replayStepMsg runs the next line of the script being replayed
+type replayStepMsg struct{}
+
+// scriptLine is a line of a script and its line number in the file
+type scriptLine struct {
+	n    int
+	text string
+}
+
+// scriptReplay is a script file being run in the session a line at a time,
+// each line sent once the interpreter is ready for it
+type scriptReplay struct {
+	path   string
+	lines  []scriptLine // Still to run
+	total  int
+	last   int  // Line number last sent
+	paused bool // Stopped at an error
+}
+
+// loadScriptReplay reads a script for replay, skipping blank lines as -f does
+func loadScriptReplay(path string) (*scriptReplay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &scriptReplay{path: path}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		r.lines = append(r.lines, scriptLine{n: i + 1, text: line})
+	}
+	r.total = len(r.lines)
+	return r, nil
+}
+
+// replayTick schedules the next line of the replay
+func replayTick() tea.Cmd {
+	return tea.Tick(replayDelay, func(time.Time) tea.Msg { return replayStepMsg{} })
+}
+
+// startReplay begins replaying a script file (palette "replay")
+func (m Model) startReplay(path string) (tea.Model, tea.Cmd) {
+	r, err := loadScriptReplay(path)
+	if err != nil {
+		m.log("Replay: %v", err)
+		m.statusMsg = "Can't replay: " + err.Error()
+		return m, nil
+	}
+	m.replay = r
+	m.log("Replaying %s (%d lines)", path, r.total)
+	return m, replayTick()
+}
+
+// replayStep sends the next line of the replay once the interpreter is
+// ready for it, trying again a tick later if it's busy. The prompt that
+// follows schedules the line after (replayNext).
+func (m Model) replayStep() (tea.Model, tea.Cmd) {
+	r := m.replay
+	if r == nil || r.paused {
+		return m, nil
+	}
+	if !m.ready || m.internalQuery != "" {
+		return m, replayTick()
+	}
+	if len(r.lines) == 0 {
+		return m.finishReplay(), nil
+	}
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	r.last = line.n
+	m.statusMsg = fmt.Sprintf("Replaying %s: %d/%d", r.path, r.total-len(r.lines), r.total)
+	return m.runInSession(line.text)
+}
+
+// replayNext schedules the next line once the interpreter is ready again
+func (m *Model) replayNext() tea.Cmd {
+	if m.replay == nil || m.replay.paused {
+		return nil
+	}
+	if len(m.replay.lines) == 0 {
+		*m = m.finishReplay()
+		return nil
+	}
+	return replayTick()
+}
+
+// replayError pauses the replay at an APL error
+func (m *Model) replayError(errNum int) {
+	if m.replay == nil {
+		return
+	}
+	m.replay.paused = true
+	m.log("Replay paused at %s:%d: APL error %d", m.replay.path, m.replay.last, errNum)
+	m.statusMsg = fmt.Sprintf("Replay paused at %s:%d (error %d): replay-resume or replay-stop",
+		m.replay.path, m.replay.last, errNum)
+}
+
+// resumeReplay carries on from the line after an error
+func (m Model) resumeReplay() (tea.Model, tea.Cmd) {
+	if m.replay == nil {
+		m.statusMsg = "No replay to resume"
+		return m, nil
+	}
+	m.replay.paused = false
+	m.statusMsg = ""
+	return m, replayTick()
+}
+
+// stopReplay abandons the replay
+func (m *Model) stopReplay() {
+	if m.replay == nil {
+		return
+	}
+	m.log("Replay of %s stopped at line %d", m.replay.path, m.replay.last)
+	m.statusMsg = "Replay stopped"
+	m.replay = nil
+}
+
+// finishReplay ends a replay that has run every line
+func (m Model) finishReplay() Model {
+	m.log("Replayed %s", m.replay.path)
+	m.statusMsg = "Replayed " + m.replay.path
+	m.replay = nil
+	return m
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSessionReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo.apl")
+	os.WriteFile(path, []byte("x←⍳3\n\n1÷0\nx\n"), 0644)
+
+	m := newRideTestModel()
+	result, cmd := m.startReplay(path)
+	m = result.(Model)
+	if cmd == nil || m.replay == nil || m.replay.total != 3 {
+		t.Fatalf("replay not started: %+v", m.replay)
+	}
+
+	prompt := rideMsg("SetPromptType", map[string]any{"type": 1.0})
+	step := func() tea.Cmd {
+		t.Helper()
+		result, cmd := m.replayStep()
+		m = result.(Model)
+		return cmd
+	}
+
+	step()
+	if got := m.lines[len(m.lines)-1].Text; got != aplIndent+"x←⍳3" {
+		t.Fatalf("first line sent = %q", got)
+	}
+	if cmd := step(); cmd == nil || m.replay.last != 1 {
+		t.Errorf("not ready: sent line %d, retry %v", m.replay.last, cmd != nil)
+	}
+	m, cmd = m.applyRide(prompt)
+	if cmd == nil {
+		t.Fatal("prompt didn't schedule the next line")
+	}
+
+	step()
+	m = applyAll(m, rideMsg("HadError", map[string]any{"error": 11.0}))
+	m, cmd = m.applyRide(prompt)
+	if cmd != nil || !m.replay.paused || !strings.Contains(m.statusMsg, path+":3") {
+		t.Fatalf("error didn't pause at line 3: paused=%v status=%q", m.replay.paused, m.statusMsg)
+	}
+	if cmd := step(); cmd != nil || m.replay.last != 3 {
+		t.Errorf("paused: sent line %d, retry %v", m.replay.last, cmd != nil)
+	}
+
+	result, _ = m.resumeReplay()
+	m = result.(Model)
+	step()
+	m, _ = m.applyRide(prompt)
+	if m.replay != nil || m.statusMsg != "Replayed "+path {
+		t.Errorf("replay not finished: %+v %q", m.replay, m.statusMsg)
+	}
+	if got := exportSession(m.lines, exportScript); got != "x←⍳3\n1÷0\nx\n" {
+		t.Errorf("session input = %q", got)
+	}
+}
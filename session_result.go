@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+)
+
+// Capturing results (result_var in gritt.json, off by default): RIDE only
+// sends output as text, so to keep the last result as a value gritt sends a
+// plain expression from the input line as ⎕←var←expr, which shows the same
+// output and leaves the value in var. insert_result types var's name at the
+// cursor. Statements that may not display a result go as typed: those with
+// an assignment, ⋄, a dfn, a control keyword, a branch or a command, and
+// ones that may be a function rather than an array: ending in a function or
+// operator glyph (+/, ∘.×) or a lone name (f). Still caught out, as the lexer
+// can't tell a name's class: a function that returns nothing gives VALUE
+// ERROR, and a train or a derived function ending in a name or a parenthesis
+// ((+/), +/f) gives SYNTAX ERROR. Errors quote the rewritten line; hence
+// opt-in.
+
+// captureResult rewrites an expression to keep its result in name, or
+// reports false if it's not a plain expression
+func captureResult(code, name string) (string, bool) {
+	runes := []rune(code)
+	tokens := lexAPL(runes)
+	if name == "" || len(tokens) == 0 {
+		return "", false
+	}
+	var stmt []aplToken // Without the comment
+	for _, tok := range tokens {
+		switch tok.kind {
+		case TokenCommand, TokenKeyword:
+			return "", false
+		case TokenPrimitive:
+			switch runes[tok.start] {
+			case '←', '⋄', '{', '}', '→':
+				return "", false
+			}
+		}
+		if tok.kind != TokenComment {
+			stmt = append(stmt, tok)
+		}
+	}
+	if len(stmt) == 0 || (len(stmt) == 1 && stmt[0].kind == TokenName) {
+		return "", false
+	}
+	// Arrays end in a value or a closing bracket; any other glyph is a
+	// function or operator
+	if last := stmt[len(stmt)-1]; last.kind == TokenPrimitive && !strings.ContainsRune(")]⍬", runes[last.start]) {
+		return "", false
+	}
+	return "⎕←" + name + "←" + strings.TrimSpace(stripComment(code)), true
+}
+
+// insertResult types the name of the variable holding the last result
+func (m *Model) insertResult() {
+	name := m.config.ResultVar
+	if name == "" {
+		m.statusMsg = "Results aren't kept: set result_var in gritt.json"
+		return
+	}
+	for _, r := range name {
+		m.insertChar(r)
+	}
+}
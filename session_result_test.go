@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCaptureResult(t *testing.T) {
+	tests := []struct {
+		code, want string
+		ok         bool
+	}{
+		{"⍳3", "⎕←⍙←⍳3", true},
+		{"+/⍳10 ⍝ sum", "⎕←⍙←+/⍳10", true},
+		{"'a←b'", "⎕←⍙←'a←b'", true},
+		{"a←⍳3", "", false},
+		{"a[1]←2", "", false},
+		{"1 ⋄ 2", "", false},
+		{"{⍵+1}3", "", false},
+		{"→0", "", false},
+		{":If 1", "", false},
+		{")clear", "", false},
+		{"]link.status", "", false},
+		{"+/", "", false}, // Functions display as themselves
+		{"∘.×", "", false},
+		{"f", "", false},
+		{"f ⍝ a function?", "", false},
+		{"x[2]", "⎕←⍙←x[2]", true},
+		{"(⍳3)", "⎕←⍙←(⍳3)", true},
+		{"⍬", "⎕←⍙←⍬", true},
+		{"2 f 3", "⎕←⍙←2 f 3", true},
+	}
+	for _, tt := range tests {
+		got, ok := captureResult(tt.code, "⍙")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("captureResult(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := captureResult("⍳3", ""); ok {
+		t.Error("captured with no variable")
+	}
+}
+
+func TestExecuteKeepsResult(t *testing.T) {
+	m := newRideTestModel()
+	m.config.ResultVar = "⍙"
+	for _, r := range "⍳3" {
+		m.insertChar(r)
+	}
+	next, _ := m.handleSessionKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if want := []string{"⎕←⍙←⍳3"}; !reflect.DeepEqual(m.sentInput, want) {
+		t.Errorf("sent %q, want %q", m.sentInput, want)
+	}
+	// The session shows the line as typed, and the echo of what was sent is hidden
+	if m.lines[0].Text != aplIndent+"⍳3" {
+		t.Errorf("input line = %q", m.lines[0].Text)
+	}
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": aplIndent + "⎕←⍙←⍳3\n", "type": float64(14)}))
+	if len(m.lines) != 1 {
+		t.Errorf("echo shown: %q", sessionText(m))
+	}
+
+	// Alt+. types the variable's name
+	m = applyAll(m, rideMsg("SetPromptType", map[string]any{"type": float64(1)}))
+	m.lines = append(m.lines, Line{Text: aplIndent})
+	m.cursorRow, m.cursorCol = len(m.lines)-1, len(aplIndent)
+	next, _ = m.handleSessionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'.'}, Alt: true})
+	m = next.(Model)
+	if got := m.lines[m.cursorRow].Text; got != aplIndent+"⍙" {
+		t.Errorf("after alt+. line = %q", got)
+	}
+
+	// Off, it says how to turn it on
+	m.config.ResultVar = ""
+	m.insertResult()
+	if m.statusMsg == "" || m.lines[m.cursorRow].Text != aplIndent+"⍙" {
+		t.Errorf("insert with result_var unset: status %q", m.statusMsg)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+// The session is a plain slice of lines. Appending grows it and trimming
+// reslices it, so both are amortized O(1), and the viewport indexes it
+// directly. BenchmarkAppend100kCapped shows the time per RIDE message goes
+// on copying the Model, not on the lines; BenchmarkAppendTrim100k is the
+// line storage alone, at a few hundred ns a line.
+
+// trimScrollback drops the oldest session lines once there are more than the
+// scrollback setting allows. The input line and any pending block above it
+// are always kept, and everything indexed by session line (the cursor, the
+// scroll position, a selection) moves up with the lines that remain.
+func (m *Model) trimScrollback() {
+	limit := m.config.ScrollbackLines()
+	if limit <= 0 || len(m.lines) <= limit {
+		return
+	}
+	n := min(len(m.lines)-limit, len(m.lines)-1-m.blockLines)
+	if n <= 0 {
+		return
+	}
+	m.lines = m.lines[n:]
+
+	m.cursorRow = max(m.cursorRow-n, 0)
+	m.scrollY = max(m.scrollY-n, 0)
+	if m.selection != nil {
+		if m.selection.row < n {
+			m.selection = nil
+		} else {
+			sel := *m.selection
+			sel.row -= n
+			m.selection = &sel
+		}
+	}
+	m.clickCount = 0 // A repeated click would be on a different line
+}
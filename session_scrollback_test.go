@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestTrimScrollback(t *testing.T) {
+	m := newRideTestModel()
+	m.config.Scrollback = 5
+	for i := range 4 {
+		m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": fmt.Sprintf("out%d\n", i), "type": float64(1)}))
+	}
+	if len(m.lines) != 5 || m.lines[0].Text != aplIndent {
+		t.Fatalf("trimmed before the cap: %q", sessionText(m))
+	}
+
+	m.selection = &sessionSelection{row: 3, start: 0, end: 3}
+	m.scrollY = 2
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": "out4\nout5\n", "type": float64(1)}))
+	if len(m.lines) != 5 || m.lines[0].Text != "out1" || m.lines[4].Text != "out5" {
+		t.Errorf("lines = %q", sessionText(m))
+	}
+	if m.cursorRow != 4 || m.scrollY != 0 || m.selection == nil || m.selection.row != 1 {
+		t.Errorf("cursorRow = %d, scrollY = %d, selection = %+v", m.cursorRow, m.scrollY, m.selection)
+	}
+
+	// A selection on a dropped line goes with it
+	m.selection = &sessionSelection{row: 0, start: 0, end: 3}
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": "out6\n", "type": float64(1)}))
+	if m.selection != nil {
+		t.Errorf("selection on a dropped line kept: %+v", m.selection)
+	}
+
+	// The input line and a pending block are never dropped
+	m.lines = []Line{{Text: "old"}, {Text: aplIndent + "a←1", Input: true}, {Text: aplIndent + "b←2", Input: true}, {Text: aplIndent + "a+b"}}
+	m.blockLines = 2
+	m.config.Scrollback = 1
+	m.trimScrollback()
+	if len(m.lines) != 3 || m.lines[0].Text != aplIndent+"a←1" {
+		t.Errorf("block trimmed: %q", sessionText(m))
+	}
+}
+
+func TestScrollbackLines(t *testing.T) {
+	for setting, want := range map[int]int{0: defaultScrollback, -1: 0, 200: 200} {
+		if got := (Config{Scrollback: setting}).ScrollbackLines(); got != want {
+			t.Errorf("scrollback %d: ScrollbackLines() = %d, want %d", setting, got, want)
+		}
+	}
+}
+
+func benchAppend(b *testing.B, scrollback int) {
+	out := make([]rideEvent, 1000)
+	for i := range out {
+		out[i] = rideMsg("AppendSessionOutput", map[string]any{"result": "line " + strconv.Itoa(i) + "\n", "type": float64(1)})
+	}
+	for b.Loop() {
+		m := newRideTestModel()
+		m.config.Scrollback = scrollback
+		for range 100 {
+			for _, ev := range out {
+				next, _ := m.handleRide(ev)
+				m = next.(Model)
+			}
+		}
+	}
+}
+
+// Appending 100k lines of output a line at a time, trimmed to the default
+// scrollback or kept in full
+func BenchmarkAppend100kCapped(b *testing.B)    { benchAppend(b, 0) }
+func BenchmarkAppend100kUnlimited(b *testing.B) { benchAppend(b, -1) }
+
+// Rendering the bottom of a 100k-line session
+func BenchmarkRender100k(b *testing.B) {
+	m := newRideTestModel()
+	m.config.Scrollback = -1
+	for i := range 100000 {
+		m.lines = append(m.lines, Line{Text: "line " + strconv.Itoa(i)})
+	}
+	m.cursorRow = len(m.lines) - 1
+	for b.Loop() {
+		m.renderSession(80, 40)
+	}
+}
+
+// The line storage alone: appending and trimming without the RIDE handling
+func BenchmarkAppendTrim100k(b *testing.B) {
+	for b.Loop() {
+		m := newRideTestModel()
+		for i := range 100000 {
+			m.lines = append(m.lines, Line{Text: "line"})
+			m.cursorRow = i
+			m.trimScrollback()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// stampWidth is the width of the timestamp gutter: "15:04:05 "
+const stampWidth = 9
+
+// gutterWidth is how many columns left of the session text the timestamps
+// take, if they're shown
+func (m Model) gutterWidth() int {
+	if m.timestamps {
+		return stampWidth
+	}
+	return 0
+}
+
+// stamp returns the timestamp shown beside session line i: the time it
+// arrived, on the first line of each block of output, so blank for input,
+// for the rest of a block, and for later output within the same second
+func (m Model) stamp(i int) string {
+	line := m.lines[i]
+	if line.Input || line.Time.IsZero() {
+		return ""
+	}
+	text := line.Time.Format("15:04:05")
+	if i > 0 {
+		prev := m.lines[i-1]
+		if !prev.Input && !prev.Time.IsZero() && prev.Time.Format("15:04:05") == text {
+			return ""
+		}
+	}
+	return text
+}
+
+// gutter renders the timestamp gutter for a row showing session line i;
+// first is false for the further rows of a wrapped line
+func (m Model) gutter(i int, first bool) string {
+	text := ""
+	if first && i < len(m.lines) {
+		text = m.stamp(i)
+	}
+	pad := strings.Repeat(" ", stampWidth-lipgloss.Width(text))
+	return lipgloss.NewStyle().Foreground(theme.Dim).Render(text) + pad
+}
+
+// toggleTimestamps shows or hides the time each block of output arrived
+func (m *Model) toggleTimestamps() {
+	m.timestamps = !m.timestamps
+	m.colOffset = 0
+	if m.timestamps {
+		m.statusMsg = "Showing output timestamps"
+	} else {
+		m.statusMsg = "Hiding output timestamps"
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionTimestamps(t *testing.T) {
+	m := newRideTestModel()
+	m = applyAll(m, rideMsg("AppendSessionOutput", map[string]any{"result": "1 2 3\n4 5 6\n", "type": float64(1)}))
+	if m.lines[1].Time.IsZero() || m.lines[2].Time != m.lines[1].Time {
+		t.Fatalf("output not timestamped: %+v", m.lines)
+	}
+
+	at := func(s string) time.Time {
+		tm, _ := time.Parse("15:04:05", s)
+		return tm
+	}
+	m.lines = []Line{
+		{Text: aplIndent + "⍳3", Input: true},
+		{Text: "1 2 3", Time: at("09:15:00")},
+		{Text: "4 5 6", Time: at("09:15:00")},
+		{Text: "done", Time: at("09:15:07")},
+		{Text: aplIndent},
+	}
+	m.width = 32 // 30 columns inside the border
+	m.cursorRow, m.cursorCol = 4, len(aplIndent)
+
+	// Off by default
+	if got := stripANSI(m.renderSession(30, 5)); strings.Contains(got, "09:15") {
+		t.Errorf("timestamps shown by default:\n%s", got)
+	}
+
+	m.toggleTimestamps()
+	lines := strings.Split(stripANSI(m.renderSession(30, 5)), "\n")
+	want := []string{
+		"         " + aplIndent + "⍳3",
+		"09:15:00 1 2 3",
+		"         4 5 6",
+		"09:15:07 done",
+		"         " + aplIndent,
+	}
+	for i, w := range want {
+		if strings.TrimRight(lines[i], " ") != strings.TrimRight(w, " ") || len([]rune(lines[i])) != 30 {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+
+	// Clicks land on the text, not the gutter
+	if !m.clickSession(stampWidth+3, 2) || m.cursorRow != 1 || m.cursorCol != 2 {
+		t.Errorf("click: row %d, col %d", m.cursorRow, m.cursorCol)
+	}
+
+	// Wrapped, the stamp is on a line's first row only
+	m.wrap = true
+	m.lines[3].Text = strings.Repeat("x", 30)
+	lines = strings.Split(stripANSI(m.renderSession(30, 6)), "\n")
+	if !strings.HasPrefix(lines[3], "09:15:07 x") || !strings.HasPrefix(lines[4], "         x") {
+		t.Errorf("wrapped rows = %q", lines[3:5])
+	}
+}
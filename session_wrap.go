@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// wrapRunes splits a line into rows of at most n display columns, returning
+// the index each row starts at. Wide glyphs aren't split and combining marks
+// stay with their character; an empty line is one row.
+func wrapRunes(runes []rune, n int) []int {
+	starts := []int{0}
+	col := 0
+	for i, r := range runes {
+		w := runeWidth(r)
+		if col+w > n && col > 0 {
+			starts = append(starts, i)
+			col = 0
+		}
+		col += w
+	}
+	return starts
+}
+
+// wrapWidth is the display columns a wrapped row of a session w columns wide
+// holds; the last column is kept for the cursor at the end of a full row
+func wrapWidth(w int) int {
+	return max(w-1, 1)
+}
+
+// cursorSegment returns which wrapped row of its line the cursor is on
+func cursorSegment(starts []int, col int) int {
+	seg := 0
+	for i, start := range starts {
+		if col >= start {
+			seg = i
+		}
+	}
+	return seg
+}
+
+// wrapStart returns the first session line shown when wrapping to n columns,
+// and how many of its rows are scrolled off the top: scrollY, moved just
+// enough to keep the cursor's row in view of h rows
+func (m Model) wrapStart(h, n int) (start, skip int) {
+	if m.cursorRow < 0 || m.cursorRow >= len(m.lines) {
+		return max(min(m.scrollY, len(m.lines)-1), 0), 0
+	}
+	rowsOf := func(i int) int { return len(wrapRunes([]rune(m.lines[i].Text), n)) }
+	seg := cursorSegment(wrapRunes([]rune(m.lines[m.cursorRow].Text), n), m.cursorCol)
+
+	start = min(m.scrollY, m.cursorRow)
+	// The earliest line from which the cursor's row still fits
+	first, rows := m.cursorRow, seg+1
+	for first > start && rows+rowsOf(first-1) <= h {
+		first--
+		rows += rowsOf(first)
+	}
+	if first > start {
+		start = first
+	}
+	if start == m.cursorRow && seg >= h {
+		skip = seg - h + 1 // The cursor is further down one long line than fits
+	}
+	return max(start, 0), skip
+}
+
+// wrappedRow is a row of the wrapped session: runes [a, b) of line
+type wrappedRow struct {
+	line, a, b int
+}
+
+// wrappedRows returns the rows on screen when wrapping to n columns, at
+// most h
+func (m Model) wrappedRows(h, n int) []wrappedRow {
+	start, skip := m.wrapStart(h, n)
+	var rows []wrappedRow
+	for line := start; line < len(m.lines) && len(rows) < h; line++ {
+		runes := []rune(m.lines[line].Text)
+		starts := wrapRunes(runes, n)
+		for i, a := range starts {
+			if line == start && i < skip {
+				continue
+			}
+			b := len(runes)
+			if i+1 < len(starts) {
+				b = starts[i+1]
+			}
+			rows = append(rows, wrappedRow{line, a, b})
+			if len(rows) == h {
+				break
+			}
+		}
+	}
+	return rows
+}
+
+// renderWrapped is renderSession with long lines wrapped onto further rows
+func (m Model) renderWrapped(w, h int) string {
+	g := m.gutterWidth()
+	w -= g
+	lines := make([]string, h)
+	rows := m.wrappedRows(h, wrapWidth(w))
+	for i := range lines {
+		if i >= len(rows) {
+			lines[i] = strings.Repeat(" ", w+g)
+			continue
+		}
+		r := rows[i]
+		rendered := m.sessionText(r.line, []rune(m.lines[r.line].Text), r.a, r.b)
+		if vw := lipgloss.Width(rendered); vw < w {
+			rendered += strings.Repeat(" ", w-vw)
+		}
+		if g > 0 {
+			rendered = m.gutter(r.line, r.a == 0) + rendered
+		}
+		lines[i] = rendered
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clickWrapped is clickSession for wrapped lines
+func (m *Model) clickWrapped(x, y, h int) bool {
+	rows := m.wrappedRows(h, wrapWidth(m.sessionWidth()))
+	m.scrollY = m.sessionStart(h)
+	if y < 1 || y > len(rows) {
+		return false
+	}
+	r := rows[y-1]
+	runes := []rune(m.lines[r.line].Text)
+	col := r.a + runeAtColumn(runes[r.a:r.b], max(x-1-m.gutterWidth(), 0))
+	if r.b < len(runes) {
+		col = min(col, r.b-1) // Past the end of a row that continues below
+	}
+	m.cursorRow, m.cursorCol = r.line, col
+	return true
+}
+
+// toggleWrap switches between wrapping long session lines and scrolling
+// them sideways
+func (m *Model) toggleWrap() {
+	m.wrap = !m.wrap
+	m.colOffset = 0
+	if m.wrap {
+		m.statusMsg = "Wrapping long lines"
+	} else {
+		m.statusMsg = "Scrolling long lines"
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/cursork/gritt/ride"
+)
+
+// runSocket starts a Unix domain socket server for APL expressions. Replies
+// are the raw output, or with jsonMode one sockResponse per line. dial
+// replaces the connection if it's abandoned after a timeout.
+func runSocket(client *ride.Client, dial func() (*ride.Client, error), sockPath string, jsonMode bool) {
+	// Remove stale socket
+	os.Remove(sockPath)
+
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		log.Fatalf("Failed to create socket: %v", err)
+	}
+	defer listener.Close()
+	defer os.Remove(sockPath)
+
+	// Handle signals for cleanup
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		listener.Close()
+		os.Remove(sockPath)
+		os.Exit(0)
+	}()
+
+	fmt.Printf("Listening on %s\n", sockPath)
+
+	serveSocket(listener, execReconnecting(client, dial), jsonMode)
+}
+
+// execReconnecting returns an execCapture on client that first redials if
+// the connection was abandoned, when a timed-out expression didn't get back
+// to a prompt. Calls must not overlap (serveSocket's executor).
+func execReconnecting(client *ride.Client, dial func() (*ride.Client, error)) func(expr string) execResult {
+	return func(expr string) execResult {
+		if client.Abandoned() {
+			log.Printf("Reconnecting: the last expression was interrupted and didn't finish")
+			c, err := dial()
+			if err != nil {
+				return execResult{Err: fmt.Errorf("reconnect failed: %v", err)}
+			}
+			client = c
+		}
+		return execCapture(client, expr)
+	}
+}
+
+// execRequest is an expression queued for execution, and where its result goes
+type execRequest struct {
+	expr  string
+	reply chan execResult
+}
+
+// serveSocket accepts clients until the listener closes. Expressions from
+// every connection go through one queue to a single executor goroutine, as
+// RIDE is single-threaded; each connection gets its own replies in order.
+func serveSocket(listener net.Listener, exec func(expr string) execResult, jsonMode bool) {
+	requests := make(chan execRequest)
+	go func() {
+		for req := range requests {
+			req.reply <- exec(req.expr)
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// Listener closed (signal handler)
+			return
+		}
+
+		go func(c net.Conn) {
+			defer c.Close()
+
+			scanner := bufio.NewScanner(c)
+			for scanner.Scan() {
+				expr := strings.TrimSpace(scanner.Text())
+				if expr == "" {
+					continue
+				}
+
+				// Buffered so the executor never waits on a client that's gone
+				reply := make(chan execResult, 1)
+				requests <- execRequest{expr: expr, reply: reply}
+				result := <-reply
+
+				if _, err := c.Write(result.reply(jsonMode)); err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+}
+
+// execResult is what executing an expression produced
+type execResult struct {
+	Output     string
+	PromptType int   // The prompt that ended it: 1 ready, 2 ⎕ input, 3/4 ⍞ input
+	ErrorNum   int   // APL error number from HadError, 0 if none
+	Err        error // Send or receive failure
+}
+
+// sockResponse is a -sock-json reply
+type sockResponse struct {
+	OK         bool   `json:"ok"`
+	Output     string `json:"output"`
+	PromptType int    `json:"promptType"`
+	Error      string `json:"error,omitempty"`    // Failure, or the APL error's first output line
+	ErrorNum   int    `json:"errorNum,omitempty"` // APL error number
+}
+
+// reply formats the result for a socket client: the raw output (with any
+// failure appended), or a JSON line
+func (r execResult) reply(jsonMode bool) []byte {
+	if !jsonMode {
+		if r.Err != nil {
+			return []byte(r.Output + r.Err.Error() + "\n")
+		}
+		return []byte(r.Output)
+	}
+
+	resp := sockResponse{OK: r.Err == nil && r.ErrorNum == 0, Output: r.Output, PromptType: r.PromptType, ErrorNum: r.ErrorNum}
+	switch {
+	case r.Err != nil:
+		resp.Error = r.Err.Error()
+	case r.ErrorNum != 0:
+		resp.Error = fmt.Sprintf("error %d", r.ErrorNum)
+		for _, line := range strings.Split(r.Output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				resp.Error = line
+				break
+			}
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(sockResponse{Error: err.Error()})
+	}
+	return append(data, '\n')
+}
+
+// execCapture executes an expression and collects its output, stopping at
+// the next prompt or after -timeout
+func execCapture(client *ride.Client, expr string) execResult {
+	var r execResult
+	var buf strings.Builder
+
+	ctx, cancel := execContext()
+	defer cancel()
+	err := client.ExecuteFunc(ctx, expr, func(msg *ride.Message) bool {
+		switch msg.Command {
+		case "AppendSessionOutput":
+			var out ride.AppendSessionOutput
+			if msg.Unmarshal(&out) == nil && out.Type != ride.OutputEcho {
+				buf.WriteString(out.Result)
+			}
+		case "HadError":
+			var e ride.HadError
+			if msg.Unmarshal(&e) == nil {
+				r.ErrorNum = e.Error
+			}
+		case "SetPromptType":
+			// Return on type > 0:
+			// - type 1: ready for input (expression complete)
+			// - type 2: quad input (⎕:)
+			// - type 3: quote-quad input (⍞)
+			// - type 0: no prompt (processing) - keep waiting
+			var p ride.SetPromptType
+			if msg.Unmarshal(&p) == nil && p.Type > 0 {
+				r.PromptType = p.Type
+				return true
+			}
+		}
+		return false
+	})
+	r.Output = buf.String()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		r.Err = execError(err)
+	case err != nil:
+		r.Err = fmt.Errorf("Recv failed: %v", err)
+	}
+	return r
+}
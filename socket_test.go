@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cursork/gritt/ride"
+)
+
+// socketSession is a recorded handshake, then ⍳5 and 1÷0 executed
+const socketSession = `{"dir":"recv","ts":"","raw":"SupportedProtocols=2"}
+{"dir":"recv","ts":"","raw":"UsingProtocol=2"}
+{"dir":"recv","ts":"","cmd":"SetPromptType","args":{"type":1}}
+{"dir":"recv","ts":"","cmd":"AppendSessionOutput","args":{"result":"      ⍳5\n","type":14}}
+{"dir":"recv","ts":"","cmd":"SetPromptType","args":{"type":0}}
+{"dir":"recv","ts":"","cmd":"AppendSessionOutput","args":{"result":"1 2 3 4 5\n","type":2}}
+{"dir":"recv","ts":"","cmd":"SetPromptType","args":{"type":1}}
+{"dir":"recv","ts":"","cmd":"AppendSessionOutput","args":{"result":"      1÷0\n","type":14}}
+{"dir":"recv","ts":"","cmd":"SetPromptType","args":{"type":0}}
+{"dir":"recv","ts":"","cmd":"AppendSessionOutput","args":{"result":"DOMAIN ERROR: Divide by zero\n      1÷0\n       ∧\n","type":5}}
+{"dir":"recv","ts":"","cmd":"HadError","args":{"error":11,"dmx":0}}
+{"dir":"recv","ts":"","cmd":"SetPromptType","args":{"type":1}}
+`
+
+func TestExecCaptureReplies(t *testing.T) {
+	conn, err := ride.NewReplayConn(strings.NewReader(socketSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ride.NewClient(conn)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	decode := func(r execResult) sockResponse {
+		t.Helper()
+		line := r.reply(true)
+		if !strings.HasSuffix(string(line), "}\n") || strings.Count(string(line), "\n") != 1 {
+			t.Fatalf("reply isn't one JSON line: %q", line)
+		}
+		var resp sockResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Success: raw mode is just the output
+	r := execCapture(client, "⍳5")
+	if got := string(r.reply(false)); got != "1 2 3 4 5\n" {
+		t.Errorf("raw reply = %q", got)
+	}
+	if resp := decode(r); resp != (sockResponse{OK: true, Output: "1 2 3 4 5\n", PromptType: 1}) {
+		t.Errorf("JSON reply = %+v", resp)
+	}
+
+	// APL error
+	r = execCapture(client, "1÷0")
+	resp := decode(r)
+	if resp.OK || resp.ErrorNum != 11 || resp.Error != "DOMAIN ERROR: Divide by zero" || resp.PromptType != 1 {
+		t.Errorf("error reply = %+v", resp)
+	}
+
+	// Connection failure: the recording has run out
+	r = execCapture(client, "2+2")
+	if got := string(r.reply(false)); !strings.HasPrefix(got, "Recv failed: ") || !strings.HasSuffix(got, "EOF\n") {
+		t.Errorf("raw failure reply = %q", got)
+	}
+	if resp := decode(r); resp.OK || !strings.HasPrefix(resp.Error, "Recv failed: ") {
+		t.Errorf("JSON failure reply = %+v", resp)
+	}
+}
+
+func TestExecReconnecting(t *testing.T) {
+	handshake := strings.Join(strings.SplitAfter(socketSession, "\n")[:3], "")
+	replay, err := ride.NewReplayConn(strings.NewReader(handshake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hung, err := ride.NewClient(&hungConn{ReplayConn: replay, deadline: make(chan time.Time, 2)})
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	dials := 0
+	exec := execReconnecting(hung, func() (*ride.Client, error) {
+		dials++
+		conn, err := ride.NewReplayConn(strings.NewReader(socketSession))
+		if err != nil {
+			return nil, err
+		}
+		return ride.NewClient(conn)
+	})
+
+	defer func(d time.Duration) { execTimeout = d }(execTimeout)
+	execTimeout = 50 * time.Millisecond
+	if r := exec("⎕DL 1000"); r.Err == nil || dials != 0 {
+		t.Fatalf("hung expression: err %v, dials %d", r.Err, dials)
+	}
+
+	// The next expression gets a new connection, not the old one's leftovers
+	if r := exec("⍳5"); r.Err != nil || r.Output != "1 2 3 4 5\n" || dials != 1 {
+		t.Errorf("after the timeout: %+v, dials %d", r, dials)
+	}
+	if r := exec("1÷0"); r.ErrorNum != 11 || dials != 1 {
+		t.Errorf("on the new connection: %+v, dials %d", r, dials)
+	}
+}
+
+func TestServeSocketConcurrentClients(t *testing.T) {
+	// Unix socket paths are short; t.TempDir() can be too long on macOS
+	dir, err := os.MkdirTemp("", "gritt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	listener, err := net.Listen("unix", filepath.Join(dir, "s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	// The fake interpreter fails the test if it's ever entered twice at once
+	var running atomic.Int32
+	exec := func(expr string) execResult {
+		if running.Add(1) != 1 {
+			t.Error("expressions executed concurrently")
+		}
+		defer running.Add(-1)
+		time.Sleep(time.Millisecond)
+		return execResult{Output: "=" + expr + "\n", PromptType: 1}
+	}
+	go serveSocket(listener, exec, false)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := net.Dial("unix", listener.Addr().String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			replies := bufio.NewScanner(conn)
+			for i := range 20 {
+				expr := name + string(rune('0'+i%10))
+				if _, err := conn.Write([]byte(expr + "\n")); err != nil {
+					t.Error(err)
+					return
+				}
+				if !replies.Scan() || replies.Text() != "="+expr {
+					t.Errorf("client %s sent %q, got %q", name, expr, replies.Text())
+					return
+				}
+			}
+		}()
+	}
+
+	// A client that leaves without reading its reply doesn't stall the others
+	conn, err := net.Dial("unix", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("gone\n"))
+	conn.Close()
+
+	wg.Wait()
+}
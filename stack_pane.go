@@ -1,43 +1,161 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/cursork/gritt/ride"
 )
 
 // StackFrame represents one frame in the SI stack
 type StackFrame struct {
-	Token   int
+	Token   int // Tracer window token (0 if the frame has no window yet)
 	Name    string
 	Line    int    // CurrentRow
 	Code    string // Line of code at that position
 	Current bool   // Is this the currently displayed frame?
+
+	Thread     int    // Thread the frame is suspended in
+	ThreadName string // Its ⎕TNAME, if any
+}
+
+// SIStack holds the interpreter's state indicator from ReplyGetSIStack.
+// Shared by pointer so it survives Model copies.
+type SIStack struct {
+	Frames []StackFrame // Bottom first, like tracerStack; Token is 0
+}
+
+// siDescRe matches an SI entry such as "#.Foo[3]*" (* marks suspended)
+var siDescRe = regexp.MustCompile(`^(.*)\[(\d+)\]\*?$`)
+
+// parseSIStack converts ReplyGetSIStack's stack (most recent first) into
+// frames, bottom first. Entries that aren't name[line] are skipped.
+func parseSIStack(stack []ride.SIStackEntry) []StackFrame {
+	var frames []StackFrame
+	for i := len(stack) - 1; i >= 0; i-- {
+		match := siDescRe.FindStringSubmatch(strings.TrimSpace(stack[i].Description))
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		frames = append(frames, StackFrame{Name: match[1], Line: line})
+	}
+	return frames
+}
+
+// sameFunction compares names ignoring a leading "#." (SI entries are
+// qualified, window names usually aren't)
+func sameFunction(a, b string) bool {
+	return strings.TrimPrefix(a, "#.") == strings.TrimPrefix(b, "#.")
+}
+
+// mergeStackFrames pairs SI frames with open tracer windows (by name, in
+// stack order). Frames without a window keep Token 0; windows the SI doesn't
+// mention are kept on top. With no SI, the windows are the stack.
+func mergeStackFrames(si, windows []StackFrame) []StackFrame {
+	if len(si) == 0 {
+		return windows
+	}
+	used := make([]bool, len(windows))
+	frames := make([]StackFrame, 0, len(si))
+	next := 0 // Windows are matched in order, so recursion pairs up correctly
+	for _, f := range si {
+		for j := next; j < len(windows); j++ {
+			if !used[j] && sameFunction(f.Name, windows[j].Name) {
+				f = windows[j]
+				used[j] = true
+				next = j + 1
+				break
+			}
+		}
+		frames = append(frames, f)
+	}
+	for j, w := range windows {
+		if !used[j] {
+			frames = append(frames, w)
+		}
+	}
+	return frames
+}
+
+// threadCount is how many threads have frames on the stack
+func threadCount(frames []StackFrame) int {
+	seen := map[int]bool{}
+	for _, f := range frames {
+		seen[f.Thread] = true
+	}
+	return len(seen)
+}
+
+// groupByThread orders the frames thread by thread, keeping each thread's
+// frames in stack order. A single thread's stack is returned as it is.
+func groupByThread(frames []StackFrame) []StackFrame {
+	if threadCount(frames) < 2 {
+		return frames
+	}
+	grouped := slices.Clone(frames)
+	slices.SortStableFunc(grouped, func(a, b StackFrame) int {
+		return cmp.Compare(a.Thread, b.Thread)
+	})
+	return grouped
+}
+
+// stackRow is a line of the stack pane: a frame (index in the stack) or,
+// when several threads are suspended, the header of a thread's frames
+type stackRow struct {
+	frame  int // -1 for a header
+	header string
+}
+
+// stackRows lays out the stack top first, under thread headers if the
+// frames come from more than one thread
+func stackRows(stack []StackFrame) []stackRow {
+	threads := threadCount(stack) > 1
+	var rows []stackRow
+	for i := len(stack) - 1; i >= 0; i-- {
+		if threads && (i == len(stack)-1 || stack[i].Thread != stack[i+1].Thread) {
+			rows = append(rows, stackRow{frame: -1, header: threadLabel(stack[i].Thread, stack[i].ThreadName)})
+		}
+		rows = append(rows, stackRow{frame: i})
+	}
+	return rows
 }
 
 // StackPane displays the tracer stack and allows navigation
 type StackPane struct {
 	getStack func() []StackFrame
-	onSelect func(token int)
+	onSelect func(frame StackFrame)
 	selected int // Index in stack (0 = bottom, len-1 = top)
 
 	// Styles
 	normalStyle   lipgloss.Style
 	selectedStyle lipgloss.Style
 	currentStyle  lipgloss.Style
+	noWindowStyle lipgloss.Style // Frames reported by the SI with no window open
 }
 
 // NewStackPane creates a stack pane with callbacks
-func NewStackPane(getStack func() []StackFrame, onSelect func(token int)) *StackPane {
-	return &StackPane{
-		getStack:      getStack,
-		onSelect:      onSelect,
-		normalStyle:   lipgloss.NewStyle(),
-		selectedStyle: lipgloss.NewStyle().Background(lipgloss.Color("240")),
-		currentStyle:  lipgloss.NewStyle().Foreground(AccentColor).Bold(true),
+func NewStackPane(getStack func() []StackFrame, onSelect func(frame StackFrame)) *StackPane {
+	s := &StackPane{
+		getStack:    getStack,
+		onSelect:    onSelect,
+		normalStyle: lipgloss.NewStyle(),
 	}
+	s.ApplyTheme()
+	return s
+}
+
+// ApplyTheme rebuilds the pane's styles from the theme
+func (s *StackPane) ApplyTheme() {
+	s.selectedStyle = lipgloss.NewStyle().Background(theme.Selection)
+	s.currentStyle = lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	s.noWindowStyle = lipgloss.NewStyle().Foreground(theme.Comment)
 }
 
 func (s *StackPane) Title() string {
@@ -62,7 +180,14 @@ func (s *StackPane) Render(w, h int) string {
 	var lines []string
 
 	// Render stack in reverse order (top of stack first)
-	for i := len(stack) - 1; i >= 0; i-- {
+	for _, row := range stackRows(stack) {
+		if row.frame < 0 {
+			header := truncateRunes("── "+row.header+" ", w)
+			header += strings.Repeat("─", max(w-lipgloss.Width(header), 0))
+			lines = append(lines, s.noWindowStyle.Render(header))
+			continue
+		}
+		i := row.frame
 		frame := stack[i]
 
 		// Format: "name[line] code"
@@ -92,6 +217,8 @@ func (s *StackPane) Render(w, h int) string {
 			}
 		} else if frame.Current {
 			line = s.currentStyle.Render("►" + line[1:])
+		} else if frame.Token == 0 {
+			line = s.noWindowStyle.Render(line)
 		}
 
 		lines = append(lines, line)
@@ -126,7 +253,7 @@ func (s *StackPane) HandleKey(msg tea.KeyMsg) bool {
 		// Select this frame - convert display index to stack index
 		stackIdx := len(stack) - 1 - s.selected
 		if stackIdx >= 0 && stackIdx < len(stack) {
-			s.onSelect(stack[stackIdx].Token)
+			s.onSelect(stack[stackIdx])
 		}
 		return true
 	}
@@ -140,14 +267,13 @@ func (s *StackPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
 	}
 
 	if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
-		// Click to select
-		if y >= 0 && y < len(stack) {
-			s.selected = y
+		// Click to select (thread headers aren't frames)
+		rows := stackRows(stack)
+		if y >= 0 && y < len(rows) && rows[y].frame >= 0 {
+			stackIdx := rows[y].frame
+			s.selected = len(stack) - 1 - stackIdx
 			// Also trigger selection
-			stackIdx := len(stack) - 1 - s.selected
-			if stackIdx >= 0 && stackIdx < len(stack) {
-				s.onSelect(stack[stackIdx].Token)
-			}
+			s.onSelect(stack[stackIdx])
 		}
 		return true
 	}
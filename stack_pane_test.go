@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cursork/gritt/ride"
+)
+
+func TestParseSIStack(t *testing.T) {
+	stack := []ride.SIStackEntry{
+		{Description: "#.Inner[2]*"},
+		{Description: "#.Middle[5]"},
+		{Description: "Outer[1]"},
+		{Description: "⍎"},
+	}
+	frames := parseSIStack(stack)
+	want := []StackFrame{{Name: "Outer", Line: 1}, {Name: "#.Middle", Line: 5}, {Name: "#.Inner", Line: 2}}
+	if len(frames) != len(want) {
+		t.Fatalf("frames = %+v", frames)
+	}
+	for i, w := range want {
+		if frames[i] != w {
+			t.Errorf("frame %d = %+v, want %+v", i, frames[i], w)
+		}
+	}
+}
+
+func TestMergeStackFrames(t *testing.T) {
+	si := []StackFrame{{Name: "#.Outer", Line: 1}, {Name: "#.Middle", Line: 5}, {Name: "#.Inner", Line: 2}}
+	windows := []StackFrame{{Token: 7, Name: "Inner", Line: 2, Code: "÷0", Current: true}}
+
+	frames := mergeStackFrames(si, windows)
+	if len(frames) != 3 {
+		t.Fatalf("frames = %+v", frames)
+	}
+	if frames[0].Token != 0 || frames[1].Token != 0 {
+		t.Errorf("outer frames should have no window: %+v", frames[:2])
+	}
+	if frames[2].Token != 7 || !frames[2].Current || frames[2].Code != "÷0" {
+		t.Errorf("top frame not merged with its window: %+v", frames[2])
+	}
+
+	// Recursive calls pair up in order
+	si = []StackFrame{{Name: "#.Fact", Line: 1}, {Name: "#.Fact", Line: 1}}
+	windows = []StackFrame{{Token: 1, Name: "Fact"}, {Token: 2, Name: "Fact"}}
+	frames = mergeStackFrames(si, windows)
+	if len(frames) != 2 || frames[0].Token != 1 || frames[1].Token != 2 {
+		t.Errorf("recursive frames = %+v", frames)
+	}
+
+	// No SI yet: just the windows
+	if frames := mergeStackFrames(nil, windows); len(frames) != 2 {
+		t.Errorf("without SI: %+v", frames)
+	}
+}
+
+func TestStackPaneSIFrames(t *testing.T) {
+	m := applyAll(newRideTestModel(), rideMsg("OpenWindow", map[string]any{
+		"token": float64(7), "name": "Inner", "debugger": float64(1), "currentRow": float64(0), "text": []any{"Inner", "÷0"},
+	}))
+	m.siStack = &SIStack{}
+	m = applyAll(m, rideMsg("ReplyGetSIStack", map[string]any{"stack": []any{
+		map[string]any{"description": "#.Inner[0]*"},
+		map[string]any{"description": "#.Outer[3]"},
+	}}))
+
+	frames := m.getStackFrames()
+	if len(frames) != 2 || frames[0].Name != "#.Outer" || frames[1].Token != 7 {
+		t.Fatalf("frames = %+v", frames)
+	}
+
+	// Selecting a frame with no window asks the interpreter to open it
+	var selected StackFrame
+	sp := NewStackPane(func() []StackFrame { return frames }, func(f StackFrame) { selected = f })
+	sp.HandleKey(tea.KeyMsg{Type: tea.KeyDown}) // Display is top first
+	sp.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if selected.Name != "#.Outer" || selected.Token != 0 {
+		t.Errorf("selected %+v, want #.Outer without a window", selected)
+	}
+}
+
+func TestStackPaneThreads(t *testing.T) {
+	open := func(token, tid int, name string) rideEvent {
+		return rideMsg("OpenWindow", map[string]any{
+			"token": float64(token), "name": name, "debugger": float64(1), "currentRow": float64(0),
+			"text": []any{name, "⎕DL 1"}, "tid": float64(tid), "tname": "worker",
+		})
+	}
+
+	// One thread: no headers, no thread in the title
+	m := applyAll(newRideTestModel(), open(1, 2, "Outer"), open(2, 2, "Inner"))
+	tracerTitle := func() string { return m.panes.Get("tracer").Content.(*EditorPane).Title() }
+	if title := tracerTitle(); title != "Inner [tracer]" {
+		t.Errorf("single-thread tracer title %q", title)
+	}
+	sp := NewStackPane(m.getStackFrames, func(StackFrame) {})
+	if out := stripANSI(sp.Render(30, 2)); strings.Contains(out, "&2") {
+		t.Errorf("single thread shown with a header:\n%s", out)
+	}
+
+	// A second thread: frames grouped under headers, titles name the thread
+	m = applyAll(m, open(3, 1, "Other"))
+	frames := m.getStackFrames()
+	if len(frames) != 3 || frames[0].Name != "Other" || frames[0].Thread != 1 || frames[2].Name != "Inner" {
+		t.Fatalf("frames = %+v", frames)
+	}
+	sp = NewStackPane(func() []StackFrame { return frames }, func(StackFrame) {})
+	lines := strings.Split(stripANSI(sp.Render(30, 5)), "\n")
+	for i, want := range []string{"── &2 worker", "Inner[0]", "Outer[0]", "── &1 worker", "ther[0]"} { // ► marks the current frame
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+	if title := tracerTitle(); title != "Other &1 worker [tracer]" {
+		t.Errorf("tracer title %q doesn't name the thread", title)
+	}
+
+	// Clicking a header selects nothing; clicking below it selects that frame
+	var selected StackFrame
+	sp = NewStackPane(func() []StackFrame { return frames }, func(f StackFrame) { selected = f })
+	click := tea.MouseMsg{Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	sp.HandleMouse(0, 3, click)
+	if selected.Token != 0 {
+		t.Errorf("header selected %+v", selected)
+	}
+	sp.HandleMouse(0, 4, click)
+	if selected.Token != 3 || sp.selected != 2 {
+		t.Errorf("selected %+v (display %d), want Other", selected, sp.selected)
+	}
+
+	// Back to one thread once the other's frame closes
+	m = applyAll(m, rideMsg("CloseWindow", map[string]any{"win": float64(3)}))
+	if m.editors[2].ShowThread {
+		t.Error("thread still shown with one thread left")
+	}
+}
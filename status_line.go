@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/cursork/gritt/ride"
+)
+
+// statusInterval is how often the status line re-measures latency
+const statusInterval = 5 * time.Second
+
+// statusTickMsg triggers a latency probe. gen is the Model's statusGen when
+// the chain of ticks started; ticks of an older chain are dropped.
+type statusTickMsg struct{ gen int }
+
+// statusTick schedules the next latency probe of chain gen after d
+func statusTick(d time.Duration, gen int) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg { return statusTickMsg{gen} })
+}
+
+// StatusInfo is what the status line shows about the interpreter.
+// Shared by pointer so internal query callbacks can fill it in.
+type StatusInfo struct {
+	Version string        // From ReplyIdentify
+	IO, ML  string        // ⎕IO and ⎕ML, queried once per connection
+	Latency time.Duration // Round trip of the last Identify
+
+	probeSent  time.Time // When the outstanding Identify was sent (zero if none)
+	envPending bool      // ⎕IO/⎕ML query in flight
+}
+
+// statusHeight returns the rows taken by the status line
+func (m Model) statusHeight() int {
+	if m.config.StatusLine {
+		return 1
+	}
+	return 0
+}
+
+// probeStatus sends a no-op Identify to time the round trip and, once per
+// connection, asks for ⎕IO and ⎕ML
+func (m *Model) probeStatus() {
+	st := m.status
+	if st == nil || !m.connected {
+		return
+	}
+
+	// Don't stack probes if the last one hasn't been answered
+	if st.probeSent.IsZero() {
+		st.probeSent = time.Now()
+		m.send("Identify", map[string]any{"apiVersion": 1, "identity": 1})
+	}
+
+	if st.IO == "" && !st.envPending && m.ready && m.internalQuery == "" {
+		st.envPending = true
+		m.executeInternal("⎕IO ⎕ML", func(outputs []string) {
+			st.envPending = false
+			if f := strings.Fields(strings.Join(outputs, " ")); len(f) == 2 {
+				st.IO, st.ML = f[0], f[1]
+			}
+		})
+	}
+}
+
+// handleReplyIdentify records latency and version from an Identify reply
+func (m *Model) handleReplyIdentify(args ride.ReplyIdentify) {
+	st := m.status
+	if st == nil {
+		return
+	}
+	if !st.probeSent.IsZero() {
+		st.Latency = time.Since(st.probeSent)
+		st.probeSent = time.Time{}
+	}
+	if args.Version != "" {
+		st.Version = args.Version
+	}
+}
+
+// renderStatusLine renders the address, latency and interpreter details
+func (m Model) renderStatusLine(w int) string {
+	parts := []string{m.addr}
+	st := m.status
+	switch {
+	case !m.connected:
+		parts = append(parts, "disconnected")
+	case st != nil:
+		latency := st.Latency
+		if !st.probeSent.IsZero() && time.Since(st.probeSent) > latency {
+			// Still waiting - show how long so far
+			latency = time.Since(st.probeSent)
+		}
+		if latency > 0 {
+			parts = append(parts, formatLatency(latency))
+		}
+		if st.Version != "" {
+			parts = append(parts, "Dyalog "+st.Version)
+		}
+		if st.IO != "" {
+			parts = append(parts, fmt.Sprintf("⎕IO=%s ⎕ML=%s", st.IO, st.ML))
+		}
+	}
+	switch {
+	case m.docs == nil:
+	case m.docs.Missing():
+		parts = append(parts, "no docs")
+	case m.docs.Meta().stale(time.Now()):
+		parts = append(parts, "docs out of date")
+	}
+
+	style := lipgloss.NewStyle().Foreground(theme.Comment)
+	if !m.connected {
+		style = style.Foreground(theme.Error)
+	}
+	return style.Render(truncateRunes(strings.Join(parts, " • "), w))
+}
+
+// formatLatency shows sub-second latencies in ms, longer ones in seconds
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
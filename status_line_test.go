@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cursork/gritt/ride"
+)
+
+func TestStatusLineIdentify(t *testing.T) {
+	m := Model{addr: "localhost:4502", connected: true, status: &StatusInfo{}, debugLog: &LogBuffer{}}
+	m.status.probeSent = time.Now().Add(-12 * time.Millisecond)
+	m.handleReplyIdentify(ride.ReplyIdentify{Version: "19.0.48958"})
+
+	if m.status.Latency < 12*time.Millisecond || !m.status.probeSent.IsZero() {
+		t.Errorf("latency = %v, probeSent = %v", m.status.Latency, m.status.probeSent)
+	}
+	m.status.IO, m.status.ML = "1", "1"
+
+	m.docs = NewDocsDB(filepath.Join(t.TempDir(), "dyalog-docs.db"))
+	line := m.renderStatusLine(80)
+	for _, want := range []string{"localhost:4502", "ms", "Dyalog 19.0.48958", "⎕IO=1 ⎕ML=1"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("status line %q missing %q", line, want)
+		}
+	}
+
+	if !strings.Contains(line, "no docs") {
+		t.Errorf("status line %q should say the docs are missing", line)
+	}
+
+	m.connected = false
+	if line := m.renderStatusLine(80); !strings.Contains(line, "disconnected") {
+		t.Errorf("status line %q should say disconnected", line)
+	}
+}
+
+func TestFormatLatency(t *testing.T) {
+	if got := formatLatency(3 * time.Millisecond); got != "3ms" {
+		t.Errorf("got %q", got)
+	}
+	if got := formatLatency(1500 * time.Millisecond); got != "1.5s" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStatusLineView(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		m := Model{
+			addr:      "localhost:4502",
+			connected: true,
+			lines:     []Line{{Text: aplIndent}},
+			width:     80,
+			height:    24,
+			panes:     NewPaneManager(80, 24),
+			debugLog:  &LogBuffer{},
+			status:    &StatusInfo{},
+			config:    Config{StatusLine: enabled},
+		}
+		view := m.View()
+		if got := strings.Count(view, "\n") + 1; got != 24 {
+			t.Errorf("status_line=%v: view has %d lines, want 24", enabled, got)
+		}
+		if got := strings.Contains(view, "localhost:4502"); got != enabled {
+			t.Errorf("status_line=%v: address shown = %v", enabled, got)
+		}
+	}
+}
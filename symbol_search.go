@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,8 +14,10 @@ type SymbolSearch struct {
 	filtered       []APLSymbol
 	query          string
 	selected       int
-	scroll         int  // Scroll offset
-	SelectedSymbol rune // Set when Enter pressed
+	scroll         int             // Scroll offset
+	keycodes       map[rune]string // Backtick key per symbol when the layout is overridden (nil = Keycode)
+	SelectedSymbol rune            // Set when Enter pressed
+	CopySymbol     rune            // Set when Ctrl+Y pressed (copy to clipboard)
 }
 
 // NewSymbolSearch creates a symbol search pane
@@ -24,32 +28,36 @@ func NewSymbolSearch() *SymbolSearch {
 }
 
 func (s *SymbolSearch) filter() {
+	s.selected = 0
+	s.scroll = 0
 	if s.query == "" {
 		s.filtered = aplSymbols
-		s.selected = 0
-		s.scroll = 0
 		return
 	}
 
-	q := strings.ToLower(s.query)
-	s.filtered = nil
+	// Best of name and description scores; description matches rank lower
+	type scored struct {
+		sym   APLSymbol
+		score int
+	}
+	var matches []scored
 	for _, sym := range aplSymbols {
-		// Match against names
+		best := fuzzyScore(s.query, sym.Desc) / 2
 		for _, name := range sym.Names {
-			if strings.Contains(name, q) {
-				s.filtered = append(s.filtered, sym)
-				break
-			}
+			best = max(best, fuzzyScore(s.query, name))
+		}
+		if best > 0 {
+			matches = append(matches, scored{sym, best})
 		}
 	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
 
-	if s.selected >= len(s.filtered) {
-		s.selected = len(s.filtered) - 1
-	}
-	if s.selected < 0 {
-		s.selected = 0
+	s.filtered = make([]APLSymbol, len(matches))
+	for i, m := range matches {
+		s.filtered[i] = m.sym
 	}
-	s.scroll = 0
 }
 
 func (s *SymbolSearch) Title() string {
@@ -60,7 +68,7 @@ func (s *SymbolSearch) Render(w, h int) string {
 	var sb strings.Builder
 
 	// Query line
-	promptStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("207"))
+	promptStyle := lipgloss.NewStyle().Foreground(theme.Symbols)
 	sb.WriteString(promptStyle.Render("/ "))
 	sb.WriteString(s.query)
 	sb.WriteString(cursorStyle.Render(" "))
@@ -71,10 +79,11 @@ func (s *SymbolSearch) Render(w, h int) string {
 	sb.WriteString("\n")
 
 	// Symbols list
-	selectedStyle := lipgloss.NewStyle().Background(lipgloss.Color("207")).Foreground(lipgloss.Color("0"))
-	symStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("207")).Bold(true)
-	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	selectedStyle := lipgloss.NewStyle().Background(theme.Symbols).Foreground(theme.CursorText)
+	symStyle := lipgloss.NewStyle().Foreground(theme.Symbols).Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+	codeStyle := lipgloss.NewStyle().Foreground(theme.Dim)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Text)
 
 	listH := h - 2
 	for i := s.scroll; i < len(s.filtered) && i < s.scroll+listH; i++ {
@@ -82,25 +91,29 @@ func (s *SymbolSearch) Render(w, h int) string {
 
 		char := string(sym.Char)
 		keycode := sym.Keycode
+		if s.keycodes != nil {
+			keycode = s.keycodes[sym.Char]
+		}
 		if keycode == "" {
 			keycode = "   "
 		} else {
 			keycode = padRight(keycode, 3)
 		}
+		codepoint := fmt.Sprintf("U+%04X", sym.Char)
 		desc := sym.Desc
 
 		// Truncate desc if needed (rune-aware)
-		maxDesc := w - 8
+		maxDesc := max(w-15, 1)
 		descRunes := []rune(desc)
-		if len(descRunes) > maxDesc {
+		if maxDesc > 1 && len(descRunes) > maxDesc {
 			desc = string(descRunes[:maxDesc-1]) + "…"
 		}
 
 		if i == s.selected {
-			line := selectedStyle.Render(" "+char+" ") + " " + keyStyle.Render(keycode) + " " + descStyle.Render(desc)
+			line := selectedStyle.Render(" "+char+" ") + " " + keyStyle.Render(keycode) + " " + codeStyle.Render(codepoint) + " " + descStyle.Render(desc)
 			sb.WriteString(line)
 		} else {
-			line := symStyle.Render(" "+char+" ") + " " + keyStyle.Render(keycode) + " " + descStyle.Render(desc)
+			line := symStyle.Render(" "+char+" ") + " " + keyStyle.Render(keycode) + " " + codeStyle.Render(codepoint) + " " + descStyle.Render(desc)
 			sb.WriteString(line)
 		}
 
@@ -139,6 +152,12 @@ func (s *SymbolSearch) HandleKey(msg tea.KeyMsg) bool {
 		}
 		return true
 
+	case tea.KeyCtrlY:
+		if s.selected >= 0 && s.selected < len(s.filtered) {
+			s.CopySymbol = s.filtered[s.selected].Char
+		}
+		return true
+
 	case tea.KeyBackspace:
 		if len(s.query) > 0 {
 			s.query = s.query[:len(s.query)-1]
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+func TestSymbolSearchNarrow(t *testing.T) {
+	s := NewSymbolSearch()
+	// Too narrow for any description: rendering mustn't slice out of range
+	for w := 1; w <= 20; w++ {
+		s.Render(w, 10)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Theme holds the UI colors by role. Panes style themselves from the
+// package-level theme, set from the config's "theme" section at startup.
+type Theme struct {
+	Accent     color.Color // Titles, prompts, status messages, highlighted rows
+	Border     color.Color // Session and pane borders
+	Cursor     color.Color // Cursor background
+	CursorText color.Color // Text on the cursor and on highlighted rows
+	Selection  color.Color // Background of selected rows and text
+	Error      color.Color // Errors, the disconnected border
+	Breakpoint color.Color // Breakpoint markers
+	Link       color.Color // Links in documentation
+	Comment    color.Color // Secondary text: hints, counts, key names
+	Dim        color.Color // Faint text: line numbers, types, codes
+	Text       color.Color // Descriptions and snippets
+	Success    color.Color // Move mode, save prompt, completed steps
+	Changed    color.Color // Modified lines in the editor
+	Info       color.Color // Interpreter commands in the palette
+	Symbols    color.Color // Symbol search
+	APLcart    color.Color // APLcart search
+}
+
+// ThemeConfig is the "theme" section of gritt.json: a preset name and color
+// overrides by role. Colors are ANSI numbers ("214") or hex ("#F2A74F").
+type ThemeConfig struct {
+	Preset     string `json:"preset"` // "default", "light" or "mono"
+	Accent     string `json:"accent"`
+	Border     string `json:"border"` // Empty = accent
+	Cursor     string `json:"cursor"`
+	CursorText string `json:"cursor_text"`
+	Selection  string `json:"selection"`
+	Error      string `json:"error"`
+	Breakpoint string `json:"breakpoint"`
+	Link       string `json:"link"` // Empty = accent
+	Comment    string `json:"comment"`
+	Dim        string `json:"dim"`
+	Text       string `json:"text"`
+	Success    string `json:"success"`
+	Changed    string `json:"changed"`
+	Info       string `json:"info"`
+	Symbols    string `json:"symbols"`
+	APLcart    string `json:"aplcart"`
+}
+
+// themePresets are the built-in themes. The default preset's empty accent
+// is Dyalog orange, picked per color profile by newTheme.
+var themePresets = map[string]ThemeConfig{
+	"default": {
+		Cursor: "255", CursorText: "0", Selection: "240",
+		Error: "196", Breakpoint: "9",
+		Comment: "245", Dim: "243", Text: "250",
+		Success: "82", Changed: "214", Info: "117",
+		Symbols: "207", APLcart: "214",
+	},
+	"light": {
+		Accent: "130", Cursor: "0", CursorText: "255", Selection: "252",
+		Error: "160", Breakpoint: "160",
+		Comment: "242", Dim: "246", Text: "238",
+		Success: "28", Changed: "130", Info: "25",
+		Symbols: "127", APLcart: "130",
+	},
+	"mono": {
+		Accent: "252", Border: "245", Cursor: "255", CursorText: "0", Selection: "240",
+		Error: "255", Breakpoint: "255",
+		Comment: "245", Dim: "240", Text: "250",
+		Success: "255", Changed: "250", Info: "250",
+		Symbols: "255", APLcart: "255",
+	},
+}
+
+// themePresetNames lists the built-in themes, sorted
+func themePresetNames() []string {
+	names := make([]string, 0, len(themePresets))
+	for name := range themePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// over returns base with every role set in c overriding it
+func (c ThemeConfig) over(base ThemeConfig) ThemeConfig {
+	pick := func(s, def string) string {
+		if s != "" {
+			return s
+		}
+		return def
+	}
+	return ThemeConfig{
+		Preset:     pick(c.Preset, base.Preset),
+		Accent:     pick(c.Accent, base.Accent),
+		Border:     pick(c.Border, base.Border),
+		Cursor:     pick(c.Cursor, base.Cursor),
+		CursorText: pick(c.CursorText, base.CursorText),
+		Selection:  pick(c.Selection, base.Selection),
+		Error:      pick(c.Error, base.Error),
+		Breakpoint: pick(c.Breakpoint, base.Breakpoint),
+		Link:       pick(c.Link, base.Link),
+		Comment:    pick(c.Comment, base.Comment),
+		Dim:        pick(c.Dim, base.Dim),
+		Text:       pick(c.Text, base.Text),
+		Success:    pick(c.Success, base.Success),
+		Changed:    pick(c.Changed, base.Changed),
+		Info:       pick(c.Info, base.Info),
+		Symbols:    pick(c.Symbols, base.Symbols),
+		APLcart:    pick(c.APLcart, base.APLcart),
+	}
+}
+
+// newTheme resolves a theme config into colors for the terminal's profile
+func newTheme(profile colorprofile.Profile, c ThemeConfig) Theme {
+	complete := lipgloss.Complete(profile)
+
+	var accent color.Color
+	switch {
+	case c.Accent == "":
+		accent = complete(
+			lipgloss.Color("3"),       // ANSI: yellow
+			lipgloss.Color("215"),     // ANSI256: #ffaf5f
+			lipgloss.Color("#F2A74F"), // TrueColor: Dyalog orange
+		)
+	case strings.HasPrefix(c.Accent, "#"):
+		accent = complete(
+			lipgloss.Color("7"),      // ANSI: white (safe neutral fallback)
+			lipgloss.Color("245"),    // ANSI256: grey
+			lipgloss.Color(c.Accent), // TrueColor: user's choice
+		)
+	default:
+		accent = lipgloss.Color(c.Accent)
+	}
+
+	orAccent := func(s string) color.Color {
+		if s == "" {
+			return accent
+		}
+		return lipgloss.Color(s)
+	}
+	return Theme{
+		Accent:     accent,
+		Border:     orAccent(c.Border),
+		Cursor:     lipgloss.Color(c.Cursor),
+		CursorText: lipgloss.Color(c.CursorText),
+		Selection:  lipgloss.Color(c.Selection),
+		Error:      lipgloss.Color(c.Error),
+		Breakpoint: lipgloss.Color(c.Breakpoint),
+		Link:       orAccent(c.Link),
+		Comment:    lipgloss.Color(c.Comment),
+		Dim:        lipgloss.Color(c.Dim),
+		Text:       lipgloss.Color(c.Text),
+		Success:    lipgloss.Color(c.Success),
+		Changed:    lipgloss.Color(c.Changed),
+		Info:       lipgloss.Color(c.Info),
+		Symbols:    lipgloss.Color(c.Symbols),
+		APLcart:    lipgloss.Color(c.APLcart),
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+func TestResolvedTheme(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want func(ThemeConfig) bool
+	}{
+		{"empty is default", Config{}, func(tc ThemeConfig) bool {
+			return tc.Accent == "" && tc.Symbols == "207" && tc.APLcart == "214"
+		}},
+		{"old accent", Config{Accent: "#808080"}, func(tc ThemeConfig) bool {
+			return tc.Accent == "#808080" && tc.Cursor == "255"
+		}},
+		{"theme accent wins", Config{Accent: "#808080", Theme: ThemeConfig{Accent: "33"}}, func(tc ThemeConfig) bool {
+			return tc.Accent == "33"
+		}},
+		{"preset", Config{Theme: ThemeConfig{Preset: "light"}}, func(tc ThemeConfig) bool {
+			return tc.Cursor == "0" && tc.Accent == "130"
+		}},
+		{"override", Config{Theme: ThemeConfig{Preset: "light", Selection: "250"}}, func(tc ThemeConfig) bool {
+			return tc.Selection == "250" && tc.Error == "160"
+		}},
+		{"unknown preset", Config{Theme: ThemeConfig{Preset: "nope"}}, func(tc ThemeConfig) bool {
+			return tc.Cursor == "255"
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.ResolvedTheme(); !tt.want(got) {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+	}
+}
+
+func TestNewTheme(t *testing.T) {
+	// Border and link follow the accent unless set
+	th := newTheme(colorprofile.TrueColor, ThemeConfig{Accent: "33", Link: "45"})
+	if th.Border != lipgloss.Color("33") || th.Link != lipgloss.Color("45") {
+		t.Errorf("border = %v, link = %v", th.Border, th.Link)
+	}
+
+	// A hex accent falls back to grey without true color
+	th = newTheme(colorprofile.ANSI256, ThemeConfig{Accent: "#123456"})
+	if th.Accent != lipgloss.Color("245") {
+		t.Errorf("ANSI256 hex accent = %v", th.Accent)
+	}
+
+	// Every preset sets every role
+	for _, name := range themePresetNames() {
+		p := themePresets[name]
+		if p.Cursor == "" || p.CursorText == "" || p.Selection == "" || p.Error == "" || p.Comment == "" || p.Symbols == "" {
+			t.Errorf("preset %s is missing roles: %+v", name, p)
+		}
+	}
+}
+
+func TestPaneManagerApplyTheme(t *testing.T) {
+	saved := theme
+	t.Cleanup(func() { setTheme(saved) })
+
+	pm := NewPaneManager(80, 24)
+	stack := NewStackPane(func() []StackFrame { return nil }, nil)
+	editor := NewEditorPane(&EditorWindow{}, TracerKeysConfig{}, EditorConfig{}, nil, nil)
+	pm.Add(NewPane("stack", stack, 0, 0, 20, 10))
+	pm.Add(NewPane("editor", editor, 20, 0, 20, 10))
+
+	setTheme(newTheme(colorprofile.TrueColor, themePresets["light"]))
+	pm.ApplyTheme()
+	if got := stack.selectedStyle.GetBackground(); got != theme.Selection {
+		t.Errorf("stack selection = %v, want %v", got, theme.Selection)
+	}
+	if got := editor.cursorStyle.GetBackground(); got != theme.Cursor {
+		t.Errorf("editor cursor = %v, want %v", got, theme.Cursor)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenKind classifies a token of APL source
+type TokenKind int
+
+const (
+	TokenNone       TokenKind = iota
+	TokenName                 // Foo, ns.Foo, ∆x, #.Foo
+	TokenSystemName           // ⎕NGET, ⎕IO, ⎕
+	TokenKeyword              // :If, :EndFor
+	TokenCommand              // )ed, ]link.create
+	TokenNumber               // 12, ¯3.5E10, 1J2
+	TokenPrimitive            // ⍳, ←, (, and multi-glyph primitives such as ∘.
+	TokenString               // 'it''s'
+	TokenComment              // ⍝ to end of line
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenName:
+		return "name"
+	case TokenSystemName:
+		return "system name"
+	case TokenKeyword:
+		return "keyword"
+	case TokenCommand:
+		return "command"
+	case TokenNumber:
+		return "number"
+	case TokenPrimitive:
+		return "primitive"
+	case TokenString:
+		return "string"
+	case TokenComment:
+		return "comment"
+	}
+	return "none"
+}
+
+// isWord reports whether the token is a name-like run of characters rather
+// than a glyph, string or comment
+func (k TokenKind) isWord() bool {
+	switch k {
+	case TokenName, TokenSystemName, TokenKeyword, TokenCommand, TokenNumber:
+		return true
+	}
+	return false
+}
+
+// multiGlyphPrimitives are primitives written with more than one glyph
+var multiGlyphPrimitives = []string{"∘."}
+
+// aplToken is a token's kind and rune span [start, end) in its line
+type aplToken struct {
+	start, end int
+	kind       TokenKind
+}
+
+// isNameStart reports whether r can begin a user name
+func isNameStart(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '_' || r == '∆' || r == '⍙'
+}
+
+// isDigit reports whether r is an ASCII digit
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// lexAPL splits a line of APL into tokens. Whitespace isn't a token. It's a
+// lexer for finding what's under the cursor, not a parser: a dfn guard is a
+// primitive ':' and anything unrecognised is a one-glyph primitive.
+func lexAPL(runes []rune) []aplToken {
+	var tokens []aplToken
+	at := func(i int) rune {
+		if i < 0 || i >= len(runes) {
+			return 0
+		}
+		return runes[i]
+	}
+	// Keywords and commands start a statement
+	atStatementStart := func(i int) bool {
+		for j := i - 1; j >= 0; j-- {
+			if runes[j] == '⋄' {
+				return true
+			}
+			if !unicode.IsSpace(runes[j]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		kind := TokenPrimitive
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '⍝':
+			i, kind = len(runes), TokenComment
+		case r == '\'' || r == '"':
+			// Doubled quotes escape; an unterminated string runs to the end
+			for i++; i < len(runes); i++ {
+				if runes[i] != r {
+					continue
+				}
+				if at(i+1) != r {
+					i++
+					break
+				}
+				i++ // Skip the escaped quote
+			}
+			kind = TokenString
+		case r == '⎕':
+			for i++; i < len(runes) && isAPLNameChar(runes[i]) && runes[i] != '⎕'; i++ {
+			}
+			kind = TokenSystemName
+		case r == ':' && isNameStart(at(i+1)) && atStatementStart(i):
+			for i++; i < len(runes) && isAPLNameChar(runes[i]); i++ {
+			}
+			kind = TokenKeyword
+		case (r == ')' || r == ']') && isNameStart(at(i+1)) && atStatementStart(i):
+			for i++; i < len(runes) && (isAPLNameChar(runes[i]) || runes[i] == '.'); i++ {
+			}
+			kind = TokenCommand
+		case isDigit(r) || ((r == '¯' || r == '.') && (isDigit(at(i+1)) || (at(i+1) == '.' && isDigit(at(i+2))))):
+			for ; i < len(runes) && (isDigit(runes[i]) || strings.ContainsRune(".¯EeJj", runes[i])); i++ {
+			}
+			kind = TokenNumber
+		case isNameStart(r) || (r == '#' && (at(i+1) == '.' || at(i+1) == '#')):
+			for i++; i < len(runes); i++ {
+				c := runes[i]
+				if c == '.' && (isNameStart(at(i+1)) || at(i+1) == '#') {
+					continue // ns.Foo
+				}
+				if c == '#' || (isAPLNameChar(c) && c != '⎕') {
+					continue
+				}
+				break
+			}
+			kind = TokenName
+		default:
+			i++
+			for _, p := range multiGlyphPrimitives {
+				if strings.HasPrefix(string(runes[start:]), p) {
+					i = start + len([]rune(p))
+					break
+				}
+			}
+		}
+		tokens = append(tokens, aplToken{start: start, end: i, kind: kind})
+	}
+	return tokens
+}
+
+// tokenAt returns the token touching cursor column col: the one ending at
+// or spanning the cursor, or the one starting at it. A word (name, number,
+// ...) on either side wins over a glyph, since the cursor usually sits just
+// before or after a name ("r←Foo").
+func tokenAt(runes []rune, col int) (string, TokenKind) {
+	var left, right *aplToken
+	tokens := lexAPL(runes)
+	for i := range tokens {
+		t := &tokens[i]
+		if t.start < col && col <= t.end {
+			left = t
+		}
+		if t.start <= col && col < t.end && right == nil {
+			right = t
+		}
+	}
+	pick := left
+	if pick == nil || (!pick.kind.isWord() && right != nil && right.kind.isWord()) {
+		pick = right
+	}
+	if pick == nil {
+		return "", TokenNone
+	}
+	return string(runes[pick.start:pick.end]), pick.kind
+}
+
+// tokenSpanAt returns the span of the token on the character at col, as
+// opposed to tokenAt's cursor gap; ok is false on whitespace or past the end
+func tokenSpanAt(runes []rune, col int) (start, end int, ok bool) {
+	for _, t := range lexAPL(runes) {
+		if t.start <= col && col < t.end {
+			return t.start, t.end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// tokenAtCursor returns the token at the session cursor and its kind
+func (m *Model) tokenAtCursor() (string, TokenKind) {
+	return tokenAt(m.currentLineRunes(), m.cursorCol)
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestTokenAtCursor(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		text string
+		kind TokenKind
+	}{
+		// Identifiers, with the cursor inside, after or before them
+		{"      r←Foo 3", 9, "Foo", TokenName},
+		{"      r←Foo 3", 11, "Foo", TokenName},
+		{"      r←Foo 3", 8, "Foo", TokenName},
+		{"      ns.sub.Fn∆2 ⍵", 10, "ns.sub.Fn∆2", TokenName},
+		{"      #.Util.⍙trim s", 9, "#.Util.⍙trim", TokenName},
+		{"      x_1←0", 7, "x_1", TokenName},
+
+		// System names, keywords and commands
+		{"      ⎕NGET f", 8, "⎕NGET", TokenSystemName},
+		{"      ⎕←x", 7, "⎕", TokenSystemName},
+		{"  :If x>0", 4, ":If", TokenKeyword},
+		{"a ⋄ :EndIf", 6, ":EndIf", TokenKeyword},
+		{"      )ed Foo", 8, ")ed", TokenCommand},
+		{"      ]link.create # .", 10, "]link.create", TokenCommand},
+
+		// Numbers
+		{"      x←12", 9, "12", TokenNumber},
+		{"      ¯3.5E10×y", 8, "¯3.5E10", TokenNumber},
+		{"      1J2", 7, "1J2", TokenNumber},
+		{"      .5+1", 7, ".5", TokenNumber},
+
+		// Primitives, including ones spanning two glyphs
+		{"      +∘.×⍳3", 8, "∘.", TokenPrimitive},
+		{"      +∘.×⍳3", 9, "∘.", TokenPrimitive},
+		{"      +∘.×⍳3", 10, "×", TokenPrimitive},
+		{"      +.×", 8, ".", TokenPrimitive},
+		{"      ⍵=0:⍺", 10, ":", TokenPrimitive}, // dfn guard, not a keyword
+		{"      a[1]", 10, "]", TokenPrimitive},  // indexing, not a command
+
+		// Strings and comments swallow what's inside them
+		{"      'it''s ⍳'", 12, "'it''s ⍳'", TokenString},
+		{"      x ⍝ Foo", 12, "⍝ Foo", TokenComment},
+
+		// Nothing at the cursor
+		{"      Foo", 3, "", TokenNone},
+		{"", 0, "", TokenNone},
+	}
+	for _, tt := range tests {
+		m := Model{lines: []Line{{Text: tt.line}}, cursorCol: tt.col}
+		text, kind := m.tokenAtCursor()
+		if text != tt.text || kind != tt.kind {
+			t.Errorf("%q col %d: tokenAtCursor() = %q %v, want %q %v", tt.line, tt.col, text, kind, tt.text, tt.kind)
+		}
+	}
+}
+
+func TestLexAPL(t *testing.T) {
+	line := []rune("r←⎕IO+'a b'⍝ c")
+	var kinds []TokenKind
+	for _, tok := range lexAPL(line) {
+		kinds = append(kinds, tok.kind)
+	}
+	want := []TokenKind{TokenName, TokenPrimitive, TokenSystemName, TokenPrimitive, TokenString, TokenComment}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("token %d kind = %v, want %v", i, kinds[i], want[i])
+		}
+	}
+}
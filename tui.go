@@ -1,14 +1,16 @@
 package main
 
 import (
-	"database/sql"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/color"
 	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,39 +24,42 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// AccentColor is the UI accent, configurable via "accent" in gritt.json.
-// Defaults to Dyalog orange (#F2A74F). Set e.g. "#808080" for grey.
-var AccentColor color.Color
-
-func initColors(profile colorprofile.Profile, accent string) {
-	complete := lipgloss.Complete(profile)
-	if accent != "" {
-		AccentColor = complete(
-			lipgloss.Color("7"),     // ANSI: white (safe neutral fallback)
-			lipgloss.Color("245"),   // ANSI256: grey
-			lipgloss.Color(accent),  // TrueColor: user's choice
-		)
-	} else {
-		AccentColor = complete(
-			lipgloss.Color("3"),       // ANSI: yellow
-			lipgloss.Color("215"),     // ANSI256: #ffaf5f
-			lipgloss.Color("#F2A74F"), // TrueColor: Dyalog orange
-		)
-	}
-}
+// theme is the UI's colors, configurable via "theme" in gritt.json
+var theme = newTheme(colorprofile.TrueColor, themePresets["default"])
 
 // Cursor style - inverted colors
 var cursorStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("255")).
-	Foreground(lipgloss.Color("0"))
+	Background(theme.Cursor).
+	Foreground(theme.CursorText)
+
+// selectionStyle highlights text selected with a double or triple click
+var selectionStyle = lipgloss.NewStyle().Background(theme.Selection)
+
+// setTheme makes t the UI's theme
+func setTheme(t Theme) {
+	theme = t
+	cursorStyle = lipgloss.NewStyle().Background(t.Cursor).Foreground(t.CursorText)
+	selectionStyle = lipgloss.NewStyle().Background(t.Selection)
+}
 
 const aplIndent = "      " // 6 spaces - APL convention
 
+// multiClickInterval is the longest gap between the clicks of a double or
+// triple click
+const multiClickInterval = 400 * time.Millisecond
+
 // Line is a single line in the session.
 type Line struct {
 	Text     string
 	Original string
-	Edited   bool // True if this line has been modified
+	Edited   bool      // True if this line has been modified
+	Input    bool      // True if this line was executed as input, not output
+	Time     time.Time // When output arrived, for the timestamp gutter (zero for input)
+}
+
+// sessionSelection is a span [start, end) of runes in one session line
+type sessionSelection struct {
+	row, start, end int
 }
 
 // Model holds all state for the TUI.
@@ -64,15 +69,27 @@ type Model struct {
 
 	// Connection state
 	addr      string
+	tls       *tls.Config // nil for a plain connection
 	connected bool
 
 	// Session state
-	lines        []Line
-	cursorRow    int
-	cursorCol    int
-	ready        bool   // Interpreter ready for input
-	lastExecute  string // Last text we sent via Execute (to skip our own echo)
-	pendingQuit  bool   // True if last command was )off
+	lines       []Line
+	cursorRow   int
+	cursorCol   int
+	scrollY     int      // First visible session line, kept in view of the cursor by sessionStart
+	colOffset   int      // First visible session column, kept in view of the cursor by sessionColOffset
+	wrap        bool     // Wrap long session lines instead of scrolling sideways
+	timestamps  bool     // Show when each block of output arrived (session_timestamps.go)
+	ready       bool     // Interpreter ready for input
+	sentInput   []string // Lines sent via Execute whose echo hasn't come back (session_echo.go)
+	blockLines  int      // Unsent lines of a block above the input line (session_block.go)
+	pendingQuit bool     // True if last command was )off
+
+	// Mouse selection: double click selects a word, triple click a line
+	selection              *sessionSelection // nil if nothing is selected
+	lastClick              time.Time
+	lastClickX, lastClickY int
+	clickCount             int // Clicks so far in the current multi-click
 
 	// Debug log (shared with debug pane, survives Model copies)
 	debugLog *LogBuffer
@@ -94,30 +111,80 @@ type Model struct {
 	keys   KeyMap
 	config Config
 
+	// Config reloading: the file in use and when it last changed
+	colorProfile colorprofile.Profile
+	configPath   string // "" for the embedded default
+	configMod    time.Time
+
 	// Leader key state
-	leaderActive bool
+	chord        *chordNode // Leader chord in progress (nil if none)
+	chordKeys    []string   // Keys pressed after the leader so far
+	chordSeq     int        // Counts chord steps, to spot stale timeouts
 	showQuitHint bool
 	confirmQuit  bool
 	paneMoveMode bool // Arrow keys move/resize focused pane
+	statusMsg    string // Transient message shown in the help line until the next key
 
 	// Save prompt state
 	savePromptActive   bool
 	savePromptFilename string
+	savePromptFormat   exportFormat
 
-	// Backtick mode for APL symbol input
-	backtickActive bool
+	// Prefix (backtick) mode for APL symbol input
+	prefixActive bool
+
+	// APLcart placeholder tabstops in the session input line
+	tabstopsActive  bool // Tab jumps to the next placeholder
+	tabstopSelected bool // Cursor is on a placeholder; typing replaces it
+
+	// The inserted idiom's placeholders still to visit, counted back from
+	// the end of the line: edits go in at or before the one being filled,
+	// so those after it stay put relative to the end
+	tabstops []int
 
 	// Documentation database
-	docsDB *sql.DB
+	docs *DocsDB // Opened on first use (shared, survives Model copies)
+
+	// Interpreter ]commands for the palette (shared, survives Model copies)
+	interpCmds *InterpCommands
+
+	// Status line details (shared, survives Model copies)
+	status    *StatusInfo
+	statusGen int // Current chain of status ticks, bumped when a reload restarts it
+
+	// Interpreter SI stack for the stack pane (shared, survives Model copies)
+	siStack *SIStack
+
+	// Known ⎕STOP settings for the breakpoints pane (shared, survives Model copies)
+	breakpoints *Breakpoints
+	// A ⎕STOP refresh waiting for another internal query to complete
+	breakpointsWaiting bool
+
+	// Open the tutorial pane once the screen size is known (gritt -tutorial)
+	tutorialPending bool
+
+	// Pane layout file to reopen once the screen size is known ("" for none)
+	layoutFile string
+
+	// Script being run in the session a line at a time (palette replay,
+	// gritt -replay), nil if none
+	replay *scriptReplay
+
+	// Input line set aside while runInSession's code runs, put back at the
+	// next prompt ("" if none)
+	heldInput    string
+	heldInputCol int
 
 	// Autocomplete state
 	acPending bool          // True if waiting for ReplyGetAutocomplete
+	acToken   int           // Window token of the pending request
+	acPos     int           // Cursor column of the pending request
 	acPopup   *Autocomplete // Non-nil when popup is showing
 
 	// Internal queries (don't display in session)
-	internalQuery    string                   // Command text being executed internally
-	internalCallback func(outputs []string)   // Where to send results
-	internalOutputs  []string                 // Accumulated outputs for internal query
+	internalQuery    string                           // Command text being executed internally
+	internalCallback func(m *Model, outputs []string) // Where to send results
+	internalOutputs  []string                         // Accumulated outputs for internal query
 
 	// Terminal dimensions
 	width  int
@@ -128,15 +195,16 @@ type Model struct {
 
 // rideEvent wraps messages from the RIDE reader goroutine.
 type rideEvent struct {
-	msg *ride.Message
-	raw string
-	err error
+	msg    *ride.Message
+	raw    string
+	err    error
+	client *ride.Client // The connection it came from (nil in tests)
 }
 
 // NewModel creates a Model connected to the given RIDE client.
 func NewModel(client *ride.Client, addr string, logFile io.Writer, profile colorprofile.Profile) Model {
-	cfg := LoadConfig()
-	initColors(profile, cfg.Accent)
+	cfg, cfgPath, cfgWarnings := LoadConfigPath()
+	setTheme(newTheme(profile, cfg.ResolvedTheme()))
 	m := Model{
 		client:    client,
 		addr:      addr,
@@ -150,33 +218,44 @@ func NewModel(client *ride.Client, addr string, logFile io.Writer, profile color
 		config:    cfg,
 		help:      help.New(),
 		keys:      cfg.ToKeyMap(),
+
+		wrap:         cfg.WrapLines,
+		colorProfile: profile,
+		configPath:   cfgPath,
+		configMod:    configModTime(cfgPath),
 	}
 	m.cursorCol = len(aplIndent)
+	m.interpCmds = &InterpCommands{}
+	m.status = &StatusInfo{}
+	m.siStack = &SIStack{}
+	m.breakpoints = &Breakpoints{}
 	m.msgs = m.startRecvLoop()
 	m.log("Connected to %s", addr)
-
-	// Open docs database (optional — F1 help is unavailable without it)
-	dbPath := filepath.Join(os.Getenv("HOME"), ".config", "gritt", "dyalog-docs.db")
-	if db, err := sql.Open("sqlite3", dbPath+"?mode=ro"); err == nil {
-		// Verify the database is usable
-		if err := db.Ping(); err == nil {
-			m.docsDB = db
-			m.log("Docs database loaded: %s", dbPath)
-		} else {
-			db.Close()
-		}
+	for _, w := range cfgWarnings {
+		m.log("Config warning: %v", w)
+		m.statusMsg = "Config problems, see the debug log"
 	}
 
+	// Docs database (optional — F1 help is unavailable without it), opened
+	// when first needed
+	m.docs = NewDocsDB(docsDBPath())
+
 	return m
 }
 
+// rideQueue is how many RIDE events the receive loop reads ahead while an
+// update is being applied. handleRide applies all that have queued up in one
+// update, so a burst of output is drawn once rather than message by message.
+const rideQueue = 256
+
 // startRecvLoop starts a goroutine to receive RIDE messages.
 func (m *Model) startRecvLoop() <-chan rideEvent {
-	ch := make(chan rideEvent)
+	ch := make(chan rideEvent, rideQueue)
+	client := m.client
 	go func() {
 		for {
-			msg, raw, err := m.client.Recv()
-			ch <- rideEvent{msg: msg, raw: raw, err: err}
+			msg, raw, err := client.Recv()
+			ch <- rideEvent{msg: msg, raw: raw, err: err, client: client}
 			if err != nil {
 				return
 			}
@@ -203,6 +282,13 @@ func (m *Model) send(cmd string, args map[string]any) error {
 // The callback receives the outputs when SetPromptType signals completion.
 // If a query is already pending, it's replaced (old callback won't be called).
 func (m *Model) executeInternal(code string, callback func(outputs []string)) error {
+	return m.executeInternalThen(code, func(_ *Model, outputs []string) { callback(outputs) })
+}
+
+// executeInternalThen is executeInternal for callbacks that need the Model
+// (status messages, sends). A *Model captured when the query is sent is a
+// stale copy by the time the reply arrives, so the live one is passed in.
+func (m *Model) executeInternalThen(code string, callback func(m *Model, outputs []string)) error {
 	if m.internalQuery != "" {
 		m.log("Replacing pending internal query: %s → %s", m.internalQuery, code)
 	}
@@ -213,6 +299,17 @@ func (m *Model) executeInternal(code string, callback func(outputs []string)) er
 	return m.send("Execute", map[string]any{"text": code + "\n", "trace": 0})
 }
 
+// internalQueryWaiting returns true, with a status message saying to try
+// again, if another internal query's reply is still to come. Sending now
+// would replace that query and lose its callback.
+func (m *Model) internalQueryWaiting() bool {
+	if m.internalQuery == "" {
+		return false
+	}
+	m.statusMsg = "Waiting for the interpreter, try again"
+	return true
+}
+
 // reconnect attempts to reconnect to the RIDE server.
 func (m Model) reconnect() (tea.Model, tea.Cmd) {
 	if m.connected {
@@ -221,24 +318,49 @@ func (m Model) reconnect() (tea.Model, tea.Cmd) {
 	}
 
 	m.log("Reconnecting to %s...", m.addr)
+	return m.connect()
+}
 
+// connect replaces the client with a new connection to m.addr, resetting
+// the state that belongs to the old interpreter
+func (m Model) connect() (tea.Model, tea.Cmd) {
 	// Close old client if exists
 	if m.client != nil {
 		m.client.Close()
 	}
+	m.connected = false
+	m.ready = false
 
 	// Try to connect
-	client, err := ride.Connect(m.addr)
+	client, err := ride.ConnectTLS(m.addr, m.tls)
 	if err != nil {
-		m.log("Reconnect failed: %v", err)
+		m.log("Connecting to %s failed: %v", m.addr, err)
 		return m, nil
 	}
+	return m.useClient(client)
+}
 
+// useClient makes client, newly connected to m.addr, the session's
+// connection
+func (m Model) useClient(client *ride.Client) (tea.Model, tea.Cmd) {
 	m.client = client
 	m.connected = true
 	m.ready = true
 	m.msgs = m.startRecvLoop()
-	m.log("Reconnected to %s", m.addr)
+	if m.interpCmds != nil {
+		// May be a different interpreter now
+		*m.interpCmds = InterpCommands{}
+	}
+	if m.status != nil {
+		*m.status = StatusInfo{}
+	}
+	if m.siStack != nil {
+		*m.siStack = SIStack{}
+	}
+	if m.breakpoints != nil {
+		*m.breakpoints = Breakpoints{}
+	}
+	m.log("Connected to %s", m.addr)
 
 	// Request any open windows from Dyalog (restores orphaned editors)
 	m.send("GetWindowLayout", map[string]any{})
@@ -269,7 +391,14 @@ func waitForRide(ch <-chan rideEvent) tea.Cmd {
 func (m Model) Init() tea.Cmd {
 	// Request any open windows from Dyalog (restores orphaned editors on reconnect)
 	m.send("GetWindowLayout", map[string]any{})
-	return waitForRide(m.msgs)
+	cmds := []tea.Cmd{waitForRide(m.msgs), configTick(configPollInterval)}
+	if m.config.StatusLine {
+		cmds = append(cmds, statusTick(0, m.statusGen))
+	}
+	if m.replay != nil {
+		cmds = append(cmds, replayTick())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -277,11 +406,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.panes.UpdateSize(msg.Width, msg.Height)
+		m.panes.UpdateSize(msg.Width, msg.Height-m.statusHeight())
+		if m.layoutFile != "" {
+			path := m.layoutFile
+			m.layoutFile = ""
+			m.restoreLayout(path)
+		}
+		if m.tutorialPending {
+			m.tutorialPending = false
+			m.toggleTutorial()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		next, cmd := m.handleKey(msg)
+		if nm, ok := next.(Model); ok {
+			nm.scrollY = nm.sessionStart(nm.sessionHeight())
+			nm.colOffset = nm.sessionColOffset(nm.sessionWidth())
+			return nm, cmd
+		}
+		return next, cmd
 
 	case tea.MouseMsg:
 		return m.handleMouse(msg)
@@ -289,11 +433,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case APLcartLoaded:
 		if pane := m.panes.Get("aplcart"); pane != nil {
 			if ac, ok := pane.Content.(*APLcart); ok {
-				ac.SetData(msg.Entries, msg.Err)
+				ac.SetData(msg.Entries, msg.Source, msg.Err)
 			}
 		}
 		return m, nil
 
+	case statusTickMsg:
+		if !m.config.StatusLine || msg.gen != m.statusGen {
+			return m, nil // Turned off, or restarted, by a config reload
+		}
+		m.probeStatus()
+		return m, statusTick(statusInterval, msg.gen)
+
+	case chordTimeoutMsg:
+		return m.chordTimedOut(msg)
+
+	case replayStepMsg:
+		return m.replayStep()
+
+	case docsBuiltMsg:
+		m.docsBuilt(msg)
+		return m, nil
+
+	case configTickMsg:
+		cmd := m.checkConfig()
+		return m, tea.Batch(cmd, configTick(configPollInterval))
+
 	case rideEvent:
 		return m.handleRide(msg)
 	}
@@ -301,14 +466,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.statusMsg = ""
+	m.selection = nil
+
 	// Handle autocomplete popup - must be first to intercept keys
 	if m.acPopup != nil {
 		switch msg.Type {
 		case tea.KeyTab, tea.KeyDown:
 			m.acPopup.CycleNext()
+			m.describeCompletion()
 			return m, nil
 		case tea.KeyShiftTab, tea.KeyUp:
 			m.acPopup.CyclePrev()
+			m.describeCompletion()
 			return m, nil
 		case tea.KeyEnter:
 			// Select and insert
@@ -325,7 +495,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Handle backtick mode - insert APL symbol
+	// Handle prefix mode - insert APL symbol
 	// insertTarget routes to focused editor if one exists, otherwise session
 	insertTarget := func(r rune) {
 		if fp := m.panes.FocusedPane(); fp != nil {
@@ -333,32 +503,62 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				ep.insertChar(r)
 				return
 			}
+			if vp, ok := fp.Content.(*VariablesPane); ok && vp.Editing() {
+				vp.insertChar(r)
+				return
+			}
+			if dp, ok := fp.Content.(*DebugPane); ok && dp.Editing() {
+				dp.insertChar(r)
+				return
+			}
+			if cp, ok := fp.Content.(*CommandPalette); ok {
+				cp.insertChar(r)
+				return
+			}
 		}
 		m.insertChar(r)
 	}
+	// insertPrefix types the prefix itself, if it's a character
+	prefix := m.config.Prefix()
+	insertPrefix := func() {
+		if r, ok := singleRune(prefix); ok {
+			insertTarget(r)
+		}
+	}
 
-	if m.backtickActive {
-		m.backtickActive = false
+	sticky := m.config.PrefixSticky
+	if m.prefixActive {
 		if len(msg.Runes) > 0 {
+			m.prefixActive = sticky
 			r := msg.Runes[0]
-			if sym, ok := backtickMap[r]; ok {
+			if sym, ok := m.config.BacktickSymbol(r); ok {
 				// Insert symbol at cursor
 				insertTarget(sym)
+				m.tutorialEvent(TutorialBacktick)
 				return m, nil
 			}
-			// Unknown key - insert the backtick and the key
-			insertTarget('`')
+			// Unknown key - insert the prefix and the key (just the key
+			// while sticky)
+			if !sticky {
+				insertPrefix()
+			}
 			insertTarget(r)
 			return m, nil
 		}
-		// Special key after backtick - just insert backtick
-		insertTarget('`')
-		return m, nil
-	}
-
-	// Check for backtick
-	if len(msg.Runes) == 1 && msg.Runes[0] == '`' {
-		m.backtickActive = true
+		switch {
+		case !sticky:
+			// Special key after prefix - just insert the prefix
+			m.prefixActive = false
+			insertPrefix()
+			return m, nil
+		case msg.Type == tea.KeyEscape || msg.String() == prefix:
+			m.prefixActive = false
+			return m, nil
+		}
+		// While sticky, other special keys (arrows, Backspace, Enter) work
+		// as usual
+	} else if msg.String() == prefix {
+		m.prefixActive = true
 		return m, nil
 	}
 
@@ -382,6 +582,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.savePromptActive = false
 			m.doSaveSession()
 			return m, nil
+		case tea.KeyTab:
+			m.cycleSaveFormat()
+			return m, nil
 		case tea.KeyBackspace:
 			if len(m.savePromptFilename) > 0 {
 				m.savePromptFilename = m.savePromptFilename[:len(m.savePromptFilename)-1]
@@ -433,55 +636,30 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case k == "shift+right":
 			fp.Width = min(m.width-2, fp.Width+step)
 			return m, nil
+		case k == "h":
+			m.panes.SnapLeft(fp)
+		case k == "l":
+			m.panes.SnapRight(fp)
+		case k == "k":
+			m.panes.SnapTop(fp)
+		case k == "j":
+			m.panes.SnapBottom(fp)
+		case k == "f":
+			m.panes.Maximize(fp)
+		case k == "r":
+			fp.Restore()
 		}
 		return m, nil
 	}
 
 	// Handle leader key sequences
-	if m.leaderActive {
-		m.leaderActive = false // Reset on any key
-		switch {
-		case key.Matches(msg, m.keys.ToggleDebug):
-			m.toggleDebugPane()
-			return m, nil
-		case key.Matches(msg, m.keys.ToggleStack):
-			m.toggleStackPane()
-			return m, nil
-		case key.Matches(msg, m.keys.ToggleLocals):
-			m.toggleVariablesPane()
-			return m, nil
-		case key.Matches(msg, m.keys.ToggleBreakpoint):
-			m.toggleBreakpoint()
-			return m, nil
-		case key.Matches(msg, m.keys.Reconnect):
-			return m.reconnect()
-		case key.Matches(msg, m.keys.CommandPalette):
-			m.openCommandPalette()
-			return m, nil
-		case key.Matches(msg, m.keys.PaneMoveMode):
-			if m.panes.FocusedPane() != nil {
-				m.paneMoveMode = true
-			}
-			return m, nil
-		case key.Matches(msg, m.keys.ShowKeys):
-			m.toggleKeysPane()
-			return m, nil
-		case key.Matches(msg, m.keys.CyclePane):
-			if m.panes.HasPanes() {
-				m.panes.FocusNext()
-			}
-			return m, nil
-		case key.Matches(msg, m.keys.Quit):
-			m.confirmQuit = true
-			return m, nil
-		}
-		// Unknown leader sequence - ignore
-		return m, nil
+	if m.chord != nil {
+		return m.chordKey(msg)
 	}
 
 	// Check for leader key
 	if key.Matches(msg, m.keys.Leader) {
-		m.leaderActive = true
+		m.startChord()
 		return m, nil
 	}
 
@@ -505,14 +683,22 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Autocomplete):
 		// Trigger autocomplete request - works in session or editor (edit mode)
 		if fp := m.panes.FocusedPane(); fp != nil {
-			// Editor in edit mode - trigger autocomplete
+			// Editor in edit mode - complete after a name, otherwise fall
+			// through so the editor handles Tab as indent
 			if ep, ok := fp.Content.(*EditorPane); ok && !ep.InTracerMode() {
-				m.requestAutocomplete(ep.window.Token)
-				return m, nil
+				line, pos := m.getAutocompleteContext(ep.window.Token)
+				if m.shouldTriggerAutocomplete(line, pos) {
+					m.requestAutocomplete(ep.window.Token)
+					return m, nil
+				}
 			}
 			// Other pane focused - autocomplete not applicable
 		} else {
 			// Session context - no panes focused
+			if m.tabstopsActive {
+				m.nextTabstop()
+				return m, nil
+			}
 			m.requestAutocomplete(0)
 			return m, nil
 		}
@@ -529,6 +715,13 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				if m.tracerCurrent != 0 {
 					m.closeEditor(m.tracerCurrent)
 				}
+			} else if vp, ok := fp.Content.(*VariablesPane); ok && (vp.Editing() || vp.Filtered()) {
+				// Esc cancels the inline edit or filter, not the pane
+				break
+			} else if dp, ok := fp.Content.(*DebugPane); ok && (dp.Editing() || dp.Filtered()) {
+				break
+			} else if kp, ok := fp.Content.(*KeysPane); ok && (kp.Editing() || kp.Filtered()) {
+				break
 			} else if strings.HasPrefix(fp.ID, "editor:") {
 				// Regular editor pane
 				var token int
@@ -537,6 +730,10 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			} else {
 				m.panes.Remove(fp.ID)
 			}
+		} else {
+			// Session - stop tabbing through placeholders
+			m.tabstopsActive = false
+			m.tabstopSelected = false
 		}
 		return m, nil
 	}
@@ -547,16 +744,21 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		// Check if command palette selected an action
 		if cp, ok := fp.Content.(*CommandPalette); ok && cp.SelectedAction != "" {
-			action := cp.SelectedAction
-			cp.SelectedAction = ""
+			action, args := cp.SelectedAction, cp.SelectedArgs
+			cp.SelectedAction, cp.SelectedArgs = "", ""
 			m.panes.Remove("commands")
+			// Failing to persist only loses the ordering
+			path := commandMRUPath()
+			if err := saveCommandMRU(path, touchCommandMRU(loadCommandMRU(path), action)); err != nil {
+				m.log("Failed to save recent commands: %v", err)
+			}
 			// For breakpoint action, focus the tracer/editor pane first
 			if action == "breakpoint" {
 				if m.panes.Get("tracer") != nil {
 					m.panes.Focus("tracer")
 				}
 			}
-			return (&m).dispatchCommand(action)
+			return (&m).dispatchCommand(action, args)
 		}
 
 		// Check if symbol search selected a symbol
@@ -568,15 +770,86 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Check if symbol search wants a symbol copied
+		if ss, ok := fp.Content.(*SymbolSearch); ok && ss.CopySymbol != 0 {
+			sym := string(ss.CopySymbol)
+			ss.CopySymbol = 0
+			m.statusMsg = "Copied " + sym + " to clipboard"
+			return m, copyToClipboard(sym)
+		}
+
 		// Check if APLcart selected a syntax
 		if ac, ok := fp.Content.(*APLcart); ok && ac.SelectedSyntax != "" {
 			syntax := ac.SelectedSyntax
 			ac.SelectedSyntax = ""
 			m.panes.Remove("aplcart")
-			// Insert the syntax
+			// Insert the syntax, then select its first placeholder. Only
+			// the syntax's own are tabstops, not capitals already typed.
+			start := m.cursorCol
 			for _, r := range syntax {
 				m.insertChar(r)
 			}
+			if ph := aplcartPlaceholders([]rune(syntax)); len(ph) > 0 {
+				lineLen := len(m.currentLineRunes())
+				m.tabstops = nil
+				for _, col := range ph[1:] {
+					m.tabstops = append(m.tabstops, lineLen-(start+col))
+				}
+				m.cursorCol = start + ph[0]
+				m.tabstopsActive = true
+				m.tabstopSelected = true
+			}
+			return m, nil
+		}
+
+		// Check if docs search selected a page
+		if ds, ok := fp.Content.(*DocSearch); ok && ds.SelectedPath != "" {
+			navPath := ds.SelectedPath
+			ds.SelectedPath = ""
+			m.panes.Remove("docsearch")
+			m.openDocPath(navPath)
+			return m, nil
+		}
+
+		// Check if a docs pane wants an external link opened
+		if dp, ok := fp.Content.(*DocPane); ok && dp.OpenURL != "" {
+			url := dp.OpenURL
+			dp.OpenURL = ""
+			return m, m.openURL(url)
+		}
+
+		// Check if breakpoints pane wants a function opened or cleared
+		if bp, ok := fp.Content.(*BreakpointsPane); ok && bp.JumpTo != "" {
+			name := bp.JumpTo
+			bp.JumpTo = ""
+			m.jumpToBreakpoint(name)
+			return m, nil
+		}
+		if bp, ok := fp.Content.(*BreakpointsPane); ok && bp.ClearNames != nil {
+			names := bp.ClearNames
+			bp.ClearNames = nil
+			m.clearBreakpoints(names)
+			return m, nil
+		}
+
+		// Check if variables pane committed an inline edit
+		if vp, ok := fp.Content.(*VariablesPane); ok && vp.AssignName != "" {
+			name, value := vp.AssignName, vp.AssignValue
+			vp.AssignName, vp.AssignValue = "", ""
+			m.assignVariable(vp, name, value)
+			return m, nil
+		}
+
+		// Check if debug pane wants the log saved
+		if dp, ok := fp.Content.(*DebugPane); ok && dp.DumpRequested {
+			dp.DumpRequested = false
+			if path, err := dp.Dump(debugDumpDir(), time.Now()); err != nil {
+				m.log("Failed to save debug log: %v", err)
+				m.statusMsg = "Couldn't save debug log: " + err.Error()
+			} else {
+				m.log("Debug log saved to %s", path)
+				m.statusMsg = "Debug log saved to " + path
+			}
 			return m, nil
 		}
 
@@ -609,7 +882,7 @@ func (m *Model) toggleKeysPane() {
 			paneY = 0
 		}
 
-		keysPane := NewKeysPane(m.keys)
+		keysPane := NewKeysPane(m.keys, m.config.TracerKeys)
 		pane := NewPane("keys", keysPane, paneX, paneY, paneW, paneH)
 		m.panes.Add(pane)
 		m.panes.Focus("keys")
@@ -646,8 +919,29 @@ func (m *Model) toggleDebugPane() {
 }
 
 func (m Model) handleSessionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing over a selected placeholder replaces it; any other key deselects
+	replacing := m.tabstopSelected
+	m.tabstopSelected = false
+
+	switch {
+	case key.Matches(msg, m.keys.ExecuteBlock):
+		m.tabstopsActive = false
+		if m.inBlock() && m.openBraces() <= 0 {
+			return m.executeBlock(true)
+		}
+		return m.execute()
+	case key.Matches(msg, m.keys.NewLine):
+		m.tabstopsActive = false
+		m.newBlockLine()
+		return m, nil
+	case key.Matches(msg, m.keys.InsertResult):
+		m.insertResult()
+		return m, nil
+	}
+
 	switch msg.Type {
 	case tea.KeyEnter:
+		m.tabstopsActive = false
 		return m.execute()
 
 	case tea.KeyBackspace:
@@ -674,6 +968,7 @@ func (m Model) handleSessionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.cursorRow > 0 {
 			m.cursorRow--
 			m.clampCol()
+			m.scrollY = m.sessionStart(m.sessionHeight())
 		}
 		return m, nil
 
@@ -717,11 +1012,17 @@ func (m Model) handleSessionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeySpace:
+		if replacing {
+			m.deleteCharForward()
+		}
 		m.insertChar(' ')
 		return m, nil
 
 	default:
 		if len(msg.Runes) > 0 {
+			if replacing {
+				m.deleteCharForward()
+			}
 			for _, r := range msg.Runes {
 				m.insertChar(r)
 			}
@@ -746,6 +1047,11 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Clicking moves focus/cursor, so any completion popup is stale
+	if msg.Type == tea.MouseLeft {
+		m.acPopup = nil
+	}
+
 	// Hit test for pane interactions
 	pane := m.panes.PaneAt(msg.X, msg.Y)
 
@@ -774,6 +1080,9 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 				fp.Focused = false
 				m.panes.focusedID = ""
 			}
+			if m.clickSession(msg.X, msg.Y) {
+				return m, m.selectClick(msg.X, msg.Y)
+			}
 		}
 		return m, nil
 
@@ -869,6 +1178,17 @@ func (m Model) execute() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// An unclosed dfn continues on a new line
+	if last := len(m.lines) - 1; (m.cursorRow == last || m.inBlock()) && m.openBraces() > 0 {
+		m.cursorRow = last
+		m.cursorCol = len([]rune(m.lines[last].Text))
+		m.newBlockLine()
+		return m, nil
+	}
+	if m.inBlock() {
+		return m.executeBlock(false)
+	}
+
 	editedText := m.currentLine()
 	code := strings.TrimSpace(editedText)
 	isInputLine := m.cursorRow == len(m.lines)-1
@@ -905,14 +1225,23 @@ func (m Model) execute() (tea.Model, tea.Cmd) {
 		m.cursorRow = lastIdx
 	}
 	m.cursorCol = len([]rune(editedText))
+	m.lines[m.cursorRow].Input = true
+	if m.blockLines > 0 {
+		return m.executeBlock(false) // A history line run from a block's input line
+	}
 
 	m.ready = false
-	m.lastExecute = editedText + "\n" // Track what we sent to skip our own echo
+	text := editedText + "\n"
+	if captured, ok := captureResult(code, m.config.ResultVar); ok {
+		text = aplIndent + captured + "\n"
+	}
+	m.expectEcho(text) // Track what we sent to skip our own echo
 	m.pendingQuit = strings.TrimSpace(editedText) == ")off"
 	m.log("→ Execute %q", editedText)
+	m.tutorialEvent(TutorialExecute)
 
 	// Send to interpreter
-	if err := m.send("Execute", map[string]any{"text": m.lastExecute, "trace": 0}); err != nil {
+	if err := m.send("Execute", map[string]any{"text": text, "trace": 0}); err != nil {
 		// Disconnect handled by send(), just return
 		return m, nil
 	}
@@ -951,6 +1280,7 @@ func (m *Model) saveEditor(token int) {
 	}
 
 	m.log("→ SaveChanges win=%d", token)
+	m.recordBreakpoints(w)
 
 	m.send("SaveChanges", map[string]any{
 		"win":     token,
@@ -987,6 +1317,7 @@ func (m *Model) sendSetLineAttributes(token int) {
 	}
 
 	m.log("→ SetLineAttributes win=%d stop=%v", token, w.Stop)
+	m.recordBreakpoints(w)
 
 	m.send("SetLineAttributes", map[string]any{
 		"win":     token,
@@ -1004,6 +1335,7 @@ func (m *Model) tracerStepInto() {
 	}
 	m.log("→ StepInto win=%d", m.tracerCurrent)
 	m.send("StepInto", map[string]any{"win": m.tracerCurrent})
+	m.tutorialEvent(TutorialTrace)
 }
 
 func (m *Model) tracerStepOver() {
@@ -1012,6 +1344,7 @@ func (m *Model) tracerStepOver() {
 	}
 	m.log("→ RunCurrentLine win=%d", m.tracerCurrent)
 	m.send("RunCurrentLine", map[string]any{"win": m.tracerCurrent})
+	m.tutorialEvent(TutorialTrace)
 }
 
 func (m *Model) tracerStepOut() {
@@ -1022,6 +1355,27 @@ func (m *Model) tracerStepOut() {
 	m.send("ContinueTrace", map[string]any{"win": m.tracerCurrent})
 }
 
+// tracerApply saves edits to the function suspended in the tracer, going
+// back to tracing once ReplySaveChanges confirms the interpreter took them
+func (m *Model) tracerApply() {
+	pane := m.panes.Get("tracer")
+	if pane == nil {
+		return
+	}
+	ep, ok := pane.Content.(*EditorPane)
+	if !ok {
+		return
+	}
+	w := ep.window
+	if !w.Modified {
+		ep.ExitEditMode()
+		return
+	}
+	w.PendingApply = true
+	m.saveEditor(w.Token)
+	m.log("  (waiting for ReplySaveChanges before tracing)")
+}
+
 func (m *Model) tracerContinue() {
 	if m.tracerCurrent == 0 {
 		return
@@ -1030,6 +1384,24 @@ func (m *Model) tracerContinue() {
 	m.send("Continue", map[string]any{"win": m.tracerCurrent})
 }
 
+// tracerCutback abandons the function in the tracer and returns to its
+// caller. The frame is popped straight away; the interpreter's CloseWindow
+// for it then finds nothing left to do.
+func (m *Model) tracerCutback() {
+	pane := m.panes.Get("tracer")
+	if pane == nil {
+		return
+	}
+	ep, ok := pane.Content.(*EditorPane)
+	if !ok {
+		return
+	}
+	token := ep.window.Token
+	m.log("→ Cutback win=%d", token)
+	m.send("Cutback", map[string]any{"win": token})
+	m.removeFromTracerStack(token)
+}
+
 func (m *Model) tracerResumeAll() {
 	m.log("→ RestartThreads")
 	m.send("RestartThreads", map[string]any{})
@@ -1112,6 +1484,7 @@ func (m *Model) removeFromTracerStack(token int) {
 			break
 		}
 	}
+	m.markThreads()
 
 	// If we removed the current tracer, switch to new top of stack
 	if m.tracerCurrent == token {
@@ -1142,7 +1515,7 @@ func (m *Model) showTracer(token int) {
 		}
 	} else {
 		// Create tracer pane
-		editorPane := NewEditorPane(w, m.config.TracerKeys,
+		editorPane := NewEditorPane(w, m.config.TracerKeys, m.config.Editor,
 			func() { m.saveEditor(m.tracerCurrent) },
 			func() { m.closeEditor(m.tracerCurrent) },
 		)
@@ -1156,6 +1529,8 @@ func (m *Model) showTracer(token int) {
 			ResumeAll: func() { m.tracerResumeAll() },
 			Backward:  func() { m.tracerBackward() },
 			Forward:   func() { m.tracerForward() },
+			Apply:     func() { m.tracerApply() },
+			Cutback:   func() { m.tracerCutback() },
 		})
 
 		// Position: center of screen
@@ -1176,6 +1551,31 @@ func (m *Model) showTracer(token int) {
 	}
 }
 
+// requestSIStack asks the interpreter for the full SI stack, so frames
+// without a tracer window show in the stack pane
+func (m *Model) requestSIStack() {
+	if m.siStack == nil {
+		return
+	}
+	m.log("→ GetSIStack")
+	m.send("GetSIStack", map[string]any{})
+}
+
+// openStackFrame shows a frame's tracer window, opening the function in an
+// editor if the interpreter reported the frame but no window is open
+func (m *Model) openStackFrame(frame StackFrame) {
+	if frame.Token != 0 {
+		m.showTracer(frame.Token)
+		return
+	}
+	// As an internal query, so its prompt isn't taken for another query's
+	// reply and nothing is echoed into the session
+	if m.internalQueryWaiting() {
+		return
+	}
+	m.executeInternal(")ed "+frame.Name, func([]string) {})
+}
+
 func (m *Model) getStackFrames() []StackFrame {
 	frames := make([]StackFrame, 0, len(m.tracerStack))
 	for _, token := range m.tracerStack {
@@ -1185,15 +1585,43 @@ func (m *Model) getStackFrames() []StackFrame {
 				code = strings.TrimSpace(w.Text[w.CurrentRow])
 			}
 			frames = append(frames, StackFrame{
-				Token:   token,
-				Name:    w.Name,
-				Line:    w.CurrentRow,
-				Code:    code,
-				Current: token == m.tracerCurrent,
+				Token:      token,
+				Name:       w.Name,
+				Line:       w.CurrentRow,
+				Code:       code,
+				Current:    token == m.tracerCurrent,
+				Thread:     w.Thread,
+				ThreadName: w.ThreadName,
 			})
 		}
 	}
-	return frames
+	if m.siStack == nil {
+		return groupByThread(frames)
+	}
+	// The SI is the shown thread's
+	si := slices.Clone(m.siStack.Frames)
+	if w, ok := m.editors[m.tracerCurrent]; ok {
+		for i := range si {
+			si[i].Thread, si[i].ThreadName = w.Thread, w.ThreadName
+		}
+	}
+	return groupByThread(mergeStackFrames(si, frames))
+}
+
+// markThreads flags the tracer windows to name their thread in the title
+// when more than one thread is suspended
+func (m *Model) markThreads() {
+	threads := map[int]bool{}
+	for _, token := range m.tracerStack {
+		if w, ok := m.editors[token]; ok {
+			threads[w.Thread] = true
+		}
+	}
+	for _, token := range m.tracerStack {
+		if w, ok := m.editors[token]; ok {
+			w.ShowThread = len(threads) > 1
+		}
+	}
 }
 
 func (m *Model) toggleStackPane() {
@@ -1205,8 +1633,9 @@ func (m *Model) toggleStackPane() {
 	// Create stack pane
 	stackPane := NewStackPane(
 		func() []StackFrame { return m.getStackFrames() },
-		func(token int) { m.showTracer(token) },
+		func(frame StackFrame) { m.openStackFrame(frame) },
 	)
+	m.requestSIStack()
 
 	// Position: right side of screen
 	paneW := 30
@@ -1222,6 +1651,140 @@ func (m *Model) toggleStackPane() {
 	m.panes.Focus("stack")
 }
 
+// recordBreakpoints notes a window's breakpoints for the breakpoints pane
+func (m *Model) recordBreakpoints(w *EditorWindow) {
+	if m.breakpoints != nil {
+		m.breakpoints.Set(w.Name, w.Stop)
+	}
+}
+
+func (m *Model) toggleBreakpointsPane() {
+	if m.panes.Get("breakpoints") != nil {
+		m.panes.Remove("breakpoints")
+		return
+	}
+	if m.breakpoints == nil {
+		return
+	}
+
+	bps := m.breakpoints
+	breakpointsPane := NewBreakpointsPane(func() []BreakpointEntry { return bps.List() })
+	m.refreshBreakpoints()
+
+	// Position: right side of screen, like the stack pane
+	paneW := 30
+	paneH := min(m.height-4, 12)
+	if paneH < 5 {
+		paneH = 5
+	}
+	paneX := m.width - paneW - 2
+	paneY := 2
+
+	pane := NewPane("breakpoints", breakpointsPane, paneX, paneY, paneW, paneH)
+	m.panes.Add(pane)
+	m.panes.Focus("breakpoints")
+}
+
+// refreshBreakpoints queries ⎕STOP for the functions in the current
+// namespace and any others already known to have breakpoints. While
+// another internal query is waiting it's put off until that one completes.
+func (m *Model) refreshBreakpoints() {
+	bps := m.breakpoints
+	if bps == nil || !m.ready || !m.connected {
+		return
+	}
+	if m.internalQuery != "" {
+		m.breakpointsWaiting = true
+		return
+	}
+	m.breakpointsWaiting = false
+	m.executeInternal(stopQueryExpr(bps.Names()), func(outputs []string) {
+		for name, lines := range parseStopQuery(outputs) {
+			bps.Set(name, lines)
+		}
+	})
+}
+
+// jumpToBreakpoint shows a function with breakpoints: an open window gets
+// focus with the cursor on its first breakpoint, otherwise it's opened with )ed
+func (m *Model) jumpToBreakpoint(name string) {
+	for token, w := range m.editors {
+		if !sameFunction(w.Name, name) {
+			continue
+		}
+		paneID := fmt.Sprintf("editor:%d", token)
+		if m.isInTracerStack(token) {
+			m.showTracer(token)
+			paneID = "tracer"
+		}
+		if len(w.Stop) > 0 {
+			first := slices.Min(w.Stop)
+			if first < len(w.Text) {
+				w.CursorRow, w.CursorCol = first, 0
+			}
+		}
+		m.panes.Focus(paneID)
+		return
+	}
+	m.log("→ Execute )ed %s", name)
+	m.send("Execute", map[string]any{"text": ")ed " + name + "\n", "trace": 0})
+}
+
+// toggleBreakpointAt toggles ⎕STOP on the lines given as "FnName 3" without
+// opening the function. Open windows on the function get the new stops.
+func (m *Model) toggleBreakpointAt(args string) {
+	name, lines, err := parseBreakArgs(args)
+	if err != nil {
+		m.statusMsg = err.Error()
+		return
+	}
+	if m.internalQueryWaiting() {
+		return
+	}
+	bps, editors := m.breakpoints, m.editors
+	m.executeInternal(stopToggleExpr(name, lines), func(outputs []string) {
+		stops, ok := parseStops(outputs)
+		if !ok {
+			m.log("break %s failed: %s", name, strings.TrimSpace(strings.Join(outputs, "")))
+			return
+		}
+		if bps != nil {
+			bps.Set(name, stops)
+		}
+		for _, w := range editors {
+			if sameFunction(w.Name, name) {
+				w.Stop = stops
+			}
+		}
+	})
+	m.statusMsg = fmt.Sprintf("Toggled breakpoint on %s%v", name, lines)
+}
+
+// clearBreakpoints removes all ⎕STOP settings from the named functions,
+// including any open windows showing them
+func (m *Model) clearBreakpoints(names []string) {
+	if m.internalQueryWaiting() {
+		return
+	}
+	for _, name := range names {
+		for token, w := range m.editors {
+			if sameFunction(w.Name, name) && len(w.Stop) > 0 {
+				w.Stop = nil
+				m.sendSetLineAttributes(token)
+			}
+		}
+		if m.breakpoints != nil {
+			m.breakpoints.Set(name, nil)
+		}
+	}
+	m.executeInternal(stopClearExpr(names), func(outputs []string) {})
+	if len(names) == 1 {
+		m.statusMsg = "Cleared breakpoints in " + names[0]
+	} else {
+		m.statusMsg = fmt.Sprintf("Cleared breakpoints in %d functions", len(names))
+	}
+}
+
 func (m *Model) toggleVariablesPane() {
 	if p := m.panes.Get("variables"); p != nil {
 		// If already open but not focused, focus it; otherwise close
@@ -1268,7 +1831,9 @@ func (m *Model) toggleVariablesPane() {
 	m.panes.Focus("variables")
 }
 
-// fetchVariables fetches variables and populates the variables pane
+// fetchVariables fetches variables and populates the variables pane. While
+// another internal query is waiting the pane is left loading, and fetched
+// once that query completes.
 func (m *Model) fetchVariables(pane *VariablesPane) {
 	if m.client == nil {
 		pane.Clear()
@@ -1276,6 +1841,9 @@ func (m *Model) fetchVariables(pane *VariablesPane) {
 	}
 
 	pane.SetLoading(true)
+	if m.internalQuery != "" {
+		return
+	}
 
 	// For locals mode in tracer, we can extract names from source without querying
 	if len(m.tracerStack) > 0 && pane.Mode() == VarsModeLocals {
@@ -1346,22 +1914,12 @@ func (m *Model) fetchVariables(pane *VariablesPane) {
 		}
 	}
 
-	// Single query: get names and values in one shot
-	// {⎕←⍵,'=',⍕⍎⍵}¨↓⎕NL 2 - for each name from ⎕NL 2, print name=value
-	m.executeInternal("{⎕←⍵,'=',⍕⍎⍵}¨↓⎕NL 2", func(outputs []string) {
-		var vars []LocalVar
-		for _, output := range outputs {
-			for _, line := range strings.Split(output, "\n") {
-				line = strings.TrimSpace(line)
-				if idx := strings.Index(line, "="); idx > 0 {
-					name := strings.TrimSpace(line[:idx])
-					value := strings.TrimSpace(line[idx+1:])
-					if nl := strings.Index(value, "\n"); nl != -1 {
-						value = value[:nl] + "..."
-					}
-					vars = append(vars, LocalVar{Name: name, Value: value, IsLocal: localVars[name]})
-				}
-			}
+	// Single query: get names, types and values in one shot - for each name
+	// from ⎕NL 2, print name⍴shape⌶dr≡depth=value
+	m.executeInternal(varInfoExpr("↓⎕NL 2"), func(outputs []string) {
+		vars := parseVarInfoLines(outputs)
+		for i := range vars {
+			vars[i].IsLocal = localVars[vars[i].Name]
 		}
 		if len(vars) == 0 {
 			pane.Clear()
@@ -1372,45 +1930,70 @@ func (m *Model) fetchVariables(pane *VariablesPane) {
 	})
 }
 
-// fetchVarValuesInternal fetches all variable values in one APL query
-// locals is a set of variable names declared as local in the function header
-func (m *Model) fetchVarValuesInternal(pane *VariablesPane, names []string, locals map[string]bool) {
-	if len(names) == 0 {
-		pane.Clear()
+// fetchWaitingVariables fetches the variables pane's contents if a fetch
+// had to wait for another internal query
+func (m *Model) fetchWaitingVariables() {
+	if m.internalQuery != "" {
 		return
 	}
-
-	// Build APL expression: {⎕←⍵,'=',⍕⍎⍵}¨'a' 'b' 'c'
-	// This prints each name=value on its own line
-	var quotedNames []string
-	for _, name := range names {
-		quotedNames = append(quotedNames, "'"+name+"'")
+	if p := m.panes.Get("variables"); p != nil {
+		if vp, ok := p.Content.(*VariablesPane); ok && vp.loading {
+			m.fetchVariables(vp)
+		}
 	}
-	expr := "{⎕←⍵,'=',⍕⍎⍵}¨" + strings.Join(quotedNames, " ")
+}
 
+// assignVariable sets a variable from the variables pane's inline edit and
+// reads back its new value. An error (SYNTAX ERROR, VALUE ERROR, ...) stops
+// the read-back, so the pane shows it and stays in edit mode.
+func (m *Model) assignVariable(pane *VariablesPane, name, value string) {
+	if m.internalQuery != "" {
+		pane.AssignDone(LocalVar{Name: name}, "waiting for the interpreter, try again")
+		return
+	}
+	expr := name + "←" + value + "⋄" + varInfoExpr(aplNames([]string{name}))
 	m.executeInternal(expr, func(outputs []string) {
-		// Parse name=value lines from output
-		valueMap := make(map[string]string)
-		for _, output := range outputs {
-			for _, line := range strings.Split(output, "\n") {
-				line = strings.TrimSpace(line)
-				if idx := strings.Index(line, "="); idx > 0 {
-					name := strings.TrimSpace(line[:idx])
-					value := strings.TrimSpace(line[idx+1:])
-					valueMap[name] = value
-				}
+		for _, vr := range parseVarInfoLines(outputs) {
+			if vr.Name == name {
+				pane.AssignDone(vr, "")
+				return
+			}
+		}
+		errMsg := "no value after assignment"
+		for _, line := range strings.Split(strings.Join(outputs, ""), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				errMsg = line
+				break
 			}
 		}
+		pane.AssignDone(LocalVar{Name: name}, errMsg)
+	})
+}
+
+// fetchVarValuesInternal fetches all variable values in one APL query
+// locals is a set of variable names declared as local in the function header
+func (m *Model) fetchVarValuesInternal(pane *VariablesPane, names []string, locals map[string]bool) {
+	if len(names) == 0 {
+		pane.Clear()
+		return
+	}
+	if m.internalQuery != "" {
+		return // Still loading: fetchVariables tries again
+	}
+
+	// Prints each name⍴shape⌶dr≡depth=value on its own line
+	m.executeInternal(varInfoExpr(aplNames(names)), func(outputs []string) {
+		info := make(map[string]LocalVar)
+		for _, vr := range parseVarInfoLines(outputs) {
+			info[vr.Name] = vr
+		}
 
-		// Build vars list with values
+		// Build vars list in name order; names not yet assigned have no value
 		var vars []LocalVar
 		for _, name := range names {
-			value := valueMap[name]
-			// Truncate multi-line values for display
-			if nl := strings.Index(value, "\n"); nl != -1 {
-				value = value[:nl] + "..."
-			}
-			vars = append(vars, LocalVar{Name: name, Value: value, IsLocal: locals[name]})
+			vr := info[name]
+			vr.Name, vr.IsLocal = name, locals[name]
+			vars = append(vars, vr)
 		}
 		pane.SetVars(vars)
 	})
@@ -1432,6 +2015,8 @@ func (m *Model) requestAutocomplete(token int) {
 	}
 
 	m.acPending = true
+	m.acToken = token
+	m.acPos = pos
 	m.log("→ GetAutocomplete line=%q pos=%d token=%d", line, pos, token)
 	m.send("GetAutocomplete", map[string]any{
 		"line":  line,
@@ -1493,6 +2078,93 @@ func (m *Model) getAutocompleteContext(token int) (string, int) {
 // showAutocomplete displays the autocomplete popup with options
 func (m *Model) showAutocomplete(options []string, skip, token, triggerCol int) {
 	m.acPopup = NewAutocomplete(options, skip, token, triggerCol)
+	m.classifyCompletions(m.acPopup)
+	m.describeCompletion()
+}
+
+// describeCompletion looks up a one-line description of the selected
+// completion, once: a glyph's from the symbol table, a system name's or
+// keyword's from the docs' help links, and a user function's first comment
+// from the interpreter. Lookups happen here rather than in Render; the
+// interpreter's answer arrives later and shows when it does.
+func (m *Model) describeCompletion() {
+	popup := m.acPopup
+	if popup == nil || len(popup.Options) == 0 {
+		return
+	}
+	if _, ok := popup.Note(); ok {
+		return
+	}
+	opt := popup.Options[popup.Selected]
+
+	if runes := []rune(opt.Text); len(runes) == 1 {
+		for _, sym := range aplSymbols {
+			if sym.Char == runes[0] {
+				popup.SetNote(opt.Text, sym.Desc)
+				return
+			}
+		}
+	}
+
+	switch opt.Class {
+	case ClassSystem, ClassKeyword, ClassCommand:
+		db := m.docsDB()
+		if db == nil {
+			return
+		}
+		var navPath string
+		err := db.QueryRow("SELECT path FROM help_urls WHERE symbol = ? COLLATE NOCASE", opt.Text).Scan(&navPath)
+		if err != nil {
+			popup.SetNote(opt.Text, "")
+			return
+		}
+		// The last part of the nav path is the page's title
+		if i := strings.LastIndex(navPath, " / "); i >= 0 {
+			navPath = navPath[i+len(" / "):]
+		}
+		popup.SetNote(opt.Text, navPath)
+
+	case ClassFunction, ClassOperator:
+		if m.internalQuery != "" || !m.ready {
+			return // Try again when it's next selected
+		}
+		name := opt.Text
+		popup.SetNote(name, "")
+		m.executeInternalThen(firstCommentExpr(name), func(m *Model, outputs []string) {
+			popup.SetNote(name, strings.TrimSpace(strings.Join(outputs, "")))
+		})
+	}
+}
+
+// firstCommentExpr asks for the comment on the line after a function's
+// header (or a dfn's opening brace), or an empty line if it has none
+func firstCommentExpr(name string) string {
+	return `{l←⎕NR ⍵ ⋄ 2>≢l:'' ⋄ c←{(∨\⍵≠' ')/⍵}⊃1↓l ⋄ '⍝'≠⊃c,' ':'' ⋄ c}'` + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// classifyCompletions looks up the classes of a popup's user names with
+// ⎕NC, unless another internal query is waiting, so the popup can color
+// them once the reply comes back
+func (m *Model) classifyCompletions(popup *Autocomplete) {
+	names := popup.Unclassified()
+	if len(names) == 0 || m.internalQuery != "" || !m.ready {
+		return
+	}
+	m.executeInternalThen("⎕NC"+aplNames(names), func(m *Model, outputs []string) {
+		var ncs []float64
+		for _, field := range strings.Fields(strings.Join(outputs, " ")) {
+			nc, err := strconv.ParseFloat(strings.ReplaceAll(field, "¯", "-"), 64)
+			if err != nil {
+				m.log("  (name classes not understood: %q)", outputs)
+				return
+			}
+			ncs = append(ncs, nc)
+		}
+		popup.SetClasses(names, ncs)
+		if m.acPopup == popup {
+			m.describeCompletion() // The selected option may be a function
+		}
+	})
 }
 
 // insertAutocomplete inserts the selected completion option
@@ -1509,37 +2181,17 @@ func (m *Model) insertAutocomplete() {
 	m.log("  inserting completion: %s (skip=%d, triggerCol=%d)", option, skip, triggerCol)
 
 	if token == 0 {
-		// Session context - replace from triggerCol-skip to triggerCol
-		runes := m.currentLineRunes()
-		replaceStart := triggerCol - skip
-		if replaceStart < 0 {
-			replaceStart = 0
-		}
-
-		newRunes := make([]rune, 0, len(runes)-skip+len([]rune(option)))
-		newRunes = append(newRunes, runes[:replaceStart]...)
-		newRunes = append(newRunes, []rune(option)...)
-		newRunes = append(newRunes, runes[triggerCol:]...)
-
-		m.setCurrentLine(string(newRunes))
-		m.cursorCol = replaceStart + len([]rune(option))
+		// Session context
+		runes, col := applyCompletion(m.currentLineRunes(), triggerCol, skip, option)
+		m.setCurrentLine(string(runes))
+		m.cursorCol = col
 	} else {
 		// Editor context
 		if w, exists := m.editors[token]; exists {
 			if w.CursorRow >= 0 && w.CursorRow < len(w.Text) {
-				runes := []rune(w.Text[w.CursorRow])
-				replaceStart := triggerCol - skip
-				if replaceStart < 0 {
-					replaceStart = 0
-				}
-
-				newRunes := make([]rune, 0, len(runes)-skip+len([]rune(option)))
-				newRunes = append(newRunes, runes[:replaceStart]...)
-				newRunes = append(newRunes, []rune(option)...)
-				newRunes = append(newRunes, runes[triggerCol:]...)
-
-				w.Text[w.CursorRow] = string(newRunes)
-				w.CursorCol = replaceStart + len([]rune(option))
+				runes, col := applyCompletion([]rune(w.Text[w.CursorRow]), triggerCol, skip, option)
+				w.Text[w.CursorRow] = string(runes)
+				w.CursorCol = col
 				w.Modified = true
 			}
 		}
@@ -1548,6 +2200,19 @@ func (m *Model) insertAutocomplete() {
 	m.acPopup = nil
 }
 
+// applyCompletion replaces the skip characters before col with option,
+// returning the new line and the cursor column after the inserted text
+func applyCompletion(runes []rune, col, skip int, option string) ([]rune, int) {
+	col = clamp(col, 0, len(runes))
+	start := max(col-skip, 0)
+
+	newRunes := make([]rune, 0, len(runes)-(col-start)+len([]rune(option)))
+	newRunes = append(newRunes, runes[:start]...)
+	newRunes = append(newRunes, []rune(option)...)
+	newRunes = append(newRunes, runes[col:]...)
+	return newRunes, start + len([]rune(option))
+}
+
 // renderAutocompleteOverlay renders the autocomplete popup over the base content
 func (m *Model) renderAutocompleteOverlay(base string, screenW, screenH int) string {
 	if m.acPopup == nil {
@@ -1564,12 +2229,21 @@ func (m *Model) renderAutocompleteOverlay(base string, screenW, screenH int) str
 		}
 	}
 
-	// Position popup at top-right (same position as stack pane)
+	// Position popup below the editor cursor, or at top-right (same
+	// position as stack pane) for the session
 	popupX := screenW - popupW - 2
 	popupY := 2
-	if popupX < 0 {
-		popupX = 0
+	if x, y, ok := m.editorCursorScreenPos(m.acPopup.Token); ok {
+		// Align the first option under the start of the word being completed
+		popupX = x - m.acPopup.Skip - 1 - classPrefixWidth
+		popupY = y + 1
+		if popupY+popupH > screenH {
+			// No room below - show above the cursor line
+			popupY = y - popupH
+		}
 	}
+	popupX = clamp(popupX, 0, max(screenW-popupW, 0))
+	popupY = max(popupY, 0)
 
 	// Use cellbuf for ANSI-aware compositing
 	baseLines := strings.Split(base, "\n")
@@ -1581,27 +2255,33 @@ func (m *Model) renderAutocompleteOverlay(base string, screenW, screenH int) str
 	// Create popup buffer and overlay it
 	popupBuf := cellbuf.NewBuffer(popupW, popupH)
 	cellbuf.SetContent(popupBuf, popup)
-
-	for dy := 0; dy < popupH; dy++ {
-		y := popupY + dy
-		if y < 0 || y >= baseH {
-			continue
-		}
-		for dx := 0; dx < popupW; dx++ {
-			x := popupX + dx
-			if x < 0 || x >= screenW {
-				continue
-			}
-			cell := popupBuf.Cell(dx, dy)
-			if cell != nil {
-				buf.SetCell(x, y, cell)
-			}
-		}
-	}
+	overlayCells(buf, popupBuf, popupX, popupY)
 
 	return cellbuf.Render(buf)
 }
 
+// editorCursorScreenPos returns the screen position of the cursor in the
+// pane showing the editor with the given token
+func (m *Model) editorCursorScreenPos(token int) (x, y int, ok bool) {
+	if token == 0 {
+		return 0, 0, false
+	}
+	pane := m.panes.Get(fmt.Sprintf("editor:%d", token))
+	if pane == nil && token == m.tracerCurrent {
+		pane = m.panes.Get("tracer")
+	}
+	if pane == nil {
+		return 0, 0, false
+	}
+	ep, isEditor := pane.Content.(*EditorPane)
+	if !isEditor {
+		return 0, 0, false
+	}
+	cx, cy := ep.CursorPos()
+	// +1 for the pane border
+	return pane.X + 1 + cx, pane.Y + 1 + cy, true
+}
+
 // isTracerFocused returns true if the focused pane is a tracer in trace mode (not edit mode)
 func (m *Model) isTracerFocused() bool {
 	fp := m.panes.FocusedPane()
@@ -1632,14 +2312,19 @@ func (m *Model) toggleBreakpoint() {
 
 	// Send immediately so breakpoint takes effect without requiring save
 	m.sendSetLineAttributes(ep.window.Token)
+	m.tutorialEvent(TutorialBreakpoint)
 }
 
-func (m *Model) dispatchCommand(action string) (tea.Model, tea.Cmd) {
+// dispatchCommand runs a palette command; args holds any text typed after
+// the name of a command that takes arguments
+func (m *Model) dispatchCommand(action, args string) (tea.Model, tea.Cmd) {
 	switch action {
 	case "debug":
 		m.toggleDebugPane()
 	case "stack":
 		m.toggleStackPane()
+	case "breakpoints":
+		m.toggleBreakpointsPane()
 	case "variables":
 		m.toggleVariablesPane()
 	case "breakpoint":
@@ -1650,8 +2335,15 @@ func (m *Model) dispatchCommand(action string) (tea.Model, tea.Cmd) {
 		m.openSymbolSearch()
 	case "aplcart":
 		return m.openAPLcart()
+	case "search-docs":
+		m.openDocSearch()
+	case "build-docs":
+		return *m, m.buildDocs()
 	case "reconnect":
 		return m.reconnect()
+	case "reload-config":
+		cmd := m.ReloadConfig()
+		return *m, cmd
 	case "save":
 		m.saveSession()
 	case "quit":
@@ -1667,16 +2359,88 @@ func (m *Model) dispatchCommand(action string) (tea.Model, tea.Cmd) {
 		m.tracerContinue()
 	case "resume-all":
 		m.tracerResumeAll()
+	case "cutback":
+		m.tracerCutback()
 	case "trace-back":
 		m.tracerBackward()
 	case "trace-forward":
 		m.tracerForward()
 	case "close-all-windows":
 		m.closeAllWindows()
+	case "tutorial":
+		m.toggleTutorial()
+	// Commands with arguments
+	case "link":
+		return m.runInSession(linkCreateExpr(args))
+	case "cs":
+		return m.runInSession(")cs " + args)
+	case "eval":
+		return m.runInSession(args)
+	case "timestamps":
+		m.toggleTimestamps()
+	case "wrap":
+		m.toggleWrap()
+	case "connect":
+		return m.connectProfile(args)
+	case "replay":
+		return m.startReplay(args)
+	case "replay-resume":
+		return m.resumeReplay()
+	case "replay-stop":
+		m.stopReplay()
+	case "break":
+		m.toggleBreakpointAt(args)
+	default:
+		// Interpreter )commands and ]commands run as typed
+		if strings.HasPrefix(action, ")") || strings.HasPrefix(action, "]") {
+			return m.runInSession(strings.TrimSpace(action + " " + args))
+		}
 	}
 	return *m, nil
 }
 
+// loadInterpCommands asks the interpreter for its ]commands the first time
+// the palette opens, adding them to palette when they arrive
+func (m *Model) loadInterpCommands(palette *CommandPalette) {
+	cache := m.interpCmds
+	if cache == nil || cache.Loaded || cache.Pending || !m.ready || !m.connected || m.internalQuery != "" {
+		return
+	}
+	cache.Pending = true
+	m.executeInternal("]?", func(outputs []string) {
+		// No user commands loaded is fine - the palette just has none
+		cache.Commands = parseUserCommands(outputs)
+		cache.Loaded = true
+		cache.Pending = false
+		palette.AddCommands(cache.Commands)
+	})
+}
+
+// runInSession puts code on the input line and executes it, as if typed.
+// Anything already typed there comes back at the next prompt. While the
+// interpreter is busy the last line is its output, so nothing is touched.
+func (m *Model) runInSession(code string) (tea.Model, tea.Cmd) {
+	if !m.ready {
+		m.statusMsg = "Interpreter busy, try again at the prompt"
+		return *m, nil
+	}
+	if len(m.lines) == 0 {
+		m.lines = append(m.lines, Line{})
+	}
+	last := len(m.lines) - 1
+	if input := m.lines[last].Text; strings.TrimSpace(input) != "" && m.heldInput == "" {
+		m.heldInput = input
+		m.heldInputCol = len([]rune(input))
+		if m.cursorRow == last {
+			m.heldInputCol = min(m.cursorCol, m.heldInputCol)
+		}
+	}
+	m.lines[last] = Line{Text: aplIndent + code}
+	m.cursorRow = last
+	m.cursorCol = len([]rune(m.lines[last].Text))
+	return m.execute()
+}
+
 func (m *Model) openSymbolSearch() {
 	if m.panes.Get("symbols") != nil {
 		m.panes.Remove("symbols")
@@ -1684,6 +2448,9 @@ func (m *Model) openSymbolSearch() {
 	}
 
 	ss := NewSymbolSearch()
+	if len(m.config.Backtick) > 0 {
+		ss.keycodes = m.config.backtickKeycodes()
+	}
 
 	// Position: center
 	paneW := 50
@@ -1694,41 +2461,130 @@ func (m *Model) openSymbolSearch() {
 	pane := NewPane("symbols", ss, paneX, paneY, paneW, paneH)
 	m.panes.Add(pane)
 	m.panes.Focus("symbols")
+	m.tutorialEvent(TutorialSymbols)
+}
+
+// nextTabstop moves the cursor to the inserted idiom's next placeholder and
+// selects it, or ends tabbing when there are none left
+func (m *Model) nextTabstop() {
+	n := len(m.currentLineRunes())
+	for len(m.tabstops) > 0 {
+		col := n - m.tabstops[0]
+		m.tabstops = m.tabstops[1:]
+		if col >= 0 && col < n {
+			m.cursorCol = col
+			m.tabstopSelected = true
+			return
+		}
+	}
+	m.tabstopsActive = false
+	m.tabstopSelected = false
+}
+
+// toggleTutorial opens the guided tutorial in the top-right corner. It is
+// not focused, so the session keeps receiving input while steps are followed.
+func (m *Model) toggleTutorial() {
+	if m.panes.Get("tutorial") != nil {
+		m.panes.Remove("tutorial")
+		return
+	}
+
+	// At least a bordered cell on a tiny screen, like a refitted pane
+	paneW := max(min(48, m.width), minPaneSize)
+	paneH := max(min(18, m.height-2), minPaneSize)
+	paneX := max(m.width-paneW-2, 0)
+	paneY := 1
+
+	pane := NewPane("tutorial", NewTutorialPane(), paneX, paneY, paneW, paneH)
+	m.panes.Add(pane)
+}
+
+// tutorialEvent reports a user action to the tutorial pane, if open
+func (m *Model) tutorialEvent(ev TutorialEvent) {
+	pane := m.panes.Get("tutorial")
+	if pane == nil {
+		return
+	}
+	if tp, ok := pane.Content.(*TutorialPane); ok && tp.Advance(ev) {
+		m.log("Tutorial: step %d/%d", min(tp.current+1, len(tp.steps)), len(tp.steps))
+	}
 }
 
 func (m *Model) openDocHelp() (tea.Model, tea.Cmd) {
+	// In APLcart, the docs for the selected idiom's primitive
+	var ac *APLcart
+	if fp := m.panes.FocusedPane(); fp != nil {
+		ac, _ = fp.Content.(*APLcart)
+	}
+
 	// Toggle off if already open
-	if m.panes.Get("docs") != nil {
+	if ac == nil && m.panes.Get("docs") != nil {
 		m.panes.Remove("docs")
 		return *m, nil
 	}
 
-	if m.docsDB == nil {
-		m.log("No docs database (run bundle-docs, copy to ~/.config/gritt/dyalog-docs.db)")
+	db := m.docsDB()
+	if db == nil {
+		m.noDocs()
 		return *m, nil
 	}
 
-	// Get symbol at cursor (to the left of cursor position)
-	symbol := m.symbolAtCursor()
-	if symbol == "" {
-		m.log("No symbol at cursor")
-		return *m, nil
+	var candidates []string
+	if ac != nil {
+		entry, ok := ac.Selected()
+		if !ok {
+			return *m, nil
+		}
+		candidates = aplcartDocSymbols(entry.Syntax)
+		if len(candidates) == 0 {
+			m.statusMsg = "No primitive in " + entry.Syntax
+			return *m, nil
+		}
+	} else {
+		// Get symbol candidates at cursor (to the left of cursor position)
+		candidates = m.docSymbolsAtCursor()
+		if len(candidates) == 0 {
+			m.log("No symbol at cursor")
+			m.statusMsg = "No symbol at cursor"
+			return *m, nil
+		}
 	}
 
-	// Look up in help_urls
+	// Look up in help_urls, longest candidate first
 	var navPath string
-	err := m.docsDB.QueryRow("SELECT path FROM help_urls WHERE symbol = ?", symbol).Scan(&navPath)
-	if err != nil {
-		m.log("No help for %q", symbol)
+	found := false
+	for _, symbol := range candidates {
+		err := db.QueryRow("SELECT path FROM help_urls WHERE symbol = ? COLLATE NOCASE", symbol).Scan(&navPath)
+		if err == nil {
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.log("No help for %q", candidates)
+		m.statusMsg = fmt.Sprintf("No docs for %s", candidates[0])
 		return *m, nil
 	}
 
+	m.openDocPath(navPath)
+	return *m, nil
+}
+
+// openDocPath opens the docs page at navPath in a centered DocPane,
+// replacing any docs pane already open
+func (m *Model) openDocPath(navPath string) {
+	db := m.docsDB()
+	if db == nil {
+		m.noDocs()
+		return
+	}
+
 	// Fetch content
 	var file, content string
-	err = m.docsDB.QueryRow("SELECT file, content FROM docs WHERE path = ?", navPath).Scan(&file, &content)
+	err := db.QueryRow("SELECT file, content FROM docs WHERE path = ?", navPath).Scan(&file, &content)
 	if err != nil {
 		m.log("Doc not found: %s", navPath)
-		return *m, nil
+		return
 	}
 
 	// Open pane
@@ -1739,12 +2595,79 @@ func (m *Model) openDocHelp() (tea.Model, tea.Cmd) {
 
 	processed, links := processLinks(content, file)
 	rendered := RenderMarkdown(processed, paneW-2)
-	doc := NewDocPane(navPath, file, rendered, links, m.docsDB, paneW-2)
+	doc := NewDocPane(navPath, file, rendered, links, db, paneW-2)
+	doc.indexHeadings(processed)
+	doc.version = m.docs.Meta().label()
+	m.panes.Remove("docs")
 	pane := NewPane("docs", doc, paneX, paneY, paneW, paneH)
 	m.panes.Add(pane)
 	m.panes.Focus("docs")
+	m.tutorialEvent(TutorialDocs)
+}
 
-	return *m, nil
+// openDocSearch toggles the full-text docs search pane
+func (m *Model) openDocSearch() {
+	if m.panes.Get("docsearch") != nil {
+		m.panes.Remove("docsearch")
+		return
+	}
+
+	db := m.docsDB()
+	if db == nil {
+		m.noDocs()
+		return
+	}
+
+	ds := NewDocSearch(db)
+
+	// Position: center, larger
+	paneW := min(80, m.width-4)
+	paneH := min(25, m.height-4)
+	paneX := (m.width - paneW) / 2
+	paneY := (m.height - paneH) / 2
+
+	pane := NewPane("docsearch", ds, paneX, paneY, paneW, paneH)
+	m.panes.Add(pane)
+	m.panes.Focus("docsearch")
+}
+
+// docSymbolsAtCursor returns help_urls lookup candidates at the cursor,
+// longest first: system names and keywords (⎕NGET, :If, )CLEAR, ]LINK),
+// two-glyph operators (∘.) and finally the single glyph.
+func (m *Model) docSymbolsAtCursor() []string {
+	runes := m.currentLineRunes()
+	col := m.cursorCol
+	if col <= 0 || col > len(runes) {
+		return nil
+	}
+
+	var candidates []string
+
+	// System name, keyword or command around the cursor, with its ⎕ : ) ]
+	switch text, kind := m.tokenAtCursor(); kind {
+	case TokenSystemName, TokenKeyword:
+		candidates = append(candidates, text)
+	case TokenCommand:
+		// ]link.create is documented under ]LINK
+		candidates = append(candidates, text)
+		if group, _, ok := strings.Cut(text, "."); ok {
+			candidates = append(candidates, group)
+		}
+	}
+
+	// Two-glyph symbols ending at or spanning the cursor
+	isGlyph := func(r rune) bool { return r != ' ' && !isAPLNameChar(r) }
+	if col >= 2 && isGlyph(runes[col-2]) && isGlyph(runes[col-1]) {
+		candidates = append(candidates, string(runes[col-2:col]))
+	}
+	if col < len(runes) && isGlyph(runes[col-1]) && isGlyph(runes[col]) {
+		candidates = append(candidates, string(runes[col-1:col+1]))
+	}
+
+	if sym := m.symbolAtCursor(); sym != "" {
+		candidates = append(candidates, sym)
+	}
+	return candidates
 }
 
 // symbolAtCursor returns the APL symbol or keyword at/before the cursor.
@@ -1767,6 +2690,36 @@ func (m *Model) symbolAtCursor() string {
 	return ""
 }
 
+// jumpToDefinition opens the function or operator named at the cursor in an
+// editor. Checks ⎕NC first so a variable or an undefined name gets a
+// message rather than an empty editor.
+func (m *Model) jumpToDefinition() {
+	name, kind := m.tokenAtCursor()
+	if kind != TokenName {
+		m.statusMsg = "No name at cursor"
+		return
+	}
+	if m.internalQueryWaiting() {
+		return
+	}
+	m.executeInternalThen("⎕NC⊂'"+name+"'", func(m *Model, outputs []string) {
+		class, err := strconv.ParseFloat(strings.TrimSpace(strings.Join(outputs, "")), 64)
+		switch {
+		case err != nil:
+			m.statusMsg = "Could not look up " + name
+		case int(class) == 3 || int(class) == 4:
+			m.log("→ Execute )ed %s", name)
+			m.send("Execute", map[string]any{"text": ")ed " + name + "\n", "trace": 0})
+		case int(class) == 0:
+			m.statusMsg = name + " is not defined"
+		case int(class) == 2:
+			m.statusMsg = name + " is a variable"
+		default:
+			m.statusMsg = name + " is not a function"
+		}
+	})
+}
+
 func (m *Model) openAPLcart() (tea.Model, tea.Cmd) {
 	if m.panes.Get("aplcart") != nil {
 		m.panes.Remove("aplcart")
@@ -1786,12 +2739,23 @@ func (m *Model) openAPLcart() (tea.Model, tea.Cmd) {
 	m.panes.Focus("aplcart")
 
 	// Start fetching data
-	return *m, FetchAPLcart
+	return *m, FetchAPLcart(m.config.APLcart.TTL())
 }
 
 func (m *Model) saveSession() {
 	m.savePromptActive = true
-	m.savePromptFilename = fmt.Sprintf("session-%s", time.Now().Format("20060102-150405"))
+	m.savePromptFormat = exportTranscript
+	m.savePromptFilename = fmt.Sprintf("session-%s", time.Now().Format("20060102-150405")) + exportTranscript.ext()
+}
+
+// cycleSaveFormat switches the save prompt to the next export format,
+// swapping the filename's extension to match
+func (m *Model) cycleSaveFormat() {
+	old := m.savePromptFormat
+	m.savePromptFormat = (old + 1) % exportFormatCount
+	if base, ok := strings.CutSuffix(m.savePromptFilename, old.ext()); ok {
+		m.savePromptFilename = base + m.savePromptFormat.ext()
+	}
 }
 
 func (m *Model) doSaveSession() {
@@ -1800,15 +2764,12 @@ func (m *Model) doSaveSession() {
 		m.log("Save cancelled")
 		return
 	}
-	var sb strings.Builder
-	for _, line := range m.lines {
-		sb.WriteString(line.Text)
-		sb.WriteString("\n")
-	}
-	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
+	text := exportSession(m.lines, m.savePromptFormat)
+	if err := os.WriteFile(filename, []byte(text), 0644); err != nil {
 		m.log("Failed to save session: %v", err)
 	} else {
-		m.log("Session saved to %s", filename)
+		m.log("Session saved to %s (%s)", filename, m.savePromptFormat)
+		m.statusMsg = "Saved " + filename
 	}
 }
 
@@ -1825,23 +2786,47 @@ func (m *Model) openCommandPalette() {
 		{Name: "stack", Help: "Toggle stack pane"},
 		{Name: "variables", Help: "Toggle variables pane (tracer)"},
 		{Name: "breakpoint", Help: "Toggle breakpoint on current line"},
+		{Name: "breakpoints", Help: "List breakpoints in all functions"},
+		{Name: "break", Help: "Toggle breakpoint in a function", Args: "fn line"},
 		{Name: "step-into", Help: "Tracer: step into (Enter)"},
 		{Name: "step-over", Help: "Tracer: step over (n)"},
 		{Name: "step-out", Help: "Tracer: step out (o)"},
 		{Name: "continue", Help: "Tracer: continue (c)"},
 		{Name: "resume-all", Help: "Tracer: resume all threads (r)"},
+		{Name: "cutback", Help: "Tracer: abandon function, back to caller (u)"},
 		{Name: "trace-back", Help: "Tracer: move back (p)"},
 		{Name: "trace-forward", Help: "Tracer: move forward (f)"},
 		{Name: "keys", Help: "Show key bindings"},
 		{Name: "symbols", Help: "Search APL symbols"},
 		{Name: "aplcart", Help: "Search APLcart idioms"},
+		{Name: "search-docs", Help: "Full-text search of Dyalog docs"},
+		{Name: "build-docs", Help: "Build the docs database with bundle-docs"},
 		{Name: "reconnect", Help: "Reconnect to Dyalog"},
+		{Name: "reload-config", Help: "Reload gritt.json (keys, theme)"},
+		{Name: "wrap", Help: "Toggle wrapping long session lines"},
+		{Name: "timestamps", Help: "Toggle output timestamps in the session"},
 		{Name: "close-all-windows", Help: "Close all editors/tracers (clear stuck state)"},
-		{Name: "save", Help: "Save session to file"},
+		{Name: "save", Help: "Save session as a transcript, script or markdown"},
+		{Name: "tutorial", Help: "Guided tour of gritt"},
+		{Name: "link", Help: "Link a directory (]link.create)", Args: "[ns:]path"},
+		{Name: "cs", Help: "Change namespace ()cs)", Args: "namespace"},
+		{Name: "eval", Help: "Run an APL expression in the session", Args: "expression"},
+		{Name: "replay", Help: "Run a script file in the session a line at a time", Args: "path"},
+		{Name: "replay-resume", Help: "Resume a replay paused at an error"},
+		{Name: "replay-stop", Help: "Abandon the replay in progress"},
 		{Name: "quit", Help: "Quit gritt"},
 	}
+	if names := m.config.connectionNames(); len(names) > 0 {
+		commands = append(commands, Command{Name: "connect", Help: "Switch connection: " + strings.Join(names, ", "), Args: "name"})
+	}
+
+	commands = append(commands, systemCommands...)
+	if m.interpCmds != nil && m.interpCmds.Loaded {
+		commands = append(commands, m.interpCmds.Commands...)
+	}
 
-	palette := NewCommandPalette(commands)
+	palette := NewCommandPalette(commands, loadCommandMRU(commandMRUPath()))
+	m.loadInterpCommands(palette)
 
 	// Position: center top
 	paneW := 40
@@ -1854,7 +2839,39 @@ func (m *Model) openCommandPalette() {
 	m.panes.Focus("commands")
 }
 
+// handleRide applies a RIDE event, and any more already queued behind it,
+// then waits for the next one
 func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for n := 1; ; n++ {
+		if ev.client != nil && ev.client != m.client {
+			return m, tea.Batch(cmds...) // From a connection since replaced; its loop has ended
+		}
+		var cmd tea.Cmd
+		m, cmd = m.applyRide(ev)
+		cmds = append(cmds, cmd)
+		if ev.err != nil {
+			return m, tea.Batch(cmds...) // Receive loop has ended
+		}
+		// Stop for a command to run (e.g. tea.Quit), and now and then so
+		// keys aren't kept waiting behind a flood of output
+		if cmd != nil || n == rideQueue {
+			break
+		}
+		select {
+		case ev = <-m.msgs:
+			continue
+		default:
+		}
+		break
+	}
+	return m, tea.Batch(append(cmds, waitForRide(m.msgs))...)
+}
+
+// applyRide updates the Model for one RIDE event. It doesn't wait on the
+// receive loop, so tests can drive it with rideEvent values directly; the
+// returned command is only set when the event needs one (e.g. tea.Quit).
+func (m Model) applyRide(ev rideEvent) (Model, tea.Cmd) {
 	if ev.err != nil {
 		m.connected = false
 		m.ready = false
@@ -1867,7 +2884,12 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 
 		m.log("Disconnected: %v", ev.err)
 		// Append visible disconnect marker to session (with blank line after)
-		m.lines = append(m.lines, Line{Text: "⍝ Disconnected"})
+		marker := "⍝ Disconnected"
+		var frameErr *ride.FrameError
+		if errors.As(ev.err, &frameErr) {
+			marker += " (corrupt RIDE data)"
+		}
+		m.lines = append(m.lines, Line{Text: marker})
 		m.lines = append(m.lines, Line{Text: ""})
 		m.cursorRow = len(m.lines) - 1
 		m.cursorCol = 0
@@ -1879,7 +2901,7 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 		if ev.raw != "" {
 			m.log("← raw: %s", ev.raw)
 		}
-		return m, waitForRide(m.msgs)
+		return m, nil
 	}
 
 	msg := ev.msg
@@ -1893,24 +2915,25 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 
 	switch msg.Command {
 	case "AppendSessionOutput":
-		// Skip input echo (type 14) only if it matches what we sent
-		if t, ok := msg.Args["type"].(float64); ok && int(t) == 14 {
-			if result, ok := msg.Args["result"].(string); ok && result == m.lastExecute {
+		var out ride.AppendSessionOutput
+		if !m.unmarshalRide(msg, &out) {
+			break
+		}
+		// Skip input echo only if it matches what we sent
+		if out.Type == ride.OutputEcho {
+			if m.matchEcho(out.Result) {
 				m.log("  (skipped: our input echo)")
-				m.lastExecute = "" // Clear after matching
-				return m, waitForRide(m.msgs)
+				return m, nil
 			}
 			// Skip internal query echo
-			if m.internalQuery != "" {
-				if result, ok := msg.Args["result"].(string); ok && result == m.internalQuery+"\n" {
-					m.log("  (skipped: internal query echo)")
-					return m, waitForRide(m.msgs)
-				}
+			if m.internalQuery != "" && out.Result == m.internalQuery+"\n" {
+				m.log("  (skipped: internal query echo)")
+				return m, nil
 			}
 			// Skip )off from external input - just noise before disconnect
-			if result, ok := msg.Args["result"].(string); ok && strings.TrimSpace(result) == ")off" {
+			if strings.TrimSpace(out.Result) == ")off" {
 				m.log("  (skipped: external )off)")
-				return m, waitForRide(m.msgs)
+				return m, nil
 			}
 			// Input from elsewhere - display it
 			m.log("  (external input)")
@@ -1918,66 +2941,91 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 
 		// Route output to internal query if one is pending
 		if m.internalQuery != "" {
-			if result, ok := msg.Args["result"].(string); ok {
-				m.internalOutputs = append(m.internalOutputs, result)
-				m.log("  (internal query output)")
-			}
-			return m, waitForRide(m.msgs)
+			m.internalOutputs = append(m.internalOutputs, out.Result)
+			m.log("  (internal query output)")
+			return m, nil
 		}
 
-		if result, ok := msg.Args["result"].(string); ok {
-			result = strings.TrimSuffix(result, "\n")
-			for _, line := range strings.Split(result, "\n") {
-				m.lines = append(m.lines, Line{Text: line})
-			}
-			m.cursorRow = len(m.lines) - 1
-			m.cursorCol = 0
+		texts := strings.Split(strings.TrimSuffix(out.Result, "\n"), "\n")
+		now := time.Now()
+		m.lines = slices.Grow(m.lines, len(texts))
+		for _, text := range texts {
+			m.lines = append(m.lines, Line{Text: text, Input: out.Type == ride.OutputEcho, Time: now})
 		}
+		m.cursorRow = len(m.lines) - 1
+		m.cursorCol = 0
+		m.trimScrollback()
 
 	case "SetPromptType":
-		if t, ok := msg.Args["type"].(float64); ok {
-			wasReady := m.ready
-			m.ready = t > 0
-			m.log("  ready: %v → %v", wasReady, m.ready)
-
-			// Complete internal query if one was pending
-			if m.ready && m.internalQuery != "" {
-				m.log("  internal query complete: %d outputs", len(m.internalOutputs))
-				oldQuery := m.internalQuery
-				if m.internalCallback != nil {
-					m.internalCallback(m.internalOutputs)
-				}
-				// Only clear if callback didn't start a new query
-				if m.internalQuery == oldQuery {
-					m.internalQuery = ""
-					m.internalCallback = nil
-					m.internalOutputs = nil
-				}
-				// Don't add new input line for internal queries
-				return m, waitForRide(m.msgs)
+		var p ride.SetPromptType
+		if !m.unmarshalRide(msg, &p) {
+			break
+		}
+		wasReady := m.ready
+		m.ready = p.Type > 0
+		m.log("  ready: %v → %v", wasReady, m.ready)
+
+		// Complete internal query if one was pending
+		if m.ready && m.internalQuery != "" {
+			m.log("  internal query complete: %d outputs", len(m.internalOutputs))
+			// Clear first, so the callback can start another query
+			callback, outputs := m.internalCallback, m.internalOutputs
+			m.internalQuery = ""
+			m.internalCallback = nil
+			m.internalOutputs = nil
+			if callback != nil {
+				callback(&m, outputs)
+			}
+			// Queries put off for this one go next, one at a time
+			m.fetchWaitingVariables()
+			if m.breakpointsWaiting && m.internalQuery == "" {
+				m.refreshBreakpoints()
 			}
+			// Don't add new input line for internal queries
+			return m, nil
+		}
 
-			if m.ready {
-				// Add new input line with APL indent
-				m.lines = append(m.lines, Line{Text: aplIndent})
-				m.cursorRow = len(m.lines) - 1
-				m.cursorCol = len(aplIndent)
+		if m.ready {
+			// Add new input line with APL indent
+			m.lines = append(m.lines, Line{Text: aplIndent})
+			m.cursorRow = len(m.lines) - 1
+			m.cursorCol = len(aplIndent)
+			m.colOffset = 0
+			if m.heldInput != "" {
+				m.lines[m.cursorRow].Text, m.cursorCol = m.heldInput, m.heldInputCol
+				m.heldInput = ""
 			}
+			return m, m.replayNext()
+		}
+
+	case "HadError":
+		var e ride.HadError
+		if m.internalQuery == "" && m.unmarshalRide(msg, &e) {
+			m.replayError(e.Error)
 		}
 
 	case "OpenWindow":
-		w := NewEditorWindow(msg.Args)
+		var open ride.OpenWindow
+		if !m.unmarshalRide(msg, &open) {
+			break
+		}
+		w := NewEditorWindow(open)
 		m.editors[w.Token] = w
+		m.recordBreakpoints(w)
 
 		if w.Debugger {
 			// Tracer window - add to stack, show single tracer pane
 			m.tracerStack = append(m.tracerStack, w.Token)
+			m.markThreads()
 			m.showTracer(w.Token)
 			m.log("  opened tracer: %s (token=%d, stack depth=%d)", w.Name, w.Token, len(m.tracerStack))
+			if m.panes.Get("stack") != nil {
+				m.requestSIStack()
+			}
 		} else {
 			// Regular editor - create pane as before
 			token := w.Token
-			editorPane := NewEditorPane(w, m.config.TracerKeys,
+			editorPane := NewEditorPane(w, m.config.TracerKeys, m.config.Editor,
 				func() { m.saveEditor(token) },
 				func() { m.closeEditor(token) },
 			)
@@ -2002,19 +3050,31 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 		}
 
 	case "UpdateWindow":
-		token := int(msg.Args["token"].(float64))
+		var update ride.UpdateWindow
+		if !m.unmarshalRide(msg, &update) {
+			break
+		}
+		token := update.Token
 		if w, exists := m.editors[token]; exists {
-			w.Update(msg.Args)
+			w.Update(update)
+			m.recordBreakpoints(w)
 			m.log("  updated: %s (token=%d)", w.Name, token)
 		}
 
 	case "CloseWindow":
-		win := int(msg.Args["win"].(float64))
+		var closed ride.CloseWindow
+		if !m.unmarshalRide(msg, &closed) {
+			break
+		}
+		win := closed.Win
 
 		// Check if this is a tracer window
 		if m.isInTracerStack(win) {
 			m.removeFromTracerStack(win)
 			m.log("  closed tracer: token=%d (stack depth=%d)", win, len(m.tracerStack))
+			if m.panes.Get("stack") != nil {
+				m.requestSIStack()
+			}
 		} else {
 			// Regular editor
 			paneID := fmt.Sprintf("editor:%d", win)
@@ -2024,16 +3084,25 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 		delete(m.editors, win)
 
 	case "ReplySaveChanges":
-		win := int(msg.Args["win"].(float64))
-		errCode := 0
-		if e, ok := msg.Args["err"].(float64); ok {
-			errCode = int(e)
+		var reply ride.ReplySaveChanges
+		if !m.unmarshalRide(msg, &reply) {
+			break
 		}
+		win, errCode := reply.Win, reply.Err
 
 		if errCode == 0 {
 			m.log("  save succeeded: token=%d", win)
 			if w, exists := m.editors[win]; exists {
-				w.Modified = false
+				w.MarkSaved()
+				// Applied tracer edit - back to tracing at the current line
+				if w.PendingApply {
+					w.PendingApply = false
+					if pane := m.panes.Get("tracer"); pane != nil && win == m.tracerCurrent {
+						if ep, ok := pane.Content.(*EditorPane); ok {
+							ep.ExitEditMode()
+						}
+					}
+				}
 				// If close was pending, send CloseWindow now
 				if w.PendingClose {
 					w.PendingClose = false
@@ -2045,12 +3114,20 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 			// Clear pending close on failure
 			if w, exists := m.editors[win]; exists {
 				w.PendingClose = false
+				if w.PendingApply {
+					// Stay in edit mode so the change can be fixed
+					w.PendingApply = false
+					m.statusMsg = "Could not apply changes to " + w.Name
+				}
 			}
 		}
 
 	case "SetHighlightLine":
-		win := int(msg.Args["win"].(float64))
-		line := int(msg.Args["line"].(float64))
+		var hl ride.SetHighlightLine
+		if !m.unmarshalRide(msg, &hl) {
+			break
+		}
+		win, line := hl.Win, hl.Line
 
 		// Store highlight in the window itself
 		if w, exists := m.editors[win]; exists {
@@ -2088,56 +3165,79 @@ func (m Model) handleRide(ev rideEvent) (tea.Model, tea.Cmd) {
 		}
 
 	case "WindowTypeChanged":
-		win := int(msg.Args["win"].(float64))
-		tracer := int(msg.Args["tracer"].(float64))
-		if w, exists := m.editors[win]; exists {
-			w.Debugger = tracer != 0
-			m.log("  window type changed: token=%d, tracer=%v", win, w.Debugger)
+		var changed ride.WindowTypeChanged
+		if !m.unmarshalRide(msg, &changed) {
+			break
+		}
+		if w, exists := m.editors[changed.Win]; exists {
+			w.Debugger = bool(changed.Tracer)
+			m.log("  window type changed: token=%d, tracer=%v", changed.Win, w.Debugger)
+		}
+
+	case "ReplyIdentify":
+		var reply ride.ReplyIdentify
+		if m.unmarshalRide(msg, &reply) {
+			m.handleReplyIdentify(reply)
+		}
+
+	case "ReplyGetSIStack":
+		var reply ride.ReplyGetSIStack
+		if m.siStack != nil && m.unmarshalRide(msg, &reply) {
+			m.siStack.Frames = parseSIStack(reply.Stack)
+			m.log("  SI stack: %d frames", len(m.siStack.Frames))
 		}
 
 	case "ReplyGetAutocomplete":
-		token := int(msg.Args["token"].(float64))
-		skip := 0
-		if s, ok := msg.Args["skip"].(float64); ok {
-			skip = int(s)
-		}
-		var options []string
-		if opts, ok := msg.Args["options"].([]any); ok {
-			for _, o := range opts {
-				if s, ok := o.(string); ok {
-					options = append(options, s)
-				}
-			}
+		var reply ride.ReplyGetAutocomplete
+		if !m.unmarshalRide(msg, &reply) {
+			break
 		}
+		token, skip, options := reply.Token, reply.Skip, reply.Options
 		m.log("  autocomplete: token=%d, skip=%d, options=%d", token, skip, len(options))
 
 		// Ignore if we're not waiting for autocomplete
 		if !m.acPending {
 			m.log("  (no pending request, ignoring)")
-			return m, waitForRide(m.msgs)
+			return m, nil
 		}
 		m.acPending = false
 
 		if len(options) == 0 {
 			// No completions - do nothing
-			return m, waitForRide(m.msgs)
+			return m, nil
 		}
 
-		// Get current cursor position for insertion later
-		_, triggerCol := m.getAutocompleteContext(token)
+		// Skip is relative to the requested position - drop the reply if
+		// the cursor has moved since, or the prefix would be mangled
+		_, col := m.getAutocompleteContext(token)
+		if token != m.acToken || col != m.acPos {
+			m.log("  (stale reply, cursor moved, ignoring)")
+			return m, nil
+		}
+		triggerCol := m.acPos
 
 		if len(options) == 1 {
 			// Single option - auto-insert immediately
 			m.acPopup = NewAutocomplete(options, skip, token, triggerCol)
 			m.insertAutocomplete()
-			return m, waitForRide(m.msgs)
+			return m, nil
 		}
 
 		// Multiple options - show popup
 		m.showAutocomplete(options, skip, token, triggerCol)
 	}
 
-	return m, waitForRide(m.msgs)
+	return m, nil
+}
+
+// unmarshalRide decodes a message's arguments into a typed message,
+// logging and ignoring one that doesn't fit
+func (m *Model) unmarshalRide(msg *ride.Message, v any) bool {
+	if err := msg.Unmarshal(v); err != nil {
+		m.log("  (ignored: %v)", err)
+		return false
+	}
+	return true
 }
 
 func (m Model) View() string {
@@ -2154,11 +3254,7 @@ func (m Model) View() string {
 	}
 
 	// Reserve space for help line
-	helpHeight := 1
-	if m.help.ShowAll {
-		helpHeight = 4 // More space for full help
-	}
-	mainH := h - helpHeight
+	mainH := m.mainHeight(h)
 
 	// Render base session
 	base := m.viewSession(w, mainH)
@@ -2173,37 +3269,214 @@ func (m Model) View() string {
 		base = m.renderAutocompleteOverlay(base, w, mainH)
 	}
 
+	// Symbol cheat sheet while the prefix key is on
+	if m.prefixActive && m.config.PrefixSheet {
+		base = m.renderPrefixSheetOverlay(base, w, mainH)
+	}
+
 	// Add help at bottom
 	m.help.Width = w
 	var helpView string
 	if m.confirmQuit {
-		confirmStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+		confirmStyle := lipgloss.NewStyle().Foreground(theme.Error).Bold(true)
 		helpView = confirmStyle.Render("Quit? (y/n)")
 	} else if m.showQuitHint {
-		hintStyle := lipgloss.NewStyle().Foreground(AccentColor)
+		hintStyle := lipgloss.NewStyle().Foreground(theme.Accent)
 		helpView = hintStyle.Render("Type C-] q to quit")
-	} else if m.leaderActive {
-		leaderStyle := lipgloss.NewStyle().Foreground(AccentColor).Bold(true)
-		helpView = leaderStyle.Render("C-] ...")
+	} else if m.statusMsg != "" {
+		statusStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+		helpView = statusStyle.Render(m.statusMsg)
+	} else if m.chord != nil {
+		leaderStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+		helpView = leaderStyle.Render(strings.Join(append([]string{"C-]"}, m.chordKeys...), " ") + " ...")
 	} else if m.paneMoveMode {
-		moveStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Bold(true)
-		helpView = moveStyle.Render("MOVE: arrows move, shift+arrows resize, esc exit")
+		moveStyle := lipgloss.NewStyle().Foreground(theme.Success).Bold(true)
+		helpView = moveStyle.Render("MOVE: arrows move, shift+arrows resize, h/j/k/l snap, f full, r restore, esc exit")
 	} else if m.savePromptActive {
-		promptStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Bold(true)
-		helpView = promptStyle.Render("Save as: ") + m.savePromptFilename + cursorStyle.Render(" ")
-	} else if m.backtickActive {
-		backtickStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("207")).Bold(true)
-		helpView = backtickStyle.Render("` APL symbol...")
+		promptStyle := lipgloss.NewStyle().Foreground(theme.Success).Bold(true)
+		hintStyle := lipgloss.NewStyle().Foreground(theme.Comment)
+		helpView = promptStyle.Render("Save "+m.savePromptFormat.String()+" as: ") + m.savePromptFilename + cursorStyle.Render(" ") +
+			hintStyle.Render("  tab: format")
+	} else if m.prefixActive {
+		prefixStyle := lipgloss.NewStyle().Foreground(theme.Symbols).Bold(true)
+		if m.config.PrefixSticky {
+			helpView = prefixStyle.Render(m.config.Prefix() + " APL symbols... (esc to stop)")
+		} else {
+			helpView = prefixStyle.Render(m.config.Prefix() + " APL symbol...")
+		}
 	} else if m.isTracerFocused() {
-		tracerStyle := lipgloss.NewStyle().Foreground(AccentColor)
-		helpView = tracerStyle.Render("n next • i into • o out • c continue • p back • f forward • e edit • esc close")
+		tracerStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+		helpView = tracerStyle.Render(tracerHelp(m.config.TracerKeys))
 	} else {
 		helpView = m.help.View(m.keys)
 	}
 
+	if m.config.StatusLine {
+		base += "\n" + m.renderStatusLine(w)
+	}
 	return base + "\n" + helpView
 }
 
+// tracerHelp lists the tracer's single-key commands for the help line
+func tracerHelp(k TracerKeysConfig) string {
+	items := []struct{ key, desc string }{
+		{k.StepOver, "next"},
+		{k.StepInto, "into"},
+		{k.StepOut, "out"},
+		{k.Continue, "continue"},
+		{k.ResumeAll, "resume all"},
+		{k.Cutback, "cutback"},
+		{k.Backward, "back"},
+		{k.Forward, "forward"},
+		{k.EditMode, "edit"},
+	}
+	var parts []string
+	for _, it := range items {
+		if it.key != "" {
+			parts = append(parts, it.key+" "+it.desc)
+		}
+	}
+	return strings.Join(append(parts, "esc close"), " • ")
+}
+
+// mainHeight is the height of the session box for a screen of height h,
+// leaving room for the help and status lines
+func (m Model) mainHeight(h int) int {
+	helpHeight := 1
+	if m.help.ShowAll {
+		helpHeight = 4 // More space for full help
+	}
+	return h - helpHeight - m.statusHeight()
+}
+
+// sessionHeight is the number of session lines on screen
+func (m Model) sessionHeight() int {
+	h := m.height
+	if h < 5 {
+		h = 24
+	}
+	return m.mainHeight(h) - 2
+}
+
+// sessionStart returns the first visible session line for a viewport of h
+// lines: scrollY, moved just enough to keep the cursor in view
+func (m Model) sessionStart(h int) int {
+	if m.wrap {
+		start, _ := m.wrapStart(h, wrapWidth(m.sessionWidth()))
+		return start
+	}
+	start := m.scrollY
+	if m.cursorRow < start {
+		start = m.cursorRow
+	}
+	if m.cursorRow >= start+h {
+		start = m.cursorRow - h + 1
+	}
+	return max(start, 0)
+}
+
+// sessionWidth is the number of session columns on screen, less the
+// timestamp gutter
+func (m Model) sessionWidth() int {
+	w := m.width
+	if w < 20 {
+		w = 80
+	}
+	return w - 2 - m.gutterWidth()
+}
+
+// sessionColOffset returns the first display column shown for a viewport w
+// columns wide: colOffset, moved just enough to keep the cursor clear of
+// the markers at the edges, or 0 once the cursor fits unscrolled
+func (m Model) sessionColOffset(w int) int {
+	if m.wrap {
+		return 0
+	}
+	if m.cursorRow < 0 || m.cursorRow >= len(m.lines) {
+		return m.colOffset
+	}
+	runes := []rune(m.lines[m.cursorRow].Text)
+	col := min(m.cursorCol, len(runes))
+	cur := lipgloss.Width(string(runes[:col]))
+	curEnd := cur + 1
+	if col < len(runes) {
+		curEnd = cur + max(runeWidth(runes[col]), 1)
+	}
+	if curEnd <= w-1 {
+		return 0
+	}
+
+	offset := m.colOffset
+	if curEnd > offset+w-1 {
+		offset = curEnd - (w - 1)
+	}
+	if offset > 0 && cur < offset+1 {
+		offset = cur - 1
+	}
+	return max(offset, 0)
+}
+
+// clickSession moves the cursor to the session text at screen position x, y.
+// Clicks on the border or below the last line leave it where it is and
+// return false.
+func (m *Model) clickSession(x, y int) bool {
+	h := m.sessionHeight()
+	if m.wrap {
+		return m.clickWrapped(x, y, h)
+	}
+	start := m.sessionStart(h)
+	m.scrollY = start
+
+	row := start + y - 1 // Inside the box's top border
+	if y < 1 || y > h || row >= len(m.lines) {
+		return false
+	}
+	offset := m.sessionColOffset(m.sessionWidth())
+	m.colOffset = offset
+	m.cursorRow = row
+	m.cursorCol = runeAtColumn([]rune(m.lines[row].Text), offset+max(x-1-m.gutterWidth(), 0))
+	return true
+}
+
+// selectClick counts repeated clicks at x, y. A double click selects the token
+// under the cursor (a whole name or number) and a triple click the line, less
+// its indent; a fourth click starts over. With copy_on_select the selection
+// is copied to the clipboard.
+func (m *Model) selectClick(x, y int) tea.Cmd {
+	now := time.Now()
+	if m.clickCount > 0 && x == m.lastClickX && y == m.lastClickY && now.Sub(m.lastClick) <= multiClickInterval {
+		m.clickCount = m.clickCount%3 + 1
+	} else {
+		m.clickCount = 1
+	}
+	m.lastClick, m.lastClickX, m.lastClickY = now, x, y
+
+	m.selection = nil
+	runes := m.currentLineRunes()
+	switch m.clickCount {
+	case 2:
+		if start, end, ok := tokenSpanAt(runes, m.cursorCol); ok {
+			m.selection = &sessionSelection{row: m.cursorRow, start: start, end: end}
+		}
+	case 3:
+		text := strings.TrimLeft(string(runes), " ")
+		if text != "" {
+			m.selection = &sessionSelection{row: m.cursorRow, start: len(runes) - len([]rune(text)), end: len(runes)}
+		}
+	}
+	if m.selection == nil || !m.config.CopyOnSelect {
+		return nil
+	}
+
+	text := string(runes[m.selection.start:m.selection.end])
+	if n := len([]rune(text)); n > 20 {
+		m.statusMsg = fmt.Sprintf("Copied %d characters to clipboard", n)
+	} else {
+		m.statusMsg = "Copied " + text + " to clipboard"
+	}
+	return copyToClipboard(text)
+}
+
 func (m Model) viewSession(w, h int) string {
 	contentW := w - 2
 	contentH := h - 2
@@ -2211,10 +3484,10 @@ func (m Model) viewSession(w, h int) string {
 	content := m.renderSession(contentW, contentH)
 
 	title := "gritt"
-	borderColor := AccentColor
+	borderColor := theme.Border
 	if !m.connected {
 		title = "gritt [disconnected]"
-		borderColor = lipgloss.Color("196") // Red
+		borderColor = theme.Error
 	}
 
 	return m.renderBox(title, content, contentW, contentH, borderColor)
@@ -2259,64 +3532,137 @@ func (m Model) renderBox(title, content string, w, h int, borderColor color.Colo
 	return sb.String()
 }
 
+// runeWidth is how many cells r takes up: 2 for wide glyphs such as CJK, 0
+// for combining marks
+func runeWidth(r rune) int {
+	return lipgloss.Width(string(r))
+}
+
+// visibleRunes returns the runes [a, b) lying wholly within display columns
+// [from, to), keeping combining marks with the character they follow, and
+// pad, the cells before rune a left blank by a wide glyph cut at from
+func visibleRunes(runes []rune, from, to int) (a, b, pad int) {
+	col := 0
+	a = len(runes)
+	for i, r := range runes {
+		if col >= from && runeWidth(r) > 0 {
+			a, pad = i, col-from
+			break
+		}
+		col += runeWidth(r)
+	}
+	for b = a; b < len(runes); b++ {
+		w := runeWidth(runes[b])
+		if col+w > to {
+			break
+		}
+		col += w
+	}
+	return a, b, pad
+}
+
+// runeAtColumn returns the index of the rune drawn at display column x, where
+// both cells of a wide glyph map to it, or len(runes) past the end of the line
+func runeAtColumn(runes []rune, x int) int {
+	col := 0
+	for i, r := range runes {
+		w := runeWidth(r)
+		if x < col+w {
+			return i
+		}
+		col += w
+	}
+	return len(runes)
+}
+
 func (m Model) renderSession(w, h int) string {
+	if m.wrap {
+		return m.renderWrapped(w, h)
+	}
+	g := m.gutterWidth()
+	w -= g
+
 	// Calculate viewport - follow cursor
-	startLine := 0
-	if m.cursorRow >= h {
-		startLine = m.cursorRow - h + 1
+	startLine := m.sessionStart(h)
+	offset := m.sessionColOffset(w)
+	markerStyle := lipgloss.NewStyle().Foreground(theme.Dim)
+
+	// Text goes up to the last column, which is kept for the cursor at the
+	// end of a line or the marker for text off to the right; scrolled
+	// right, the first column is the marker for text off to the left
+	from, to := offset, offset+w-1
+	if offset > 0 {
+		from++
 	}
 
 	lines := make([]string, h)
 	for i := 0; i < h; i++ {
 		srcIdx := startLine + i
 		if srcIdx >= len(m.lines) {
-			lines[i] = strings.Repeat(" ", w)
+			lines[i] = strings.Repeat(" ", w+g)
 			continue
 		}
 
-		lineData := m.lines[srcIdx]
-		text := lineData.Text
+		text := m.lines[srcIdx].Text
 		runes := []rune(text)
+		a, b, pad := visibleRunes(runes, from, to)
 
-		// Truncate if too wide (leave room for cursor)
-		maxLen := w - 1
-		if len(runes) > maxLen {
-			runes = runes[:maxLen]
+		rendered := strings.Repeat(" ", pad) + m.sessionText(srcIdx, runes, a, b)
+		if offset > 0 {
+			rendered = markerStyle.Render("‹") + rendered
 		}
 
-		// Render with cursor if this is the current line
-		if srcIdx == m.cursorRow {
-			col := m.cursorCol
-			if col > len(runes) {
-				col = len(runes)
-			}
-
-			var rendered string
-			var visualLen int
-			if col < len(runes) {
-				// Cursor on a character - visual length unchanged
-				rendered = string(runes[:col]) + cursorStyle.Render(string(runes[col])) + string(runes[col+1:])
-				visualLen = len(runes)
-			} else {
-				// Cursor at end - adds a space
-				rendered = string(runes) + cursorStyle.Render(" ")
-				visualLen = len(runes) + 1
-			}
-			// Pad to width
-			if visualLen < w {
-				rendered += strings.Repeat(" ", w-visualLen)
-			}
-			lines[i] = rendered
-		} else {
-			// Pad to width
-			line := string(runes)
-			if len(runes) < w {
-				line += strings.Repeat(" ", w-len(runes))
-			}
-			lines[i] = line
+		// Pad to width in display cells, not runes
+		right := lipgloss.Width(text) > to
+		padTo := w
+		if right {
+			padTo = w - 1
+		}
+		if vw := lipgloss.Width(rendered); vw < padTo {
+			rendered += strings.Repeat(" ", padTo-vw)
 		}
+		if right {
+			rendered += markerStyle.Render("›")
+		}
+		if g > 0 {
+			rendered = m.gutter(srcIdx, true) + rendered
+		}
+		lines[i] = rendered
 	}
 
 	return strings.Join(lines, "\n")
 }
 
+// sessionText renders runes[a:b] of session line row, highlighting the
+// selection and showing the cursor if they fall within it
+func (m Model) sessionText(row int, runes []rune, a, b int) string {
+	// Selected span on this line, clamped to the line
+	selStart, selEnd := 0, 0
+	if sel := m.selection; sel != nil && sel.row == row {
+		selStart, selEnd = min(sel.start, len(runes)), min(sel.end, len(runes))
+	}
+	// plain renders runes[a:b], highlighting any selected part
+	plain := func(a, b int) string {
+		s, e := max(a, selStart), min(b, selEnd)
+		if s >= e {
+			return string(runes[a:b])
+		}
+		return string(runes[a:s]) + selectionStyle.Render(string(runes[s:e])) + string(runes[e:b])
+	}
+
+	col := m.cursorCol
+	switch {
+	case row == m.cursorRow && col >= a && col < b:
+		// Cursor on a character, with any combining marks after it
+		next := col + 1
+		for next < len(runes) && runeWidth(runes[next]) == 0 {
+			next++
+		}
+		return plain(a, col) + cursorStyle.Render(string(runes[col:next])) + plain(next, b)
+	case row == m.cursorRow && col >= len(runes) && b == len(runes):
+		// Cursor at end - adds a space
+		return plain(a, b) + cursorStyle.Render(" ")
+	}
+	return plain(a, b)
+}
+
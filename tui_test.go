@@ -59,10 +59,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Test 2: C-] d toggles debug pane
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("d")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord("d")
 	runner.Snapshot("After C-] d (debug pane open)")
 
 	runner.Test("C-] d opens debug pane", func() bool {
@@ -76,7 +73,7 @@ func TestTUI(t *testing.T) {
 
 	// Test 4: Esc closes pane
 	runner.SendKeys("Escape")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Esc (debug pane closed)")
 
 	runner.Test("Esc closes debug pane", func() bool {
@@ -84,21 +81,18 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Test 5: C-] d reopens
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("d")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord("d")
 
 	runner.Test("C-] d reopens debug pane", func() bool {
 		return runner.Contains("debug")
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test 6: Execute 1+1
 	runner.SendLine("1+1")
-	runner.Sleep(1 * time.Second)
+	runner.WaitStable()
 	runner.Snapshot("After executing 1+1")
 
 	runner.Test("Execute 1+1 returns 2", func() bool {
@@ -107,7 +101,7 @@ func TestTUI(t *testing.T) {
 
 	// Test 7: Execute iota
 	runner.SendLine("⍳5")
-	runner.Sleep(1 * time.Second)
+	runner.WaitFor("1 2 3 4 5", 5*time.Second)
 	runner.Snapshot("After executing ⍳5")
 
 	runner.Test("Execute ⍳5 returns sequence", func() bool {
@@ -116,15 +110,15 @@ func TestTUI(t *testing.T) {
 
 	// Test 8: Edit and re-execute
 	runner.SendKeys("Up", "Up", "Up", "Up")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("End")
 	runner.SendKeys("BSpace")
 	runner.SendKeys("2")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After editing 1+1 to 1+2")
 
 	runner.SendKeys("Enter")
-	runner.Sleep(1 * time.Second)
+	runner.WaitStable()
 	runner.Snapshot("After executing edited line")
 
 	runner.Test("Edit and re-execute works", func() bool {
@@ -132,10 +126,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Test 9: Debug pane shows protocol
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("d")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord("d")
 	runner.Snapshot("Debug pane with protocol log")
 
 	runner.Test("Debug pane shows Execute messages", func() bool {
@@ -143,13 +134,10 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test 10: C-] ? shows key mappings pane
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("?")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord("?")
 	runner.Snapshot("After C-] ? (key mappings pane)")
 
 	runner.Test("C-] ? opens key mappings pane", func() bool {
@@ -161,17 +149,14 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Esc closes key mappings pane", func() bool {
 		return !runner.Contains("key mappings")
 	})
 
 	// Test: C-] : opens command palette
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord(":")
 	runner.Snapshot("After C-] : (command palette)")
 
 	runner.Test("C-] : opens command palette", func() bool {
@@ -188,7 +173,7 @@ func TestTUI(t *testing.T) {
 
 	// Test: Filter commands by typing
 	runner.SendText("deb")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Command palette filtered to 'deb'")
 
 	runner.Test("Typing filters commands", func() bool {
@@ -198,7 +183,7 @@ func TestTUI(t *testing.T) {
 
 	// Test: Execute command from palette
 	runner.SendKeys("Enter")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After selecting debug from palette")
 
 	runner.Test("Selecting debug opens debug pane", func() bool {
@@ -206,33 +191,23 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: Escape closes command palette
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord(":")
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Escape closes command palette", func() bool {
 		return !runner.Contains("Commands")
 	})
 
 	// Test: Save command shows filename prompt
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
-	runner.SendText("save")
-	runner.Sleep(200 * time.Millisecond)
-	runner.SendKeys("Enter")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Palette("save")
 	runner.Snapshot("Save prompt with default filename")
 
 	runner.Test("Save command shows filename prompt", func() bool {
-		return runner.Contains("Save as:")
+		return runner.Contains("Save transcript as:")
 	})
 
 	runner.Test("Save prompt has default filename", func() bool {
@@ -241,18 +216,12 @@ func TestTUI(t *testing.T) {
 
 	// Cancel save and continue
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: Pane move mode
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("d") // Open debug pane first
-	runner.Sleep(300 * time.Millisecond)
-
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("m") // Enter move mode
-	runner.Sleep(200 * time.Millisecond)
+	runner.Chord("d") // Open debug pane first
+
+	runner.Chord("m") // Enter move mode
 	runner.Snapshot("Pane move mode active")
 
 	runner.Test("C-] m enters pane move mode", func() bool {
@@ -261,7 +230,7 @@ func TestTUI(t *testing.T) {
 
 	// Move pane with arrow keys
 	runner.SendKeys("Up", "Up", "Left", "Left")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After moving pane")
 
 	runner.Test("Arrow keys move pane in move mode", func() bool {
@@ -270,7 +239,7 @@ func TestTUI(t *testing.T) {
 
 	// Exit move mode
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Escape exits pane move mode", func() bool {
 		return !runner.Contains("MOVE")
@@ -278,11 +247,11 @@ func TestTUI(t *testing.T) {
 
 	// Close the debug pane
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: Backtick mode for APL symbols
 	runner.SendKeys("`")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Backtick mode active")
 
 	runner.Test("Backtick activates APL symbol mode", func() bool {
@@ -290,7 +259,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("i") // Should insert ⍳
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After backtick-i (iota)")
 
 	runner.Test("Backtick-i inserts iota", func() bool {
@@ -298,14 +267,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Test: Symbol search
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
-	runner.SendText("symbols")
-	runner.Sleep(200 * time.Millisecond)
-	runner.SendKeys("Enter")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Palette("symbols")
 	runner.Snapshot("Symbol search pane")
 
 	runner.Test("Symbol search opens", func() bool {
@@ -314,7 +276,7 @@ func TestTUI(t *testing.T) {
 
 	// Search for "rho"
 	runner.SendText("rho")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Symbol search filtered to rho")
 
 	runner.Test("Symbol search filters by name", func() bool {
@@ -322,17 +284,10 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: APLcart
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
-	runner.SendText("aplcart")
-	runner.Sleep(200 * time.Millisecond)
-	runner.SendKeys("Enter")
-	runner.Sleep(500 * time.Millisecond)
+	runner.Palette("aplcart")
 	runner.Snapshot("APLcart pane loading")
 
 	runner.Test("APLcart opens", func() bool {
@@ -340,7 +295,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Wait for data to load
-	runner.Sleep(3 * time.Second)
+	runner.WaitForNot("Loading APLcart", 10*time.Second)
 	runner.Snapshot("APLcart loaded")
 
 	runner.Test("APLcart loads data", func() bool {
@@ -350,7 +305,7 @@ func TestTUI(t *testing.T) {
 
 	// Filter for "interval"
 	runner.SendText("interval")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("APLcart filtered for interval")
 
 	runner.Test("APLcart filters results", func() bool {
@@ -359,11 +314,11 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: Ctrl+C shows quit hint
 	runner.SendKeys("C-c")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Ctrl+C (quit hint)")
 
 	runner.Test("Ctrl+C shows quit hint", func() bool {
@@ -372,17 +327,14 @@ func TestTUI(t *testing.T) {
 
 	// Test 14: Any key clears the hint
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Key clears quit hint", func() bool {
 		return !runner.Contains("C-] q to quit")
 	})
 
 	// Test 15: C-] q shows quit confirmation
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("q")
-	runner.Sleep(200 * time.Millisecond)
+	runner.Chord("q")
 	runner.Snapshot("After C-] q (quit confirmation)")
 
 	runner.Test("C-] q shows quit confirmation", func() bool {
@@ -391,7 +343,7 @@ func TestTUI(t *testing.T) {
 
 	// Test 16: n cancels quit
 	runner.SendKeys("n")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("n cancels quit confirmation", func() bool {
 		return !runner.Contains("Quit? (y/n)")
@@ -400,15 +352,22 @@ func TestTUI(t *testing.T) {
 	// === BREAKPOINT WORKFLOW TEST ===
 	// Clear input line (may have leftover ⍳ from backtick test)
 	runner.SendKeys("BSpace")
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 
 	// Erase B if it exists from previous runs
 	runner.SendLine(")erase B")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
+
+	// breakpointOn reports whether the editor line starting with its number
+	// (e.g. "[2] ⎕←'before'") has a breakpoint in the gutter: "● [2] ..."
+	breakpointOn := func(line string) bool {
+		x, y, ok := runner.Find(line)
+		return ok && runner.CellAt(x-2, y) == '●'
+	}
 
 	// Define function B with multiple lines
 	runner.SendLine(")ed B")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitFor("╔", 5*time.Second)
 
 	runner.Test("Editor opens for B", func() bool {
 		return runner.Contains("B")
@@ -421,25 +380,22 @@ func TestTUI(t *testing.T) {
 	runner.SendText("1+2")
 	runner.SendKeys("Enter")
 	runner.SendText("⎕←'after'")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("B function defined")
 
 	// Move to line 2 and set breakpoint
 	runner.SendKeys("Up", "Up") // Go to line 2 (⎕←'before')
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("b") // Toggle breakpoint
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
+	runner.Chord("b") // Toggle breakpoint
 	runner.Snapshot("B with breakpoint on line 2")
 
 	runner.Test("Breakpoint set in editor", func() bool {
-		return runner.Contains("●")
+		return breakpointOn("[2] ⎕←'before'")
 	})
 
 	// Save and close editor
 	runner.SendKeys("Escape")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("B editor closes", func() bool {
 		return runner.WaitForNoFocusedPane(3 * time.Second)
@@ -447,7 +403,7 @@ func TestTUI(t *testing.T) {
 
 	// Run B - should stop at breakpoint
 	runner.SendLine("B")
-	runner.Sleep(1 * time.Second)
+	runner.WaitFor("[tracer]", 5*time.Second)
 	runner.Snapshot("Stopped at breakpoint in B")
 
 	runner.Test("Tracer opens at breakpoint", func() bool {
@@ -455,50 +411,46 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.Test("Breakpoint still visible in tracer", func() bool {
-		return runner.Contains("●")
+		return breakpointOn("[2] ⎕←'before'")
 	})
 
 	// Test breakpoint toggling - add a second breakpoint on line 3
 	runner.SendKeys("Down") // Move to line 3
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("b")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
+	runner.Chord("b")
 	runner.Snapshot("Two breakpoints set")
 
-	// Count breakpoints - we should see two ● symbols now
-	// (This is a bit tricky to test, but we can check the snapshot)
+	runner.Test("Second breakpoint set on line 3", func() bool {
+		return breakpointOn("[2] ⎕←'before'") && breakpointOn("[3] 1+2")
+	})
 
 	// Remove the second breakpoint
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("b")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord("b")
 	runner.Snapshot("Back to one breakpoint")
 
+	runner.Test("Second breakpoint removed", func() bool {
+		return breakpointOn("[2] ⎕←'before'") && !breakpointOn("[3] 1+2")
+	})
+
 	// Test breakpoint via command palette
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys(":")
-	runner.Sleep(300 * time.Millisecond)
+	runner.Chord(":")
 	runner.SendText("break")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Command palette shows breakpoint command", func() bool {
 		return runner.Contains("breakpoint")
 	})
 
 	runner.SendKeys("Escape") // Cancel palette
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Focus tracer before edit test
 	runner.SendKeys("C-]", "n")
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test breakpoint persistence after editing
 	runner.SendKeys("e") // Enter edit mode
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Edit mode in tracer")
 
 	runner.Test("Edit mode active", func() bool {
@@ -508,11 +460,11 @@ func TestTUI(t *testing.T) {
 	// Make a small edit - add a space somewhere
 	runner.SendKeys("End")
 	runner.SendText(" ")
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 
 	// Exit edit mode with Escape
 	runner.SendKeys("Escape")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After edit - back to tracer")
 
 	runner.Test("Back to tracer after edit", func() bool {
@@ -520,12 +472,12 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.Test("Breakpoint persists after editing", func() bool {
-		return runner.Contains("●")
+		return breakpointOn("[2] ⎕←'before'")
 	})
 
 	// Step with 'n' - execute line 2
 	runner.SendKeys("n")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After first step (before printed)")
 
 	runner.Test("Step executes line - 'before' printed", func() bool {
@@ -534,7 +486,7 @@ func TestTUI(t *testing.T) {
 
 	// Step again - execute 1+2
 	runner.SendKeys("n")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After second step (1+2)")
 
 	runner.Test("Step executes 1+2 - shows 3", func() bool {
@@ -543,7 +495,7 @@ func TestTUI(t *testing.T) {
 
 	// Step again - execute ⎕←'after'
 	runner.SendKeys("n")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After third step (after printed)")
 
 	runner.Test("Step executes - 'after' printed", func() bool {
@@ -552,7 +504,7 @@ func TestTUI(t *testing.T) {
 
 	// One more step should complete execution
 	runner.SendKeys("n")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitForNot("[tracer]", 5*time.Second)
 	runner.Snapshot("After function completes")
 
 	runner.Test("Function completes - tracer closes", func() bool {
@@ -561,16 +513,16 @@ func TestTUI(t *testing.T) {
 
 	// Clean up B
 	runner.SendLine(")erase B")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 
 	// === ERROR STACK TEST - nested functions X→Y→Z ===
 	// Clean up any existing functions from previous runs
 	runner.SendLine(")erase X Y Z")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	// Define Z (will error) - with LOCAL variables a and b declared in header
 	runner.SendLine(")ed Z")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitFor("╔", 5*time.Second)
 	runner.Snapshot("Editor opened for Z")
 
 	runner.Test("Editor opens for Z", func() bool {
@@ -587,12 +539,12 @@ func TestTUI(t *testing.T) {
 	runner.SendText("b←'hello world'⍝ok") // space test; ⍝ sent directly (backtick works manually but not via tmux)
 	runner.SendKeys("Enter")
 	runner.SendText("9÷0")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Z function with locals a;b and 9÷0")
 
 	// Save and close - wait for editor to actually close
 	runner.SendKeys("Escape")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Z editor closes after Escape", func() bool {
 		return runner.WaitForNoFocusedPane(3 * time.Second)
@@ -601,7 +553,7 @@ func TestTUI(t *testing.T) {
 
 	// Define Y (calls Z)
 	runner.SendLine(")ed Y")
-	runner.Sleep(1 * time.Second)
+	runner.WaitFor("╔", 5*time.Second)
 	runner.Snapshot("Y editor opened")
 
 	runner.Test("Y editor opens", func() bool {
@@ -612,9 +564,9 @@ func TestTUI(t *testing.T) {
 	runner.SendText("yvar←123") // Variable in Y's scope (not local to Z)
 	runner.SendKeys("Enter")
 	runner.SendText("Z")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Escape")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Y editor closes after Escape", func() bool {
 		return runner.WaitForNoFocusedPane(3 * time.Second)
@@ -622,7 +574,7 @@ func TestTUI(t *testing.T) {
 
 	// Define X (calls Y)
 	runner.SendLine(")ed X")
-	runner.Sleep(1 * time.Second)
+	runner.WaitFor("╔", 5*time.Second)
 	runner.Snapshot("X editor opened")
 
 	runner.Test("X editor opens", func() bool {
@@ -631,9 +583,9 @@ func TestTUI(t *testing.T) {
 
 	runner.SendKeys("End", "Enter", "Enter")
 	runner.SendText("Y")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Escape")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("X editor closes after Escape", func() bool {
 		return runner.WaitForNoFocusedPane(3 * time.Second)
@@ -642,7 +594,7 @@ func TestTUI(t *testing.T) {
 
 	// Execute X - triggers nested error
 	runner.SendLine("X")
-	runner.Sleep(2 * time.Second) // Give time for error and tracer to open
+	runner.WaitFor("[tracer]", 5*time.Second)
 	runner.Snapshot("After X errors - tracer opens")
 
 	runner.Test("Tracer opens on error", func() bool {
@@ -650,10 +602,7 @@ func TestTUI(t *testing.T) {
 	})
 
 	// Test stack IMMEDIATELY - before any manipulation
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("s")
-	runner.Sleep(500 * time.Millisecond)
+	runner.Chord("s")
 	runner.Snapshot("Stack pane showing X→Y→Z (fresh)")
 
 	runner.Test("Stack shows 3 frames", func() bool {
@@ -661,27 +610,24 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.Test("Stack pane shows Z (top of stack)", func() bool {
-		return runner.Contains("Z[") || runner.Contains("Z ")
+		return runner.ContainsRegex(`Z[[ ]`)
 	})
 
 	runner.Test("Stack pane shows Y", func() bool {
-		return runner.Contains("Y[") || runner.Contains("Y ")
+		return runner.ContainsRegex(`Y[[ ]`)
 	})
 
 	runner.Test("Stack pane shows X", func() bool {
-		return runner.Contains("X[") || runner.Contains("X ")
+		return runner.ContainsRegex(`X[[ ]`)
 	})
 
 	// Close stack pane before variables test
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// === VARIABLES PANE TEST ===
 	// Open variables pane (C-] l) - should show Z's local variables
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("l")
-	runner.Sleep(500 * time.Millisecond)
+	runner.Chord("l")
 	runner.Snapshot("Variables pane showing Z's variables")
 
 	runner.Test("Variables pane shows 'a'", func() bool {
@@ -694,12 +640,12 @@ func TestTUI(t *testing.T) {
 
 	// Test 1: Select second variable (b) with Down arrow
 	runner.SendKeys("Down")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Variables pane - 'b' selected")
 
 	// Test 2: Open editor for variable 'b' with Enter
 	runner.SendKeys("Enter")
-	runner.Sleep(800 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Editor opened for variable b")
 
 	runner.Test("Editor opens for variable b", func() bool {
@@ -709,17 +655,14 @@ func TestTUI(t *testing.T) {
 
 	// Close the variable editor
 	runner.SendKeys("Escape")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 
 	// Re-focus variables pane
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("l")
-	runner.Sleep(500 * time.Millisecond)
+	runner.Chord("l")
 
 	// Test: ~ toggles to "all" mode (shows globals too)
 	runner.SendText("~")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Variables pane - all mode")
 
 	runner.Test("Variables pane shows [all] in title", func() bool {
@@ -737,7 +680,7 @@ func TestTUI(t *testing.T) {
 
 	// ~ back to locals mode
 	runner.SendText("~")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Variables pane - back to locals mode")
 
 	runner.Test("Variables pane back to [local] mode", func() bool {
@@ -746,18 +689,18 @@ func TestTUI(t *testing.T) {
 
 	// Close variables pane
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Focus tracer
 	runner.SendKeys("C-]", "n")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test: Tracer mode blocks text insertion
 	runner.Snapshot("Before typing in tracer")
 
 	// Try to type some text - should be blocked in tracer mode
 	runner.SendText("xyz")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After typing xyz in tracer mode")
 
 	runner.Test("Tracer mode blocks text insertion", func() bool {
@@ -767,7 +710,7 @@ func TestTUI(t *testing.T) {
 
 	// Test: Edit mode toggle with 'e' key
 	runner.SendText("e")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After pressing e - edit mode")
 
 	runner.Test("Edit mode shows [edit] in title", func() bool {
@@ -776,7 +719,7 @@ func TestTUI(t *testing.T) {
 
 	// Test: Can type in edit mode
 	runner.SendText("test123")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After typing in edit mode")
 
 	runner.Test("Edit mode allows text insertion", func() bool {
@@ -785,7 +728,7 @@ func TestTUI(t *testing.T) {
 
 	// Test: Escape in edit mode returns to tracer (doesn't close)
 	runner.SendKeys("Escape")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Escape in edit mode")
 
 	runner.Test("Escape in edit mode returns to tracer", func() bool {
@@ -795,14 +738,14 @@ func TestTUI(t *testing.T) {
 
 	// Test: Second Escape pops Z frame (closes tracer for Z)
 	runner.SendKeys("Escape")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After second Escape - Z popped")
 
 	// Pop remaining frames to clean up
 	runner.SendKeys("Escape") // Pop Y
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Escape") // Pop X
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After popping all frames - clean state")
 
 	runner.Test("Stack cleared after popping all frames", func() bool {
@@ -812,14 +755,11 @@ func TestTUI(t *testing.T) {
 	// === TEST 5: SESSION VARIABLES (main window, not tracer) ===
 	// Create a global variable in the session
 	runner.SendLine("sessionVar←999")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After creating sessionVar")
 
 	// Open variables pane in main session context
-	runner.SendKeys("C-]")
-	runner.Sleep(100 * time.Millisecond)
-	runner.SendKeys("l")
-	runner.Sleep(500 * time.Millisecond)
+	runner.Chord("l")
 	runner.Snapshot("Session variables pane")
 
 	runner.Test("Session variables pane shows sessionVar", func() bool {
@@ -828,27 +768,27 @@ func TestTUI(t *testing.T) {
 
 	// Close variables pane
 	runner.SendKeys("Escape")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 
 	// Clean up the test variable
 	runner.SendLine(")erase sessionVar")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 
 	// === AUTOCOMPLETE TEST ===
 	// Define some variables with similar prefixes
 	runner.SendLine("alpha←1")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendLine("alphabet←2")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendLine("alpine←3")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After defining alpha, alphabet, alpine")
 
 	// Test 1: Tab triggers autocomplete popup with multiple options
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Autocomplete popup showing")
 
 	runner.Test("Popup shows alpha option", func() bool {
@@ -865,7 +805,7 @@ func TestTUI(t *testing.T) {
 
 	// Test 2: Enter immediately selects first option (alpha=1)
 	runner.SendKeys("Enter") // Select first option without cycling
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Enter to select first option")
 
 	runner.Test("First option selected is alpha", func() bool {
@@ -875,7 +815,7 @@ func TestTUI(t *testing.T) {
 
 	// Execute to verify alpha (value 1)
 	runner.SendKeys("Enter")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After executing alpha")
 
 	runner.Test("Alpha value is 1", func() bool {
@@ -884,17 +824,17 @@ func TestTUI(t *testing.T) {
 
 	// Test 3: Tab cycles DOWN to second option (alphabet=2)
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Open popup
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Cycle to second option
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Tab to cycle to alphabet")
 
 	runner.SendKeys("Enter") // Select second option
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After executing alphabet")
 
 	runner.Test("Second option is alphabet with value 2", func() bool {
@@ -903,19 +843,19 @@ func TestTUI(t *testing.T) {
 
 	// Test 4: Down arrow also cycles forward (alpine=3)
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Open popup
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Down") // Cycle to second
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Down") // Cycle to third (alpine)
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Down×2 to alpine")
 
 	runner.SendKeys("Enter") // Select third option
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After executing alpine")
 
 	runner.Test("Third option is alpine with value 3", func() bool {
@@ -924,21 +864,21 @@ func TestTUI(t *testing.T) {
 
 	// Test 5: Shift+Tab cycles BACKWARDS
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Open popup (starts at alpha)
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Forward to alphabet
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Forward to alpine
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("S-Tab") // Back to alphabet
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Shift+Tab back to alphabet")
 
 	runner.SendKeys("Enter") // Select
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Shift+Tab navigation")
 
 	runner.Test("Shift+Tab went back to alphabet (value 2)", func() bool {
@@ -947,17 +887,17 @@ func TestTUI(t *testing.T) {
 
 	// Test 6: Up arrow also cycles backwards
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab") // Open popup (starts at alpha)
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Up") // Wraps to last (alpine)
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Up wraps to alpine")
 
 	runner.SendKeys("Enter") // Select
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Up arrow wrapped to alpine (value 3)", func() bool {
 		return runner.Contains("3")
@@ -966,14 +906,14 @@ func TestTUI(t *testing.T) {
 	// Test 7: Scrolling with 50 options
 	// Create 50 variables: scr1←1, scr2←2, ..., scr50←50
 	runner.SendLine("{⍎'scr',(⍕⍵),'←',⍕⍵}¨⍳50")
-	runner.Sleep(1000 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After creating 50 scr variables")
 
 	// Trigger autocomplete - should show scr1, scr10, scr11, etc. (sorted)
 	runner.SendText("scr")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("Autocomplete with 50 options")
 
 	runner.Test("Popup shows scr options", func() bool {
@@ -983,13 +923,12 @@ func TestTUI(t *testing.T) {
 	// Navigate down 29 times to get to 30th option
 	for i := 0; i < 29; i++ {
 		runner.SendKeys("Down")
-		runner.Sleep(20 * time.Millisecond)
 	}
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After scrolling down 29 times")
 
 	runner.SendKeys("Enter") // Select current option
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After selecting scrolled option")
 
 	// The selection should have worked (not crashed, inserted something)
@@ -999,21 +938,21 @@ func TestTUI(t *testing.T) {
 	})
 
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	// Test wrap-around: go up from first option to reach last
 	runner.SendText("scr")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Up") // Wrap to last (scr9 or scr50 depending on sort)
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Up to wrap to last")
 
 	runner.SendKeys("Enter") // Select last option
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Enter") // Execute
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After selecting wrapped option")
 
 	runner.Test("Wrap works - last option selected and executed", func() bool {
@@ -1023,11 +962,11 @@ func TestTUI(t *testing.T) {
 
 	// Test 9: Single completion auto-inserts without popup
 	runner.SendLine("zetaUnique←42")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendText("zeta")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After single completion")
 
 	runner.Test("Single completion auto-inserts zetaUnique", func() bool {
@@ -1037,7 +976,7 @@ func TestTUI(t *testing.T) {
 
 	// Execute to verify
 	runner.SendKeys("Enter")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Single completion result is 42", func() bool {
 		return runner.Contains("42")
@@ -1045,16 +984,16 @@ func TestTUI(t *testing.T) {
 
 	// Test 10: Escape cancels popup
 	runner.SendText("alp")
-	runner.Sleep(200 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendKeys("Tab")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	runner.Test("Popup shows for cancel test", func() bool {
 		return runner.Contains("alpha") && runner.Contains("alphabet")
 	})
 
 	runner.SendKeys("Escape")
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After Escape to cancel")
 
 	runner.Test("Escape cancels popup - alpha not in popup", func() bool {
@@ -1065,9 +1004,9 @@ func TestTUI(t *testing.T) {
 
 	// Test 11: Typing cancels popup and processes the key
 	runner.SendKeys("Tab") // Reopen popup
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendText("x") // Type something - should cancel and insert 'x'
-	runner.Sleep(300 * time.Millisecond)
+	runner.WaitStable()
 	runner.Snapshot("After typing to cancel")
 
 	runner.Test("Typing cancels popup and inserts char", func() bool {
@@ -1080,9 +1019,9 @@ func TestTUI(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		runner.SendKeys("Delete")
 	}
-	runner.Sleep(100 * time.Millisecond)
+	runner.WaitStable()
 	runner.SendLine(")erase alpha alphabet alpine zetaUnique")
-	runner.Sleep(500 * time.Millisecond)
+	runner.WaitStable()
 
 	// Final snapshot
 	runner.Snapshot("Final state")
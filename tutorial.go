@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// TutorialEvent is a user action a tutorial step waits for
+type TutorialEvent int
+
+const (
+	TutorialExecute TutorialEvent = iota
+	TutorialBacktick
+	TutorialSymbols
+	TutorialDocs
+	TutorialBreakpoint
+	TutorialTrace
+)
+
+// TutorialStep is one scripted prompt in the tutorial
+type TutorialStep struct {
+	Title string
+	Text  string
+	Event TutorialEvent // Completing this action advances to the next step
+}
+
+// tutorialSteps is the guided tour shown by gritt -tutorial
+var tutorialSteps = []TutorialStep{
+	{
+		Title: "Execute an expression",
+		Text:  "Type 1+2 in the session and press Enter. The interpreter's result appears below your input.",
+		Event: TutorialExecute,
+	},
+	{
+		Title: "Type APL symbols",
+		Text:  "Press ` then a key to enter a symbol: `i gives ⍳, `r gives ⍴. Try typing ⍳10 and run it.",
+		Event: TutorialBacktick,
+	},
+	{
+		Title: "Search for symbols",
+		Text:  "Press C-] : and choose symbols to search every APL symbol by name. Enter inserts the one you pick.",
+		Event: TutorialSymbols,
+	},
+	{
+		Title: "Open the docs",
+		Text:  "Put the cursor just after a symbol such as ⍳ and press F1 to read its documentation. Esc closes it.",
+		Event: TutorialDocs,
+	},
+	{
+		Title: "Set a breakpoint",
+		Text:  "Run )ed Double. Make line 0 read r←Double x and add line 1 r←2×x. On line 1 press C-] b to set a breakpoint, then Esc to save.",
+		Event: TutorialBreakpoint,
+	},
+	{
+		Title: "Step through code",
+		Text:  "Run Double 21. The tracer stops at the breakpoint: press n to step over the line, or i to step into calls.",
+		Event: TutorialTrace,
+	},
+}
+
+// TutorialPane shows the current tutorial step and tracks progress
+type TutorialPane struct {
+	steps   []TutorialStep
+	current int // Index of the step in progress (len(steps) = finished)
+
+	titleStyle lipgloss.Style
+	doneStyle  lipgloss.Style
+	dimStyle   lipgloss.Style
+}
+
+// NewTutorialPane creates a tutorial pane at the first step
+func NewTutorialPane() *TutorialPane {
+	t := &TutorialPane{steps: tutorialSteps}
+	t.ApplyTheme()
+	return t
+}
+
+// ApplyTheme rebuilds the pane's styles from the theme
+func (t *TutorialPane) ApplyTheme() {
+	t.titleStyle = lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	t.doneStyle = lipgloss.NewStyle().Foreground(theme.Success)
+	t.dimStyle = lipgloss.NewStyle().Foreground(theme.Dim)
+}
+
+// Advance moves to the next step if ev completes the current one
+func (t *TutorialPane) Advance(ev TutorialEvent) bool {
+	if t.Done() || t.steps[t.current].Event != ev {
+		return false
+	}
+	t.current++
+	return true
+}
+
+// Done returns true once every step is complete
+func (t *TutorialPane) Done() bool {
+	return t.current >= len(t.steps)
+}
+
+func (t *TutorialPane) Title() string {
+	if t.Done() {
+		return "tutorial (done)"
+	}
+	return fmt.Sprintf("tutorial (%d/%d)", t.current+1, len(t.steps))
+}
+
+func (t *TutorialPane) Render(w, h int) string {
+	var lines []string
+
+	// Checklist of all steps
+	for i, step := range t.steps {
+		switch {
+		case i < t.current:
+			lines = append(lines, t.doneStyle.Render(" ✓ "+step.Title))
+		case i == t.current:
+			lines = append(lines, t.titleStyle.Render(" ► "+step.Title))
+		default:
+			lines = append(lines, t.dimStyle.Render("   "+step.Title))
+		}
+	}
+	lines = append(lines, "")
+
+	// Instructions for the current step
+	var text string
+	if t.Done() {
+		text = "All done! C-] n focuses this pane and Esc closes it. C-] ? lists every key binding."
+	} else {
+		text = t.steps[t.current].Text
+	}
+	wrapped := lipgloss.NewStyle().Width(max(w-2, 1)).Render(text)
+	for _, l := range strings.Split(wrapped, "\n") {
+		lines = append(lines, " "+l)
+	}
+
+	if !t.Done() {
+		lines = append(lines, "", t.dimStyle.Render(" C-] n to focus, then ←/→ back/skip"))
+	}
+
+	for len(lines) < h {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:h], "\n")
+}
+
+func (t *TutorialPane) HandleKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyRight:
+		if !t.Done() {
+			t.current++
+		}
+		return true
+	case tea.KeyLeft:
+		if t.current > 0 {
+			t.current--
+		}
+		return true
+	}
+	return false
+}
+
+func (t *TutorialPane) HandleMouse(x, y int, msg tea.MouseMsg) bool {
+	return false
+}
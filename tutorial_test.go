@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTutorialAdvance(t *testing.T) {
+	tp := NewTutorialPane()
+
+	// Events for later steps don't skip ahead
+	if tp.Advance(TutorialDocs) {
+		t.Error("Advance(TutorialDocs) on step 1 should not advance")
+	}
+	if tp.current != 0 {
+		t.Fatalf("current = %d, want 0", tp.current)
+	}
+
+	for i, step := range tp.steps {
+		if !tp.Advance(step.Event) {
+			t.Fatalf("step %d (%s) did not advance", i, step.Title)
+		}
+	}
+	if !tp.Done() {
+		t.Error("tutorial not done after all steps")
+	}
+	if tp.Advance(TutorialExecute) {
+		t.Error("Advance after done should be a no-op")
+	}
+	if tp.Title() != "tutorial (done)" {
+		t.Errorf("Title() = %q", tp.Title())
+	}
+}
+
+func TestTutorialSkipAndRender(t *testing.T) {
+	tp := NewTutorialPane()
+
+	tp.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	if tp.current != 1 {
+		t.Errorf("after skip: current = %d, want 1", tp.current)
+	}
+	tp.HandleKey(tea.KeyMsg{Type: tea.KeyLeft})
+	tp.HandleKey(tea.KeyMsg{Type: tea.KeyLeft})
+	if tp.current != 0 {
+		t.Errorf("after back: current = %d, want 0", tp.current)
+	}
+
+	out := tp.Render(40, 16)
+	if got := len(strings.Split(out, "\n")); got != 16 {
+		t.Errorf("Render height = %d, want 16", got)
+	}
+	if !strings.Contains(out, "1+2") {
+		t.Errorf("first step instructions missing: %q", out)
+	}
+}
+
+func TestToggleTutorialTinyScreen(t *testing.T) {
+	for _, size := range [][2]int{{80, 2}, {1, 1}} {
+		m := newRideTestModel()
+		m.width, m.height = size[0], size[1]
+		m.panes.UpdateSize(m.width, m.height)
+		m.toggleTutorial()
+		pane := m.panes.Get("tutorial")
+		if pane == nil {
+			t.Fatalf("%dx%d: tutorial didn't open", m.width, m.height)
+		}
+		if pane.Width < minPaneSize || pane.Height < minPaneSize {
+			t.Errorf("%dx%d: pane is %dx%d", m.width, m.height, pane.Width, pane.Height)
+		}
+		m.View() // Mustn't panic
+	}
+}
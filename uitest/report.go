@@ -71,35 +71,158 @@ func (r *Report) Failed() int {
 	return len(r.Tests) - r.Passed()
 }
 
-// ansiToHTML converts ANSI escape codes to HTML spans
-func ansiToHTML(s string) string {
-	// First escape HTML special chars (but not in a way that breaks our processing)
-	s = html.EscapeString(s)
-
-	// 256-color foreground: \x1b[38;5;Nm
-	re256 := regexp.MustCompile(`\x1b\[38;5;(\d+)m`)
-	s = re256.ReplaceAllStringFunc(s, func(match string) string {
-		matches := re256.FindStringSubmatch(match)
-		if len(matches) < 2 {
-			return ""
-		}
-		colorNum, _ := strconv.Atoi(matches[1])
-		hexColor := ansi256ToHex(colorNum)
-		return fmt.Sprintf(`<span style="color:%s">`, hexColor)
-	})
+// sgrRe matches an SGR escape (colors and attributes)
+var sgrRe = regexp.MustCompile(`\x1b\[([0-9;:]*)m`)
 
-	// Bold: \x1b[1m
-	s = strings.ReplaceAll(s, "\x1b[1m", `<span style="font-weight:bold">`)
+// Snapshot <pre> colors, for reversed text with a default color
+const (
+	snapshotFg = "#00d9ff"
+	snapshotBg = "#0a0a15"
+)
 
-	// Reset: \x1b[0m or \x1b[m
-	s = strings.ReplaceAll(s, "\x1b[0m", `</span>`)
-	s = strings.ReplaceAll(s, "\x1b[m", `</span>`)
+// sgrState is the styling in force at a point in a capture
+type sgrState struct {
+	fg, bg                        string // CSS colors, "" for the default
+	bold, dim, italic, under, rev bool
+}
+
+// apply updates the state with an SGR escape's parameters
+func (st *sgrState) apply(params string) {
+	if params == "" {
+		*st = sgrState{}
+		return
+	}
+	// Parameters are ;-separated, an empty one meaning 0. An extended color
+	// may instead be one parameter with :-separated parts.
+	var p []string
+	for _, param := range strings.Split(params, ";") {
+		switch {
+		case strings.Contains(param, ":"):
+			p = append(p, strings.FieldsFunc(param, func(r rune) bool { return r == ':' })...)
+		case param == "":
+			p = append(p, "0")
+		default:
+			p = append(p, param)
+		}
+	}
+	for i := 0; i < len(p); i++ {
+		n, _ := strconv.Atoi(p[i])
+		switch {
+		case n == 0:
+			*st = sgrState{}
+		case n == 1:
+			st.bold = true
+		case n == 2:
+			st.dim = true
+		case n == 3:
+			st.italic = true
+		case n == 4:
+			st.under = true
+		case n == 7:
+			st.rev = true
+		case n == 22:
+			st.bold, st.dim = false, false
+		case n == 23:
+			st.italic = false
+		case n == 24:
+			st.under = false
+		case n == 27:
+			st.rev = false
+		case n >= 30 && n <= 37:
+			st.fg = ansi256ToHex(n - 30)
+		case n >= 90 && n <= 97:
+			st.fg = ansi256ToHex(n - 90 + 8)
+		case n >= 40 && n <= 47:
+			st.bg = ansi256ToHex(n - 40)
+		case n >= 100 && n <= 107:
+			st.bg = ansi256ToHex(n - 100 + 8)
+		case n == 39:
+			st.fg = ""
+		case n == 49:
+			st.bg = ""
+		case n == 38 || n == 48:
+			// Extended color: 5;N (256 colors) or 2;R;G;B (true color)
+			var color string
+			if i+2 < len(p) && p[i+1] == "5" {
+				c, _ := strconv.Atoi(p[i+2])
+				color = ansi256ToHex(c)
+				i += 2
+			} else if i+4 < len(p) && p[i+1] == "2" {
+				r, _ := strconv.Atoi(p[i+2])
+				g, _ := strconv.Atoi(p[i+3])
+				b, _ := strconv.Atoi(p[i+4])
+				color = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+				i += 4
+			} else {
+				i = len(p) // Malformed; ignore the rest
+				continue
+			}
+			if n == 38 {
+				st.fg = color
+			} else {
+				st.bg = color
+			}
+		}
+	}
+}
 
-	// Remove any remaining ANSI codes
-	reAny := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	s = reAny.ReplaceAllString(s, "")
+// css renders the state as an inline style ("" for default text)
+func (st sgrState) css() string {
+	fg, bg := st.fg, st.bg
+	if st.rev {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = snapshotBg
+		}
+		if bg == "" {
+			bg = snapshotFg
+		}
+	}
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background-color:"+bg)
+	}
+	if st.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if st.dim {
+		css = append(css, "opacity:0.6")
+	}
+	if st.italic {
+		css = append(css, "font-style:italic")
+	}
+	if st.under {
+		css = append(css, "text-decoration:underline")
+	}
+	return strings.Join(css, ";")
+}
 
-	return s
+// ansiToHTML converts a capture with ANSI escape codes to HTML, each run of
+// styled text becoming a span
+func ansiToHTML(s string) string {
+	var b strings.Builder
+	var st sgrState
+	write := func(text string) {
+		if text == "" {
+			return
+		}
+		if css := st.css(); css != "" {
+			fmt.Fprintf(&b, `<span style="%s">%s</span>`, css, html.EscapeString(text))
+		} else {
+			b.WriteString(html.EscapeString(text))
+		}
+	}
+	last := 0
+	for _, m := range sgrRe.FindAllStringSubmatchIndex(s, -1) {
+		write(s[last:m[0]])
+		st.apply(s[m[2]:m[3]])
+		last = m[1]
+	}
+	write(s[last:])
+	return b.String()
 }
 
 // ansi256ToHex converts 256-color ANSI code to hex
@@ -129,8 +252,7 @@ func ansi256ToHex(n int) string {
 
 // stripANSI removes ANSI escape codes from a string
 func stripANSI(s string) string {
-	re := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	return re.ReplaceAllString(s, "")
+	return sgrRe.ReplaceAllString(s, "")
 }
 
 // GenerateText writes a plain text log alongside the HTML
@@ -0,0 +1,45 @@
+package uitest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestANSIToHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "a<b", "a&lt;b"},
+		{"256 colors", "\x1b[38;5;214mx\x1b[0m y", `<span style="color:#ff9900">x</span> y`},
+		{"background", "\x1b[48;5;236mx\x1b[49my", `<span style="background-color:#303030">x</span>y`},
+		{"combined", "\x1b[1;38;5;196;48;5;16mx\x1b[m", `<span style="color:#ff0000;background-color:#000000;font-weight:bold">x</span>`},
+		{"basic and bright", "\x1b[31mx\x1b[92my\x1b[39mz", `<span style="color:#800000">x</span><span style="color:#00ff00">y</span>z`},
+		{"true color", "\x1b[38;2;242;167;79mx", `<span style="color:#f2a74f">x</span>`},
+		{"true color background", "\x1b[48;2;10;10;21mx\x1b[0m", `<span style="background-color:#0a0a15">x</span>`},
+		{"true color both", "\x1b[38;2;255;255;255;48;2;0;0;0mx", `<span style="color:#ffffff;background-color:#000000">x</span>`},
+		{"colon form", "\x1b[38:2::1:2:3mx", `<span style="color:#010203">x</span>`},
+		{"truncated color", "\x1b[38;5mx", `x`},
+		{"reverse with defaults", "\x1b[7mx\x1b[27my", `<span style="color:#0a0a15;background-color:#00d9ff">x</span>y`},
+		{"reverse", "\x1b[38;5;16;48;5;231;7mx", `<span style="color:#ffffff;background-color:#000000">x</span>`},
+		{"attributes", "\x1b[3;4mx\x1b[23;24my", `<span style="font-style:italic;text-decoration:underline">x</span>y`},
+		{"empty parameter resets", "\x1b[31mx\x1b[;1my", `<span style="color:#800000">x</span><span style="font-weight:bold">y</span>`},
+		{"repeated resets", "\x1b[1mx\x1b[0m\x1b[0m\x1b[my\x1b[0m", `<span style="font-weight:bold">x</span>y`},
+		{"normal intensity", "\x1b[1;2;32mx\x1b[22my", `<span style="color:#008000;font-weight:bold;opacity:0.6">x</span><span style="color:#008000">y</span>`},
+		{"reset keeps nothing", "\x1b[1;31mx\x1b[0my\x1b[0m", `<span style="color:#800000;font-weight:bold">x</span>y`},
+	}
+	for _, tt := range tests {
+		if got := ansiToHTML(tt.in); got != tt.want {
+			t.Errorf("%s: ansiToHTML(%q) =\n  %s\nwant\n  %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+
+	// Spans never nest, so a capture ending mid-style is still balanced
+	got := ansiToHTML("\x1b[31ma\x1b[48;5;17mb\x1b[1mc\nd")
+	if strings.Count(got, "<span") != 3 || strings.Count(got, "</span>") != 3 {
+		t.Errorf("unbalanced spans: %s", got)
+	}
+	if !strings.HasSuffix(got, `<span style="color:#800000;background-color:#000033;font-weight:bold">c
+d</span>`) {
+		t.Errorf("style carried across the line break: %s", got)
+	}
+}
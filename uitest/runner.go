@@ -2,6 +2,8 @@ package uitest
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -34,7 +36,7 @@ func (r *Runner) Close() error {
 
 // Snapshot captures the current screen with a label
 func (r *Runner) Snapshot(label string) {
-	content, err := r.Session.Capture()
+	content, err := r.Session.CaptureANSI()
 	if err != nil {
 		r.T.Logf("Warning: failed to capture snapshot %q: %v", label, err)
 		return
@@ -77,27 +79,88 @@ func (r *Runner) SendText(text string) {
 	}
 }
 
+// Polling for the Wait helpers. The screen counts as stable once it's gone
+// settleTime without changing.
+const (
+	pollInterval = 30 * time.Millisecond
+	settleTime   = 150 * time.Millisecond
+	stableLimit  = 5 * time.Second
+)
+
+// WaitUntil polls the screen (as plain text) until cond holds, logging
+// desc on timeout
+func (r *Runner) WaitUntil(desc string, timeout time.Duration, cond func(screen string) bool) bool {
+	return r.waitUntil(r.Session.Capture, desc, timeout, cond)
+}
+
+func (r *Runner) waitUntil(capture func() (string, error), desc string, timeout time.Duration, cond func(screen string) bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		screen, err := capture()
+		if err == nil && cond(screen) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			r.T.Logf("Timeout waiting for %s", desc)
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
 // WaitFor waits for a pattern to appear
 func (r *Runner) WaitFor(pattern string, timeout time.Duration) bool {
-	err := r.Session.WaitFor(pattern, timeout)
-	if err != nil {
-		r.T.Logf("WaitFor failed: %v", err)
-		return false
-	}
-	return true
+	return r.WaitUntil(fmt.Sprintf("%q", pattern), timeout, func(screen string) bool {
+		return strings.Contains(screen, pattern)
+	})
 }
 
 // WaitForNot waits for a pattern to disappear
 func (r *Runner) WaitForNot(pattern string, timeout time.Duration) bool {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		if !r.Contains(pattern) {
-			return true
-		}
-		time.Sleep(100 * time.Millisecond)
+	return r.WaitUntil(fmt.Sprintf("%q to disappear", pattern), timeout, func(screen string) bool {
+		return !strings.Contains(screen, pattern)
+	})
+}
+
+// WaitForRegex waits for the screen to match a regex
+func (r *Runner) WaitForRegex(pattern string, timeout time.Duration) bool {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		r.T.Fatalf("WaitForRegex: %v", err)
 	}
-	r.T.Logf("Timeout waiting for %q to disappear", pattern)
-	return false
+	return r.WaitUntil(fmt.Sprintf("/%s/", pattern), timeout, re.MatchString)
+}
+
+// WaitStable waits for the screen to stop changing, so the keys sent so far
+// have been handled and drawn. Use it after input with no particular text to
+// wait for; WaitFor is better when there is.
+func (r *Runner) WaitStable() bool {
+	var last string
+	changed := time.Now()
+	// Styling counts, so a focus change is waited for too
+	return r.waitUntil(r.Session.CaptureANSI, "the screen to settle", stableLimit, func(screen string) bool {
+		if screen != last {
+			last, changed = screen, time.Now()
+			return false
+		}
+		return time.Since(changed) >= settleTime
+	})
+}
+
+// Chord sends the leader key (C-]) followed by keys, and waits for the
+// result to be drawn
+func (r *Runner) Chord(keys ...string) {
+	r.SendKeys(append([]string{"C-]"}, keys...)...)
+	r.WaitStable()
+}
+
+// Palette runs a command palette command by name
+func (r *Runner) Palette(command string) {
+	r.Chord(":")
+	r.SendText(command)
+	r.WaitStable()
+	r.SendKeys("Enter")
+	r.WaitStable()
 }
 
 // Contains checks if the screen contains a pattern
@@ -110,20 +173,54 @@ func (r *Runner) Contains(pattern string) bool {
 	return found
 }
 
+// ContainsRegex checks if the screen matches a regex
+func (r *Runner) ContainsRegex(pattern string) bool {
+	found, err := r.Session.ContainsRegex(pattern)
+	if err != nil {
+		r.T.Fatalf("ContainsRegex: %v", err)
+	}
+	return found
+}
+
+// ContainsAt checks if the w×h rectangle of the screen at (x, y) contains a
+// pattern, so a check can't pass on the same text elsewhere
+func (r *Runner) ContainsAt(x, y, w, h int, pattern string) bool {
+	screen, err := r.Session.Capture()
+	if err != nil {
+		r.T.Logf("ContainsAt check failed: %v", err)
+		return false
+	}
+	return strings.Contains(region(screen, x, y, w, h), pattern)
+}
+
+// CellAt returns the rune at column x, row y of the screen (0 if there's
+// none)
+func (r *Runner) CellAt(x, y int) rune {
+	screen, err := r.Session.Capture()
+	if err != nil {
+		r.T.Logf("CellAt failed: %v", err)
+		return 0
+	}
+	return cellAt(screen, x, y)
+}
+
+// Find returns the column and row where pattern first appears on screen
+func (r *Runner) Find(pattern string) (x, y int, ok bool) {
+	screen, err := r.Session.Capture()
+	if err != nil {
+		r.T.Logf("Find failed: %v", err)
+		return 0, 0, false
+	}
+	return find(screen, pattern)
+}
+
 // WaitForNoFocusedPane waits until no pane has double-border focus indicator
 func (r *Runner) WaitForNoFocusedPane(timeout time.Duration) bool {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		if !r.Contains("╔") {
-			return true
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	r.T.Logf("Timeout waiting for focused pane to close")
-	return false
+	return r.WaitForNot("╔", timeout)
 }
 
-// Sleep pauses execution
+// Sleep pauses execution. It's slow where the screen is ready sooner and
+// flaky where it needs longer: prefer WaitFor or WaitStable.
 func (r *Runner) Sleep(d time.Duration) {
 	time.Sleep(d)
 }
@@ -0,0 +1,67 @@
+package uitest
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// screenCells splits a plain capture into rows of terminal cells. A wide
+// glyph fills its first cell and leaves the next 0.
+func screenCells(screen string) [][]rune {
+	var rows [][]rune
+	for _, line := range strings.Split(strings.TrimSuffix(screen, "\n"), "\n") {
+		var row []rune
+		for _, r := range line {
+			switch w := lipgloss.Width(string(r)); {
+			case w == 0 && len(row) > 0:
+				// Combining marks don't take a cell
+			case w == 2:
+				row = append(row, r, 0)
+			default:
+				row = append(row, r)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// cellAt returns the rune in cell (x, y) of a capture: 0 off the screen or
+// in the second cell of a wide glyph, and a space past the end of a line
+func cellAt(screen string, x, y int) rune {
+	rows := screenCells(screen)
+	if y < 0 || y >= len(rows) || x < 0 {
+		return 0
+	}
+	if x >= len(rows[y]) {
+		return ' '
+	}
+	return rows[y][x]
+}
+
+// region returns the w×h rectangle at (x, y) of a capture, one line per row
+func region(screen string, x, y, w, h int) string {
+	rows := screenCells(screen)
+	lines := make([]string, 0, h)
+	for row := y; row < y+h && row < len(rows); row++ {
+		var sb strings.Builder
+		for col := x; col < x+w && col < len(rows[row]); col++ {
+			if r := rows[row][col]; r != 0 {
+				sb.WriteRune(r)
+			}
+		}
+		lines = append(lines, sb.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// find returns the cell where pattern first appears in a capture
+func find(screen, pattern string) (x, y int, ok bool) {
+	for y, line := range strings.Split(screen, "\n") {
+		if i := strings.Index(line, pattern); i >= 0 {
+			return lipgloss.Width(line[:i]), y, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,48 @@
+package uitest
+
+import "testing"
+
+func TestScreenRegions(t *testing.T) {
+	screen := "     1 2 3\n╔═ B ═══════╗\n║●▎[1] ⍳全x ║\n║ ▎[2] ⎕←1  ║\n╚═══════════╝\n"
+
+	if got := cellAt(screen, 1, 2); got != '●' {
+		t.Errorf("cellAt(1, 2) = %q, want the breakpoint", got)
+	}
+	if got := cellAt(screen, 1, 3); got != ' ' {
+		t.Errorf("cellAt(1, 3) = %q, want no breakpoint", got)
+	}
+	// Columns count display cells: 全 is two wide
+	if got := cellAt(screen, 8, 2); got != '全' {
+		t.Errorf("cellAt(8, 2) = %q, want 全", got)
+	}
+	if got := cellAt(screen, 9, 2); got != 0 {
+		t.Errorf("cellAt(9, 2) = %q, want 0 (second half of 全)", got)
+	}
+	if got := cellAt(screen, 10, 2); got != 'x' {
+		t.Errorf("cellAt(10, 2) = %q, want x", got)
+	}
+	if got := cellAt(screen, 20, 0); got != ' ' {
+		t.Errorf("past the end of a line = %q, want a space", got)
+	}
+	if got := cellAt(screen, 0, 9); got != 0 {
+		t.Errorf("off the screen = %q, want 0", got)
+	}
+
+	// The gutter column alone
+	if got := region(screen, 1, 2, 1, 2); got != "●\n " {
+		t.Errorf("gutter = %q", got)
+	}
+	if got := region(screen, 7, 2, 4, 1); got != "⍳全x" {
+		t.Errorf("region with a wide glyph = %q", got)
+	}
+
+	if x, y, ok := find(screen, "⎕←1"); !ok || x != 7 || y != 3 {
+		t.Errorf("find = %d, %d, %v, want 7, 3", x, y, ok)
+	}
+	if x, y, ok := find(screen, "x"); !ok || x != 10 || y != 2 {
+		t.Errorf("find after a wide glyph = %d, %d, %v, want 10, 2", x, y, ok)
+	}
+	if _, _, ok := find(screen, "zzz"); ok {
+		t.Error("found text that isn't there")
+	}
+}
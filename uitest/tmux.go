@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -66,9 +67,21 @@ func (s *Session) SendText(text string) error {
 	return exec.Command("tmux", "send-keys", "-t", s.Name, "-l", text).Run()
 }
 
-// Capture returns the current pane content (with ANSI escape codes)
+// Capture returns the current pane content as plain text, for checking
+// what's on screen
 func (s *Session) Capture() (string, error) {
-	out, err := exec.Command("tmux", "capture-pane", "-t", s.Name, "-p", "-e").Output()
+	return s.capture()
+}
+
+// CaptureANSI returns the current pane content with ANSI escape codes, for
+// snapshots that show colors
+func (s *Session) CaptureANSI() (string, error) {
+	return s.capture("-e")
+}
+
+func (s *Session) capture(flags ...string) (string, error) {
+	args := append([]string{"capture-pane", "-t", s.Name, "-p"}, flags...)
+	out, err := exec.Command("tmux", args...).Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to capture pane: %w", err)
 	}
@@ -94,14 +107,17 @@ func (s *Session) WaitFor(pattern string, timeout time.Duration) error {
 
 // WaitForRegex waits until the output matches the regex or timeout
 func (s *Session) WaitForRegex(pattern string, timeout time.Duration) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		content, err := s.Capture()
 		if err != nil {
 			return err
 		}
-		matched, _ := regexpMatch(pattern, content)
-		if matched {
+		if re.MatchString(content) {
 			return nil
 		}
 		time.Sleep(200 * time.Millisecond)
@@ -110,9 +126,13 @@ func (s *Session) WaitForRegex(pattern string, timeout time.Duration) error {
 	return fmt.Errorf("timeout waiting for pattern %q\nCurrent content:\n%s", pattern, content)
 }
 
+// regexpMatch reports whether content matches the regex pattern
 func regexpMatch(pattern, content string) (bool, error) {
-	// Simple contains check - can be extended to full regex
-	return strings.Contains(content, pattern), nil
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(content), nil
 }
 
 // Contains checks if the current output contains the pattern
@@ -0,0 +1,35 @@
+package uitest
+
+import "testing"
+
+func TestRegexpMatch(t *testing.T) {
+	// A capture of gritt's stack pane
+	content := "╔═ stack (3) ══╗\n║ Z[2] 9÷0     ║\n║ Y[2] Z       ║\n║ X[1] Y       ║\n"
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{`stack \(\d+\)`, true},
+		{`stack \(4\)`, false},
+		{`Z\[\d\]`, true},
+		{`(?m)^║ X\[`, true}, // Line start
+		{`(?m)^Z\[`, false},
+		{`(?m)Y       ║$`, true}, // Line end
+		{`[XY]\[1\]`, true},      // Character class
+		{`[WV]\[`, false},
+		{`W\[|X\[`, true}, // Alternation
+		{`Z[[ ]`, true},   // Z[ or Z followed by a space
+	}
+	for _, tt := range tests {
+		got, err := regexpMatch(tt.pattern, content)
+		if err != nil {
+			t.Errorf("regexpMatch(%q): %v", tt.pattern, err)
+		} else if got != tt.want {
+			t.Errorf("regexpMatch(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := regexpMatch(`Z[`, content); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}